@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
 	"time"
 
@@ -14,8 +16,13 @@ import (
 	"github.com/myysophia/ossmanager-backend/internal/config"
 	"github.com/myysophia/ossmanager-backend/internal/db"
 	"github.com/myysophia/ossmanager-backend/internal/function"
+	"github.com/myysophia/ossmanager-backend/internal/jobs"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
 	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
 	"go.uber.org/zap"
 )
 
@@ -40,6 +47,13 @@ func main() {
 	logger.Info("OSS管理系统后端服务启动中...")
 	logger.Info("配置加载成功", zap.String("env", cfg.App.Env))
 
+	// 初始化凭据加密密钥，未配置时仍可读取加密前保存的凭据，但无法保存新的凭据
+	if err := secrets.Init(cfg.Secrets); errors.Is(err, secrets.ErrNoKey) {
+		logger.Warn("未配置凭据加密密钥，无法保存存储配置和访问密钥", zap.String("env", secrets.EnvKeys))
+	} else if err != nil {
+		logger.Fatal("初始化凭据加密密钥失败", zap.Error(err))
+	}
+
 	// 初始化数据库
 	if err := db.Init(&cfg.Database); err != nil {
 		logger.Fatal("初始化数据库失败", zap.Error(err))
@@ -47,14 +61,60 @@ func main() {
 	logger.Info("数据库初始化成功")
 
 	// 创建存储服务工厂
-	storageFactory := oss.NewStorageFactory(&cfg.OSS)
+	storageFactory := oss.NewStorageFactory(&cfg.OSS, db.GetDB())
+
+	// 配置热加载：修改访问密钥、日志级别或存储配置后无需重启，正在进行的上传继续使用原来的客户端
+	var configWatcher *config.Watcher
+	var ossConfigWatcher *oss.ConfigWatcher
+	if cfg.Reload.Enabled {
+		// 每次成功加载后更新比较基准，已经提示过的需重启配置不再重复提示
+		current := cfg
+		configWatcher = config.NewWatcher("configs", env, func(newCfg *config.Config, err error) {
+			if err != nil {
+				logger.Error("重新加载配置失败，继续使用原来的配置", zap.Error(err))
+				return
+			}
+			logger.SetLevel(newCfg.Log.Level)
+			reloaded := storageFactory.Reload(&newCfg.OSS)
+			logger.Info("配置已重新加载", zap.String("logLevel", logger.Level()), zap.Strings("storageTypes", reloaded))
+			if sections := restartRequired(current, newCfg); len(sections) > 0 {
+				logger.Warn("以下配置的修改需要重启服务后生效", zap.Strings("sections", sections))
+			}
+			current = newCfg
+		})
+		if err := configWatcher.Start(); err != nil {
+			logger.Error("启动配置文件监听失败", zap.Error(err))
+			configWatcher = nil
+		}
+		ossConfigWatcher = oss.NewConfigWatcher(db.GetDB(), storageFactory, cfg.Reload)
+		ossConfigWatcher.Start()
+	}
 
 	// 创建MD5计算器
 	md5Calculator := function.NewMD5Calculator(storageFactory, cfg.App.Workers)
 	logger.Info("MD5计算器初始化成功", zap.Int("workers", cfg.App.Workers))
 
+	// 创建回收站管理器并启动过期清理
+	trashManager := trash.NewManager(db.GetDB(), storageFactory, cfg.Trash)
+	trashManager.Start()
+	logger.Info("回收站管理器初始化成功", zap.Bool("enabled", cfg.Trash.Enabled))
+
+	// 创建配额管理器并启动用量统计
+	quotaManager := quota.NewManager(db.GetDB(), cfg.Quota)
+	quotaManager.Start()
+
+	// 创建后台任务管理器，任务进度通过上传进度管理器推送
+	jobManager := jobs.NewManager(db.GetDB(), upload.DefaultManager, cfg.Jobs)
+
+	// 创建上传会话管理器并启动过期会话清理
+	sessionManager := upload.NewSessionManager(db.GetDB(), storageFactory, cfg.Upload)
+	sessionManager.Start()
+
 	// 设置路由
-	router := api.SetupRouter(storageFactory, md5Calculator, db.GetDB())
+	router := api.SetupRouter(storageFactory, md5Calculator, trashManager, quotaManager, jobManager, sessionManager, db.GetDB())
+
+	// 路由创建时各处理器已注册任务类型，此时再启动调度
+	jobManager.Start()
 
 	// 创建HTTP服务器 - 禁用HTTP/2以确保SSE连接稳定性
 	// 根据配置计算超时时间，若未配置则使用默认值 30 秒
@@ -104,6 +164,26 @@ func main() {
 	md5Calculator.Stop()
 	logger.Info("MD5计算器已关闭")
 
+	// 停止回收站清理任务
+	trashManager.Stop()
+
+	// 停止配额用量统计任务
+	quotaManager.Stop()
+
+	// 停止后台任务调度，执行中的任务在下次启动时恢复
+	jobManager.Stop()
+
+	// 停止上传会话清理任务
+	sessionManager.Stop()
+
+	// 停止配置热加载
+	if configWatcher != nil {
+		configWatcher.Stop()
+	}
+	if ossConfigWatcher != nil {
+		ossConfigWatcher.Stop()
+	}
+
 	// 设置关闭超时时间
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -120,3 +200,32 @@ func main() {
 
 	logger.Info("服务器已安全关闭")
 }
+
+// restartRequired 返回修改后需要重启才能生效的配置项，这些配置只在启动时读取一次
+func restartRequired(old, new *config.Config) []string {
+	// 日志只有级别可以在运行时修改
+	oldLog, newLog := old.Log, new.Log
+	oldLog.Level, newLog.Level = "", ""
+
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"app", old.App, new.App},
+		{"database", old.Database, new.Database},
+		{"log", oldLog, newLog},
+		{"trash", old.Trash, new.Trash},
+		{"quota", old.Quota, new.Quota},
+		{"jobs", old.Jobs, new.Jobs},
+		{"upload", old.Upload, new.Upload},
+		{"reload", old.Reload, new.Reload},
+		{"secrets", old.Secrets, new.Secrets},
+	}
+	var changed []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.old, section.new) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
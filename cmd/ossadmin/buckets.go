@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// findMapping 按地域和桶名查找映射
+func findMapping(tx *gorm.DB, region, bucket string) (*models.RegionBucketMapping, error) {
+	var mapping models.RegionBucketMapping
+	if err := tx.Where("region_code = ? AND bucket_name = ?", region, bucket).First(&mapping).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("地域-桶映射 %s/%s 不存在", region, bucket)
+		}
+		return nil, fmt.Errorf("查询地域-桶映射失败: %w", err)
+	}
+	return &mapping, nil
+}
+
+// splitBucket 拆分 <地域>/<桶名> 形式的参数
+func splitBucket(raw string) (string, string, error) {
+	region, bucket, ok := strings.Cut(raw, "/")
+	if !ok || region == "" || bucket == "" {
+		return "", "", fmt.Errorf("存储桶格式应为 <地域>/<桶名>: %s", raw)
+	}
+	return region, bucket, nil
+}
+
+// findRoleAndMapping 解析授权命令的角色和存储桶参数
+func findRoleAndMapping(tx *gorm.DB, roleName, rawBucket string) (*models.Role, *models.RegionBucketMapping, error) {
+	region, bucket, err := splitBucket(rawBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapping, err := findMapping(tx, region, bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 只能授权存储桶所属组织的角色
+	roles, err := findRoles(tx, mapping.OrgID, []string{roleName})
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles[0], mapping, nil
+}
+
+func runBucketList(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+
+	var mappings []models.RegionBucketMapping
+	if err := conn.Preload("Roles").Order("region_code, bucket_name").Find(&mappings).Error; err != nil {
+		return fmt.Errorf("查询地域-桶映射失败: %w", err)
+	}
+	for _, m := range mappings {
+		names := make([]string, 0, len(m.Roles))
+		for _, r := range m.Roles {
+			names = append(names, r.Name)
+		}
+		fmt.Printf("%-6d %-6d %-20s %-30s %s\n", m.ID, m.OrgID, m.RegionCode, m.BucketName, strings.Join(names, ","))
+	}
+	return nil
+}
+
+func runBucketAdd(a *app, args []string) error {
+	fs := a.flags()
+	orgRef := orgFlag(fs, "所属组织名称或 ID，默认为默认组织")
+	positional, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		org, err := findOrg(tx, *orgRef)
+		if err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.RegionBucketMapping{}).
+			Where("region_code = ? AND bucket_name = ?", positional[0], positional[1]).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("检查映射是否存在失败: %w", err)
+		}
+		if count > 0 {
+			return errors.New("该地域-桶映射已存在")
+		}
+		mapping := &models.RegionBucketMapping{OrgID: org.ID, RegionCode: positional[0], BucketName: positional[1]}
+		if err := tx.Create(mapping).Error; err != nil {
+			return fmt.Errorf("创建地域-桶映射失败: %w", err)
+		}
+		a.report("已添加地域-桶映射 %s/%s (ID %d)", mapping.RegionCode, mapping.BucketName, mapping.ID)
+		return nil
+	})
+}
+
+func runBucketRemove(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		mapping, err := findMapping(tx, positional[0], positional[1])
+		if err != nil {
+			return err
+		}
+		// 同时删除访问权限和上传策略，访问权限直接删除，避免软删除的记录仍参与权限校验
+		access := tx.Unscoped().Where("region_bucket_mapping_id = ?", mapping.ID).Delete(&models.RoleRegionBucketAccess{})
+		if access.Error != nil {
+			return fmt.Errorf("删除相关访问权限失败: %w", access.Error)
+		}
+		if err := tx.Unscoped().Where("region_bucket_mapping_id = ?", mapping.ID).Delete(&models.BucketUploadPolicy{}).Error; err != nil {
+			return fmt.Errorf("删除上传策略失败: %w", err)
+		}
+		if err := tx.Delete(mapping).Error; err != nil {
+			return fmt.Errorf("删除地域-桶映射失败: %w", err)
+		}
+		a.report("已删除地域-桶映射 %s/%s 及 %d 条访问权限", mapping.RegionCode, mapping.BucketName, access.RowsAffected)
+		return nil
+	})
+}
+
+func runBucketGrant(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		role, mapping, err := findRoleAndMapping(tx, positional[0], positional[1])
+		if err != nil {
+			return err
+		}
+		// 权限校验直接关联该表，软删除的记录同样有效
+		var count int64
+		if err := tx.Table(models.RoleRegionBucketAccess{}.TableName()).
+			Where("role_id = ? AND region_bucket_mapping_id = ?", role.ID, mapping.ID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("检查访问权限失败: %w", err)
+		}
+		if count > 0 {
+			a.report("角色 %s 已可访问 %s", role.Name, positional[1])
+			return nil
+		}
+		if err := tx.Model(role).Association("RegionBuckets").Append(mapping); err != nil {
+			return fmt.Errorf("授权失败: %w", err)
+		}
+		a.report("已授权角色 %s 访问 %s", role.Name, positional[1])
+		return nil
+	})
+}
+
+func runBucketRevoke(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		role, mapping, err := findRoleAndMapping(tx, positional[0], positional[1])
+		if err != nil {
+			return err
+		}
+		result := tx.Unscoped().
+			Where("role_id = ? AND region_bucket_mapping_id = ?", role.ID, mapping.ID).
+			Delete(&models.RoleRegionBucketAccess{})
+		if result.Error != nil {
+			return fmt.Errorf("撤销访问权限失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			a.report("角色 %s 没有 %s 的访问权限", role.Name, positional[1])
+			return nil
+		}
+		a.report("已撤销角色 %s 对 %s 的访问权限", role.Name, positional[1])
+		return nil
+	})
+}
@@ -0,0 +1,230 @@
+// ossadmin 是 OSS Manager 的运维命令行工具，直接连接数据库管理组织、用户、角色、存储桶映射，
+// 并执行数据库迁移、用量对账和审计日志清理
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
+	"gorm.io/gorm"
+)
+
+// errDryRun 试运行时用于回滚事务
+var errDryRun = errors.New("试运行，未提交修改")
+
+// options 所有子命令共用的参数
+type options struct {
+	configPath string
+	env        string
+	dryRun     bool
+}
+
+// register 注册全局参数
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", o.configPath, "配置文件目录或文件路径")
+	fs.StringVar(&o.env, "env", o.env, "运行环境，默认读取 APP_ENV")
+	fs.BoolVar(&o.dryRun, "dry-run", o.dryRun, "试运行，只显示将要执行的修改")
+}
+
+// command 子命令，name 形如 "user create"
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"org list", "org list", "列出组织", runOrgList},
+		{"org create", "org create <组织名> [--description <描述>]", "创建组织及其组织管理员角色", runOrgCreate},
+		{"user list", "user list", "列出用户及其所属组织和角色", runUserList},
+		{"user create", "user create <用户名> --email <邮箱> [--password <密码>] [--real-name <姓名>] [--role <角色>,...] [--org <组织>]", "创建用户", runUserCreate},
+		{"user disable", "user disable <用户名>", "禁用用户", runUserDisable},
+		{"user enable", "user enable <用户名>", "启用用户", runUserEnable},
+		{"user passwd", "user passwd <用户名> [--password <密码>]", "重置用户密码", runUserPasswd},
+		{"role list", "role list [--org <组织>]", "列出角色及可访问的存储桶", runRoleList},
+		{"role create", "role create <角色名> [--description <描述>] [--admin ORG|GLOBAL] [--org <组织>]", "创建角色", runRoleCreate},
+		{"role assign", "role assign <用户名> <角色>...", "为用户分配角色", runRoleAssign},
+		{"role revoke", "role revoke <用户名> <角色>...", "撤销用户的角色", runRoleRevoke},
+		{"bucket list", "bucket list", "列出地域-桶映射", runBucketList},
+		{"bucket add", "bucket add <地域> <桶名> [--org <组织>]", "添加地域-桶映射", runBucketAdd},
+		{"bucket remove", "bucket remove <地域> <桶名>", "删除地域-桶映射及其访问权限", runBucketRemove},
+		{"bucket grant", "bucket grant <角色> <地域>/<桶名>", "授权角色访问存储桶", runBucketGrant},
+		{"bucket revoke", "bucket revoke <角色> <地域>/<桶名>", "撤销角色的存储桶访问权限", runBucketRevoke},
+		{"migrate", "migrate", "执行尚未执行的数据库迁移", runMigrate},
+		{"migrate down", "migrate down [--steps <数量>]", "回滚最近执行的数据库迁移", runMigrateDown},
+		{"migrate status", "migrate status", "列出数据库迁移及执行状态", runMigrateStatus},
+		{"reconcile", "reconcile [--only quotas,sessions,trash]", "重新统计配额用量，清理过期上传会话和回收站", runReconcile},
+		{"secrets reencrypt", "secrets reencrypt", "使用主密钥重新加密存储配置和访问密钥中的凭据", runSecretsReencrypt},
+		{"audit purge", "audit purge (--before <日期> | --days <天数>)", "删除早于指定时间的审计日志", runAuditPurge},
+	}
+}
+
+func main() {
+	opts := &options{configPath: "configs", env: os.Getenv("APP_ENV")}
+	fs := flag.NewFlagSet("ossadmin", flag.ContinueOnError)
+	opts.register(fs)
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	args := fs.Args()
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		if len(args) > 0 {
+			fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", strings.Join(args, " "))
+		}
+		usage(fs)
+		os.Exit(2)
+	}
+
+	a := &app{opts: opts, cmd: cmd}
+	if err := cmd.run(a, rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		os.Exit(1)
+	}
+}
+
+// findCommand 按名称查找子命令，名称有重叠时取最长的匹配，例如 "migrate down" 优先于 "migrate"
+func findCommand(args []string) (*command, []string) {
+	var found *command
+	var rest []string
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) < len(words) || strings.Join(args[:len(words)], " ") != commands[i].name {
+			continue
+		}
+		if found == nil || len(words) > len(strings.Fields(found.name)) {
+			found, rest = &commands[i], args[len(words):]
+		}
+	}
+	return found, rest
+}
+
+// usage 输出帮助信息
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "用法: ossadmin [全局参数] <命令> [参数]")
+	fmt.Fprintln(os.Stderr, "\n命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\n全局参数（也可以写在子命令之后）:")
+	fs.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "\n环境变量: APP_ENV 运行环境，OSSADMIN_PASSWORD 创建用户或重置密码时使用的密码，OSSMANAGER_SECRET_KEYS 凭据加密密钥")
+}
+
+// app 子命令的运行环境，数据库在首次使用时连接
+type app struct {
+	opts *options
+	cmd  *command
+	cfg  *config.Config
+}
+
+// flags 创建子命令参数集
+func (a *app) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(a.cmd.name, flag.ContinueOnError)
+	a.opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: ossadmin %s\n", a.cmd.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse 解析子命令参数，允许参数和位置参数交替出现，并检查位置参数数量
+func (a *app) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		if args[0] == "--" {
+			positional = append(positional, args[1:]...)
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < minArgs || (maxArgs >= 0 && len(positional) > maxArgs) {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	return positional, nil
+}
+
+// db 加载配置并连接数据库
+func (a *app) db() (*gorm.DB, error) {
+	if a.cfg == nil {
+		cfg, err := config.LoadConfigWithEnv(a.opts.configPath, a.opts.env)
+		if err != nil {
+			return nil, err
+		}
+		// 日志只写到配置的文件中，避免干扰命令输出
+		logCfg := cfg.Log
+		if logCfg.Output == "stdout" || logCfg.Output == "both" {
+			logCfg.Output = "file"
+		}
+		if err := logger.InitLogger(&logCfg); err != nil {
+			return nil, fmt.Errorf("初始化日志失败: %w", err)
+		}
+		if err := secrets.Init(cfg.Secrets); err != nil && !errors.Is(err, secrets.ErrNoKey) {
+			return nil, fmt.Errorf("初始化凭据加密密钥失败: %w", err)
+		}
+		// 迁移只通过 migrate 命令执行，避免试运行或回滚前先执行了迁移
+		dbCfg := cfg.Database
+		dbCfg.AutoMigrate = false
+		if err := db.Init(&dbCfg); err != nil {
+			return nil, err
+		}
+		a.cfg = cfg
+	}
+	return db.GetDB(), nil
+}
+
+// change 在事务中执行修改，试运行时回滚事务
+func (a *app) change(fn func(tx *gorm.DB) error) error {
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+	err = conn.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if a.opts.dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		fmt.Println("[试运行] 以上修改已回滚")
+		return nil
+	}
+	return err
+}
+
+// report 输出一行结果，试运行时加上前缀
+func (a *app) report(format string, args ...interface{}) {
+	if a.opts.dryRun {
+		format = "[试运行] " + format
+	}
+	fmt.Printf(format+"\n", args...)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand(t *testing.T) {
+	cmd, rest := findCommand([]string{"user", "create", "alice", "--email", "a@example.com"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "user create", cmd.name)
+	assert.Equal(t, []string{"alice", "--email", "a@example.com"}, rest)
+
+	cmd, rest = findCommand([]string{"migrate", "--dry-run"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "migrate", cmd.name)
+	assert.Equal(t, []string{"--dry-run"}, rest)
+
+	cmd, rest = findCommand([]string{"migrate", "down", "--steps", "2"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "migrate down", cmd.name)
+	assert.Equal(t, []string{"--steps", "2"}, rest)
+
+	cmd, _ = findCommand([]string{"user"})
+	assert.Nil(t, cmd)
+}
+
+func TestPurgeCutoff(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+
+	cutoff, err := purgeCutoff("", 30, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 13, 12, 0, 0, 0, time.Local), cutoff)
+
+	cutoff, err = purgeCutoff("2026-01-01", 0, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), cutoff)
+
+	for _, tc := range []struct {
+		before string
+		days   int
+	}{
+		{"", 0},
+		{"2026-01-01", 30},
+		{"2026/01/01", 0},
+		{"2026-04-01", 0},
+	} {
+		_, err := purgeCutoff(tc.before, tc.days, now)
+		assert.Error(t, err, "before=%q days=%d", tc.before, tc.days)
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/db"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"gorm.io/gorm"
+)
+
+// auditPurgeBatchSize 每批删除的审计日志数量，避免长时间锁表
+const auditPurgeBatchSize = 5000
+
+// reconcileTasks reconcile 支持的对账项
+var reconcileTasks = []string{"quotas", "sessions", "trash"}
+
+func runMigrate(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+
+	migrations, err := db.Migrate(conn, a.opts.dryRun)
+	for _, m := range migrations {
+		a.report("执行迁移 %s_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		fmt.Println("数据库已是最新版本")
+	}
+	return nil
+}
+
+func runMigrateDown(a *app, args []string) error {
+	fs := a.flags()
+	steps := fs.Int("steps", 1, "回滚的迁移数量")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+
+	migrations, err := db.Rollback(conn, *steps, a.opts.dryRun)
+	for _, m := range migrations {
+		a.report("回滚迁移 %s_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		fmt.Println("没有可以回滚的迁移")
+	}
+	return nil
+}
+
+func runMigrateStatus(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+
+	applied, err := db.AppliedMigrations(conn)
+	if err != nil {
+		return err
+	}
+	appliedAt := make(map[string]time.Time, len(applied))
+	for _, m := range applied {
+		appliedAt[m.Version] = m.AppliedAt
+	}
+	migrations, err := db.Migrations(conn.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		status := "未执行"
+		if t, ok := appliedAt[m.Version]; ok {
+			status = "已执行 " + t.Format(time.DateTime)
+			delete(appliedAt, m.Version)
+		}
+		fmt.Printf("%s  %-30s %s\n", m.Version, m.Name, status)
+	}
+	// 数据库中有记录但当前版本没有对应文件，通常是使用了更新版本的程序执行过迁移
+	for _, m := range applied {
+		if _, ok := appliedAt[m.Version]; ok {
+			fmt.Printf("%s  %-30s 已执行 %s（缺少迁移文件）\n", m.Version, m.Name, m.AppliedAt.Format(time.DateTime))
+		}
+	}
+	return nil
+}
+
+func runReconcile(a *app, args []string) error {
+	fs := a.flags()
+	only := fs.String("only", "", "只执行指定的对账项，多个用逗号分隔: quotas,sessions,trash")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	tasks := reconcileTasks
+	if *only != "" {
+		tasks = splitList(*only)
+	}
+	for _, task := range tasks {
+		if !slices.Contains(reconcileTasks, task) {
+			return fmt.Errorf("未知的对账项: %s", task)
+		}
+	}
+
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+	storageFactory := oss.NewStorageFactory(&a.cfg.OSS, conn)
+	for _, task := range tasks {
+		switch task {
+		case "quotas":
+			err = reconcileQuotas(a, quota.NewManager(conn, a.cfg.Quota))
+		case "sessions":
+			err = reconcileSessions(a, conn, upload.NewSessionManager(conn, storageFactory, a.cfg.Upload))
+		case "trash":
+			err = reconcileTrash(a, conn, trash.NewManager(conn, storageFactory, a.cfg.Trash))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileQuotas 重新统计配额用量，试运行时只列出与记录不一致的配额
+func reconcileQuotas(a *app, manager *quota.Manager) error {
+	if !a.opts.dryRun {
+		count, err := manager.RecalculateAll()
+		if err != nil {
+			return err
+		}
+		a.report("已重新统计 %d 个配额的用量", count)
+		return nil
+	}
+
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+	var quotas []models.StorageQuota
+	if err := conn.Order("id").Find(&quotas).Error; err != nil {
+		return fmt.Errorf("查询配额失败: %w", err)
+	}
+	changed := 0
+	for _, q := range quotas {
+		usage, err := manager.Usage(q)
+		if err != nil {
+			return err
+		}
+		if usage.Bytes == q.UsedBytes && usage.Objects == q.UsedObjects {
+			continue
+		}
+		changed++
+		a.report("配额 %d (%s %d %s): %d 字节/%d 个对象 -> %d 字节/%d 个对象",
+			q.ID, q.ScopeType, q.ScopeID, q.BucketName, q.UsedBytes, q.UsedObjects, usage.Bytes, usage.Objects)
+	}
+	a.report("共 %d 个配额，%d 个用量需要更新", len(quotas), changed)
+	return nil
+}
+
+// reconcileSessions 中止所有过期的上传会话
+func reconcileSessions(a *app, conn *gorm.DB, manager *upload.SessionManager) error {
+	expired := func() (int64, error) {
+		var count int64
+		err := conn.Model(&models.UploadSession{}).
+			Where("status = ? AND expires_at < ?", models.UploadSessionActive, time.Now()).
+			Count(&count).Error
+		return count, err
+	}
+
+	remaining, err := expired()
+	if err != nil {
+		return fmt.Errorf("查询过期上传会话失败: %w", err)
+	}
+	if a.opts.dryRun {
+		a.report("%d 个过期上传会话待中止", remaining)
+		return nil
+	}
+
+	// CleanupExpired 每次处理一批，中止失败的会话同样会被标记为已中止
+	aborted := 0
+	for remaining > 0 {
+		count, err := manager.CleanupExpired()
+		if err != nil {
+			return fmt.Errorf("中止过期上传会话失败: %w", err)
+		}
+		aborted += count
+		left, err := expired()
+		if err != nil {
+			return fmt.Errorf("查询过期上传会话失败: %w", err)
+		}
+		if left >= remaining {
+			break
+		}
+		remaining = left
+	}
+	a.report("已中止 %d 个过期上传会话", aborted)
+	return nil
+}
+
+// reconcileTrash 彻底删除超过保留期的回收站文件
+func reconcileTrash(a *app, conn *gorm.DB, manager *trash.Manager) error {
+	cutoff := time.Now().Add(-manager.Retention())
+	if a.opts.dryRun {
+		var count int64
+		if err := conn.Model(&models.OSSFile{}).
+			Where("status = ? AND trashed_at < ?", trash.StatusTrashed, cutoff).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("查询回收站文件失败: %w", err)
+		}
+		a.report("%d 个回收站文件超过保留期待删除", count)
+		return nil
+	}
+
+	count, err := manager.PurgeWhere(conn.Where("trashed_at < ?", cutoff))
+	if err != nil {
+		return err
+	}
+	a.report("已彻底删除 %d 个过期回收站文件", count)
+	return nil
+}
+
+func runAuditPurge(a *app, args []string) error {
+	fs := a.flags()
+	before := fs.String("before", "", "删除该日期之前的日志，格式 2006-01-02")
+	days := fs.Int("days", 0, "删除早于该天数的日志")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	cutoff, err := purgeCutoff(*before, *days, time.Now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+	query := conn.Unscoped().Model(&models.AuditLog{}).Where("created_at < ?", cutoff)
+	if a.opts.dryRun {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return fmt.Errorf("查询审计日志失败: %w", err)
+		}
+		a.report("%d 条 %s 之前的审计日志待删除", count, cutoff.Format(time.DateTime))
+		return nil
+	}
+
+	// 先查出一批 ID 再删除，MySQL 不支持在删除语句的子查询中读取同一张表并使用 LIMIT
+	var total int64
+	for {
+		var ids []uint
+		if err := conn.Unscoped().Model(&models.AuditLog{}).
+			Where("created_at < ?", cutoff).Order("id").Limit(auditPurgeBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("查询审计日志失败: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		result := conn.Unscoped().Where("id IN ?", ids).Delete(&models.AuditLog{})
+		if result.Error != nil {
+			return fmt.Errorf("删除审计日志失败: %w", result.Error)
+		}
+		total += result.RowsAffected
+		if len(ids) < auditPurgeBatchSize {
+			break
+		}
+	}
+	a.report("已删除 %d 条 %s 之前的审计日志", total, cutoff.Format(time.DateTime))
+	return nil
+}
+
+// purgeCutoff 根据 --before 或 --days 计算截止时间，两者必须且只能指定一个
+func purgeCutoff(before string, days int, now time.Time) (time.Time, error) {
+	switch {
+	case before != "" && days > 0:
+		return time.Time{}, errors.New("--before 和 --days 只能指定一个")
+	case before != "":
+		t, err := time.ParseInLocation(time.DateOnly, before, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的日期: %s", before)
+		}
+		if !t.Before(now) {
+			return time.Time{}, errors.New("截止日期必须早于当前时间")
+		}
+		return t, nil
+	case days > 0:
+		return now.AddDate(0, 0, -days), nil
+	}
+	return time.Time{}, errors.New("请通过 --before 或 --days 指定要删除的日志范围")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
+	"gorm.io/gorm"
+)
+
+// encryptedColumns 加密保存凭据的表和字段
+var encryptedColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"oss_configs", []string{"access_key", "secret_key"}},
+	{"access_keys", []string{"secret_key"}},
+}
+
+func runSecretsReencrypt(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	if _, err := a.db(); err != nil {
+		return err
+	}
+	keyring := secrets.Default()
+	if keyring == nil {
+		return fmt.Errorf("%w，请在配置文件的 secrets 中或通过环境变量 %s 设置", secrets.ErrNoKey, secrets.EnvKeys)
+	}
+
+	return a.change(func(tx *gorm.DB) error {
+		for _, t := range encryptedColumns {
+			count, err := reencryptTable(tx, keyring, t.table, t.columns)
+			if err != nil {
+				return err
+			}
+			a.report("%s: 使用密钥 %s 重新加密 %d 条记录", t.table, keyring.PrimaryKeyID(), count)
+		}
+		return nil
+	})
+}
+
+// reencryptTable 将尚未加密或使用旧密钥加密的字段改为使用主密钥加密，包括已软删除的记录
+// 直接读写字段的原始值，不经过模型的自动加解密
+func reencryptTable(tx *gorm.DB, keyring *secrets.Keyring, table string, columns []string) (int, error) {
+	rows, err := tx.Table(table).Select("id, " + strings.Join(columns, ", ")).Order("id").Rows()
+	if err != nil {
+		return 0, fmt.Errorf("查询 %s 失败: %w", table, err)
+	}
+	type pending struct {
+		id      uint
+		updates map[string]interface{}
+	}
+	var changes []pending
+	for rows.Next() {
+		var id uint
+		values := make([]string, len(columns))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("读取 %s 失败: %w", table, err)
+		}
+
+		updates := make(map[string]interface{})
+		for i, value := range values {
+			if !keyring.NeedsReencrypt(value) {
+				continue
+			}
+			plaintext, err := keyring.Decrypt(value)
+			if err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("解密 %s %d 的 %s 失败: %w", table, id, columns[i], err)
+			}
+			encrypted, err := keyring.Encrypt(plaintext)
+			if err != nil {
+				rows.Close()
+				return 0, err
+			}
+			updates[columns[i]] = encrypted
+		}
+		if len(updates) > 0 {
+			changes = append(changes, pending{id: id, updates: updates})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("读取 %s 失败: %w", table, err)
+	}
+
+	// 读完再更新，SQLite 等单连接的数据库不能在遍历结果时写入
+	for _, c := range changes {
+		if err := tx.Table(table).Where("id = ?", c.id).UpdateColumns(c.updates).Error; err != nil {
+			return 0, fmt.Errorf("更新 %s %d 失败: %w", table, c.id, err)
+		}
+	}
+	return len(changes), nil
+}
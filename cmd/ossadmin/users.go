@@ -0,0 +1,321 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// minPasswordLength 与注册接口的密码长度要求一致
+const minPasswordLength = 6
+
+// findUser 按用户名查找用户
+func findUser(tx *gorm.DB, username string) (*models.User, error) {
+	var user models.User
+	if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("用户 %s 不存在", username)
+		}
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return &user, nil
+}
+
+// findRoles 按名称查找组织内的角色，任一角色不存在时报错
+func findRoles(tx *gorm.DB, orgID uint, names []string) ([]*models.Role, error) {
+	var roles []*models.Role
+	if err := tx.Where("org_id = ? AND name IN ?", orgID, names).Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	found := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		found[r.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("角色 %s 不存在", name)
+		}
+	}
+	return roles, nil
+}
+
+// splitList 拆分逗号分隔的参数
+func splitList(raw string) []string {
+	var items []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			items = append(items, s)
+		}
+	}
+	return items
+}
+
+// password 取参数或环境变量 OSSADMIN_PASSWORD 中的密码
+func password(flagValue string) (string, error) {
+	if flagValue == "" {
+		flagValue = os.Getenv("OSSADMIN_PASSWORD")
+	}
+	if flagValue == "" {
+		return "", errors.New("请通过 --password 或环境变量 OSSADMIN_PASSWORD 提供密码")
+	}
+	if len(flagValue) < minPasswordLength {
+		return "", fmt.Errorf("密码长度不能少于 %d 位", minPasswordLength)
+	}
+	return flagValue, nil
+}
+
+func runUserList(a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := conn.Preload("Roles").Order("id").Find(&users).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	for _, u := range users {
+		status := "启用"
+		if !u.Status {
+			status = "禁用"
+		}
+		names := make([]string, 0, len(u.Roles))
+		for _, r := range u.Roles {
+			names = append(names, r.Name)
+		}
+		fmt.Printf("%-6d %-6d %-20s %-30s %s  %s\n", u.ID, u.OrgID, u.Username, u.Email, status, strings.Join(names, ","))
+	}
+	return nil
+}
+
+func runUserCreate(a *app, args []string) error {
+	fs := a.flags()
+	email := fs.String("email", "", "邮箱（必填）")
+	pass := fs.String("password", "", "密码，也可以通过环境变量 OSSADMIN_PASSWORD 提供")
+	realName := fs.String("real-name", "", "姓名")
+	roleList := fs.String("role", "", "角色名称，多个用逗号分隔")
+	orgRef := orgFlag(fs, "所属组织名称或 ID，默认为默认组织")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("邮箱不能为空")
+	}
+	secret, err := password(*pass)
+	if err != nil {
+		return err
+	}
+
+	user := &models.User{Username: positional[0], Email: *email, RealName: *realName, Status: true}
+	if err := user.SetPassword(secret); err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	return a.change(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ? OR email = ?", user.Username, user.Email).Count(&count).Error; err != nil {
+			return fmt.Errorf("检查用户失败: %w", err)
+		}
+		if count > 0 {
+			return errors.New("用户名或邮箱已存在")
+		}
+		org, err := findOrg(tx, *orgRef)
+		if err != nil {
+			return err
+		}
+		user.OrgID = org.ID
+		if names := splitList(*roleList); len(names) > 0 {
+			roles, err := findRoles(tx, org.ID, names)
+			if err != nil {
+				return err
+			}
+			user.Roles = roles
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+		a.report("已创建用户 %s (ID %d，组织 %s)", user.Username, user.ID, org.Name)
+		return nil
+	})
+}
+
+// setUserStatus 启用或禁用用户，禁用后用户无法再访问任何接口
+func setUserStatus(a *app, args []string, enabled bool) error {
+	positional, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		user, err := findUser(tx, positional[0])
+		if err != nil {
+			return err
+		}
+		action := "禁用"
+		if enabled {
+			action = "启用"
+		}
+		if user.Status == enabled {
+			a.report("用户 %s 已是%s状态", user.Username, action)
+			return nil
+		}
+		if err := tx.Model(user).Update("status", enabled).Error; err != nil {
+			return fmt.Errorf("%s用户失败: %w", action, err)
+		}
+		a.report("已%s用户 %s", action, user.Username)
+		return nil
+	})
+}
+
+func runUserDisable(a *app, args []string) error {
+	return setUserStatus(a, args, false)
+}
+
+func runUserEnable(a *app, args []string) error {
+	return setUserStatus(a, args, true)
+}
+
+func runUserPasswd(a *app, args []string) error {
+	fs := a.flags()
+	pass := fs.String("password", "", "新密码，也可以通过环境变量 OSSADMIN_PASSWORD 提供")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	secret, err := password(*pass)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		user, err := findUser(tx, positional[0])
+		if err != nil {
+			return err
+		}
+		if err := user.SetPassword(secret); err != nil {
+			return fmt.Errorf("密码加密失败: %w", err)
+		}
+		if err := tx.Model(user).Update("password", user.Password).Error; err != nil {
+			return fmt.Errorf("重置密码失败: %w", err)
+		}
+		a.report("已重置用户 %s 的密码", user.Username)
+		return nil
+	})
+}
+
+func runRoleList(a *app, args []string) error {
+	fs := a.flags()
+	orgRef := orgFlag(fs, "只列出该组织的角色，默认列出所有组织")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+
+	query := conn.Preload("RegionBuckets").Order("org_id, id")
+	if *orgRef != "" {
+		org, err := findOrg(conn, *orgRef)
+		if err != nil {
+			return err
+		}
+		query = query.Where("org_id = ?", org.ID)
+	}
+	var roles []models.Role
+	if err := query.Find(&roles).Error; err != nil {
+		return fmt.Errorf("查询角色失败: %w", err)
+	}
+	for _, r := range roles {
+		buckets := make([]string, 0, len(r.RegionBuckets))
+		for _, m := range r.RegionBuckets {
+			buckets = append(buckets, m.RegionCode+"/"+m.BucketName)
+		}
+		fmt.Printf("%-6d %-6d %-20s %-6s %s\n", r.ID, r.OrgID, r.Name, r.AdminScope, strings.Join(buckets, ","))
+	}
+	return nil
+}
+
+func runRoleCreate(a *app, args []string) error {
+	fs := a.flags()
+	description := fs.String("description", "", "角色描述")
+	adminScope := fs.String("admin", "", "管理员范围：ORG 为组织管理员，GLOBAL 为全局管理员")
+	orgRef := orgFlag(fs, "所属组织名称或 ID，默认为默认组织")
+	positional, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	switch *adminScope {
+	case "", models.AdminScopeOrg, models.AdminScopeGlobal:
+	default:
+		return fmt.Errorf("管理员范围只能是 %s 或 %s", models.AdminScopeOrg, models.AdminScopeGlobal)
+	}
+	return a.change(func(tx *gorm.DB) error {
+		org, err := findOrg(tx, *orgRef)
+		if err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("org_id = ? AND name = ?", org.ID, positional[0]).Count(&count).Error; err != nil {
+			return fmt.Errorf("检查角色失败: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("角色 %s 已存在", positional[0])
+		}
+		role := &models.Role{OrgID: org.ID, Name: positional[0], Description: *description, AdminScope: *adminScope}
+		if err := tx.Create(role).Error; err != nil {
+			return fmt.Errorf("创建角色失败: %w", err)
+		}
+		a.report("已创建角色 %s (ID %d)", role.Name, role.ID)
+		return nil
+	})
+}
+
+func runRoleAssign(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, -1)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		user, err := findUser(tx, positional[0])
+		if err != nil {
+			return err
+		}
+		roles, err := findRoles(tx, user.OrgID, positional[1:])
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(user).Association("Roles").Append(roles); err != nil {
+			return fmt.Errorf("分配角色失败: %w", err)
+		}
+		a.report("已为用户 %s 分配角色 %s", user.Username, strings.Join(positional[1:], ","))
+		return nil
+	})
+}
+
+func runRoleRevoke(a *app, args []string) error {
+	positional, err := a.parse(a.flags(), args, 2, -1)
+	if err != nil {
+		return err
+	}
+	return a.change(func(tx *gorm.DB) error {
+		user, err := findUser(tx, positional[0])
+		if err != nil {
+			return err
+		}
+		roles, err := findRoles(tx, user.OrgID, positional[1:])
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(user).Association("Roles").Delete(roles); err != nil {
+			return fmt.Errorf("撤销角色失败: %w", err)
+		}
+		a.report("已撤销用户 %s 的角色 %s", user.Username, strings.Join(positional[1:], ","))
+		return nil
+	})
+}
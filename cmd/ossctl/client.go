@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiPrefix 服务端 API 路径前缀
+const apiPrefix = "/api/v1"
+
+// apiResponse 服务端统一响应结构
+type apiResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// apiError 服务端返回的业务错误
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// client 访问 OSS Manager API 的客户端
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+	// transfer 用于上传、下载数据，不设置整体超时
+	transfer *http.Client
+}
+
+// newClient 创建客户端，server 不含 /api/v1 前缀
+func newClient(server, token string) *client {
+	return &client{
+		server:   strings.TrimRight(server, "/"),
+		token:    token,
+		http:     &http.Client{Timeout: 60 * time.Second},
+		transfer: &http.Client{},
+	}
+}
+
+// url 拼接 API 地址
+func (c *client) url(path string, query url.Values) string {
+	u := c.server + apiPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// newRequest 创建携带认证头的 API 请求
+func (c *client) newRequest(method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.url(path, query), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// call 发送 JSON 请求并把响应中的 data 解析到 out，out 为 nil 时忽略 data
+func (c *client) call(method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.do(c.http, req, out)
+}
+
+// do 发送请求并解析统一响应结构
+func (c *client) do(hc *http.Client, req *http.Request, out interface{}) error {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, out)
+}
+
+// decodeResponse 解析统一响应结构，业务错误转换为 apiError
+func decodeResponse(resp *http.Response, out interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	var result apiResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &apiError{Code: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		}
+		return fmt.Errorf("无法解析服务端响应: %w", err)
+	}
+	// 认证中间件直接返回 HTTP 401，业务错误以 HTTP 200 返回非 200 的 code
+	if resp.StatusCode != http.StatusOK || result.Code != http.StatusOK {
+		code := result.Code
+		if code == 0 {
+			code = resp.StatusCode
+		}
+		return &apiError{Code: code, Message: result.Message}
+	}
+	if out == nil || len(result.Data) == 0 || string(result.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// fileItem 文件列表中的一项
+type fileItem struct {
+	ID               uint      `json:"id"`
+	OriginalFilename string    `json:"original_filename"`
+	FileSize         int64     `json:"file_size"`
+	Bucket           string    `json:"bucket"`
+	ObjectKey        string    `json:"object_key"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func runLogin(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("login", "login [-u 用户名] [-p 密码]")
+	username := fs.String("u", "", "用户名")
+	password := fs.String("p", os.Getenv("OSSCTL_PASSWORD"), "密码，未指定时从标准输入读取")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if *username == "" {
+		fmt.Fprint(os.Stderr, "用户名: ")
+		line, _ := in.ReadString('\n')
+		*username = strings.TrimSpace(line)
+	}
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "密码: ")
+		line, _ := in.ReadString('\n')
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	if *username == "" || *password == "" {
+		return errors.New("用户名和密码不能为空")
+	}
+
+	c, creds, err := g.client()
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Token string `json:"token"`
+		User  struct {
+			ID       uint   `json:"id"`
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	if err := c.call(http.MethodPost, "/auth/login", nil, map[string]string{
+		"username": *username,
+		"password": *password,
+	}, &resp); err != nil {
+		return err
+	}
+
+	creds.Server = c.server
+	creds.Token = resp.Token
+	creds.Username = resp.User.Username
+	if err := creds.save(); err != nil {
+		return fmt.Errorf("保存令牌失败: %w", err)
+	}
+	g.print(map[string]interface{}{"server": c.server, "user_id": resp.User.ID, "username": resp.User.Username}, func() {
+		fmt.Printf("已登录 %s（%s）\n", c.server, resp.User.Username)
+	})
+	return nil
+}
+
+func runLogout(ctx context.Context, g *globalOptions, args []string) error {
+	if _, err := parseArgs(g.newFlags("logout", "logout"), args); err != nil {
+		return err
+	}
+	creds, err := loadCredentials()
+	if err != nil {
+		return err
+	}
+	creds.Token = ""
+	creds.Username = ""
+	if err := creds.save(); err != nil {
+		return err
+	}
+	g.print(map[string]bool{"logged_out": true}, func() { fmt.Println("已退出登录") })
+	return nil
+}
+
+func runWhoami(ctx context.Context, g *globalOptions, args []string) error {
+	if _, err := parseArgs(g.newFlags("whoami", "whoami"), args); err != nil {
+		return err
+	}
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	var user json.RawMessage
+	if err := c.call(http.MethodGet, "/user/current", nil, nil, &user); err != nil {
+		return err
+	}
+	g.print(user, func() {
+		var u struct {
+			ID       uint   `json:"id"`
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		}
+		json.Unmarshal(user, &u)
+		fmt.Printf("%s（ID %d，%s）@ %s\n", u.Username, u.ID, u.Email, c.server)
+	})
+	return nil
+}
+
+func runUpload(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("upload", "upload --region 区域 --bucket 存储桶 [--path 目录] [--tags k=v&k2=v2] [--force] 文件...")
+	opts := uploadOptions{ChunkSize: defaultChunkSize, Threshold: 100 << 20}
+	fs.StringVar(&opts.Region, "region", "", "区域代码（必填）")
+	fs.StringVar(&opts.Bucket, "bucket", "", "存储桶（必填）")
+	fs.StringVar(&opts.Path, "path", "", "目标目录，为空时由服务端生成路径")
+	fs.StringVar(&opts.Tags, "tags", "", "文件标签，格式为 k1=v1&k2=v2")
+	fs.BoolVar(&opts.Force, "force", false, "同路径文件已存在时覆盖（旧文件保留为历史版本）")
+	fs.Var(sizeFlag{&opts.ChunkSize}, "chunk-size", "分片大小")
+	fs.Var(sizeFlag{&opts.Threshold}, "threshold", "超过该大小时使用分片上传")
+	fs.IntVar(&opts.Concurrency, "concurrency", 4, "并发上传的分片数")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if opts.Region == "" || opts.Bucket == "" || len(files) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	opts.Progress = g.progress()
+
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	results := make([]json.RawMessage, 0, len(files))
+	for _, name := range files {
+		file, err := c.upload(ctx, name, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		results = append(results, file)
+		if !g.json {
+			var item fileItem
+			json.Unmarshal(file, &item)
+			fmt.Printf("%s -> %s/%s（ID %d）\n", name, item.Bucket, item.ObjectKey, item.ID)
+		}
+	}
+	if g.json {
+		g.print(results, nil)
+	}
+	return nil
+}
+
+func runDownload(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("download", "download [-o 输出路径|-] 文件ID")
+	output := fs.String("o", "", "输出文件或目录，- 表示标准输出，默认保存到当前目录")
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if *output == "-" && g.json {
+		return errors.New("--json 不能与 -o - 同时使用")
+	}
+
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	result, err := c.download(ctx, ids[0], *output, g.progress())
+	if err != nil {
+		return err
+	}
+	if *output != "-" {
+		g.print(result, func() {
+			fmt.Printf("已保存到 %s（%s）\n", result.Output, formatSize(result.Size))
+		})
+	}
+	return nil
+}
+
+func runList(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("ls", "ls [--bucket 存储桶] [--prefix 前缀] [--keyword 关键字] [--limit N] [--all]")
+	bucket := fs.String("bucket", "", "存储桶")
+	prefix := fs.String("prefix", "", "对象路径前缀")
+	keyword := fs.String("keyword", "", "文件名关键字")
+	limit := fs.Int("limit", 50, "每页数量（最大 200）")
+	cursor := fs.String("cursor", "", "从上次返回的 next_cursor 继续")
+	all := fs.Bool("all", false, "自动翻页列出全部文件")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	query := url.Values{"limit": {strconv.Itoa(*limit)}}
+	for k, v := range map[string]string{"bucket_name": *bucket, "prefix": *prefix, "keyword": *keyword, "cursor": *cursor} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+
+	var items []json.RawMessage
+	var hasMore bool
+	var nextCursor string
+	for {
+		var page struct {
+			Items      []json.RawMessage `json:"items"`
+			HasMore    bool              `json:"has_more"`
+			NextCursor string            `json:"next_cursor"`
+		}
+		if err := c.call(http.MethodGet, "/oss/files", query, nil, &page); err != nil {
+			return err
+		}
+		items = append(items, page.Items...)
+		hasMore, nextCursor = page.HasMore, page.NextCursor
+		if !*all || !hasMore {
+			break
+		}
+		query.Set("cursor", nextCursor)
+	}
+
+	out := map[string]interface{}{"items": items, "has_more": hasMore}
+	if hasMore {
+		out["next_cursor"] = nextCursor
+	}
+	g.print(out, func() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\t大小\t创建时间\t存储桶\t路径")
+		for _, raw := range items {
+			var item fileItem
+			json.Unmarshal(raw, &item)
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", item.ID, formatSize(item.FileSize),
+				item.CreatedAt.Local().Format("2006-01-02 15:04"), item.Bucket, item.ObjectKey)
+		}
+		w.Flush()
+		if hasMore {
+			fmt.Fprintf(os.Stderr, "还有更多文件，使用 --cursor %s 继续\n", nextCursor)
+		}
+	})
+	return nil
+}
+
+func runRemove(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("rm", "rm 文件ID...")
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	type result struct {
+		ID    string `json:"id"`
+		Error string `json:"error,omitempty"`
+	}
+	results := make([]result, 0, len(ids))
+	failed := 0
+	for _, id := range ids {
+		r := result{ID: id}
+		if err := c.call(http.MethodDelete, "/oss/files/"+url.PathEscape(id), nil, nil, nil); err != nil {
+			r.Error = err.Error()
+			failed++
+		}
+		results = append(results, r)
+	}
+	g.print(results, func() {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(os.Stderr, "%s: 删除失败: %s\n", r.ID, r.Error)
+			} else {
+				fmt.Printf("%s: 已删除\n", r.ID)
+			}
+		}
+	})
+	if failed > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func runURL(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("url", "url [--expire-hours N] 文件ID")
+	expireHours := fs.Int("expire-hours", 0, "有效期（小时），默认使用服务端配置")
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	link, err := c.downloadURL(ids[0], *expireHours)
+	if err != nil {
+		return err
+	}
+	g.print(map[string]string{"file_id": ids[0], "download_url": link}, func() { fmt.Println(link) })
+	return nil
+}
+
+func runShare(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("share", "share [--expire-hours N] [--password 密码] [--max-downloads N] 文件ID")
+	req := struct {
+		Password     string `json:"password,omitempty"`
+		ExpireHours  int    `json:"expire_hours"`
+		MaxDownloads int    `json:"max_downloads"`
+	}{}
+	fs.IntVar(&req.ExpireHours, "expire-hours", 0, "有效期（小时），0 表示永不过期")
+	fs.StringVar(&req.Password, "password", "", "访问密码")
+	fs.IntVar(&req.MaxDownloads, "max-downloads", 0, "最大下载次数，0 表示不限制")
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	c, err := g.authedClient()
+	if err != nil {
+		return err
+	}
+	var link map[string]interface{}
+	if err := c.call(http.MethodPost, "/oss/files/"+url.PathEscape(ids[0])+"/share", nil, req, &link); err != nil {
+		return err
+	}
+	token, _ := link["token"].(string)
+	link["url"] = c.server + "/s/" + token
+	g.print(link, func() { fmt.Println(link["url"]) })
+	return nil
+}
+
+func runWatch(ctx context.Context, g *globalOptions, args []string) error {
+	fs := g.newFlags("watch", "watch 任务ID")
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	c, _, err := g.client()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	last, err := c.followTask(ctx, ids[0], func(p taskProgress) {
+		if g.json {
+			// 每条进度一行，便于脚本逐行处理
+			enc.Encode(p)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "\r%-10s %5.1f%%  %s / %s   ", p.Status, p.Percentage, formatSize(p.Uploaded), formatSize(p.Total))
+	})
+	if !g.json {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+	if last.Status == statusFailed {
+		return fmt.Errorf("任务失败: %s", last.Message)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// downloadResult 下载结果
+type downloadResult struct {
+	FileID  string `json:"file_id"`
+	Output  string `json:"output"`
+	Size    int64  `json:"size"`
+	Resumed bool   `json:"resumed"`
+}
+
+// downloadURL 获取文件的临时下载链接
+func (c *client) downloadURL(fileID string, expireHours int) (string, error) {
+	var query url.Values
+	if expireHours > 0 {
+		query = url.Values{"expire_hours": {strconv.Itoa(expireHours)}}
+	}
+	var resp struct {
+		DownloadURL string `json:"download_url"`
+	}
+	if err := c.call(http.MethodGet, "/oss/files/"+url.PathEscape(fileID)+"/download", query, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.DownloadURL, nil
+}
+
+// download 下载文件到本地，output 为 "-" 时写到标准输出
+// 写入文件时先写到 .part 临时文件，中断后再次执行会按已下载长度续传
+func (c *client) download(ctx context.Context, fileID, output string, progress bool) (*downloadResult, error) {
+	link, err := c.downloadURL(fileID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	toStdout := output == "-"
+	var partPath string
+	var offset int64
+	if !toStdout {
+		if output == "" {
+			output = linkFilename(link)
+		} else if info, err := os.Stat(output); err == nil && info.IsDir() {
+			output = filepath.Join(output, linkFilename(link))
+		}
+		partPath = output + ".part"
+		if info, err := os.Stat(partPath); err == nil {
+			offset = info.Size()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.transfer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 临时文件已是完整内容
+		if partPath != "" {
+			if err := os.Rename(partPath, output); err != nil {
+				return nil, err
+			}
+		}
+		return &downloadResult{FileID: fileID, Output: output, Size: offset, Resumed: true}, nil
+	default:
+		return nil, fmt.Errorf("下载失败，状态码: %d", resp.StatusCode)
+	}
+
+	var w io.Writer = os.Stdout
+	if !toStdout {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if offset > 0 {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(partPath, flags, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	total := offset + resp.ContentLength
+	if resp.ContentLength < 0 {
+		total = 0
+	}
+	bar := newProgressBar(filepath.Base(output), total, progress && !toStdout)
+	bar.Set(offset)
+	bar.Start()
+	n, err := io.Copy(w, &countingReader{r: resp.Body, bar: bar})
+	bar.Stop()
+	if err != nil {
+		return nil, fmt.Errorf("下载中断: %w", err)
+	}
+
+	if !toStdout {
+		if f, ok := w.(*os.File); ok {
+			if err := f.Close(); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.Rename(partPath, output); err != nil {
+			return nil, err
+		}
+	}
+	return &downloadResult{FileID: fileID, Output: output, Size: offset + n, Resumed: offset > 0}, nil
+}
+
+// linkFilename 从下载链接的对象路径推断文件名
+func linkFilename(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		if name := path.Base(u.Path); name != "/" && name != "." {
+			return name
+		}
+	}
+	return "download"
+}
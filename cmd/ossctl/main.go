@@ -0,0 +1,231 @@
+// ossctl 是 OSS Manager 的命令行客户端，支持登录、上传、下载、列表、删除和分享
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// globalOptions 所有子命令共用的参数
+type globalOptions struct {
+	server string
+	json   bool
+	quiet  bool
+}
+
+// register 注册全局参数
+func (g *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.server, "server", g.server, "服务地址，默认使用登录时的地址")
+	fs.BoolVar(&g.json, "json", g.json, "以 JSON 格式输出结果")
+	fs.BoolVar(&g.quiet, "q", g.quiet, "不显示进度")
+}
+
+// command 子命令
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, g *globalOptions, args []string) error
+}
+
+var commands = []command{
+	{"login", "登录并保存令牌", runLogin},
+	{"logout", "删除本地保存的令牌", runLogout},
+	{"whoami", "显示当前登录用户", runWhoami},
+	{"upload", "上传文件，大文件自动分片并支持续传", runUpload},
+	{"download", "下载文件，中断后再次执行可续传", runDownload},
+	{"ls", "列出文件", runList},
+	{"rm", "删除文件（移入回收站）", runRemove},
+	{"url", "生成临时下载链接", runURL},
+	{"share", "创建分享链接", runShare},
+	{"watch", "通过 SSE 跟踪上传或后台任务进度", runWatch},
+}
+
+func main() {
+	g := &globalOptions{}
+	g.server = os.Getenv("OSSCTL_SERVER")
+	fs := flag.NewFlagSet("ossctl", flag.ContinueOnError)
+	g.register(fs)
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if fs.NArg() == 0 {
+		usage(fs)
+		os.Exit(2)
+	}
+
+	name, args := fs.Arg(0), fs.Args()[1:]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := cmd.run(ctx, g, args)
+		stop()
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				os.Exit(2)
+			}
+			g.fail(err)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	usage(fs)
+	os.Exit(2)
+}
+
+// usage 输出帮助信息
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "用法: ossctl [全局参数] <命令> [参数]")
+	fmt.Fprintln(os.Stderr, "\n命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\n全局参数:")
+	fs.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "\n环境变量: OSSCTL_SERVER 服务地址，OSSCTL_PASSWORD 登录密码，OSSCTL_HOME 本地配置目录")
+}
+
+// newFlags 创建子命令参数集，usage 为不含程序名的用法说明
+// 全局参数也可以写在子命令之后
+func (g *globalOptions) newFlags(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	g.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: ossctl %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs 解析子命令参数，允许参数和位置参数交替出现
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// client 创建已登录的客户端
+func (g *globalOptions) client() (*client, *credentials, error) {
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取本地凭证失败: %w", err)
+	}
+	server := g.server
+	if server == "" {
+		server = creds.Server
+	}
+	if server == "" {
+		server = defaultServer
+	}
+	return newClient(server, creds.Token), creds, nil
+}
+
+// authedClient 创建已登录的客户端，未登录时报错
+func (g *globalOptions) authedClient() (*client, error) {
+	c, creds, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	if creds.Token == "" {
+		return nil, errors.New("尚未登录，请先执行 ossctl login")
+	}
+	return c, nil
+}
+
+// progress 是否显示进度
+func (g *globalOptions) progress() bool {
+	return !g.json && !g.quiet
+}
+
+// print 输出结果，JSON 模式下输出 v，否则调用 human 输出可读文本
+func (g *globalOptions) print(v interface{}, human func()) {
+	if g.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		return
+	}
+	human()
+}
+
+// fail 输出错误并退出，JSON 模式下错误同样以 JSON 输出
+func (g *globalOptions) fail(err error) {
+	if g.json {
+		out := map[string]interface{}{"error": err.Error()}
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			out["error"] = apiErr.Message
+			out["code"] = apiErr.Code
+		}
+		json.NewEncoder(os.Stdout).Encode(out)
+	} else {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+	}
+	os.Exit(1)
+}
+
+// parseSize 解析带单位的大小，例如 10M、100MiB、1G，不带单位时为字节
+func parseSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("无效的大小: %q", raw)
+	}
+	return value * multiplier, nil
+}
+
+// sizeFlag 以带单位的大小作为参数值
+type sizeFlag struct{ value *int64 }
+
+func (f sizeFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return formatSize(*f.value)
+}
+
+func (f sizeFlag) Set(s string) error {
+	v, err := parseSize(s)
+	if err != nil {
+		return err
+	}
+	*f.value = v
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{"1024": 1024, "10M": 10 << 20, "100MiB": 100 << 20, "2g": 2 << 30, "512KB": 512 << 10}
+	for in, want := range cases {
+		got, err := parseSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "M", "-1", "10X", "1.5G"} {
+		_, err := parseSize(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestPlanChunkSize(t *testing.T) {
+	assert.Equal(t, int64(minChunkSize), planChunkSize(1<<20, 1))
+	assert.Equal(t, int64(defaultChunkSize), planChunkSize(1<<30, defaultChunkSize))
+	// 分片数超过上限时放大分片
+	size := int64(200) << 30
+	chunk := planChunkSize(size, defaultChunkSize)
+	assert.LessOrEqual(t, (size+chunk-1)/chunk, int64(maxParts))
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := "event:connected\ndata:{\"taskId\":\"t\"}\n\n" +
+		": heartbeat comment\n\n" +
+		"event:progress\ndata:{\"uploaded\":10,\"total\":100,\"status\":\"uploading\"}\n\n" +
+		"event:progress\ndata: {\"uploaded\":100,\"total\":100,\"status\":\"completed\"}\n\n" +
+		"event:progress\ndata:{\"uploaded\":0}\n\n"
+
+	var names, data []string
+	err := readEvents(strings.NewReader(stream), func(e sseEvent) bool {
+		names = append(names, e.Name)
+		data = append(data, e.Data)
+		return !strings.Contains(e.Data, statusCompleted)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"connected", "progress", "progress"}, names)
+	assert.Equal(t, `{"uploaded":100,"total":100,"status":"completed"}`, data[2])
+}
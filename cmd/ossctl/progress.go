@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 服务端任务的终止状态
+const (
+	statusCompleted = "completed"
+	statusFailed    = "failed"
+	statusCancelled = "cancelled"
+)
+
+// taskProgress 服务端推送的任务进度
+type taskProgress struct {
+	Total      int64   `json:"total"`
+	Uploaded   int64   `json:"uploaded"`
+	Percentage float64 `json:"percentage"`
+	Speed      int64   `json:"speed"`
+	Status     string  `json:"status"`
+	Type       string  `json:"type,omitempty"`
+	Message    string  `json:"message,omitempty"`
+}
+
+// finished 任务是否已结束
+func (p taskProgress) finished() bool {
+	return p.Status == statusCompleted || p.Status == statusFailed || p.Status == statusCancelled
+}
+
+// sseEvent 一条服务端推送事件
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+// readEvents 按行解析 text/event-stream，每解析出一条事件调用一次 fn，fn 返回 false 时停止
+func readEvents(r io.Reader, fn func(sseEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	var event sseEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event.Name != "" || len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				if !fn(event) {
+					return nil
+				}
+			}
+			event, data = sseEvent{}, nil
+		case strings.HasPrefix(line, ":"):
+			// 注释行
+		case strings.HasPrefix(line, "event:"):
+			event.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
+
+// followTask 通过 SSE 订阅任务进度，直到任务结束、连接关闭或 ctx 取消
+// 返回最后收到的进度
+func (c *client) followTask(ctx context.Context, taskID string, onProgress func(taskProgress)) (taskProgress, error) {
+	var last taskProgress
+	req, err := c.newRequest(http.MethodGet, "/uploads/"+taskID+"/stream", nil, nil)
+	if err != nil {
+		return last, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.transfer.Do(req)
+	if err != nil {
+		return last, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return last, &apiError{Code: resp.StatusCode, Message: "任务不存在或已结束"}
+	}
+
+	err = readEvents(resp.Body, func(e sseEvent) bool {
+		switch e.Name {
+		case "progress":
+			var p taskProgress
+			if json.Unmarshal([]byte(e.Data), &p) != nil {
+				return true
+			}
+			last = p
+			if onProgress != nil {
+				onProgress(p)
+			}
+			return !p.finished()
+		case "complete":
+			return false
+		}
+		return true
+	})
+	if ctx.Err() != nil {
+		return last, nil
+	}
+	return last, err
+}
+
+// progressBar 在标准错误输出上刷新单行进度
+type progressBar struct {
+	label   string
+	total   int64
+	done    atomic.Int64
+	start   time.Time
+	enabled bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// newProgressBar 创建进度条，enabled 为 false 时不输出任何内容
+func newProgressBar(label string, total int64, enabled bool) *progressBar {
+	return &progressBar{label: label, total: total, start: time.Now(), enabled: enabled}
+}
+
+// Add 增加已完成字节数
+func (p *progressBar) Add(n int64) {
+	p.done.Add(n)
+}
+
+// Set 设置已完成字节数
+func (p *progressBar) Set(n int64) {
+	p.done.Store(n)
+}
+
+// Start 定时刷新进度，直到调用 Stop
+func (p *progressBar) Start() {
+	if !p.enabled {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render()
+			case <-p.stop:
+				p.render()
+				fmt.Fprintln(os.Stderr)
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止刷新并换行
+func (p *progressBar) Stop() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.stopped
+	p.stop = nil
+}
+
+// render 输出当前进度
+func (p *progressBar) render() {
+	done := p.done.Load()
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(done) * 100 / float64(p.total)
+	}
+	var speed int64
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		speed = int64(float64(done) / elapsed)
+	}
+	fmt.Fprintf(os.Stderr, "\r%s  %s / %s  %5.1f%%  %s/s   ",
+		p.label, formatSize(done), formatSize(p.total), percent, formatSize(speed))
+}
+
+// formatSize 以二进制单位格式化字节数
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// countingReader 读取时累计进度，n 为已读取字节数，用于失败重试时回退进度
+type countingReader struct {
+	r   io.Reader
+	bar *progressBar
+	n   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	r.bar.Add(int64(n))
+	return n, err
+}
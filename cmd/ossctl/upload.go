@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultChunkSize 默认分片大小
+	defaultChunkSize = 10 << 20
+	// minChunkSize 存储服务允许的最小分片（最后一片除外）
+	minChunkSize = 100 << 10
+	// maxParts 单次分片上传的分片数上限
+	maxParts = 10000
+	// partURLBatch 单次向服务端申请的分片上传链接数
+	partURLBatch = 100
+	// partRetries 单个分片的上传尝试次数
+	partRetries = 3
+)
+
+// uploadOptions 上传参数
+type uploadOptions struct {
+	Region      string
+	Bucket      string
+	Path        string // 目标目录，为空时由服务端生成对象路径
+	Tags        string // 标签，格式为 k1=v1&k2=v2
+	Force       bool   // 同路径文件已存在时覆盖
+	ChunkSize   int64
+	Threshold   int64 // 超过该大小时使用分片上传
+	Concurrency int
+	Progress    bool
+}
+
+// uploadState 分片上传的本地续传状态
+type uploadState struct {
+	SessionID string         `json:"session_id"`
+	UploadID  string         `json:"upload_id"`
+	Region    string         `json:"region_code"`
+	Bucket    string         `json:"bucket_name"`
+	ObjectKey string         `json:"object_key"`
+	FileSize  int64          `json:"file_size"`
+	ModTime   time.Time      `json:"mod_time"`
+	ChunkSize int64          `json:"chunk_size"`
+	ETags     map[int]string `json:"etags"`
+}
+
+// upload 上传本地文件，返回服务端的文件记录
+// 小文件经服务端流式上传并通过 SSE 跟踪进度，大文件由客户端直传分片并支持续传
+func (c *client) upload(ctx context.Context, localPath string, opts uploadOptions) (json.RawMessage, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s 是目录", localPath)
+	}
+	if info.Size() == 0 {
+		return nil, errors.New("不能上传空文件")
+	}
+
+	if info.Size() <= opts.Threshold {
+		return c.streamUpload(ctx, f, info, opts)
+	}
+	return c.multipartUpload(ctx, f, localPath, info, opts)
+}
+
+// streamUpload 以请求体流式上传文件，进度由服务端通过 SSE 推送
+func (c *client) streamUpload(ctx context.Context, f *os.File, info os.FileInfo, opts uploadOptions) (json.RawMessage, error) {
+	var task struct {
+		ID string `json:"id"`
+	}
+	if err := c.call(http.MethodPost, "/uploads/init", nil, map[string]int64{"total": info.Size()}, &task); err != nil {
+		return nil, fmt.Errorf("创建进度任务失败: %w", err)
+	}
+
+	bar := newProgressBar(info.Name(), info.Size(), opts.Progress)
+	bar.Start()
+	defer bar.Stop()
+	followCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if opts.Progress {
+		go c.followTask(followCtx, task.ID, func(p taskProgress) { bar.Set(p.Uploaded) })
+	}
+
+	req, err := c.newRequest(http.MethodPost, "/oss/files", nil, f)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-File-Name", info.Name())
+	req.Header.Set("region_code", opts.Region)
+	req.Header.Set("bucket_name", opts.Bucket)
+	req.Header.Set("Upload-Task-ID", task.ID)
+	req.Header.Set("X-Chunk-Threshold", strconv.FormatInt(opts.Threshold, 10))
+	if opts.Path != "" {
+		req.Header.Set("X-Custom-Path", opts.Path)
+	}
+	if opts.Force {
+		req.Header.Set("X-Force-Overwrite", "true")
+	}
+	if opts.Tags != "" {
+		req.Header.Set("X-File-Tags", opts.Tags)
+	}
+
+	var file json.RawMessage
+	if err := c.do(c.transfer, req, &file); err != nil {
+		return nil, err
+	}
+	bar.Set(info.Size())
+	return file, nil
+}
+
+// errFileExists 目标路径已有文件
+var errFileExists = errors.New("在相同路径下文件已存在，使用 --force 覆盖")
+
+// checkExists 分片上传不经服务端检查同路径文件，非强制覆盖时先行确认
+func (c *client) checkExists(bucket, objectKey string) error {
+	var resp struct {
+		Items []struct {
+			ObjectKey string `json:"object_key"`
+		} `json:"items"`
+	}
+	query := url.Values{"bucket_name": {bucket}, "prefix": {objectKey}, "limit": {"20"}}
+	if err := c.call(http.MethodGet, "/oss/files", query, nil, &resp); err != nil {
+		return err
+	}
+	for _, item := range resp.Items {
+		if item.ObjectKey == objectKey {
+			return errFileExists
+		}
+	}
+	return nil
+}
+
+// planChunkSize 调整分片大小，保证分片数不超过上限
+func planChunkSize(fileSize, chunkSize int64) int64 {
+	if chunkSize < minChunkSize {
+		chunkSize = minChunkSize
+	}
+	if need := (fileSize + maxParts - 1) / maxParts; chunkSize < need {
+		// 向上取整到 MiB，便于阅读
+		chunkSize = (need + 1<<20 - 1) &^ (1<<20 - 1)
+	}
+	return chunkSize
+}
+
+// statePath 分片上传续传状态文件路径，同一文件上传到同一位置时复用
+func (c *client) statePath(localPath string, opts uploadOptions) (string, error) {
+	abs, err := filepath.Abs(localPath)
+	if err != nil {
+		return "", err
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.server, abs, opts.Region, opts.Bucket, opts.Path}, "\x00")))
+	return filepath.Join(dir, "uploads", hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// loadUploadState 读取续传状态，文件已变化或状态损坏时返回 nil
+func loadUploadState(path string, info os.FileInfo) *uploadState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state uploadState
+	if json.Unmarshal(data, &state) != nil || state.SessionID == "" ||
+		state.FileSize != info.Size() || !state.ModTime.Equal(info.ModTime()) || state.ChunkSize <= 0 {
+		return nil
+	}
+	if state.ETags == nil {
+		state.ETags = make(map[int]string)
+	}
+	return &state
+}
+
+// multipartTransfer 一次分片上传的运行状态
+type multipartTransfer struct {
+	c     *client
+	f     *os.File
+	state *uploadState
+	path  string // 续传状态文件，为空时不保存
+	total int
+	bar   *progressBar
+
+	mu   sync.Mutex
+	urls map[int]string
+}
+
+// multipartUpload 通过分片接口直传存储服务，中断后再次执行同一命令即可续传
+func (c *client) multipartUpload(ctx context.Context, f *os.File, localPath string, info os.FileInfo, opts uploadOptions) (json.RawMessage, error) {
+	statePath, err := c.statePath(localPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := &multipartTransfer{c: c, f: f, path: statePath, urls: make(map[int]string)}
+
+	if state := loadUploadState(statePath, info); state != nil {
+		var detail struct {
+			Parts []int `json:"parts"`
+		}
+		if err := c.call(http.MethodGet, "/uploads/mine/"+state.SessionID, nil, nil, &detail); err != nil {
+			// 会话已结束或已过期，重新开始
+			os.Remove(statePath)
+		} else {
+			// 以存储端实际存在的分片为准
+			uploaded := make(map[int]bool, len(detail.Parts))
+			for _, n := range detail.Parts {
+				uploaded[n] = true
+			}
+			for n := range state.ETags {
+				if !uploaded[n] {
+					delete(state.ETags, n)
+				}
+			}
+			t.state = state
+		}
+	}
+
+	if t.state == nil {
+		if opts.Path != "" && !opts.Force {
+			objectKey := info.Name()
+			if dir := strings.Trim(opts.Path, "/"); dir != "" {
+				objectKey = dir + "/" + objectKey
+			}
+			if err := c.checkExists(opts.Bucket, objectKey); err != nil {
+				return nil, err
+			}
+		}
+		if err := t.init(info, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	t.total = int((info.Size() + t.state.ChunkSize - 1) / t.state.ChunkSize)
+	if t.state.SessionID == "" && t.total > len(t.urls) {
+		return nil, fmt.Errorf("服务端未启用上传会话，最多支持 %d 个分片，请增大 --chunk-size", len(t.urls))
+	}
+
+	var pending []int
+	var done int64
+	for n := 1; n <= t.total; n++ {
+		if t.state.ETags[n] != "" {
+			done += t.partSize(n)
+		} else {
+			pending = append(pending, n)
+		}
+	}
+
+	t.bar = newProgressBar(info.Name(), info.Size(), opts.Progress)
+	t.bar.Set(done)
+	t.bar.Start()
+	err = t.run(ctx, pending, opts.Concurrency)
+	t.bar.Stop()
+	if err != nil {
+		if t.path != "" {
+			return nil, fmt.Errorf("%w（再次执行相同命令可续传）", err)
+		}
+		return nil, err
+	}
+	return t.complete(opts)
+}
+
+// init 初始化分片上传并保存续传状态
+func (t *multipartTransfer) init(info os.FileInfo, opts uploadOptions) error {
+	chunkSize := planChunkSize(info.Size(), opts.ChunkSize)
+	var resp struct {
+		UploadID  string   `json:"upload_id"`
+		ObjectKey string   `json:"object_key"`
+		URLs      []string `json:"urls"`
+		SessionID string   `json:"session_id"`
+	}
+	err := t.c.call(http.MethodPost, "/oss/multipart/init", nil, map[string]interface{}{
+		"region_code": opts.Region,
+		"bucket_name": opts.Bucket,
+		"file_name":   info.Name(),
+		"file_size":   info.Size(),
+		"chunk_size":  chunkSize,
+		"custom_path": opts.Path,
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("初始化分片上传失败: %w", err)
+	}
+	for i, u := range resp.URLs {
+		t.urls[i+1] = u
+	}
+
+	t.state = &uploadState{
+		SessionID: resp.SessionID,
+		UploadID:  resp.UploadID,
+		Region:    opts.Region,
+		Bucket:    opts.Bucket,
+		ObjectKey: resp.ObjectKey,
+		FileSize:  info.Size(),
+		ModTime:   info.ModTime(),
+		ChunkSize: chunkSize,
+		ETags:     make(map[int]string),
+	}
+	if resp.SessionID == "" {
+		t.path = ""
+		return nil
+	}
+	return t.save()
+}
+
+// save 保存续传状态
+func (t *multipartTransfer) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(t.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(t.path, data, 0o600)
+}
+
+// partSize 分片大小，最后一片可能较小
+func (t *multipartTransfer) partSize(n int) int64 {
+	if n == t.total {
+		return t.state.FileSize - int64(n-1)*t.state.ChunkSize
+	}
+	return t.state.ChunkSize
+}
+
+// run 并发上传待传分片，任一分片失败时停止
+func (t *multipartTransfer) run(ctx context.Context, pending []int, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				if err := t.uploadPart(ctx, n); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, n := range pending {
+		select {
+		case jobs <- n:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// uploadPart 上传单个分片，链接过期时重新申请，网络错误时重试
+func (t *multipartTransfer) uploadPart(ctx context.Context, n int) error {
+	var lastErr error
+	refresh := false
+	for attempt := 0; attempt < partRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		url, err := t.partURL(n, refresh)
+		if err != nil {
+			return err
+		}
+		etag, status, err := t.putPart(ctx, url, n)
+		if err == nil {
+			t.mu.Lock()
+			t.state.ETags[n] = etag
+			err = t.save()
+			t.mu.Unlock()
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+		// 预签名链接过期时存储服务返回 403
+		refresh = status == http.StatusForbidden
+	}
+	return fmt.Errorf("上传分片 %d 失败: %w", n, lastErr)
+}
+
+// putPart 将分片数据 PUT 到预签名链接，返回去掉引号的 ETag
+func (t *multipartTransfer) putPart(ctx context.Context, url string, n int) (string, int, error) {
+	size := t.partSize(n)
+	body := &countingReader{r: io.NewSectionReader(t.f, int64(n-1)*t.state.ChunkSize, size), bar: t.bar}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return "", 0, err
+	}
+	req.ContentLength = size
+	// 预签名时包含了 Content-Type，必须一致
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := t.c.transfer.Do(req)
+	if err != nil {
+		t.bar.Add(-body.n)
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.bar.Add(-body.n)
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			return "", resp.StatusCode, fmt.Errorf("状态码 %d: %s", resp.StatusCode, data)
+		}
+		return "", resp.StatusCode, fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if etag == "" {
+		return "", resp.StatusCode, errors.New("存储服务未返回 ETag")
+	}
+	return etag, resp.StatusCode, nil
+}
+
+// partURL 获取分片上传链接，缺失或需要刷新时批量向服务端申请后续分片的链接
+func (t *multipartTransfer) partURL(n int, refresh bool) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if url, ok := t.urls[n]; ok && !refresh {
+		return url, nil
+	}
+	if t.state.SessionID == "" {
+		return "", fmt.Errorf("分片 %d 的上传链接已失效", n)
+	}
+
+	batch := []int{n}
+	for m := n + 1; m <= t.total && len(batch) < partURLBatch; m++ {
+		if _, ok := t.urls[m]; !ok && t.state.ETags[m] == "" {
+			batch = append(batch, m)
+		}
+	}
+	var resp struct {
+		URLs map[string]string `json:"urls"`
+	}
+	err := t.c.call(http.MethodPost, "/oss/multipart/urls", nil, map[string]interface{}{
+		"session_id":   t.state.SessionID,
+		"part_numbers": batch,
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("获取分片上传链接失败: %w", err)
+	}
+	for k, v := range resp.URLs {
+		if m, err := strconv.Atoi(k); err == nil {
+			t.urls[m] = v
+		}
+	}
+	url, ok := t.urls[n]
+	if !ok {
+		return "", fmt.Errorf("服务端未返回分片 %d 的上传链接", n)
+	}
+	return url, nil
+}
+
+// complete 按分片顺序提交 ETag 完成上传，成功后删除续传状态
+func (t *multipartTransfer) complete(opts uploadOptions) (json.RawMessage, error) {
+	numbers := make([]int, 0, len(t.state.ETags))
+	for n := range t.state.ETags {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	etags := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		etags = append(etags, t.state.ETags[n])
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"session_id":        t.state.SessionID,
+		"region_code":       t.state.Region,
+		"bucket_name":       t.state.Bucket,
+		"object_key":        t.state.ObjectKey,
+		"upload_id":         t.state.UploadID,
+		"parts":             etags,
+		"original_filename": filepath.Base(t.f.Name()),
+		"file_size":         t.state.FileSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := t.c.newRequest(http.MethodPost, "/oss/multipart/complete", nil, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.Tags != "" {
+		req.Header.Set("X-File-Tags", opts.Tags)
+	}
+
+	var file json.RawMessage
+	if err := t.c.do(t.c.transfer, req, &file); err != nil {
+		return nil, fmt.Errorf("完成分片上传失败: %w", err)
+	}
+	if t.path != "" {
+		os.Remove(t.path)
+	}
+	return file, nil
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxAccessKeysPerUser 每个用户最多可创建的访问密钥数量
+const maxAccessKeysPerUser = 5
+
+// AccessKeyHandler S3 兼容接口访问密钥处理器
+type AccessKeyHandler struct {
+	*BaseHandler
+	DB *gorm.DB
+}
+
+// NewAccessKeyHandler 创建访问密钥处理器
+func NewAccessKeyHandler(db *gorm.DB) *AccessKeyHandler {
+	return &AccessKeyHandler{
+		BaseHandler: NewBaseHandler(),
+		DB:          db,
+	}
+}
+
+// AccessKeyRequest 创建或更新访问密钥请求
+type AccessKeyRequest struct {
+	Description string `json:"description" binding:"max=255"`
+	Status      string `json:"status" binding:"omitempty,oneof=ACTIVE DISABLED"`
+}
+
+// newAccessKeyPair 生成访问密钥ID和密钥
+func newAccessKeyPair() (string, string, error) {
+	id := make([]byte, 10)
+	secret := make([]byte, 30)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	return "OSSM" + base32.StdEncoding.EncodeToString(id), base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// List 获取当前用户的访问密钥，不返回密钥原文
+func (h *AccessKeyHandler) List(c *gin.Context) {
+	var keys []models.AccessKey
+	if err := h.DB.WithContext(c).Where("user_id = ?", c.GetUint("userID")).Order("id DESC").Find(&keys).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取访问密钥列表失败")
+		return
+	}
+	h.Success(c, keys)
+}
+
+// Create 创建访问密钥，密钥原文仅在创建时返回一次
+func (h *AccessKeyHandler) Create(c *gin.Context) {
+	var req AccessKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	userID := c.GetUint("userID")
+	var count int64
+	if err := h.DB.WithContext(c).Model(&models.AccessKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取访问密钥数量失败")
+		return
+	}
+	if count >= maxAccessKeysPerUser {
+		h.Error(c, utils.CodeInvalidParams, "访问密钥数量已达上限")
+		return
+	}
+
+	accessKeyID, secretKey, err := newAccessKeyPair()
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "生成访问密钥失败")
+		return
+	}
+	key := models.AccessKey{
+		UserID:      userID,
+		AccessKeyID: accessKeyID,
+		SecretKey:   secrets.String(secretKey),
+		Description: req.Description,
+		Status:      models.AccessKeyActive,
+	}
+	if err := h.DB.WithContext(c).Create(&key).Error; err != nil {
+		logger.Error("创建访问密钥失败", zap.Uint("user_id", userID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "创建访问密钥失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"access_key":        key,
+		"secret_access_key": secretKey,
+	})
+}
+
+// Update 更新访问密钥的描述或启用状态
+func (h *AccessKeyHandler) Update(c *gin.Context) {
+	key, ok := h.getOwnKey(c)
+	if !ok {
+		return
+	}
+	var req AccessKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	updates := map[string]interface{}{"description": req.Description}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if err := h.DB.WithContext(c).Model(key).Updates(updates).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "更新访问密钥失败")
+		return
+	}
+	h.Success(c, key)
+}
+
+// Delete 删除访问密钥
+func (h *AccessKeyHandler) Delete(c *gin.Context) {
+	key, ok := h.getOwnKey(c)
+	if !ok {
+		return
+	}
+	if err := h.DB.WithContext(c).Delete(key).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "删除访问密钥失败")
+		return
+	}
+	h.Success(c, nil)
+}
+
+// getOwnKey 获取当前用户的访问密钥，失败时已写入响应
+func (h *AccessKeyHandler) getOwnKey(c *gin.Context) (*models.AccessKey, bool) {
+	var key models.AccessKey
+	err := h.DB.WithContext(c).Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("userID")).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.Error(c, utils.CodeNotFound, "访问密钥不存在")
+		return nil, false
+	}
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取访问密钥失败")
+		return nil, false
+	}
+	return &key, true
+}
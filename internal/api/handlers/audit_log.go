@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/myysophia/ossmanager-backend/internal/db"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // AuditLogHandler 审计日志处理器
@@ -50,7 +54,7 @@ func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
 		zap.String("method", c.Request.Method))
 
 	// 构建查询条件
-	query := db.GetDB().Model(&models.AuditLog{})
+	query := db.GetDB().WithContext(c).Model(&models.AuditLog{})
 
 	// 处理时间范围筛选
 	startTimeStr := c.Query("start_time")
@@ -153,3 +157,52 @@ func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
 		"items":     logs,
 	})
 }
+
+// recordObjectAudit 异步记录协议接口（S3、WebDAV）对单个对象的操作审计日志
+// 这些接口不经过审计日志中间件，用户信息取自各自认证中间件写入的上下文
+func recordObjectAudit(gdb *gorm.DB, c *gin.Context, resourceType, action, status, bucket, key string, extra map[string]interface{}) {
+	fields := map[string]interface{}{
+		"bucket": bucket,
+		"key":    key,
+	}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	details, err := json.Marshal(fields)
+	if err != nil {
+		details = []byte("{}")
+	}
+
+	auditLog := &models.AuditLog{
+		OrgID:        tenant.OrgID(c),
+		UserID:       c.GetUint("userID"),
+		Username:     c.GetString("username"),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   truncateResourceID(bucket + "/" + key),
+		Details:      string(details),
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Status:       status,
+	}
+
+	go func(log *models.AuditLog) {
+		if err := gdb.Create(log).Error; err != nil {
+			logger.Error("保存对象操作审计日志失败", zap.Error(err), zap.String("action", log.Action))
+		}
+	}(auditLog)
+}
+
+// truncateResourceID 截断过长的资源ID以适配审计日志字段长度，完整对象键保存在详情中
+func truncateResourceID(id string) string {
+	const maxLen = 100
+	if len(id) <= maxLen {
+		return id
+	}
+	for i := maxLen; i > 0; i-- {
+		if utf8.RuneStart(id[i]) {
+			return id[:i]
+		}
+	}
+	return ""
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/myysophia/ossmanager-backend/internal/config"
 	"github.com/myysophia/ossmanager-backend/internal/db"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
 	"github.com/myysophia/ossmanager-backend/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -137,7 +138,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// 打印绑定后的结构体
 	fmt.Printf("【DEBUG】绑定后的请求结构体: %+v\n", req)
 
-	// 检查用户名是否已存在
+	// 检查用户名是否已存在，用户名和邮箱在所有组织中唯一
 	var count int64
 	if err := db.GetDB().Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
 		fmt.Printf("【ERROR】检查用户名失败: %v\n", err)
@@ -170,6 +171,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// 自助注册的用户归属默认组织
+	orgCtx := tenant.WithScope(c, &tenant.Scope{OrgID: models.DefaultOrgID, UserOrgID: models.DefaultOrgID})
+
 	// 创建用户
 	user := models.User{
 		Username: req.Username,
@@ -181,7 +185,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	fmt.Printf("【DEBUG】准备创建用户: %+v\n", user)
 
-	if err := db.GetDB().Create(&user).Error; err != nil {
+	if err := db.GetDB().WithContext(orgCtx).Create(&user).Error; err != nil {
 		fmt.Printf("【ERROR】创建用户失败: %v\n", err)
 		utils.ResponseError(c, utils.CodeInternalError, errors.New("创建用户失败"))
 		return
@@ -191,13 +195,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// 分配默认角色
 	var defaultRole models.Role
-	if err := db.GetDB().Where("name = ?", "user").First(&defaultRole).Error; err != nil {
+	if err := db.GetDB().WithContext(orgCtx).Where("name = ?", "user").First(&defaultRole).Error; err != nil {
 		fmt.Printf("【ERROR】获取默认角色失败: %v\n", err)
 		utils.ResponseError(c, utils.CodeInternalError, errors.New("获取默认角色失败"))
 		return
 	}
 
-	if err := db.GetDB().Model(&user).Association("Roles").Append(&defaultRole); err != nil {
+	if err := db.GetDB().WithContext(orgCtx).Model(&user).Association("Roles").Append(&defaultRole); err != nil {
 		fmt.Printf("【ERROR】分配角色失败: %v\n", err)
 		utils.ResponseError(c, utils.CodeInternalError, errors.New("分配角色失败"))
 		return
@@ -222,6 +226,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
+	// 全局管理员切换组织后仍然查询自己的记录，不限制组织
 	var user models.User
 	if err := db.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
 		utils.ResponseError(c, utils.CodeNotFound, errors.New("用户不存在"))
@@ -240,8 +245,10 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		"username":    user.Username,
 		"email":       user.Email,
 		"real_name":   user.RealName,
+		"org_id":      user.OrgID,
 		"roles":       user.Roles,
 		"permissions": permissions,
+		"scope":       tenant.FromContext(c),
 	})
 }
 
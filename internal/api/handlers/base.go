@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
 	"github.com/myysophia/ossmanager-backend/internal/utils"
 )
 
@@ -50,3 +51,24 @@ func (h *BaseHandler) NotFound(c *gin.Context, message string) {
 func (h *BaseHandler) InternalError(c *gin.Context, message string) {
 	utils.ResponseError(c, utils.CodeInternalError, errors.New(message))
 }
+
+// requireOrgAdmin 检查当前用户是否为组织管理员，不是时已写入响应
+// 会批量删除或放宽限制的管理操作在路由中间件之外再检查一次，路由配置遗漏时也不会被普通用户调用
+func (h *BaseHandler) requireOrgAdmin(c *gin.Context) bool {
+	scope := tenant.FromContext(c)
+	if scope == nil || !scope.OrgAdmin {
+		h.Forbidden(c, "没有权限执行此操作")
+		return false
+	}
+	return true
+}
+
+// requireGlobalAdmin 检查当前用户是否为全局管理员，不是时已写入响应
+func (h *BaseHandler) requireGlobalAdmin(c *gin.Context) bool {
+	scope := tenant.FromContext(c)
+	if scope == nil || !scope.GlobalAdmin {
+		h.Forbidden(c, "没有权限执行此操作")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/jobs"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// JobHandler 后台任务处理器
+type JobHandler struct {
+	*BaseHandler
+	DB         *gorm.DB
+	jobManager *jobs.Manager
+}
+
+// NewJobHandler 创建后台任务处理器
+func NewJobHandler(db *gorm.DB, jobManager *jobs.Manager) *JobHandler {
+	return &JobHandler{
+		BaseHandler: NewBaseHandler(),
+		DB:          db,
+		jobManager:  jobManager,
+	}
+}
+
+// List 获取当前用户的后台任务列表
+func (h *JobHandler) List(c *gin.Context) {
+	h.list(c, h.DB.WithContext(c).Where("creator_id = ?", c.GetUint("userID")))
+}
+
+// ListAll 获取所有用户的后台任务列表（仅管理员）
+func (h *JobHandler) ListAll(c *gin.Context) {
+	if !h.requireOrgAdmin(c) {
+		return
+	}
+	query := h.DB.WithContext(c)
+	// 任务表没有组织字段，通过创建者所属组织过滤
+	if orgID := tenant.OrgID(c); orgID != 0 {
+		query = query.Where("creator_id IN (?)", h.DB.Model(&models.User{}).Select("id").Where("org_id = ?", orgID))
+	}
+	if creatorID := c.Query("creator_id"); creatorID != "" {
+		query = query.Where("creator_id = ?", creatorID)
+	}
+	h.list(c, query)
+}
+
+// list 按类型和状态过滤并分页
+func (h *JobHandler) list(c *gin.Context, query *gorm.DB) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query = query.Model(&models.Job{})
+	if jobType := c.Query("type"); jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取任务总数失败")
+		return
+	}
+
+	var jobList []models.Job
+	if err := query.Omit("result").
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&jobList).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取任务列表失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": jobList,
+	})
+}
+
+// Get 获取任务详情，执行中的任务附带实时进度
+func (h *JobHandler) Get(c *gin.Context) {
+	job, ok := h.getOwnJob(c)
+	if !ok {
+		return
+	}
+
+	var progress *upload.Progress
+	if p, exists := upload.DefaultManager.Get(job.TaskID); exists {
+		progress = &p
+	}
+
+	h.Success(c, gin.H{
+		"job":      job,
+		"progress": progress,
+	})
+}
+
+// Cancel 取消任务
+func (h *JobHandler) Cancel(c *gin.Context) {
+	job, ok := h.getOwnJob(c)
+	if !ok {
+		return
+	}
+
+	if err := h.jobManager.Cancel(job.ID); err != nil {
+		if errors.Is(err, jobs.ErrJobFinished) || errors.Is(err, jobs.ErrNotRunningHere) {
+			h.Error(c, utils.CodeInvalidParams, err.Error())
+			return
+		}
+		logger.Error("取消任务失败", zap.Uint("job_id", job.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "取消任务失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
+
+// Retry 重新执行失败或已取消的任务
+func (h *JobHandler) Retry(c *gin.Context) {
+	job, ok := h.getOwnJob(c)
+	if !ok {
+		return
+	}
+
+	if err := h.jobManager.Retry(job.ID); err != nil {
+		if errors.Is(err, jobs.ErrJobNotFinished) {
+			h.Error(c, utils.CodeInvalidParams, err.Error())
+			return
+		}
+		logger.Error("重试任务失败", zap.Uint("job_id", job.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "重试任务失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
+
+// getOwnJob 获取当前用户创建的任务，失败时已写入响应
+func (h *JobHandler) getOwnJob(c *gin.Context) (*models.Job, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的任务ID")
+		return nil, false
+	}
+
+	var job models.Job
+	if err := h.DB.WithContext(c).Where("id = ? AND creator_id = ?", id, c.GetUint("userID")).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.Error(c, utils.CodeNotFound, "任务不存在")
+			return nil, false
+		}
+		h.Error(c, utils.CodeServerError, "获取任务失败")
+		return nil, false
+	}
+	return &job, true
+}
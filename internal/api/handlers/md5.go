@@ -36,7 +36,7 @@ func (h *MD5Handler) TriggerCalculation(c *gin.Context) {
 
 	// 从数据库获取文件信息
 	var file models.OSSFile
-	if err := db.GetDB().First(&file, fileID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&file, fileID).Error; err != nil {
 		h.NotFound(c, "文件不存在")
 		return
 	}
@@ -69,7 +69,7 @@ func (h *MD5Handler) GetMD5(c *gin.Context) {
 
 	// 从数据库获取文件信息
 	var file models.OSSFile
-	if err := db.GetDB().First(&file, fileID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&file, fileID).Error; err != nil {
 		h.NotFound(c, "文件不存在")
 		return
 	}
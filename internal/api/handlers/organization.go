@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// OrganizationHandler 组织管理处理器（仅全局管理员可访问，查询不按组织过滤）
+type OrganizationHandler struct {
+	*BaseHandler
+	DB *gorm.DB
+}
+
+// NewOrganizationHandler 创建组织管理处理器
+func NewOrganizationHandler(db *gorm.DB) *OrganizationHandler {
+	return &OrganizationHandler{
+		BaseHandler: NewBaseHandler(),
+		DB:          db,
+	}
+}
+
+// OrganizationRequest 创建或更新组织请求
+type OrganizationRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	Description string `json:"description"`
+	Status      *bool  `json:"status"`
+}
+
+// List 获取组织列表
+func (h *OrganizationHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.DB.Model(&models.Organization{})
+	if name := c.Query("name"); name != "" {
+		query = query.Where("name LIKE ?", "%"+name+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取组织总数失败")
+		return
+	}
+
+	var orgs []models.Organization
+	if err := query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&orgs).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取组织列表失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": orgs,
+	})
+}
+
+// Create 创建组织，同时创建该组织的管理员角色
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	var count int64
+	if err := h.DB.Unscoped().Model(&models.Organization{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "检查组织名称失败")
+		return
+	}
+	if count > 0 {
+		h.Error(c, utils.CodeInvalidParams, "组织名称已存在")
+		return
+	}
+
+	org := models.Organization{
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      req.Status == nil || *req.Status,
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		// Status 为 false 时 gorm 会使用列默认值，需要单独更新
+		if !org.Status {
+			if err := tx.Model(&org).Update("status", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.Role{
+			OrgID:       org.ID,
+			Name:        models.OrgAdminRoleName,
+			Description: "组织管理员",
+			AdminScope:  models.AdminScopeOrg,
+		}).Error
+	})
+	if err != nil {
+		logger.Error("创建组织失败", zap.String("name", req.Name), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "创建组织失败")
+		return
+	}
+
+	h.Success(c, org)
+}
+
+// Get 获取组织详情
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	var org models.Organization
+	if err := h.DB.First(&org, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeNotFound, "组织不存在")
+		return
+	}
+
+	h.Success(c, org)
+}
+
+// Update 更新组织
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	var org models.Organization
+	if err := h.DB.First(&org, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeNotFound, "组织不存在")
+		return
+	}
+
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	var count int64
+	if err := h.DB.Unscoped().Model(&models.Organization{}).Where("name = ? AND id != ?", req.Name, org.ID).Count(&count).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "检查组织名称失败")
+		return
+	}
+	if count > 0 {
+		h.Error(c, utils.CodeInvalidParams, "组织名称已存在")
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+	}
+	if req.Status != nil {
+		if !*req.Status && org.ID == models.DefaultOrgID {
+			h.Error(c, utils.CodeInvalidParams, "默认组织不能禁用")
+			return
+		}
+		updates["status"] = *req.Status
+	}
+	if err := h.DB.Model(&org).Updates(updates).Error; err != nil {
+		logger.Error("更新组织失败", zap.Uint("orgID", org.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "更新组织失败")
+		return
+	}
+
+	h.Success(c, org)
+}
+
+// Delete 删除组织，组织下仍有用户或存储桶时拒绝删除
+func (h *OrganizationHandler) Delete(c *gin.Context) {
+	var org models.Organization
+	if err := h.DB.First(&org, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeNotFound, "组织不存在")
+		return
+	}
+	if org.ID == models.DefaultOrgID {
+		h.Error(c, utils.CodeInvalidParams, "默认组织不能删除")
+		return
+	}
+
+	for _, check := range []struct {
+		model interface{}
+		msg   string
+	}{
+		{&models.User{}, "组织下仍有用户，无法删除"},
+		{&models.RegionBucketMapping{}, "组织下仍有存储桶，无法删除"},
+		{&models.OSSConfig{}, "组织下仍有存储配置，无法删除"},
+	} {
+		var count int64
+		if err := h.DB.Model(check.model).Where("org_id = ?", org.ID).Count(&count).Error; err != nil {
+			h.Error(c, utils.CodeServerError, "检查组织资源失败")
+			return
+		}
+		if count > 0 {
+			h.Error(c, utils.CodeInvalidParams, check.msg)
+			return
+		}
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("org_id = ?", org.ID).Delete(&models.Role{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&org).Error
+	})
+	if err != nil {
+		logger.Error("删除组织失败", zap.Uint("orgID", org.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "删除组织失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
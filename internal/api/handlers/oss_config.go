@@ -8,6 +8,7 @@ import (
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
 	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
 	"github.com/myysophia/ossmanager-backend/internal/utils"
 	"go.uber.org/zap"
 )
@@ -28,11 +29,19 @@ func NewOSSConfigHandler(storageFactory oss.StorageFactory) *OSSConfigHandler {
 
 // CreateConfig 创建存储配置
 func (h *OSSConfigHandler) CreateConfig(c *gin.Context) {
-	var config models.OSSConfig
-	if err := c.ShouldBindJSON(&config); err != nil {
+	// 凭据字段在模型中不参与 JSON 序列化，需要单独接收
+	var req struct {
+		models.OSSConfig
+		AccessKey string `json:"access_key" binding:"required"`
+		SecretKey string `json:"secret_key" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		h.BadRequest(c, "参数错误")
 		return
 	}
+	config := req.OSSConfig
+	config.AccessKey = secrets.String(req.AccessKey)
+	config.SecretKey = secrets.String(req.SecretKey)
 
 	// 验证配置类型
 	if !isValidStorageType(config.StorageType) {
@@ -42,7 +51,7 @@ func (h *OSSConfigHandler) CreateConfig(c *gin.Context) {
 
 	// OSSConfig没有CreatedBy字段，不需要设置
 
-	if err := db.GetDB().Create(&config).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Create(&config).Error; err != nil {
 		h.InternalError(c, "创建存储配置失败")
 		return
 	}
@@ -55,7 +64,7 @@ func (h *OSSConfigHandler) UpdateConfig(c *gin.Context) {
 	configID := c.Param("id")
 
 	var config models.OSSConfig
-	if err := db.GetDB().First(&config, configID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&config, configID).Error; err != nil {
 		h.NotFound(c, "存储配置不存在")
 		return
 	}
@@ -65,8 +74,8 @@ func (h *OSSConfigHandler) UpdateConfig(c *gin.Context) {
 		StorageType string `json:"storage_type" binding:"required"`
 		Endpoint    string `json:"endpoint" binding:"required"`
 		Bucket      string `json:"bucket" binding:"required"`
-		AccessKey   string `json:"access_key" binding:"required"`
-		SecretKey   string `json:"secret_key" binding:"required"`
+		AccessKey   string `json:"access_key"` // 留空时保留原有密钥
+		SecretKey   string `json:"secret_key"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -85,10 +94,14 @@ func (h *OSSConfigHandler) UpdateConfig(c *gin.Context) {
 	config.StorageType = updateData.StorageType
 	config.Endpoint = updateData.Endpoint
 	config.Bucket = updateData.Bucket
-	config.AccessKey = updateData.AccessKey
-	config.SecretKey = updateData.SecretKey
+	if updateData.AccessKey != "" {
+		config.AccessKey = secrets.String(updateData.AccessKey)
+	}
+	if updateData.SecretKey != "" {
+		config.SecretKey = secrets.String(updateData.SecretKey)
+	}
 
-	if err := db.GetDB().Save(&config).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Save(&config).Error; err != nil {
 		h.InternalError(c, "更新存储配置失败")
 		return
 	}
@@ -101,14 +114,14 @@ func (h *OSSConfigHandler) DeleteConfig(c *gin.Context) {
 	configID := c.Param("id")
 
 	var config models.OSSConfig
-	if err := db.GetDB().First(&config, configID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&config, configID).Error; err != nil {
 		h.NotFound(c, "存储配置不存在")
 		return
 	}
 
 	// 检查是否有文件使用此配置
 	var count int64
-	if err := db.GetDB().Model(&models.OSSFile{}).Where("config_id = ?", configID).Count(&count).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Model(&models.OSSFile{}).Where("config_id = ?", configID).Count(&count).Error; err != nil {
 		h.InternalError(c, "检查文件关联失败")
 		return
 	}
@@ -117,7 +130,7 @@ func (h *OSSConfigHandler) DeleteConfig(c *gin.Context) {
 		return
 	}
 
-	if err := db.GetDB().Delete(&config).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Delete(&config).Error; err != nil {
 		h.InternalError(c, "删除存储配置失败")
 		return
 	}
@@ -152,7 +165,7 @@ func (h *OSSConfigHandler) GetConfigList(c *gin.Context) {
 		zap.String("method", c.Request.Method))
 
 	var total int64
-	if err := db.GetDB().Model(&models.OSSConfig{}).Count(&total).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Model(&models.OSSConfig{}).Count(&total).Error; err != nil {
 		logger.Error("获取配置总数失败", zap.Error(err))
 		h.InternalError(c, "获取配置总数失败")
 		return
@@ -164,7 +177,7 @@ func (h *OSSConfigHandler) GetConfigList(c *gin.Context) {
 	var configs []models.OSSConfig
 
 	// 增加详细SQL日志
-	query := db.GetDB().Debug()
+	query := db.GetDB().WithContext(c).Debug()
 
 	// 仅当有数据且需要分页时应用分页
 	if total > 0 {
@@ -190,7 +203,7 @@ func (h *OSSConfigHandler) GetConfigList(c *gin.Context) {
 
 		// 尝试不使用软删除查询
 		var allConfigs []models.OSSConfig
-		if err := db.GetDB().Debug().Unscoped().Find(&allConfigs).Error; err != nil {
+		if err := db.GetDB().WithContext(c).Debug().Unscoped().Find(&allConfigs).Error; err != nil {
 			logger.Error("不使用软删除查询失败", zap.Error(err))
 		} else {
 			for i, config := range allConfigs {
@@ -208,7 +221,7 @@ func (h *OSSConfigHandler) GetConfigList(c *gin.Context) {
 
 		// 查询是否记录被软删除
 		var deletedConfigs []models.OSSConfig
-		if err := db.GetDB().Debug().Unscoped().Where("deleted_at IS NOT NULL").Find(&deletedConfigs).Error; err != nil {
+		if err := db.GetDB().WithContext(c).Debug().Unscoped().Where("deleted_at IS NOT NULL").Find(&deletedConfigs).Error; err != nil {
 			logger.Error("查询软删除记录失败", zap.Error(err))
 		} else {
 			logger.Info("软删除记录数量", zap.Int("count", len(deletedConfigs)))
@@ -226,7 +239,7 @@ func (h *OSSConfigHandler) GetConfig(c *gin.Context) {
 	configID := c.Param("id")
 
 	var config models.OSSConfig
-	if err := db.GetDB().First(&config, configID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&config, configID).Error; err != nil {
 		h.NotFound(c, "存储配置不存在")
 		return
 	}
@@ -239,13 +252,13 @@ func (h *OSSConfigHandler) SetDefaultConfig(c *gin.Context) {
 	configID := c.Param("id")
 
 	var config models.OSSConfig
-	if err := db.GetDB().First(&config, configID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&config, configID).Error; err != nil {
 		h.NotFound(c, "存储配置不存在")
 		return
 	}
 
 	// 开始事务
-	tx := db.GetDB().Begin()
+	tx := db.GetDB().WithContext(c).Begin()
 
 	// 取消所有默认配置
 	if err := tx.Model(&models.OSSConfig{}).Where("is_default = ?", true).Update("is_default", false).Error; err != nil {
@@ -284,11 +297,52 @@ func isValidStorageType(storageType string) bool {
 	return false
 }
 
-// Test 测试存储配置
+// Test 测试存储配置的连通性
+// 路径中带有配置 ID 时使用已保存的配置，请求中提交的非空字段会覆盖已保存的值，便于保存前验证新的凭据；
+// 不带 ID 时只使用提交的配置。提交的内容不会被保存，测试结果中不包含凭据
 func (h *OSSConfigHandler) Test(c *gin.Context) {
 	var config models.OSSConfig
-	if err := c.ShouldBindJSON(&config); err != nil {
-		h.BadRequest(c, "参数错误")
+	if configID := c.Param("id"); configID != "" {
+		if err := db.GetDB().WithContext(c).First(&config, configID).Error; err != nil {
+			h.NotFound(c, "存储配置不存在")
+			return
+		}
+	}
+
+	var req struct {
+		StorageType string `json:"storage_type"`
+		Endpoint    string `json:"endpoint"`
+		Bucket      string `json:"bucket"`
+		Region      string `json:"region"`
+		AccessKey   string `json:"access_key"`
+		SecretKey   string `json:"secret_key"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.BadRequest(c, "参数错误")
+			return
+		}
+	}
+	if req.StorageType != "" {
+		config.StorageType = req.StorageType
+	}
+	if req.Endpoint != "" {
+		config.Endpoint = req.Endpoint
+	}
+	if req.Bucket != "" {
+		config.Bucket = req.Bucket
+	}
+	if req.Region != "" {
+		config.Region = req.Region
+	}
+	if req.AccessKey != "" {
+		config.AccessKey = secrets.String(req.AccessKey)
+	}
+	if req.SecretKey != "" {
+		config.SecretKey = secrets.String(req.SecretKey)
+	}
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		h.BadRequest(c, "缺少 endpoint、bucket 或访问密钥")
 		return
 	}
 
@@ -298,14 +352,19 @@ func (h *OSSConfigHandler) Test(c *gin.Context) {
 		return
 	}
 
-	// TODO: 实现存储配置测试逻辑
-	// 1. 创建临时存储服务
-	// 2. 尝试上传测试文件
-	// 3. 尝试下载测试文件
-	// 4. 删除测试文件
-	// 5. 返回测试结果
+	var result *oss.ProbeResult
+	service, err := oss.NewProbeService(&config)
+	if err != nil {
+		result = oss.ProbeInitFailed(err)
+	} else {
+		result = oss.Probe(service)
+	}
 
-	h.Success(c, gin.H{
-		"message": "存储配置测试成功",
-	})
+	logger.Info("存储配置连通性测试完成",
+		zap.Uint("configID", config.ID),
+		zap.String("storageType", config.StorageType),
+		zap.String("bucket", config.Bucket),
+		zap.Bool("success", result.Success))
+
+	h.Success(c, result)
 }
@@ -3,6 +3,8 @@ package handlers
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,12 +17,16 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/jobs"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
 
 	"github.com/gin-gonic/gin"
 	"github.com/myysophia/ossmanager-backend/internal/config"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/policy"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
 	"github.com/myysophia/ossmanager-backend/internal/upload"
 	"github.com/myysophia/ossmanager-backend/internal/utils"
 	"go.uber.org/zap"
@@ -58,15 +64,27 @@ func (pr *ProgressReader) Read(p []byte) (n int, err error) {
 type OSSFileHandler struct {
 	*BaseHandler
 	storageFactory oss.StorageFactory
+	trashManager   *trash.Manager
+	quotaManager   *quota.Manager
+	jobManager     *jobs.Manager
+	sessionManager *upload.SessionManager
 	DB             *gorm.DB
 }
 
-func NewOSSFileHandler(storageFactory oss.StorageFactory, db *gorm.DB) *OSSFileHandler {
-	return &OSSFileHandler{
+func NewOSSFileHandler(storageFactory oss.StorageFactory, db *gorm.DB, trashManager *trash.Manager, quotaManager *quota.Manager, jobManager *jobs.Manager, sessionManager *upload.SessionManager) *OSSFileHandler {
+	h := &OSSFileHandler{
 		BaseHandler:    NewBaseHandler(),
 		storageFactory: storageFactory,
+		trashManager:   trashManager,
+		quotaManager:   quotaManager,
+		jobManager:     jobManager,
+		sessionManager: sessionManager,
 		DB:             db,
 	}
+	if jobManager != nil {
+		jobManager.Register(bulkJobType, h.runBulkJob)
+	}
+	return h
 }
 
 // Upload 上传文件 - 智能选择上传方式
@@ -82,6 +100,12 @@ func (h *OSSFileHandler) Upload(c *gin.Context) {
 		}
 	}
 
+	// 传输前校验标签格式
+	if _, err := parseTagHeader(c.GetHeader(tagHeader)); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
 	// 如果是multipart/form-data，使用表单上传方式
 	if strings.Contains(contentType, "multipart/form-data") {
 		h.uploadFormFileWithChunking(c, chunkThreshold)
@@ -97,9 +121,18 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 	// 获取用户ID
 	userID := c.GetUint("userID")
 
+	// 续传时从上传会话恢复存储位置
+	session, ok := h.loadResumeSession(c)
+	if !ok {
+		return
+	}
+
 	// 获取用户指定的 bucket 信息
 	regionCode := c.GetHeader("region_code")
 	bucketName := c.GetHeader("bucket_name")
+	if session != nil {
+		regionCode, bucketName = session.RegionCode, session.BucketName
+	}
 
 	if regionCode == "" || bucketName == "" {
 		h.Error(c, utils.CodeInvalidParams, "请指定 region_code 和 bucket_name")
@@ -108,13 +141,13 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 
 	// 获取存储配置
 	var config models.OSSConfig
-	if err := h.DB.Where("is_default = ?", true).First(&config).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
 		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶
-	if !auth.CheckBucketAccess(h.DB, userID, regionCode, bucketName) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, regionCode, bucketName) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
@@ -125,8 +158,13 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 		return
 	}
 
+	// 传输前检查存储配额
+	if !h.checkQuota(c, userID, bucketName, file.Size) {
+		return
+	}
+
 	// 获取存储服务
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
@@ -140,43 +178,32 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 
 	// 获取自定义路径
 	customPath := c.GetHeader("X-Custom-Path")
-	if customPath != "" {
-		// 清理和验证自定义路径
-		customPath = strings.Trim(customPath, "/")
-		// 验证路径中不包含危险字符
-		if strings.Contains(customPath, "..") || strings.ContainsAny(customPath, "\\<>:\"|?*") {
-			h.Error(c, utils.CodeInvalidParams, "自定义路径包含非法字符")
-			return
-		}
-		// 使用用户自定义路径
-		if customPath == "" {
-			// 自定义路径为空，直接上传到根目录
-			objectKey = file.Filename
-		} else {
-			objectKey = customPath + "/" + file.Filename
-		}
-	} else {
-		// 没有提供自定义路径，使用固定路径生成方式
-		username, _ := c.Get("username")
-		objectKey = utils.GenerateFixedObjectKey(username.(string), file.Filename)
+	if session != nil {
+		// 续传时沿用会话中的对象路径
+		objectKey = session.ObjectKey
+	} else if objectKey, err = resolveObjectKey(c.GetString("username"), customPath, file.Filename); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
 	}
 
-	// 如果不是强制覆盖，检查文件是否已存在（基于完整路径）
-	if !forceOverwrite {
-		var existingFile models.OSSFile
-		err := h.DB.Where("object_key = ? AND bucket = ? AND status = ?",
-			objectKey, bucketName, "ACTIVE").First(&existingFile).Error
+	// 检查存储桶上传策略
+	uploadPolicy, ok := h.checkUploadPolicy(c, bucketName, objectKey, file.Size)
+	if !ok {
+		return
+	}
 
-		if err == nil {
-			// 文件已存在，返回错误提示用户确认
-			h.Error(c, utils.CodeFileExists, "在相同路径下文件已存在，请确认是否要覆盖")
-			return
-		} else if err != gorm.ErrRecordNotFound {
-			// 数据库查询错误
-			h.Error(c, utils.CodeServerError, "检查文件是否存在失败")
-			return
-		}
+	// 检查同路径文件，续传时已在首次上传时确认过覆盖，只需重新保留当前版本
+	pending, ok := h.checkOverwrite(c, storage, objectKey, regionCode, bucketName, forceOverwrite || session != nil)
+	if !ok {
+		return
 	}
+	// 新对象写入前失败时删除刚保留的历史版本
+	stored := false
+	defer func() {
+		if !stored {
+			pending.discard()
+		}
+	}()
 
 	// 如果客户端提供了上传任务ID，则使用该ID；否则生成新的
 	taskID := c.GetHeader("Upload-Task-ID")
@@ -194,8 +221,21 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 	}
 	defer src.Close()
 
+	// 按文件头校验文件类型，读取后回到文件开头
+	if policy.NeedsContent(uploadPolicy) {
+		head := make([]byte, policy.SniffLen)
+		n, _ := io.ReadFull(src, head)
+		if !h.checkUploadHead(c, uploadPolicy, head[:n]) {
+			return
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			h.Error(c, utils.CodeServerError, "读取文件失败")
+			return
+		}
+	}
+
 	// 根据文件大小选择上传方式
-	if file.Size <= chunkThreshold {
+	if session == nil && file.Size <= chunkThreshold {
 		// 简单上传
 		logger.Info("使用简单上传", zap.Int64("file_size", file.Size), zap.Int64("threshold", chunkThreshold))
 		upload.DefaultManager.Start(taskID, file.Size)
@@ -211,6 +251,7 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 			upload.DefaultManager.Finish(taskID)
 			return
 		}
+		stored = true
 		upload.DefaultManager.Finish(taskID)
 
 		// 保存文件记录并返回
@@ -224,6 +265,7 @@ func (h *OSSFileHandler) uploadFormFileWithChunking(c *gin.Context, chunkThresho
 			upload.DefaultManager.Finish(taskID)
 			return
 		}
+		stored = true
 
 		// 保存文件记录并返回
 		h.saveFileRecord(c, config, objectKey, file.Filename, file.Size, bucketName, uploadURL)
@@ -235,6 +277,12 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 	// 获取用户ID
 	userID := c.GetUint("userID")
 
+	// 续传时从上传会话恢复存储位置
+	session, ok := h.loadResumeSession(c)
+	if !ok {
+		return
+	}
+
 	// 获取用户指定的 bucket 信息
 	regionCode := c.GetHeader("region_code")
 	bucketName := c.GetHeader("bucket_name")
@@ -242,6 +290,10 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 	// 获取文件元数据（从请求头中获取）
 	originalFilename := c.GetHeader("X-File-Name")
 	contentLengthStr := c.GetHeader("Content-Length")
+	if session != nil {
+		regionCode, bucketName = session.RegionCode, session.BucketName
+		originalFilename = session.OriginalFilename
+	}
 
 	if regionCode == "" || bucketName == "" {
 		h.Error(c, utils.CodeInvalidParams, "请指定 region_code 和 bucket_name")
@@ -261,19 +313,24 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 
 	// 获取存储配置
 	var config models.OSSConfig
-	if err := h.DB.Where("is_default = ?", true).First(&config).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
 		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶
-	if !auth.CheckBucketAccess(h.DB, userID, regionCode, bucketName) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, regionCode, bucketName) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
+	// 传输前检查存储配额
+	if !h.checkQuota(c, userID, bucketName, contentLength) {
+		return
+	}
+
 	// 获取存储服务
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
@@ -287,43 +344,32 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 
 	// 获取自定义路径
 	customPath := c.GetHeader("X-Custom-Path")
-	if customPath != "" {
-		// 清理和验证自定义路径
-		customPath = strings.Trim(customPath, "/")
-		// 验证路径中不包含危险字符
-		if strings.Contains(customPath, "..") || strings.ContainsAny(customPath, "\\<>:\"|?*") {
-			h.Error(c, utils.CodeInvalidParams, "自定义路径包含非法字符")
-			return
-		}
-		// 使用用户自定义路径
-		if customPath == "" {
-			// 自定义路径为空，直接上传到根目录
-			objectKey = originalFilename
-		} else {
-			objectKey = customPath + "/" + originalFilename
-		}
-	} else {
-		// 没有提供自定义路径，使用固定路径生成方式
-		username, _ := c.Get("username")
-		objectKey = utils.GenerateFixedObjectKey(username.(string), originalFilename)
+	if session != nil {
+		// 续传时沿用会话中的对象路径
+		objectKey = session.ObjectKey
+	} else if objectKey, err = resolveObjectKey(c.GetString("username"), customPath, originalFilename); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
 	}
 
-	// 如果不是强制覆盖，检查文件是否已存在（基于完整路径）
-	if !forceOverwrite {
-		var existingFile models.OSSFile
-		err := h.DB.Where("object_key = ? AND bucket = ? AND status = ?",
-			objectKey, bucketName, "ACTIVE").First(&existingFile).Error
+	// 检查存储桶上传策略
+	uploadPolicy, ok := h.checkUploadPolicy(c, bucketName, objectKey, contentLength)
+	if !ok {
+		return
+	}
 
-		if err == nil {
-			// 文件已存在，返回错误提示用户确认
-			h.Error(c, utils.CodeFileExists, "在相同路径下文件已存在，请确认是否要覆盖")
-			return
-		} else if err != gorm.ErrRecordNotFound {
-			// 数据库查询错误
-			h.Error(c, utils.CodeServerError, "检查文件是否存在失败")
-			return
-		}
+	// 检查同路径文件，续传时已在首次上传时确认过覆盖，只需重新保留当前版本
+	pending, ok := h.checkOverwrite(c, storage, objectKey, regionCode, bucketName, forceOverwrite || session != nil)
+	if !ok {
+		return
 	}
+	// 新对象写入前失败时删除刚保留的历史版本
+	stored := false
+	defer func() {
+		if !stored {
+			pending.discard()
+		}
+	}()
 
 	// 获取任务ID
 	taskID := c.GetHeader("Upload-Task-ID")
@@ -334,13 +380,19 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 		taskID = uuid.NewString()
 	}
 
+	// 按文件头校验文件类型
+	body, ok := h.checkUploadStream(c, uploadPolicy, c.Request.Body)
+	if !ok {
+		return
+	}
+
 	// 根据文件大小选择上传方式
-	if contentLength <= chunkThreshold {
+	if session == nil && contentLength <= chunkThreshold {
 		// 简单上传
 		logger.Info("使用简单上传", zap.Int64("content_length", contentLength), zap.Int64("threshold", chunkThreshold))
 		upload.DefaultManager.Start(taskID, contentLength)
 
-		uploadURL, err := storage.UploadToBucketWithProgress(c.Request.Body, objectKey, regionCode, bucketName, func(consumed, total int64) {
+		uploadURL, err := storage.UploadToBucketWithProgress(body, objectKey, regionCode, bucketName, func(consumed, total int64) {
 			if total == 0 {
 				total = contentLength
 			}
@@ -351,6 +403,7 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 			upload.DefaultManager.Finish(taskID)
 			return
 		}
+		stored = true
 		upload.DefaultManager.Finish(taskID)
 
 		// 保存文件记录并返回
@@ -358,18 +411,65 @@ func (h *OSSFileHandler) uploadStreamWithChunking(c *gin.Context, chunkThreshold
 	} else {
 		// 分片上传
 		logger.Info("使用分片上传", zap.Int64("content_length", contentLength), zap.Int64("threshold", chunkThreshold))
-		uploadURL, err := h.uploadFileWithChunks(c, storage, c.Request.Body, objectKey, regionCode, bucketName, contentLength, taskID, originalFilename)
+		uploadURL, err := h.uploadFileWithChunks(c, storage, body, objectKey, regionCode, bucketName, contentLength, taskID, originalFilename)
 		if err != nil {
 			h.Error(c, utils.CodeServerError, err.Error())
 			upload.DefaultManager.Finish(taskID)
 			return
 		}
+		stored = true
 
 		// 保存文件记录并返回
 		h.saveFileRecord(c, config, objectKey, originalFilename, contentLength, bucketName, uploadURL)
 	}
 }
 
+// checkOverwrite 非强制覆盖时检查同路径文件是否已存在，强制覆盖时先保留当前版本，失败时已写入响应
+// 续传时已在首次上传时确认覆盖，只重新保留当前版本；返回的历史版本在新对象写入失败时需要清理
+func (h *OSSFileHandler) checkOverwrite(c *gin.Context, storage oss.StorageService, objectKey, regionCode, bucketName string, forceOverwrite bool) (*pendingVersion, bool) {
+	// 如果不是强制覆盖，检查文件是否已存在（基于完整路径）
+	if !forceOverwrite {
+		var existingFile models.OSSFile
+		err := h.DB.WithContext(c).Where("object_key = ? AND bucket = ? AND status = ?",
+			objectKey, bucketName, "ACTIVE").First(&existingFile).Error
+
+		if err == nil {
+			// 文件已存在，返回错误提示用户确认
+			h.Error(c, utils.CodeFileExists, "在相同路径下文件已存在，请确认是否要覆盖")
+			return nil, false
+		} else if err != gorm.ErrRecordNotFound {
+			// 数据库查询错误
+			h.Error(c, utils.CodeServerError, "检查文件是否存在失败")
+			return nil, false
+		}
+		return nil, true
+	}
+
+	// 覆盖前保留当前版本
+	pending, err := h.preserveCurrentVersion(c, storage, objectKey, regionCode, bucketName)
+	if err != nil {
+		logger.Error("保留历史版本失败", zap.String("object_key", objectKey), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "保留历史版本失败")
+		return nil, false
+	}
+	return pending, true
+}
+
+// customObjectKey 根据自定义路径生成对象路径，路径包含非法字符时返回 false
+func customObjectKey(customPath, originalFilename string) (string, bool) {
+	// 清理和验证自定义路径
+	customPath = strings.Trim(customPath, "/")
+	// 验证路径中不包含危险字符
+	if strings.Contains(customPath, "..") || strings.ContainsAny(customPath, "\\<>:\"|?*") || isReservedKey(customPath) {
+		return "", false
+	}
+	if customPath == "" {
+		// 自定义路径为空，直接上传到根目录
+		return originalFilename, true
+	}
+	return customPath + "/" + originalFilename, true
+}
+
 // uploadFileWithChunks 分片上传文件
 func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.StorageService, reader io.Reader, objectKey, regionCode, bucketName string, totalSize int64, taskID, originalFilename string) (string, error) {
 	// 默认分片大小：10MB
@@ -392,9 +492,6 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 		}
 	}
 
-	// 计算总分片数
-	totalChunks := int((totalSize + chunkSize - 1) / chunkSize)
-
 	resumeUploadID := c.GetHeader("X-Upload-Id")
 	if resumeUploadID == "" {
 		resumeUploadID = c.Query("upload_id")
@@ -406,6 +503,20 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 		}
 	}
 
+	// 按上传会话续传时只需会话ID，分片大小必须与首次上传一致
+	session := resumeSessionFromContext(c)
+	if session != nil {
+		if session.FileSize != totalSize {
+			return "", fmt.Errorf("文件大小与上传会话不一致")
+		}
+		chunkSize = session.ChunkSize
+		resumeUploadID = session.UploadID
+		objectKey = session.ObjectKey
+	}
+
+	// 计算总分片数
+	totalChunks := int((totalSize + chunkSize - 1) / chunkSize)
+
 	var uploadID string
 	logger.Debug("Initializing multipart upload", zap.String("objectKey", objectKey), zap.String("regionCode", regionCode), zap.String("bucketName", bucketName))
 	var err error
@@ -414,6 +525,18 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 		if err != nil {
 			return "", fmt.Errorf("初始化分片上传失败: %v", err)
 		}
+		session = h.createUploadSession(c, &models.UploadSession{
+			Mode:             models.UploadSessionModeServer,
+			StorageType:      storage.GetType(),
+			RegionCode:       regionCode,
+			BucketName:       bucketName,
+			ObjectKey:        objectKey,
+			OriginalFilename: originalFilename,
+			UploadID:         uploadID,
+			FileSize:         totalSize,
+			ChunkSize:        chunkSize,
+			TotalParts:       totalChunks,
+		})
 	} else {
 		uploadID = resumeUploadID
 	}
@@ -458,6 +581,9 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 				partNumber++
 			}
 		}
+		if err == nil && session != nil {
+			h.sessionManager.SyncParts(session, parts)
+		}
 	}
 
 	// 读取分片超时时间，可通过头部 X-Chunk-Read-Timeout 调整，默认 5 分钟
@@ -534,6 +660,9 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 
 		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
 			upload.DefaultManager.Fail(taskID, "读取分片数据失败")
+			if session != nil {
+				h.sessionManager.Fail(session, "读取分片数据失败")
+			}
 			return "", fmt.Errorf("读取分片数据失败: %v", readErr)
 		}
 
@@ -587,6 +716,9 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 			mu.Lock()
 			parts = append(parts, oss.Part{PartNumber: curPart, ETag: etag})
 			mu.Unlock()
+			if session != nil {
+				h.sessionManager.PartDone(session, int64(len(dataCopy)))
+			}
 			upload.DefaultManager.UpdateChunk(taskID, curPart, true)
 			logger.Debug("分片上传成功",
 				zap.Int("part_number", curPart),
@@ -597,9 +729,15 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 
 	wg.Wait()
 	if len(errCh) > 0 {
-		h.safeAbortMultipartUpload(storage, uploadID, objectKey, regionCode, bucketName)
-		upload.DefaultManager.Fail(taskID, (<-errCh).Error())
-		return "", fmt.Errorf("%v", <-errCh)
+		partErr := <-errCh
+		if session != nil {
+			// 保留已上传的分片，客户端可凭会话ID续传
+			h.sessionManager.Fail(session, partErr.Error())
+		} else {
+			h.safeAbortMultipartUpload(storage, uploadID, objectKey, regionCode, bucketName)
+		}
+		upload.DefaultManager.Fail(taskID, partErr.Error())
+		return "", partErr
 	}
 
 	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
@@ -614,12 +752,18 @@ func (h *OSSFileHandler) uploadFileWithChunks(c *gin.Context, storage oss.Storag
 	if err != nil {
 		// 完成失败，中止分片上传（使用正确的方法）
 		h.safeAbortMultipartUpload(storage, uploadID, objectKey, regionCode, bucketName)
+		if session != nil {
+			h.sessionManager.Fail(session, "完成分片上传失败")
+		}
 		upload.DefaultManager.Fail(taskID, "完成分片上传失败")
 		return "", fmt.Errorf("完成分片上传失败: %v", err)
 	}
 
 	// 完成进度追踪
 	upload.DefaultManager.Finish(taskID)
+	if session != nil {
+		h.sessionManager.Complete(session)
+	}
 
 	logger.Info("分片上传完全成功",
 		zap.String("task_id", taskID),
@@ -720,6 +864,18 @@ func (h *OSSFileHandler) uploadChunk(storage oss.StorageService, uploadURL strin
 
 // saveFileRecord 保存文件记录
 func (h *OSSFileHandler) saveFileRecord(c *gin.Context, config models.OSSConfig, objectKey, originalFilename string, fileSize int64, bucketName, uploadURL string) {
+	ossFile, err := h.createFileRecord(c, config, objectKey, originalFilename, fileSize, bucketName, uploadURL, utils.GetUserID(c))
+	if err != nil {
+		h.Error(c, utils.CodeServerError, err.Error())
+		return
+	}
+
+	h.applyUploadTags(c, ossFile)
+	h.Success(c, ossFile)
+}
+
+// createFileRecord 将同路径的旧记录标记为已替换并创建新的文件记录
+func (h *OSSFileHandler) createFileRecord(c *gin.Context, config models.OSSConfig, objectKey, originalFilename string, fileSize int64, bucketName, uploadURL string, uploaderID uint) (*models.OSSFile, error) {
 	// 从配置中获取过期时间，如果未配置则默认为24小时
 	expireTime := config.URLExpireTime
 	if expireTime <= 0 {
@@ -728,7 +884,7 @@ func (h *OSSFileHandler) saveFileRecord(c *gin.Context, config models.OSSConfig,
 	expiresAt := time.Now().Add(time.Duration(expireTime) * time.Second)
 
 	// 开始数据库事务，确保原子性
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -740,13 +896,12 @@ func (h *OSSFileHandler) saveFileRecord(c *gin.Context, config models.OSSConfig,
 		"object_key = ? AND bucket = ? AND status = ?",
 		objectKey, bucketName, "ACTIVE",
 	).Update("status", "REPLACED").Error; err != nil {
-		logger.Warn("标记旧文件记录失败", 
+		logger.Warn("标记旧文件记录失败",
 			zap.String("object_key", objectKey),
 			zap.Error(err),
 		)
 		tx.Rollback()
-		h.Error(c, utils.CodeServerError, "更新旧文件记录失败")
-		return
+		return nil, errors.New("更新旧文件记录失败")
 	}
 
 	// 2. 创建新的文件记录
@@ -759,7 +914,7 @@ func (h *OSSFileHandler) saveFileRecord(c *gin.Context, config models.OSSConfig,
 		Bucket:           bucketName,
 		ObjectKey:        objectKey,
 		DownloadURL:      uploadURL,
-		UploaderID:       utils.GetUserID(c),
+		UploaderID:       uploaderID,
 		UploadIP:         c.ClientIP(),
 		ExpiresAt:        expiresAt,
 		Status:           "ACTIVE",
@@ -767,14 +922,12 @@ func (h *OSSFileHandler) saveFileRecord(c *gin.Context, config models.OSSConfig,
 
 	if err := tx.Create(&ossFile).Error; err != nil {
 		tx.Rollback()
-		h.Error(c, utils.CodeServerError, "保存文件记录失败")
-		return
+		return nil, errors.New("保存文件记录失败")
 	}
 
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
-		h.Error(c, utils.CodeServerError, "提交事务失败")
-		return
+		return nil, errors.New("提交事务失败")
 	}
 
 	logger.Info("文件记录保存成功",
@@ -783,7 +936,7 @@ func (h *OSSFileHandler) saveFileRecord(c *gin.Context, config models.OSSConfig,
 		zap.String("status", "ACTIVE"),
 	)
 
-	h.Success(c, ossFile)
+	return &ossFile, nil
 }
 
 // saveFileRecordForMultipart 分片上传专用文件记录保存函数
@@ -796,7 +949,7 @@ func (h *OSSFileHandler) saveFileRecordForMultipart(c *gin.Context, config model
 	expiresAt := time.Now().Add(time.Duration(expireTime) * time.Second)
 
 	// 开始数据库事务，确保原子性
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -853,6 +1006,7 @@ func (h *OSSFileHandler) saveFileRecordForMultipart(c *gin.Context, config model
 		zap.String("download_url", uploadURL),
 	)
 
+	h.applyUploadTags(c, &ossFile)
 	h.Success(c, ossFile)
 }
 
@@ -862,6 +1016,9 @@ func (h *OSSFileHandler) InitMultipartUpload(c *gin.Context) {
 		RegionCode string `json:"region_code" binding:"required"`
 		BucketName string `json:"bucket_name" binding:"required"`
 		FileName   string `json:"file_name" binding:"required"`
+		FileSize   int64  `json:"file_size"`
+		ChunkSize  int64  `json:"chunk_size"`
+		CustomPath string `json:"custom_path"` // 为空时按用户名生成对象路径
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -871,26 +1028,39 @@ func (h *OSSFileHandler) InitMultipartUpload(c *gin.Context) {
 
 	// 获取存储配置
 	var config models.OSSConfig
-	if err := h.DB.Where("is_default = ?", true).First(&config).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
 		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶
-	if !auth.CheckBucketAccess(h.DB, c.GetUint("userID"), req.RegionCode, req.BucketName) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), req.RegionCode, req.BucketName) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
 	}
 
-	ext := filepath.Ext(req.FileName)
-	username, _ := c.Get("username")
-	objectKey := utils.GenerateObjectKey(username.(string), ext)
+	var objectKey string
+	if req.CustomPath != "" {
+		var ok bool
+		if objectKey, ok = customObjectKey(req.CustomPath, filepath.Base(req.FileName)); !ok {
+			h.Error(c, utils.CodeInvalidParams, "自定义路径包含非法字符")
+			return
+		}
+	} else {
+		username, _ := c.Get("username")
+		objectKey = utils.GenerateObjectKey(username.(string), filepath.Ext(req.FileName))
+	}
+
+	// 检查存储桶上传策略（分片上传不经过本服务，无法按文件头校验类型）
+	if _, ok := h.checkUploadPolicy(c, req.BucketName, objectKey, req.FileSize); !ok {
+		return
+	}
 
 	uploadID, urls, err := storage.InitMultipartUploadToBucket(objectKey, req.RegionCode, req.BucketName)
 	if err != nil {
@@ -898,20 +1068,42 @@ func (h *OSSFileHandler) InitMultipartUpload(c *gin.Context) {
 		return
 	}
 
+	// 登记上传会话，之后续传、查询分片和完成上传只需会话ID
+	session := &models.UploadSession{
+		Mode:             models.UploadSessionModeClient,
+		StorageType:      config.StorageType,
+		RegionCode:       req.RegionCode,
+		BucketName:       req.BucketName,
+		ObjectKey:        objectKey,
+		OriginalFilename: req.FileName,
+		UploadID:         uploadID,
+		FileSize:         req.FileSize,
+		ChunkSize:        req.ChunkSize,
+	}
+	if req.ChunkSize > 0 && req.FileSize > 0 {
+		session.TotalParts = int((req.FileSize + req.ChunkSize - 1) / req.ChunkSize)
+	}
+	sessionID := ""
+	if session = h.createUploadSession(c, session); session != nil {
+		sessionID = session.SessionID
+	}
+
 	h.Success(c, gin.H{
 		"upload_id":  uploadID,
 		"object_key": objectKey,
 		"urls":       urls,
+		"session_id": sessionID,
 	})
 }
 
 // CompleteMultipartUpload 完成分片上传
 func (h *OSSFileHandler) CompleteMultipartUpload(c *gin.Context) {
 	var req struct {
-		RegionCode       string   `json:"region_code" binding:"required"`
-		BucketName       string   `json:"bucket_name" binding:"required"`
-		ObjectKey        string   `json:"object_key" binding:"required"`
-		UploadID         string   `json:"upload_id" binding:"required"`
+		SessionID        string   `json:"session_id"`
+		RegionCode       string   `json:"region_code"`
+		BucketName       string   `json:"bucket_name"`
+		ObjectKey        string   `json:"object_key"`
+		UploadID         string   `json:"upload_id"`
 		Parts            []string `json:"parts" binding:"required"`
 		OriginalFilename string   `json:"original_filename"`
 		FileSize         int64    `json:"file_size"`
@@ -923,32 +1115,64 @@ func (h *OSSFileHandler) CompleteMultipartUpload(c *gin.Context) {
 		return
 	}
 
+	// 携带会话ID时由会话补全上传位置
+	var session *models.UploadSession
+	if req.SessionID != "" && h.sessionManager != nil {
+		var ok bool
+		if session, ok = h.getOwnSession(c, req.SessionID); !ok {
+			return
+		}
+		req.RegionCode, req.BucketName = session.RegionCode, session.BucketName
+		req.ObjectKey, req.UploadID = session.ObjectKey, session.UploadID
+		if req.OriginalFilename == "" {
+			req.OriginalFilename = session.OriginalFilename
+		}
+		if req.FileSize == 0 {
+			req.FileSize = session.FileSize
+		}
+	}
+	if req.RegionCode == "" || req.BucketName == "" || req.ObjectKey == "" || req.UploadID == "" {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+	if _, err := parseTagHeader(c.GetHeader(tagHeader)); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
 	// 获取存储配置
 	var config models.OSSConfig
-	if err := h.DB.Where("is_default = ?", true).First(&config).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
 		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶
-	if !auth.CheckBucketAccess(h.DB, c.GetUint("userID"), req.RegionCode, req.BucketName) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), req.RegionCode, req.BucketName) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
 	}
 
-	// 转换parts为oss.Part类型
-	ossParts := make([]oss.Part, len(req.Parts))
-	for i, part := range req.Parts {
-		ossParts[i] = oss.Part{
-			PartNumber: i + 1,
-			ETag:       part,
-		}
+	// 按存储端实际的分片大小计算文件大小，客户端声明的 file_size 不可信
+	ossParts, fileSize, err := multipartParts(storage, req.ObjectKey, req.UploadID, req.RegionCode, req.BucketName, req.Parts)
+	if err != nil {
+		logger.Error("校验分片失败", zap.String("upload_id", req.UploadID), zap.Error(err))
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
+	// 合并前按实际大小再次检查存储配额和上传策略
+	if !h.checkQuota(c, c.GetUint("userID"), req.BucketName, fileSize) {
+		return
+	}
+	if _, ok := h.checkUploadPolicy(c, req.BucketName, req.ObjectKey, fileSize); !ok {
+		return
 	}
 
 	logger.Info("开始完成分片上传",
@@ -958,9 +1182,18 @@ func (h *OSSFileHandler) CompleteMultipartUpload(c *gin.Context) {
 		zap.String("task_id", req.TaskID),
 	)
 
+	// 合并后会覆盖同路径对象，先保留当前版本
+	pending, err := h.preserveCurrentVersion(c, storage, req.ObjectKey, req.RegionCode, req.BucketName)
+	if err != nil {
+		logger.Error("保留历史版本失败", zap.String("object_key", req.ObjectKey), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "保留历史版本失败")
+		return
+	}
+
 	// 完成分片上传
 	url, err := storage.CompleteMultipartUploadToBucket(req.ObjectKey, req.UploadID, ossParts, req.RegionCode, req.BucketName)
 	if err != nil {
+		pending.discard()
 		if req.TaskID != "" {
 			upload.DefaultManager.Fail(req.TaskID, "完成分片上传失败")
 		}
@@ -980,8 +1213,12 @@ func (h *OSSFileHandler) CompleteMultipartUpload(c *gin.Context) {
 		expireTime = 24 * 3600 // 默认24小时
 	}
 
+	if session != nil {
+		h.sessionManager.Complete(session)
+	}
+
 	// 使用改进的文件记录保存逻辑
-	h.saveFileRecordForMultipart(c, config, req.ObjectKey, originalFilename, req.FileSize, req.BucketName, url)
+	h.saveFileRecordForMultipart(c, config, req.ObjectKey, originalFilename, fileSize, req.BucketName, url)
 
 	// 完成进度追踪
 	if req.TaskID != "" {
@@ -989,6 +1226,31 @@ func (h *OSSFileHandler) CompleteMultipartUpload(c *gin.Context) {
 	}
 }
 
+// multipartParts 按客户端提交的 ETag 顺序组装分片，并以存储端记录的分片大小计算文件总大小
+// ETag 必须与已上传的分片一致，保证合并的正是参与计算大小的分片
+func multipartParts(storage oss.StorageService, objectKey, uploadID, regionCode, bucketName string, etags []string) ([]oss.Part, int64, error) {
+	uploaded, err := storage.ListUploadedPartsToBucket(objectKey, uploadID, regionCode, bucketName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取已上传分片失败: %w", err)
+	}
+	byNumber := make(map[int]oss.Part, len(uploaded))
+	for _, p := range uploaded {
+		byNumber[p.PartNumber] = p
+	}
+
+	parts := make([]oss.Part, len(etags))
+	var totalSize int64
+	for i, etag := range etags {
+		part, ok := byNumber[i+1]
+		if !ok || strings.Trim(part.ETag, "\"") != strings.Trim(etag, "\"") {
+			return nil, 0, fmt.Errorf("分片 %d 不存在或 ETag 不匹配", i+1)
+		}
+		parts[i] = oss.Part{PartNumber: i + 1, ETag: etag}
+		totalSize += part.Size
+	}
+	return parts, totalSize, nil
+}
+
 // AbortMultipartUpload 取消分片上传
 func (h *OSSFileHandler) AbortMultipartUpload(c *gin.Context) {
 	var req struct {
@@ -1003,18 +1265,18 @@ func (h *OSSFileHandler) AbortMultipartUpload(c *gin.Context) {
 	}
 
 	var config models.OSSConfig
-	if err := h.DB.First(&config, req.ConfigID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&config, req.ConfigID).Error; err != nil {
 		h.Error(c, utils.CodeConfigNotFound, "存储配置不存在")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶
-	if !auth.CheckBucketAccess(h.DB, c.GetUint("userID"), config.Region, config.Bucket) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), config.Region, config.Bucket) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
@@ -1035,6 +1297,17 @@ func (h *OSSFileHandler) ListUploadedParts(c *gin.Context) {
 	objectKey := c.Query("object_key")
 	uploadID := c.Query("upload_id")
 
+	// 携带会话ID时由会话补全上传位置
+	var session *models.UploadSession
+	if sessionID := c.Query("session_id"); sessionID != "" && h.sessionManager != nil {
+		var ok bool
+		if session, ok = h.getOwnSession(c, sessionID); !ok {
+			return
+		}
+		regionCode, bucketName = session.RegionCode, session.BucketName
+		objectKey, uploadID = session.ObjectKey, session.UploadID
+	}
+
 	if regionCode == "" || bucketName == "" || objectKey == "" || uploadID == "" {
 		h.Error(c, utils.CodeInvalidParams, "参数错误")
 		return
@@ -1042,18 +1315,18 @@ func (h *OSSFileHandler) ListUploadedParts(c *gin.Context) {
 
 	// 获取存储配置
 	var config models.OSSConfig
-	if err := h.DB.Where("is_default = ?", true).First(&config).Error; err != nil {
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
 		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
 		return
 	}
 
 	// 权限检查
-	if !auth.CheckBucketAccess(h.DB, c.GetUint("userID"), regionCode, bucketName) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), regionCode, bucketName) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
@@ -1064,6 +1337,9 @@ func (h *OSSFileHandler) ListUploadedParts(c *gin.Context) {
 		h.Error(c, utils.CodeServerError, "获取已上传分片失败")
 		return
 	}
+	if session != nil {
+		h.sessionManager.SyncParts(session, uploadedParts)
+	}
 
 	partNumbers := make([]int, len(uploadedParts))
 	for i, p := range uploadedParts {
@@ -1073,83 +1349,98 @@ func (h *OSSFileHandler) ListUploadedParts(c *gin.Context) {
 	h.Success(c, gin.H{"parts": partNumbers})
 }
 
-// List 获取文件列表，相同文件名只获取最新一个
-func (h *OSSFileHandler) List(c *gin.Context) {
-	// 获取用户ID
-	userID := c.GetUint("userID")
-
-	// 获取用户可访问的桶列表
-	buckets, err := auth.GetUserAccessibleBuckets(h.DB, userID, "")
+// getRegionByBucket 通过存储桶名称获取区域代码
+func (h *OSSFileHandler) getRegionByBucket(ctx context.Context, bucketName string) (string, error) {
+	var mapping models.RegionBucketMapping
+	err := h.DB.WithContext(ctx).Where("bucket_name = ?", bucketName).First(&mapping).Error
 	if err != nil {
-		h.Error(c, utils.CodeServerError, "获取可访问桶列表失败")
-		return
+		return "", fmt.Errorf("未找到存储桶 %s 对应的区域信息: %w", bucketName, err)
 	}
+	return mapping.RegionCode, nil
+}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	configID := c.Query("config_id")
-	// 首先，获取去重后的所有文件名
-	var uniqueFileNames []string
-	query := h.DB.Model(&models.OSSFile{}).Select("DISTINCT original_filename").Where("bucket IN ?", buckets)
-	if configID != "" {
-		query = query.Where("config_id = ?", configID)
+// checkQuota 检查上传是否超出存储配额，超出或检查失败时已写入响应
+func (h *OSSFileHandler) checkQuota(c *gin.Context, userID uint, bucketName string, fileSize int64) bool {
+	if h.quotaManager == nil {
+		return true
 	}
-
-	if err := query.Pluck("original_filename", &uniqueFileNames).Error; err != nil {
-		h.Error(c, utils.CodeServerError, "获取唯一文件名失败")
-		return
+	if err := h.quotaManager.Check(userID, bucketName, fileSize); err != nil {
+		if errors.Is(err, quota.ErrQuotaExceeded) {
+			h.Error(c, utils.CodeQuotaExceeded, err.Error())
+			return false
+		}
+		logger.Error("检查存储配额失败",
+			zap.Uint("user_id", userID),
+			zap.String("bucket", bucketName),
+			zap.Error(err))
+		h.Error(c, utils.CodeServerError, "检查存储配额失败")
+		return false
 	}
+	return true
+}
 
-	total := int64(len(uniqueFileNames))
-
-	// 对于分页的处理
-	startIdx := (page - 1) * pageSize
-	endIdx := startIdx + pageSize
-	if startIdx >= len(uniqueFileNames) {
-		h.Success(c, gin.H{
-			"total": total,
-			"items": []models.OSSFile{},
-		})
-		return
+// checkUploadPolicy 检查对象键和文件大小是否符合存储桶上传策略，不符合或检查失败时已写入响应
+// 返回的策略用于后续按文件头校验类型
+func (h *OSSFileHandler) checkUploadPolicy(c *gin.Context, bucketName, objectKey string, fileSize int64) (*models.BucketUploadPolicy, bool) {
+	uploadPolicy, err := policy.Load(h.DB.WithContext(c), bucketName)
+	if err != nil {
+		logger.Error("获取存储桶上传策略失败", zap.String("bucket", bucketName), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "获取存储桶上传策略失败")
+		return nil, false
 	}
-	if endIdx > len(uniqueFileNames) {
-		endIdx = len(uniqueFileNames)
+	if err := policy.CheckFile(uploadPolicy, objectKey, fileSize); err != nil {
+		h.respondPolicyError(c, err)
+		return nil, false
 	}
+	return uploadPolicy, true
+}
 
-	// 获取当前页的文件名
-	pageFileNames := uniqueFileNames[startIdx:endIdx]
-
-	// 对于每个文件名，获取最新的记录
-	var files []models.OSSFile
-	for _, fileName := range pageFileNames {
-		var latest models.OSSFile
-		subQuery := h.DB.Model(&models.OSSFile{}).Where("original_filename = ? AND bucket IN ?", fileName, buckets)
-		if configID != "" {
-			subQuery = subQuery.Where("config_id = ?", configID)
-		}
-
-		if err := subQuery.Order("created_at DESC").First(&latest).Error; err != nil {
-			// 如果查询出错，跳过这个文件名
-			continue
-		}
+// checkUploadHead 按文件头校验文件类型，不符合时已写入响应
+func (h *OSSFileHandler) checkUploadHead(c *gin.Context, uploadPolicy *models.BucketUploadPolicy, head []byte) bool {
+	if err := policy.CheckContent(uploadPolicy, head); err != nil {
+		h.respondPolicyError(c, err)
+		return false
+	}
+	return true
+}
 
-		files = append(files, latest)
+// checkUploadStream 预读流的文件头校验文件类型，返回包含完整内容的 Reader，不符合时已写入响应
+func (h *OSSFileHandler) checkUploadStream(c *gin.Context, uploadPolicy *models.BucketUploadPolicy, reader io.Reader) (io.Reader, bool) {
+	if !policy.NeedsContent(uploadPolicy) {
+		return reader, true
+	}
+	br := bufio.NewReaderSize(reader, policy.SniffLen)
+	head, err := br.Peek(policy.SniffLen)
+	if err != nil && err != io.EOF {
+		h.Error(c, utils.CodeInvalidParams, "读取文件内容失败")
+		return nil, false
 	}
+	if !h.checkUploadHead(c, uploadPolicy, head) {
+		return nil, false
+	}
+	return br, true
+}
 
-	h.Success(c, gin.H{
-		"total": total,
-		"items": files,
-	})
+// respondPolicyError 返回上传策略检查错误
+func (h *OSSFileHandler) respondPolicyError(c *gin.Context, err error) {
+	if errors.Is(err, policy.ErrViolation) {
+		h.Error(c, utils.CodePolicyDenied, err.Error())
+		return
+	}
+	logger.Error("检查存储桶上传策略失败", zap.Error(err))
+	h.Error(c, utils.CodeServerError, "检查存储桶上传策略失败")
 }
 
-// getRegionByBucket 通过存储桶名称获取区域代码
-func (h *OSSFileHandler) getRegionByBucket(bucketName string) (string, error) {
-	var mapping models.RegionBucketMapping
-	err := h.DB.Where("bucket_name = ?", bucketName).First(&mapping).Error
-	if err != nil {
-		return "", fmt.Errorf("未找到存储桶 %s 对应的区域信息: %w", bucketName, err)
+// resolveObjectKey 根据自定义路径和文件名生成对象键，未提供自定义路径时使用用户名目录
+func resolveObjectKey(username, customPath, filename string) (string, error) {
+	if customPath == "" {
+		return utils.GenerateFixedObjectKey(username, filename), nil
 	}
-	return mapping.RegionCode, nil
+	objectKey, ok := customObjectKey(customPath, filename)
+	if !ok {
+		return "", errors.New("自定义路径包含非法字符")
+	}
+	return objectKey, nil
 }
 
 // Delete 删除文件
@@ -1159,13 +1450,22 @@ func (h *OSSFileHandler) Delete(c *gin.Context) {
 
 	// 获取文件信息
 	var file models.OSSFile
-	if err := h.DB.First(&file, c.Param("id")).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&file, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeFileNotFound, "文件不存在")
+		return
+	}
+	if file.Status == trash.StatusTrashed {
+		h.Error(c, utils.CodeInvalidParams, "文件已在回收站中")
+		return
+	}
+	// 历史版本记录与当前文件共用对象键，只允许删除当前版本
+	if file.Status != "ACTIVE" {
 		h.Error(c, utils.CodeFileNotFound, "文件不存在")
 		return
 	}
 
 	// 通过存储桶名称获取区域信息
-	regionCode, err := h.getRegionByBucket(file.Bucket)
+	regionCode, err := h.getRegionByBucket(c, file.Bucket)
 	if err != nil {
 		logger.Error("获取存储桶区域信息失败",
 			zap.String("bucket", file.Bucket),
@@ -1176,23 +1476,37 @@ func (h *OSSFileHandler) Delete(c *gin.Context) {
 
 	// 获取配置信息
 	var config models.OSSConfig
-	if err := h.DB.First(&config, file.ConfigID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&config, file.ConfigID).Error; err != nil {
 		h.Error(c, utils.CodeConfigNotFound, "存储配置不存在")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶（使用获取到的区域和存储桶）
-	if !auth.CheckBucketAccess(h.DB, userID, regionCode, file.Bucket) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, regionCode, file.Bucket) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
 	}
 
+	// 启用回收站时移入回收站，保留期内可恢复
+	if h.trashManager != nil && h.trashManager.Enabled() {
+		if err := h.trashManager.MoveToTrash(&file, userID); err != nil {
+			logger.Error("移动文件到回收站失败",
+				zap.Uint("fileID", file.ID),
+				zap.String("objectKey", file.ObjectKey),
+				zap.Error(err))
+			h.Error(c, utils.CodeServerError, "删除文件失败")
+			return
+		}
+		h.Success(c, nil)
+		return
+	}
+
 	// 使用获取到的区域和存储桶信息删除文件
 	if err := storage.DeleteObjectFromBucket(file.ObjectKey, regionCode, file.Bucket); err != nil {
 		logger.Error("删除文件失败",
@@ -1204,7 +1518,7 @@ func (h *OSSFileHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.Delete(&file).Error; err != nil {
+	if err := h.DB.WithContext(c).Delete(&file).Error; err != nil {
 		h.Error(c, utils.CodeServerError, "删除文件记录失败")
 		return
 	}
@@ -1222,7 +1536,6 @@ func (h *OSSFileHandler) Delete(c *gin.Context) {
 func (h *OSSFileHandler) CheckDuplicateFile(c *gin.Context) {
 	// 获取用户ID
 	userID := c.GetUint("userID")
-	username, _ := c.Get("username")
 
 	// 获取查询参数
 	originalFilename := c.Query("filename")
@@ -1240,46 +1553,49 @@ func (h *OSSFileHandler) CheckDuplicateFile(c *gin.Context) {
 	}
 
 	// 检查用户是否有权限访问该桶
-	if !auth.CheckBucketAccess(h.DB, userID, regionCode, bucketName) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, regionCode, bucketName) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	// 获取自定义路径并生成对象键
-	customPath := c.Query("custom_path")
-	var objectKey string
-	if customPath != "" {
-		// 清理和验证自定义路径
-		customPath = strings.Trim(customPath, "/")
-		// 验证路径中不包含危险字符
-		if strings.Contains(customPath, "..") || strings.ContainsAny(customPath, "\\<>:\"|?*") {
-			h.Error(c, utils.CodeInvalidParams, "自定义路径包含非法字符")
-			return
-		}
-		// 使用用户自定义路径
-		if customPath == "" {
-			// 自定义路径为空，直接上传到根目录
-			objectKey = originalFilename
-		} else {
-			objectKey = customPath + "/" + originalFilename
-		}
-	} else {
-		// 没有提供自定义路径，使用固定路径生成方式。但为了兼容性，也支持检查绝对路径
-		objectKey = utils.GenerateFixedObjectKey(username.(string), originalFilename)
+	// 按上传接口相同的规则生成对象键
+	objectKey, err := resolveObjectKey(c.GetString("username"), c.Query("custom_path"), originalFilename)
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
+	// 提前检查存储桶上传策略，避免传输后才被拒绝
+	fileSize, _ := strconv.ParseInt(c.Query("file_size"), 10, 64)
+	if _, ok := h.checkUploadPolicy(c, bucketName, objectKey, fileSize); !ok {
+		return
 	}
 
 	// 查询数据库中是否存在相同对象键（完整路径）的文件
 	var existingFile models.OSSFile
-	err := h.DB.Where("object_key = ? AND bucket = ? AND status = ?",
+	err = h.DB.WithContext(c).Where("object_key = ? AND bucket = ? AND status = ?",
 		objectKey, bucketName, "ACTIVE").First(&existingFile).Error
 
+	// 客户端提供了MD5和大小时，同时检查是否可以秒传
+	instantUpload := false
+	if md5 := strings.ToLower(c.Query("md5")); md5 != "" {
+		if fileSize > 0 {
+			source, err := h.findInstantUploadSource(c, userID, md5, fileSize, regionCode, bucketName)
+			if err != nil {
+				logger.Warn("检查秒传源文件失败", zap.String("md5", md5), zap.Error(err))
+			}
+			instantUpload = source != nil
+		}
+	}
+
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// 文件不存在，可以上传
 			h.Success(c, gin.H{
-				"exists":     false,
-				"object_key": objectKey,
-				"message":    "文件不存在，可以上传",
+				"exists":         false,
+				"object_key":     objectKey,
+				"instant_upload": instantUpload,
+				"message":        "文件不存在，可以上传",
 			})
 			return
 		}
@@ -1299,8 +1615,9 @@ func (h *OSSFileHandler) CheckDuplicateFile(c *gin.Context) {
 	}
 
 	h.Success(c, gin.H{
-		"exists":     true,
-		"object_key": objectKey,
+		"exists":         true,
+		"object_key":     objectKey,
+		"instant_upload": instantUpload,
 		"existing_file": gin.H{
 			"id":                existingFile.ID,
 			"filename":          existingFile.Filename,
@@ -1319,7 +1636,7 @@ func (h *OSSFileHandler) GetDownloadURL(c *gin.Context) {
 	fileID := c.Param("id")
 
 	var file models.OSSFile
-	if err := h.DB.First(&file, fileID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&file, fileID).Error; err != nil || file.Status == trash.StatusTrashed {
 		h.Error(c, utils.CodeFileNotFound, "文件不存在")
 		return
 	}
@@ -1354,7 +1671,7 @@ func (h *OSSFileHandler) GetDownloadURL(c *gin.Context) {
 	}
 
 	// 通过存储桶名称获取区域信息
-	regionCode, err := h.getRegionByBucket(file.Bucket)
+	regionCode, err := h.getRegionByBucket(c, file.Bucket)
 	if err != nil {
 		logger.Error("获取存储桶区域信息失败",
 			zap.String("bucket", file.Bucket),
@@ -1365,18 +1682,18 @@ func (h *OSSFileHandler) GetDownloadURL(c *gin.Context) {
 
 	// 获取配置信息
 	var config models.OSSConfig
-	if err := h.DB.First(&config, file.ConfigID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&config, file.ConfigID).Error; err != nil {
 		h.Error(c, utils.CodeConfigNotFound, "存储配置不存在")
 		return
 	}
 
 	// 检查用户是否有权限访问该桶（使用获取到的区域和存储桶）
-	if !auth.CheckBucketAccess(h.DB, c.GetUint("userID"), regionCode, file.Bucket) {
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), regionCode, file.Bucket) {
 		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
 		return
 	}
 
-	storage, err := h.storageFactory.GetStorageService(config.StorageType)
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
 	if err != nil {
 		h.Error(c, utils.CodeServerError, "获取存储服务失败")
 		return
@@ -0,0 +1,786 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/jobs"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 批量操作限制
+const (
+	bulkJobType         = "bulk_operation"
+	maxBulkItems        = 1000
+	bulkProgressEvery   = 50
+	defaultBulkURLTTL   = 3600
+	maxBulkURLTTL       = 7 * 24 * 3600
+	bulkTagModeReplace  = "replace"
+	bulkTagModeMerge    = "merge"
+	bulkNotFoundMessage = "文件不存在或没有访问权限"
+)
+
+// bulkRequest 批量操作请求，ids 与 filter 二选一
+type bulkRequest struct {
+	IDs    []uint `json:"ids"`
+	Filter string `json:"filter"` // 与文件列表相同的查询参数，例如 bucket_name=logs&prefix=2024/&tag=env=dev
+	DryRun bool   `json:"dry_run"`
+
+	Destination string            `json:"destination,omitempty"` // 移动：目标目录
+	Tags        map[string]string `json:"tags,omitempty"`        // 打标签：标签键值
+	TagMode     string            `json:"tag_mode,omitempty"`    // 打标签：replace 覆盖（默认）或 merge 合并
+	ExpiresIn   int               `json:"expires_in,omitempty"`  // 下载链接：有效期（秒）
+}
+
+// bulkItem 待处理的文件及其结果
+type bulkItem struct {
+	file   *models.OSSFile
+	result models.BulkItemResult
+}
+
+// BulkDelete 批量删除文件，启用回收站时移入回收站
+func (h *OSSFileHandler) BulkDelete(c *gin.Context) {
+	h.handleBulk(c, models.BulkActionDelete)
+}
+
+// BulkMove 批量移动文件到指定目录
+func (h *OSSFileHandler) BulkMove(c *gin.Context) {
+	h.handleBulk(c, models.BulkActionMove)
+}
+
+// BulkTag 批量设置文件标签
+func (h *OSSFileHandler) BulkTag(c *gin.Context) {
+	h.handleBulk(c, models.BulkActionTag)
+}
+
+// BulkDownloadURLs 批量生成下载链接
+func (h *OSSFileHandler) BulkDownloadURLs(c *gin.Context) {
+	h.handleBulk(c, models.BulkActionDownloadURLs)
+}
+
+// handleBulk 校验参数并解析目标文件，预演时直接返回受影响的文件，否则创建后台任务
+func (h *OSSFileHandler) handleBulk(c *gin.Context, action string) {
+	var req bulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+
+	switch action {
+	case models.BulkActionMove:
+		dest, err := normalizeFolderPath(req.Destination)
+		if err != nil {
+			h.Error(c, utils.CodeInvalidParams, err.Error())
+			return
+		}
+		req.Destination = dest
+	case models.BulkActionTag:
+		if req.TagMode == "" {
+			req.TagMode = bulkTagModeReplace
+		}
+		if req.TagMode != bulkTagModeReplace && req.TagMode != bulkTagModeMerge {
+			h.Error(c, utils.CodeInvalidParams, "tag_mode 只能为 replace 或 merge")
+			return
+		}
+		if req.TagMode == bulkTagModeMerge && len(req.Tags) == 0 {
+			h.Error(c, utils.CodeInvalidParams, "请指定要合并的标签")
+			return
+		}
+		if err := validateTags(req.Tags); err != nil {
+			h.Error(c, utils.CodeInvalidParams, err.Error())
+			return
+		}
+	case models.BulkActionDownloadURLs:
+		if req.ExpiresIn <= 0 {
+			req.ExpiresIn = defaultBulkURLTTL
+		}
+		if req.ExpiresIn > maxBulkURLTTL {
+			h.Error(c, utils.CodeInvalidParams, fmt.Sprintf("链接有效期不能超过 %d 秒", maxBulkURLTTL))
+			return
+		}
+	}
+
+	items, ok := h.resolveBulkItems(c, &req)
+	if !ok {
+		return
+	}
+	if action == models.BulkActionMove {
+		if err := h.planBulkMove(c, items, req.Destination); err != nil {
+			logger.Error("检查移动目标失败", zap.Error(err))
+			h.Error(c, utils.CodeServerError, "检查移动目标失败")
+			return
+		}
+	}
+
+	results := bulkResults(items)
+	if req.DryRun {
+		affected := 0
+		for _, r := range results {
+			if r.Status == models.BulkItemPlanned {
+				affected++
+			}
+		}
+		h.Success(c, gin.H{
+			"dry_run":  true,
+			"action":   action,
+			"total":    len(results),
+			"affected": affected,
+			"items":    results,
+		})
+		return
+	}
+
+	params, _ := json.Marshal(req)
+	op := models.BulkOperation{
+		CreatorID: c.GetUint("userID"),
+		Action:    action,
+		Status:    models.BulkStatusPending,
+		Params:    string(params),
+		Total:     len(items),
+	}
+	if err := op.SetResults(results); err != nil {
+		h.Error(c, utils.CodeServerError, "创建批量任务失败")
+		return
+	}
+	if err := h.DB.WithContext(c).Create(&op).Error; err != nil {
+		logger.Error("创建批量任务失败", zap.String("action", action), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "创建批量任务失败")
+		return
+	}
+
+	if h.jobManager == nil {
+		h.Error(c, utils.CodeServerError, "后台任务未启用")
+		return
+	}
+	job, err := h.jobManager.Enqueue(bulkJobType, op.CreatorID, bulkJobParams{OperationID: op.ID, Scope: tenant.FromContext(c)})
+	if err != nil {
+		logger.Error("创建批量后台任务失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+		h.DB.WithContext(c).Model(&op).Updates(map[string]interface{}{
+			"status": models.BulkStatusFailed,
+			"error":  err.Error(),
+		})
+		h.Error(c, utils.CodeServerError, "创建批量任务失败")
+		return
+	}
+	op.JobID = job.ID
+	if err := h.DB.WithContext(c).Model(&op).Update("job_id", job.ID).Error; err != nil {
+		logger.Warn("关联批量后台任务失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+	}
+
+	h.Success(c, gin.H{
+		"operation": op,
+		"job":       job,
+	})
+}
+
+// resolveBulkItems 根据文件ID列表或过滤条件解析目标文件，只包含用户可访问的ACTIVE文件
+func (h *OSSFileHandler) resolveBulkItems(c *gin.Context, req *bulkRequest) ([]*bulkItem, bool) {
+	if (len(req.IDs) == 0) == (req.Filter == "") {
+		h.Error(c, utils.CodeInvalidParams, "请指定 ids 或 filter 其中之一")
+		return nil, false
+	}
+	if len(req.IDs) > maxBulkItems {
+		h.Error(c, utils.CodeInvalidParams, fmt.Sprintf("单次最多处理 %d 个文件", maxBulkItems))
+		return nil, false
+	}
+
+	buckets, err := auth.GetUserAccessibleBuckets(h.DB.WithContext(c), c.GetUint("userID"), "")
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取可访问桶列表失败")
+		return nil, false
+	}
+
+	var files []models.OSSFile
+	if len(req.IDs) > 0 {
+		if err := h.DB.WithContext(c).Where("id IN ? AND status = ? AND bucket IN ?", req.IDs, "ACTIVE", buckets).
+			Order("id").Find(&files).Error; err != nil {
+			h.Error(c, utils.CodeServerError, "获取文件列表失败")
+			return nil, false
+		}
+	} else {
+		params, err := url.ParseQuery(req.Filter)
+		if err != nil {
+			h.Error(c, utils.CodeInvalidParams, "过滤条件格式错误")
+			return nil, false
+		}
+		// 批量操作只针对当前版本
+		params.Set("status", "ACTIVE")
+		query, ok := h.buildListQuery(c, params, buckets)
+		if !ok {
+			return nil, false
+		}
+		if err := query.Order("oss_files.id").Limit(maxBulkItems + 1).Find(&files).Error; err != nil {
+			h.Error(c, utils.CodeServerError, "获取文件列表失败")
+			return nil, false
+		}
+		if len(files) > maxBulkItems {
+			h.Error(c, utils.CodeInvalidParams, fmt.Sprintf("匹配的文件超过 %d 个，请缩小过滤范围", maxBulkItems))
+			return nil, false
+		}
+	}
+
+	items := make([]*bulkItem, 0, len(files)+len(req.IDs))
+	found := make(map[uint]bool, len(files))
+	for i := range files {
+		file := &files[i]
+		found[file.ID] = true
+		items = append(items, &bulkItem{
+			file: file,
+			result: models.BulkItemResult{
+				FileID:    file.ID,
+				Bucket:    file.Bucket,
+				ObjectKey: file.ObjectKey,
+				Status:    models.BulkItemPlanned,
+			},
+		})
+	}
+	for _, id := range req.IDs {
+		if !found[id] {
+			found[id] = true
+			items = append(items, &bulkItem{result: models.BulkItemResult{
+				FileID: id,
+				Status: models.BulkItemSkipped,
+				Error:  bulkNotFoundMessage,
+			}})
+		}
+	}
+	return items, true
+}
+
+// planBulkMove 计算每个文件的目标对象键，目标已被占用或批次内重复时跳过
+func (h *OSSFileHandler) planBulkMove(c *gin.Context, items []*bulkItem, destination string) error {
+	targets := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.file == nil {
+			continue
+		}
+		target := folderPrefix(destination) + path.Base(item.file.ObjectKey)
+		item.result.Target = target
+
+		if target == item.file.ObjectKey {
+			item.result.Status = models.BulkItemSkipped
+			item.result.Error = "文件已在目标目录"
+			continue
+		}
+		key := item.file.Bucket + "/" + target
+		if targets[key] {
+			item.result.Status = models.BulkItemSkipped
+			item.result.Error = "批次内存在同名文件"
+			continue
+		}
+		targets[key] = true
+
+		var count int64
+		if err := h.DB.WithContext(c).Model(&models.OSSFile{}).
+			Where("bucket = ? AND object_key = ? AND status = ?", item.file.Bucket, target, "ACTIVE").
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			item.result.Status = models.BulkItemSkipped
+			item.result.Error = "目标路径已存在文件"
+		}
+	}
+	return nil
+}
+
+// bulkResults 提取逐项结果
+func bulkResults(items []*bulkItem) []models.BulkItemResult {
+	results := make([]models.BulkItemResult, 0, len(items))
+	for _, item := range items {
+		results = append(results, item.result)
+	}
+	return results
+}
+
+// bulkJobParams 批量操作后台任务参数
+type bulkJobParams struct {
+	OperationID uint          `json:"operation_id"`
+	Scope       *tenant.Scope `json:"scope,omitempty"` // 创建任务时的组织范围，任务执行时按该范围访问数据
+}
+
+// runBulkJob 批量操作的后台任务处理器
+// 逐项结果随进度保存，重试或服务重启后恢复执行时跳过已处理的文件
+func (h *OSSFileHandler) runBulkJob(ctx context.Context, task *jobs.Task) error {
+	var params bulkJobParams
+	if err := task.Bind(&params); err != nil {
+		return jobs.Permanent(err)
+	}
+	scope := params.Scope
+	if scope == nil {
+		// 之前创建的任务没有保存组织范围，按创建者所属的组织执行
+		var err error
+		if scope, err = auth.LoadScope(task.Job.CreatorID, 0); err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				return jobs.Permanent(err)
+			}
+			return err
+		}
+	}
+	// 数据库操作不跟随 ctx 取消：服务关闭时仍需保存进度，已删除的对象也要删除对应记录
+	dbCtx := tenant.WithScope(context.Background(), scope)
+
+	var op models.BulkOperation
+	if err := h.DB.WithContext(dbCtx).First(&op, params.OperationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("批量操作不存在: %d", params.OperationID))
+		}
+		return err
+	}
+	var req bulkRequest
+	if err := json.Unmarshal([]byte(op.Params), &req); err != nil {
+		return jobs.Permanent(fmt.Errorf("解析批量操作参数失败: %w", err))
+	}
+	results, err := op.GetResults()
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("解析批量操作结果失败: %w", err))
+	}
+	items, err := h.loadBulkItems(dbCtx, results)
+	if err != nil {
+		return err
+	}
+
+	processed := 0
+	for _, item := range items {
+		if item.result.Status != models.BulkItemPlanned {
+			processed++
+		}
+	}
+	task.SetTotal(int64(len(items)))
+	task.Advance(int64(processed))
+
+	updates := map[string]interface{}{"status": models.BulkStatusRunning}
+	if op.StartedAt == nil {
+		updates["started_at"] = time.Now()
+	}
+	h.DB.WithContext(dbCtx).Model(&op).Updates(updates)
+
+	save := func(extra map[string]interface{}) error {
+		if err := op.SetResults(bulkResults(items)); err != nil {
+			return err
+		}
+		fields := map[string]interface{}{
+			"succeeded": op.Succeeded,
+			"failed":    op.Failed,
+			"skipped":   op.Skipped,
+			"results":   op.Results,
+		}
+		for k, v := range extra {
+			fields[k] = v
+		}
+		return h.DB.WithContext(dbCtx).Model(&op).Updates(fields).Error
+	}
+
+	regions := make(map[string]string)
+	regionFor := func(bucket string) (string, error) {
+		if region, ok := regions[bucket]; ok {
+			return region, nil
+		}
+		region, err := h.getRegionByBucket(dbCtx, bucket)
+		if err == nil {
+			regions[bucket] = region
+		}
+		return region, err
+	}
+
+	progress := func(n int) {
+		task.Advance(int64(n))
+		processed += n
+		if processed%bulkProgressEvery < n {
+			if err := save(nil); err != nil {
+				logger.Warn("保存批量操作进度失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+			}
+		}
+	}
+
+	switch op.Action {
+	case models.BulkActionDelete:
+		h.bulkDelete(ctx, dbCtx, items, op.CreatorID, regionFor, progress)
+	default:
+		for _, item := range items {
+			if ctx.Err() != nil {
+				break
+			}
+			if item.result.Status != models.BulkItemPlanned {
+				continue
+			}
+			var err error
+			switch op.Action {
+			case models.BulkActionMove:
+				err = h.bulkMoveItem(dbCtx, item, regionFor)
+			case models.BulkActionTag:
+				err = h.bulkTagItem(dbCtx, item, req.Tags, req.TagMode)
+			case models.BulkActionDownloadURLs:
+				err = h.bulkDownloadURLItem(dbCtx, item, regionFor, time.Duration(req.ExpiresIn)*time.Second)
+			}
+			finishBulkItem(item, err)
+			progress(1)
+		}
+	}
+
+	if ctx.Err() != nil {
+		// 服务关闭时保留未处理的文件，恢复执行时继续；用户取消时标记为跳过
+		if !task.Cancelled() {
+			if err := save(nil); err != nil {
+				logger.Warn("保存批量操作进度失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+			}
+			return ctx.Err()
+		}
+		for _, item := range items {
+			if item.result.Status == models.BulkItemPlanned {
+				item.result.Status = models.BulkItemSkipped
+				item.result.Error = "任务已取消"
+			}
+		}
+		if err := save(map[string]interface{}{
+			"status":      models.BulkStatusCancelled,
+			"finished_at": time.Now(),
+		}); err != nil {
+			logger.Warn("保存批量操作结果失败", zap.Uint("operation_id", op.ID), zap.Error(err))
+		}
+		return ctx.Err()
+	}
+
+	if err := save(map[string]interface{}{
+		"status":      models.BulkStatusCompleted,
+		"finished_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	logger.Info("批量操作完成",
+		zap.Uint("operation_id", op.ID),
+		zap.String("action", op.Action),
+		zap.Int("succeeded", op.Succeeded),
+		zap.Int("failed", op.Failed),
+		zap.Int("skipped", op.Skipped))
+
+	return task.SetResult(gin.H{
+		"operation_id": op.ID,
+		"succeeded":    op.Succeeded,
+		"failed":       op.Failed,
+		"skipped":      op.Skipped,
+	})
+}
+
+// loadBulkItems 根据保存的逐项结果重新加载待处理的文件，已不再是ACTIVE的文件标记为跳过
+func (h *OSSFileHandler) loadBulkItems(dbCtx context.Context, results []models.BulkItemResult) ([]*bulkItem, error) {
+	var ids []uint
+	for _, r := range results {
+		if r.Status == models.BulkItemPlanned {
+			ids = append(ids, r.FileID)
+		}
+	}
+
+	files := make(map[uint]*models.OSSFile, len(ids))
+	if len(ids) > 0 {
+		var rows []models.OSSFile
+		if err := h.DB.WithContext(dbCtx).Where("id IN ? AND status = ?", ids, "ACTIVE").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			files[rows[i].ID] = &rows[i]
+		}
+	}
+
+	items := make([]*bulkItem, 0, len(results))
+	for _, r := range results {
+		item := &bulkItem{result: r}
+		if r.Status == models.BulkItemPlanned {
+			file, ok := files[r.FileID]
+			if !ok || file.ObjectKey != r.ObjectKey {
+				item.result.Status = models.BulkItemSkipped
+				item.result.Error = "文件已被删除或修改"
+			} else {
+				item.file = file
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// finishBulkItem 根据执行结果设置单项状态
+func finishBulkItem(item *bulkItem, err error) {
+	if err != nil {
+		item.result.Status = models.BulkItemFailed
+		item.result.Error = err.Error()
+		return
+	}
+	item.result.Status = models.BulkItemSuccess
+}
+
+// bulkDelete 批量删除；未启用回收站时按存储配置和存储桶分组，使用对象存储的批量删除接口
+// ctx 用于中止处理，dbCtx 用于数据库操作
+func (h *OSSFileHandler) bulkDelete(ctx, dbCtx context.Context, items []*bulkItem, operatorID uint, regionFor func(string) (string, error), progress func(int)) {
+	if h.trashManager != nil && h.trashManager.Enabled() {
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return
+			}
+			if item.result.Status != models.BulkItemPlanned {
+				continue
+			}
+			finishBulkItem(item, h.trashManager.MoveToTrash(item.file, operatorID))
+			progress(1)
+		}
+		return
+	}
+
+	groups := make(map[string][]*bulkItem)
+	var order []string
+	for _, item := range items {
+		if item.result.Status != models.BulkItemPlanned {
+			continue
+		}
+		key := strconv.FormatUint(uint64(item.file.ConfigID), 10) + "/" + item.file.Bucket
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], item)
+	}
+
+	for _, key := range order {
+		if ctx.Err() != nil {
+			return
+		}
+		group := groups[key]
+		failGroup := func(err error) {
+			for _, item := range group {
+				finishBulkItem(item, err)
+			}
+			progress(len(group))
+		}
+
+		first := group[0].file
+		region, err := regionFor(first.Bucket)
+		if err != nil {
+			failGroup(err)
+			continue
+		}
+		storage, err := h.storageForFile(dbCtx, first)
+		if err != nil {
+			failGroup(err)
+			continue
+		}
+
+		keys := make([]string, 0, len(group))
+		for _, item := range group {
+			keys = append(keys, item.file.ObjectKey)
+		}
+		deleted, batchErr := storage.DeleteObjectsFromBucket(keys, region, first.Bucket)
+		deletedSet := make(map[string]bool, len(deleted))
+		for _, k := range deleted {
+			deletedSet[k] = true
+		}
+
+		var ids []uint
+		for _, item := range group {
+			if deletedSet[item.file.ObjectKey] {
+				ids = append(ids, item.file.ID)
+			}
+		}
+		var dbErr error
+		if len(ids) > 0 {
+			dbErr = h.DB.WithContext(dbCtx).Where("id IN ?", ids).Delete(&models.OSSFile{}).Error
+		}
+
+		for _, item := range group {
+			switch {
+			case !deletedSet[item.file.ObjectKey] && batchErr != nil:
+				finishBulkItem(item, batchErr)
+			case !deletedSet[item.file.ObjectKey]:
+				finishBulkItem(item, fmt.Errorf("对象未被删除"))
+			default:
+				finishBulkItem(item, dbErr)
+			}
+		}
+		progress(len(group))
+	}
+}
+
+// bulkMoveItem 移动单个文件到规划好的目标路径
+func (h *OSSFileHandler) bulkMoveItem(dbCtx context.Context, item *bulkItem, regionFor func(string) (string, error)) error {
+	region, err := regionFor(item.file.Bucket)
+	if err != nil {
+		return err
+	}
+	return h.moveFile(dbCtx, item.file, region, item.result.Target)
+}
+
+// bulkTagItem 覆盖或合并单个文件的标签，并同步到对象存储
+func (h *OSSFileHandler) bulkTagItem(dbCtx context.Context, item *bulkItem, tags map[string]string, mode string) error {
+	if mode == bulkTagModeMerge {
+		var existing []models.FileTag
+		if err := h.DB.WithContext(dbCtx).Where("file_id = ?", item.file.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		merged := make(map[string]string, len(existing)+len(tags))
+		for _, tag := range existing {
+			merged[tag.Key] = tag.Value
+		}
+		for key, value := range tags {
+			merged[key] = value
+		}
+		if err := validateTags(merged); err != nil {
+			return err
+		}
+		tags = merged
+	}
+
+	if err := h.DB.WithContext(dbCtx).Transaction(func(tx *gorm.DB) error {
+		return replaceFileTags(tx, item.file.ID, tags)
+	}); err != nil {
+		return err
+	}
+	h.syncObjectTags(dbCtx, item.file, tags)
+	return nil
+}
+
+// bulkDownloadURLItem 为单个文件生成下载链接
+func (h *OSSFileHandler) bulkDownloadURLItem(dbCtx context.Context, item *bulkItem, regionFor func(string) (string, error), ttl time.Duration) error {
+	region, err := regionFor(item.file.Bucket)
+	if err != nil {
+		return err
+	}
+	storage, err := h.storageForFile(dbCtx, item.file)
+	if err != nil {
+		return err
+	}
+	downloadURL, _, err := storage.GenerateDownloadURLFromBucket(item.file.ObjectKey, region, item.file.Bucket, ttl)
+	if err != nil {
+		return err
+	}
+	item.result.URL = downloadURL
+	return nil
+}
+
+// ListBulkOperations 获取当前用户的批量任务列表
+func (h *OSSFileHandler) ListBulkOperations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.DB.WithContext(c).Model(&models.BulkOperation{}).Where("creator_id = ?", c.GetUint("userID"))
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取批量任务总数失败")
+		return
+	}
+
+	var ops []models.BulkOperation
+	if err := query.Omit("results").
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&ops).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取批量任务列表失败")
+		return
+	}
+	h.syncBulkStatus(c, ops)
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": ops,
+	})
+}
+
+// GetBulkOperation 获取批量任务详情及逐项结果
+func (h *OSSFileHandler) GetBulkOperation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的任务ID")
+		return
+	}
+
+	var op models.BulkOperation
+	if err := h.DB.WithContext(c).Where("id = ? AND creator_id = ?", id, c.GetUint("userID")).First(&op).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			h.Error(c, utils.CodeNotFound, "批量任务不存在")
+			return
+		}
+		h.Error(c, utils.CodeServerError, "获取批量任务失败")
+		return
+	}
+
+	ops := []models.BulkOperation{op}
+	h.syncBulkStatus(c, ops)
+	op = ops[0]
+
+	results, err := op.GetResults()
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "解析任务结果失败")
+		return
+	}
+
+	var job *models.Job
+	if op.JobID != 0 {
+		var j models.Job
+		if err := h.DB.WithContext(c).First(&j, op.JobID).Error; err == nil {
+			job = &j
+		}
+	}
+
+	h.Success(c, gin.H{
+		"operation": op,
+		"job":       job,
+		"items":     results,
+	})
+}
+
+// syncBulkStatus 后台任务在处理器之外结束（排队时取消、最终失败）时，以任务状态为准
+func (h *OSSFileHandler) syncBulkStatus(c *gin.Context, ops []models.BulkOperation) {
+	var jobIDs []uint
+	for _, op := range ops {
+		if op.JobID != 0 && (op.Status == models.BulkStatusPending || op.Status == models.BulkStatusRunning) {
+			jobIDs = append(jobIDs, op.JobID)
+		}
+	}
+	if len(jobIDs) == 0 {
+		return
+	}
+
+	var jobList []models.Job
+	if err := h.DB.WithContext(c).Where("id IN ?", jobIDs).Find(&jobList).Error; err != nil {
+		return
+	}
+	byID := make(map[uint]models.Job, len(jobList))
+	for _, j := range jobList {
+		byID[j.ID] = j
+	}
+	for i := range ops {
+		j, ok := byID[ops[i].JobID]
+		if !ok {
+			continue
+		}
+		switch j.Status {
+		case models.JobStatusFailed:
+			ops[i].Status = models.BulkStatusFailed
+			ops[i].Error = j.Error
+		case models.JobStatusCancelled:
+			ops[i].Status = models.BulkStatusCancelled
+		}
+	}
+}
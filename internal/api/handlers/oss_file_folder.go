@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FolderEntry 目录列表中的子目录
+type FolderEntry struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Count int64  `json:"count"`
+}
+
+// normalizeFolderPath 清理目录路径，去掉首尾斜杠，根目录返回空字符串
+func normalizeFolderPath(p string) (string, error) {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if strings.Contains(p, "..") || strings.Contains(p, "//") || strings.ContainsAny(p, "\\<>:\"|?*") {
+		return "", errors.New("目录路径包含非法字符")
+	}
+	return p, nil
+}
+
+// folderPrefix 目录对应的对象键前缀
+func folderPrefix(p string) string {
+	if p == "" {
+		return ""
+	}
+	return p + "/"
+}
+
+// firstSegment 返回列值从 start 位置（从 1 开始计数）到下一个 '/' 之前部分的 SQL 表达式，该位置之后必须含有 '/'
+// PostgreSQL 使用 strpos 查找位置，SQLite 和 MySQL 没有 strpos，使用参数顺序相同的 instr
+func firstSegment(tx *gorm.DB, column string, start int) string {
+	pos := "instr"
+	if tx.Dialector.Name() == "postgres" {
+		pos = "strpos"
+	}
+	return fmt.Sprintf("substr(%[1]s, %[2]d, %[3]s(substr(%[1]s, %[2]d), '/') - 1)", column, start, pos)
+}
+
+// folderAccess 校验存储桶访问权限并返回区域代码，失败时已写入响应
+func (h *OSSFileHandler) folderAccess(c *gin.Context, bucketName string) (string, bool) {
+	if bucketName == "" {
+		h.Error(c, utils.CodeInvalidParams, "请指定 bucket_name")
+		return "", false
+	}
+	regionCode, err := h.getRegionByBucket(c, bucketName)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储桶区域信息失败")
+		return "", false
+	}
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), regionCode, bucketName) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return "", false
+	}
+	return regionCode, true
+}
+
+// ListFolder 列出目录下的直接子目录（含汇总大小和文件数）以及该层级的文件
+func (h *OSSFileHandler) ListFolder(c *gin.Context) {
+	bucketName := c.Query("bucket_name")
+	if _, ok := h.folderAccess(c, bucketName); !ok {
+		return
+	}
+	path, err := normalizeFolderPath(c.Query("path"))
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+	prefix := folderPrefix(path)
+
+	// 由对象键前缀推导的子目录
+	var folders []FolderEntry
+	if err := h.DB.WithContext(c).Model(&models.OSSFile{}).
+		Select(firstSegment(h.DB.WithContext(c), "object_key", len(prefix)+1)+" AS name, COALESCE(SUM(file_size), 0) AS size, COUNT(*) AS count").
+		Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucketName, "ACTIVE", escapeLike(prefix)+"%/%").
+		Group("name").
+		Scan(&folders).Error; err != nil {
+		logger.Error("统计子目录失败", zap.String("bucket", bucketName), zap.String("path", path), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "获取目录列表失败")
+		return
+	}
+
+	// 合并显式创建的空目录
+	var virtualPaths []string
+	if err := h.DB.WithContext(c).Model(&models.VirtualFolder{}).
+		Where("bucket = ? AND path LIKE ? ESCAPE '!'", bucketName, escapeLike(prefix)+"%").
+		Pluck("path", &virtualPaths).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取目录列表失败")
+		return
+	}
+	seen := make(map[string]bool, len(folders))
+	for _, f := range folders {
+		seen[f.Name] = true
+	}
+	for _, p := range virtualPaths {
+		name, _, _ := strings.Cut(strings.TrimPrefix(p, prefix), "/")
+		if name != "" && !seen[name] {
+			folders = append(folders, FolderEntry{Name: name})
+			seen[name] = true
+		}
+	}
+	for i := range folders {
+		folders[i].Path = prefix + folders[i].Name
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Name < folders[j].Name })
+
+	// 当前层级的文件，按对象键游标分页
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	fileQuery := h.DB.WithContext(c).Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucketName, "ACTIVE", escapeLike(prefix)+"%").
+		Where("object_key NOT LIKE ? ESCAPE '!'", escapeLike(prefix)+"%/%")
+	if after := c.Query("after"); after != "" {
+		fileQuery = fileQuery.Where("object_key > ?", after)
+	}
+	var files []models.OSSFile
+	if err := fileQuery.Order("object_key").Limit(limit + 1).Find(&files).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取目录文件失败")
+		return
+	}
+	nextAfter := ""
+	if len(files) > limit {
+		files = files[:limit]
+		nextAfter = files[len(files)-1].ObjectKey
+	}
+
+	h.Success(c, gin.H{
+		"path":       path,
+		"folders":    folders,
+		"files":      files,
+		"next_after": nextAfter,
+	})
+}
+
+// CreateFolder 创建空目录
+func (h *OSSFileHandler) CreateFolder(c *gin.Context) {
+	var req struct {
+		BucketName string `json:"bucket_name" binding:"required"`
+		Path       string `json:"path" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+	if _, ok := h.folderAccess(c, req.BucketName); !ok {
+		return
+	}
+	path, err := normalizeFolderPath(req.Path)
+	if err != nil || path == "" {
+		h.Error(c, utils.CodeInvalidParams, "无效的目录路径")
+		return
+	}
+
+	folder := models.VirtualFolder{Bucket: req.BucketName, Path: path, CreatorID: c.GetUint("userID")}
+	if err := h.DB.WithContext(c).Where("bucket = ? AND path = ?", folder.Bucket, folder.Path).FirstOrCreate(&folder).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "创建目录失败")
+		return
+	}
+
+	h.Success(c, folder)
+}
+
+// RenameFolder 重命名目录，将前缀下的所有文件移动到新前缀
+func (h *OSSFileHandler) RenameFolder(c *gin.Context) {
+	var req struct {
+		BucketName string `json:"bucket_name" binding:"required"`
+		Path       string `json:"path" binding:"required"`
+		NewPath    string `json:"new_path" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+	regionCode, ok := h.folderAccess(c, req.BucketName)
+	if !ok {
+		return
+	}
+	oldPath, err1 := normalizeFolderPath(req.Path)
+	newPath, err2 := normalizeFolderPath(req.NewPath)
+	if err1 != nil || err2 != nil || oldPath == "" || newPath == "" {
+		h.Error(c, utils.CodeInvalidParams, "无效的目录路径")
+		return
+	}
+	if oldPath == newPath || strings.HasPrefix(newPath+"/", oldPath+"/") {
+		h.Error(c, utils.CodeInvalidParams, "不能将目录移动到自身或其子目录下")
+		return
+	}
+	oldPrefix, newPrefix := folderPrefix(oldPath), folderPrefix(newPath)
+
+	var files []models.OSSFile
+	if err := h.DB.WithContext(c).Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", req.BucketName, "ACTIVE", escapeLike(oldPrefix)+"%").
+		Order("object_key").Find(&files).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取目录文件失败")
+		return
+	}
+
+	// 先检查目标路径冲突，避免移动到一半才失败
+	var conflicts int64
+	if err := h.DB.WithContext(c).Model(&models.OSSFile{}).
+		Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", req.BucketName, "ACTIVE", escapeLike(newPrefix)+"%").
+		Count(&conflicts).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "检查目标目录失败")
+		return
+	}
+	if conflicts > 0 {
+		h.Error(c, utils.CodeFileExists, "目标目录已存在文件")
+		return
+	}
+
+	moved := 0
+	for i := range files {
+		newKey := newPrefix + strings.TrimPrefix(files[i].ObjectKey, oldPrefix)
+		if err := h.moveFile(c, &files[i], regionCode, newKey); err != nil {
+			logger.Error("移动文件失败",
+				zap.Uint("file_id", files[i].ID),
+				zap.String("object_key", files[i].ObjectKey),
+				zap.String("new_key", newKey),
+				zap.Error(err))
+			h.Error(c, utils.CodeServerError, fmt.Sprintf("移动文件失败，已移动 %d/%d 个文件", moved, len(files)))
+			return
+		}
+		moved++
+	}
+
+	// 同步更新显式创建的目录
+	if err := h.DB.WithContext(c).Model(&models.VirtualFolder{}).
+		Where("bucket = ? AND (path = ? OR path LIKE ? ESCAPE '!')", req.BucketName, oldPath, escapeLike(oldPrefix)+"%").
+		Update("path", gorm.Expr("CONCAT(?, substr(path, ?))", newPath, len(oldPath)+1)).Error; err != nil {
+		logger.Warn("更新虚拟目录失败", zap.String("path", oldPath), zap.Error(err))
+	}
+
+	h.Success(c, gin.H{
+		"path":  newPath,
+		"moved": moved,
+	})
+}
+
+// DeleteFolder 递归删除目录下的所有文件，启用回收站时移入回收站
+func (h *OSSFileHandler) DeleteFolder(c *gin.Context) {
+	bucketName := c.Query("bucket_name")
+	regionCode, ok := h.folderAccess(c, bucketName)
+	if !ok {
+		return
+	}
+	path, err := normalizeFolderPath(c.Query("path"))
+	if err != nil || path == "" {
+		h.Error(c, utils.CodeInvalidParams, "无效的目录路径")
+		return
+	}
+	prefix := folderPrefix(path)
+
+	var files []models.OSSFile
+	if err := h.DB.WithContext(c).Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucketName, "ACTIVE", escapeLike(prefix)+"%").
+		Find(&files).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取目录文件失败")
+		return
+	}
+
+	deleted := 0
+	for i := range files {
+		if err := h.removeFile(c, &files[i], regionCode, c.GetUint("userID")); err != nil {
+			logger.Error("删除文件失败",
+				zap.Uint("file_id", files[i].ID),
+				zap.String("object_key", files[i].ObjectKey),
+				zap.Error(err))
+			h.Error(c, utils.CodeServerError, fmt.Sprintf("删除文件失败，已删除 %d/%d 个文件", deleted, len(files)))
+			return
+		}
+		deleted++
+	}
+
+	if err := h.DB.WithContext(c).Unscoped().Where("bucket = ? AND (path = ? OR path LIKE ? ESCAPE '!')", bucketName, path, escapeLike(prefix)+"%").
+		Delete(&models.VirtualFolder{}).Error; err != nil {
+		logger.Warn("删除虚拟目录失败", zap.String("path", path), zap.Error(err))
+	}
+
+	h.Success(c, gin.H{"deleted": deleted})
+}
+
+// storageForFile 获取文件所属配置的存储服务
+func (h *OSSFileHandler) storageForFile(ctx context.Context, file *models.OSSFile) (oss.StorageService, error) {
+	var config models.OSSConfig
+	if err := h.DB.WithContext(ctx).First(&config, file.ConfigID).Error; err != nil {
+		return nil, fmt.Errorf("存储配置不存在: %w", err)
+	}
+	return h.storageFactory.GetConfigStorageService(&config)
+}
+
+// defaultStorage 获取默认存储配置和对应的存储服务
+func (h *OSSFileHandler) defaultStorage(c *gin.Context) (models.OSSConfig, oss.StorageService, error) {
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
+		return config, nil, fmt.Errorf("获取默认存储配置失败: %w", err)
+	}
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
+	if err != nil {
+		return config, nil, fmt.Errorf("获取存储服务失败: %w", err)
+	}
+	return config, storage, nil
+}
+
+// moveFile 在同一存储桶内将文件移动到新的对象键，历史版本记录随之更新路径
+func (h *OSSFileHandler) moveFile(ctx context.Context, file *models.OSSFile, regionCode, newKey string) error {
+	storage, err := h.storageForFile(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	url, err := storage.CopyObjectToBucket(file.Bucket, file.ObjectKey, newKey, regionCode, file.Bucket)
+	if err != nil {
+		return err
+	}
+	if err := storage.DeleteObjectFromBucket(file.ObjectKey, regionCode, file.Bucket); err != nil {
+		logger.Warn("删除原对象失败", zap.String("object_key", file.ObjectKey), zap.Error(err))
+	}
+
+	oldKey := file.ObjectKey
+	return h.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OSSFile{}).
+			Where("bucket = ? AND object_key = ? AND status = ?", file.Bucket, oldKey, "REPLACED").
+			Updates(map[string]interface{}{"object_key": newKey, "filename": newKey}).Error; err != nil {
+			return err
+		}
+		file.ObjectKey = newKey
+		file.Filename = newKey
+		file.DownloadURL = url
+		return tx.Model(file).Updates(map[string]interface{}{
+			"object_key":   newKey,
+			"filename":     newKey,
+			"download_url": url,
+		}).Error
+	})
+}
+
+// removeFile 删除单个文件，启用回收站时移入回收站，否则删除对象和记录
+func (h *OSSFileHandler) removeFile(ctx context.Context, file *models.OSSFile, regionCode string, operatorID uint) error {
+	if h.trashManager != nil && h.trashManager.Enabled() {
+		return h.trashManager.MoveToTrash(file, operatorID)
+	}
+
+	storage, err := h.storageForFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	if err := storage.DeleteObjectFromBucket(file.ObjectKey, regionCode, file.Bucket); err != nil {
+		return err
+	}
+	return h.DB.WithContext(ctx).Delete(file).Error
+}
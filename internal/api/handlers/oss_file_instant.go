@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// InstantUpload 秒传：根据文件MD5和大小查找内容相同的已有对象，命中后在服务端复制，不传输文件数据
+// 未命中时返回 hit=false，客户端应回退到普通上传
+func (h *OSSFileHandler) InstantUpload(c *gin.Context) {
+	var req struct {
+		RegionCode     string `json:"region_code" binding:"required"`
+		BucketName     string `json:"bucket_name" binding:"required"`
+		FileName       string `json:"file_name" binding:"required"`
+		FileSize       int64  `json:"file_size" binding:"required,gt=0"`
+		MD5            string `json:"md5" binding:"required,len=32,hexadecimal"`
+		CustomPath     string `json:"custom_path"`
+		ForceOverwrite bool   `json:"force_overwrite"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+	if _, err := parseTagHeader(c.GetHeader(tagHeader)); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+	req.MD5 = strings.ToLower(req.MD5)
+	userID := c.GetUint("userID")
+
+	// 获取存储配置
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
+		return
+	}
+
+	// 检查用户是否有权限访问目标桶
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, req.RegionCode, req.BucketName) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return
+	}
+
+	username, _ := c.Get("username")
+	objectKey, err := resolveObjectKey(username.(string), req.CustomPath, req.FileName)
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+	if _, ok := h.checkUploadPolicy(c, req.BucketName, objectKey, req.FileSize); !ok {
+		return
+	}
+
+	// 如果不是强制覆盖，检查文件是否已存在（基于完整路径）
+	if !req.ForceOverwrite {
+		var existingFile models.OSSFile
+		err := h.DB.WithContext(c).Where("object_key = ? AND bucket = ? AND status = ?",
+			objectKey, req.BucketName, "ACTIVE").First(&existingFile).Error
+		if err == nil {
+			h.Error(c, utils.CodeFileExists, "在相同路径下文件已存在，请确认是否要覆盖")
+			return
+		} else if err != gorm.ErrRecordNotFound {
+			h.Error(c, utils.CodeServerError, "检查文件是否存在失败")
+			return
+		}
+	}
+
+	source, err := h.findInstantUploadSource(c, userID, req.MD5, req.FileSize, req.RegionCode, req.BucketName)
+	if err != nil {
+		logger.Error("查找秒传源文件失败", zap.String("md5", req.MD5), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "查找秒传源文件失败")
+		return
+	}
+	if source == nil {
+		h.Success(c, gin.H{
+			"hit":        false,
+			"object_key": objectKey,
+		})
+		return
+	}
+
+	// 秒传同样占用目标的存储配额
+	if !h.checkQuota(c, userID, req.BucketName, req.FileSize) {
+		return
+	}
+
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储服务失败")
+		return
+	}
+
+	// 源与目标完全相同时无需复制
+	uploadURL := source.DownloadURL
+	if source.Bucket != req.BucketName || source.ObjectKey != objectKey {
+		var pending *pendingVersion
+		if req.ForceOverwrite {
+			if pending, err = h.preserveCurrentVersion(c, storage, objectKey, req.RegionCode, req.BucketName); err != nil {
+				logger.Error("保留历史版本失败", zap.String("object_key", objectKey), zap.Error(err))
+				h.Error(c, utils.CodeServerError, "保留历史版本失败")
+				return
+			}
+		}
+		uploadURL, err = storage.CopyObjectToBucket(source.Bucket, source.ObjectKey, objectKey, req.RegionCode, req.BucketName)
+		if err != nil {
+			pending.discard()
+			h.Error(c, utils.CodeServerError, "服务端复制文件失败")
+			return
+		}
+	}
+
+	ossFile := models.OSSFile{
+		ConfigID:         config.ID,
+		Filename:         objectKey,
+		OriginalFilename: req.FileName,
+		FileSize:         req.FileSize,
+		MD5:              req.MD5,
+		MD5Status:        models.MD5StatusCompleted,
+		StorageType:      config.StorageType,
+		Bucket:           req.BucketName,
+		ObjectKey:        objectKey,
+		DownloadURL:      uploadURL,
+		UploaderID:       utils.GetUserID(c),
+		UploadIP:         c.ClientIP(),
+		ExpiresAt:        fileRecordExpiresAt(config),
+		Status:           "ACTIVE",
+	}
+	if err := h.replaceActiveFileRecord(c, &ossFile); err != nil {
+		logger.Error("保存秒传文件记录失败", zap.String("object_key", objectKey), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "保存文件记录失败")
+		return
+	}
+
+	logger.Info("秒传成功",
+		zap.Uint("file_id", ossFile.ID),
+		zap.Uint("source_file_id", source.ID),
+		zap.String("object_key", objectKey),
+		zap.String("bucket", req.BucketName))
+
+	h.applyUploadTags(c, &ossFile)
+	h.Success(c, gin.H{
+		"hit":            true,
+		"object_key":     objectKey,
+		"source_file_id": source.ID,
+		"file":           ossFile,
+	})
+}
+
+// findInstantUploadSource 在用户可访问且与目标同地域的存储桶中查找内容相同的文件
+// 优先选择目标桶内的文件；未找到时返回 nil
+func (h *OSSFileHandler) findInstantUploadSource(c *gin.Context, userID uint, md5 string, fileSize int64, regionCode, bucketName string) (*models.OSSFile, error) {
+	// 服务端复制只能在同一地域内进行
+	buckets, err := auth.GetUserAccessibleBuckets(h.DB.WithContext(c), userID, regionCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	query := func() *gorm.DB {
+		return h.DB.WithContext(c).Where("md5 = ? AND file_size = ? AND md5_status = ? AND status = ?",
+			md5, fileSize, models.MD5StatusCompleted, "ACTIVE")
+	}
+
+	var source models.OSSFile
+	err = query().Where("bucket = ?", bucketName).Order("created_at DESC").First(&source).Error
+	if err == nil {
+		return &source, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = query().Where("bucket IN ?", buckets).Order("created_at DESC").First(&source).Error
+	if err == nil {
+		return &source, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// replaceActiveFileRecord 在事务中将同路径的旧记录标记为REPLACED并写入新记录
+func (h *OSSFileHandler) replaceActiveFileRecord(c *gin.Context, ossFile *models.OSSFile) error {
+	return h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OSSFile{}).Where(
+			"object_key = ? AND bucket = ? AND status = ?",
+			ossFile.ObjectKey, ossFile.Bucket, "ACTIVE",
+		).Update("status", "REPLACED").Error; err != nil {
+			return fmt.Errorf("更新旧文件记录失败: %w", err)
+		}
+		if err := tx.Create(ossFile).Error; err != nil {
+			return fmt.Errorf("保存文件记录失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// fileRecordExpiresAt 根据存储配置计算文件链接过期时间，未配置时默认24小时
+func fileRecordExpiresAt(config models.OSSConfig) time.Time {
+	expireTime := config.URLExpireTime
+	if expireTime <= 0 {
+		expireTime = 24 * 3600
+	}
+	return time.Now().Add(time.Duration(expireTime) * time.Second)
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// listSortColumns 文件列表允许的排序字段
+var listSortColumns = map[string]bool{
+	"created_at":        true,
+	"file_size":         true,
+	"original_filename": true,
+}
+
+// listCursor 游标分页位置，记录上一页最后一条记录的排序值和ID
+type listCursor struct {
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// encodeListCursor 生成不透明的游标字符串
+func encodeListCursor(file models.OSSFile, sortBy string) string {
+	cursor := listCursor{ID: file.ID}
+	switch sortBy {
+	case "file_size":
+		cursor.Value = strconv.FormatInt(file.FileSize, 10)
+	case "original_filename":
+		cursor.Value = file.OriginalFilename
+	default:
+		cursor.Value = file.CreatedAt.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor 解析游标，返回排序字段对应类型的值
+func decodeListCursor(raw, sortBy string) (interface{}, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, 0, errors.New("无效的游标")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, 0, errors.New("无效的游标")
+	}
+
+	switch sortBy {
+	case "file_size":
+		size, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, 0, errors.New("无效的游标")
+		}
+		return size, cursor.ID, nil
+	case "original_filename":
+		return cursor.Value, cursor.ID, nil
+	default:
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, 0, errors.New("无效的游标")
+		}
+		return t, cursor.ID, nil
+	}
+}
+
+// escapeLike 转义 LIKE 模式中的通配符，查询条件需写明 ESCAPE '!'
+// SQLite 没有默认的转义字符，反斜杠在不同数据库的字符串中含义也不同，因此统一使用 '!'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
+
+// List 获取文件列表
+// 支持按存储桶、路径前缀、上传者、大小范围、时间范围、MD5状态、状态和标签过滤，可选择排序字段
+// 默认使用游标分页（cursor/limit）；传入 page 时使用兼容的页码分页并返回总数
+// status 默认为 ACTIVE，此时每个路径只有一条记录；查询其他状态时每个路径只返回最新一条
+func (h *OSSFileHandler) List(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	// 获取用户可访问的桶列表
+	buckets, err := auth.GetUserAccessibleBuckets(h.DB.WithContext(c), userID, "")
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取可访问桶列表失败")
+		return
+	}
+
+	query, ok := h.buildListQuery(c, c.Request.URL.Query(), buckets)
+	if !ok {
+		return
+	}
+
+	sortBy := c.DefaultQuery("sort_by", "created_at")
+	if !listSortColumns[sortBy] {
+		h.Error(c, utils.CodeInvalidParams, "不支持的排序字段")
+		return
+	}
+	direction := "DESC"
+	if strings.EqualFold(c.Query("order"), "asc") {
+		direction = "ASC"
+	}
+	orderBy := "oss_files." + sortBy + " " + direction + ", oss_files.id " + direction
+
+	// 兼容页码分页
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, _ := strconv.Atoi(pageStr)
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+		if page <= 0 {
+			page = 1
+		}
+		if pageSize <= 0 || pageSize > 100 {
+			pageSize = 10
+		}
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			logger.Error("获取文件总数失败", zap.Error(err))
+			h.Error(c, utils.CodeServerError, "获取文件总数失败")
+			return
+		}
+
+		var files []models.OSSFile
+		if err := query.Preload("Tags").Order(orderBy).
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Find(&files).Error; err != nil {
+			logger.Error("获取文件列表失败", zap.Error(err))
+			h.Error(c, utils.CodeServerError, "获取文件列表失败")
+			return
+		}
+
+		h.Success(c, gin.H{
+			"total": total,
+			"items": files,
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		value, id, err := decodeListCursor(raw, sortBy)
+		if err != nil {
+			h.Error(c, utils.CodeInvalidParams, err.Error())
+			return
+		}
+		op := "<"
+		if direction == "ASC" {
+			op = ">"
+		}
+		query = query.Where("(oss_files."+sortBy+", oss_files.id) "+op+" (?, ?)", value, id)
+	}
+
+	// 多取一条用于判断是否还有下一页
+	var files []models.OSSFile
+	if err := query.Preload("Tags").Order(orderBy).Limit(limit + 1).Find(&files).Error; err != nil {
+		logger.Error("获取文件列表失败", zap.Error(err))
+		h.Error(c, utils.CodeServerError, "获取文件列表失败")
+		return
+	}
+
+	hasMore := len(files) > limit
+	nextCursor := ""
+	if hasMore {
+		files = files[:limit]
+		nextCursor = encodeListCursor(files[len(files)-1], sortBy)
+	}
+
+	h.Success(c, gin.H{
+		"items":       files,
+		"has_more":    hasMore,
+		"next_cursor": nextCursor,
+	})
+}
+
+// buildListQuery 根据查询参数构造文件列表查询，参数错误时已写入响应
+func (h *OSSFileHandler) buildListQuery(c *gin.Context, params url.Values, buckets []string) (*gorm.DB, bool) {
+	query := h.DB.WithContext(c).Model(&models.OSSFile{}).Where("oss_files.bucket IN ?", buckets)
+
+	if bucketName := params.Get("bucket_name"); bucketName != "" {
+		query = query.Where("oss_files.bucket = ?", bucketName)
+	}
+	if prefix := params.Get("prefix"); prefix != "" {
+		query = query.Where("oss_files.object_key LIKE ? ESCAPE '!'", escapeLike(prefix)+"%")
+	}
+	if keyword := params.Get("keyword"); keyword != "" {
+		query = query.Where("LOWER(oss_files.original_filename) LIKE LOWER(?) ESCAPE '!'", "%"+escapeLike(keyword)+"%")
+	}
+	if configID := params.Get("config_id"); configID != "" {
+		query = query.Where("oss_files.config_id = ?", configID)
+	}
+	if uploaderID := params.Get("uploader_id"); uploaderID != "" {
+		query = query.Where("oss_files.uploader_id = ?", uploaderID)
+	}
+	if md5Status := params.Get("md5_status"); md5Status != "" {
+		query = query.Where("oss_files.md5_status = ?", strings.ToUpper(md5Status))
+	}
+
+	for param, cond := range map[string]string{
+		"min_size": "oss_files.file_size >= ?",
+		"max_size": "oss_files.file_size <= ?",
+	} {
+		if raw := params.Get(param); raw != "" {
+			size, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || size < 0 {
+				h.Error(c, utils.CodeInvalidParams, "无效的文件大小参数: "+param)
+				return nil, false
+			}
+			query = query.Where(cond, size)
+		}
+	}
+	for param, cond := range map[string]string{
+		"start_time": "oss_files.created_at >= ?",
+		"end_time":   "oss_files.created_at <= ?",
+	} {
+		if raw := params.Get(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				h.Error(c, utils.CodeInvalidParams, "时间参数需为 RFC3339 格式: "+param)
+				return nil, false
+			}
+			query = query.Where(cond, t)
+		}
+	}
+
+	query = applyTagFilters(query, params["tag"])
+
+	// ACTIVE 记录每个路径唯一，可直接走索引；查询其他状态时按路径去重，只保留最新记录
+	status := strings.ToUpper(params.Get("status"))
+	if status == "" {
+		status = "ACTIVE"
+	}
+	switch status {
+	case "ACTIVE":
+		return query.Where("oss_files.status = ?", status), true
+	case "REPLACED", "TRASHED":
+		query = query.Where("oss_files.status = ?", status)
+	case "ALL":
+	default:
+		h.Error(c, utils.CodeInvalidParams, "无效的状态参数")
+		return nil, false
+	}
+
+	// 使用窗口函数而不是 PostgreSQL 特有的 DISTINCT ON，SQLite 同样支持
+	latest := query.Select("oss_files.*, ROW_NUMBER() OVER (PARTITION BY oss_files.bucket, oss_files.object_key " +
+		"ORDER BY oss_files.created_at DESC, oss_files.id DESC) AS version_rank")
+	return h.DB.WithContext(c).Table("(?) AS oss_files", latest).Model(&models.OSSFile{}).Where("oss_files.version_rank = 1"), true
+}
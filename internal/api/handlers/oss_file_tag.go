@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 文件标签限制，与对象存储的对象标签限制保持一致
+const (
+	tagHeader      = "X-File-Tags" // 上传时设置标签的请求头，格式为 project=foo&release=1.2
+	maxFileTags    = 10
+	maxTagKeyLen   = 128
+	maxTagValueLen = 256
+)
+
+// parseTagHeader 解析 URL 查询串格式的标签请求头，未提供时返回 nil
+func parseTagHeader(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, fmt.Errorf("标签格式错误: %w", err)
+	}
+	tags := make(map[string]string, len(values))
+	for key, vals := range values {
+		tags[key] = vals[len(vals)-1]
+	}
+	return tags, validateTags(tags)
+}
+
+// validateTags 校验标签数量和键值长度
+func validateTags(tags map[string]string) error {
+	if len(tags) > maxFileTags {
+		return fmt.Errorf("每个文件最多 %d 个标签", maxFileTags)
+	}
+	for key, value := range tags {
+		if key == "" || utf8.RuneCountInString(key) > maxTagKeyLen {
+			return fmt.Errorf("标签键不能为空且不超过 %d 个字符", maxTagKeyLen)
+		}
+		if utf8.RuneCountInString(value) > maxTagValueLen {
+			return fmt.Errorf("标签值不超过 %d 个字符", maxTagValueLen)
+		}
+	}
+	return nil
+}
+
+// replaceFileTags 在事务中用给定标签替换文件的全部标签
+func replaceFileTags(tx *gorm.DB, fileID uint, tags map[string]string) error {
+	if err := tx.Unscoped().Where("file_id = ?", fileID).Delete(&models.FileTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([]models.FileTag, 0, len(tags))
+	for _, key := range keys {
+		rows = append(rows, models.FileTag{FileID: fileID, Key: key, Value: tags[key]})
+	}
+	return tx.Create(&rows).Error
+}
+
+// syncObjectTags 将标签同步到对象存储的对象标签，失败只记录日志
+// 在请求结束后异步执行时 ctx 应传入 c.Copy()
+func (h *OSSFileHandler) syncObjectTags(ctx context.Context, file *models.OSSFile, tags map[string]string) {
+	regionCode, err := h.getRegionByBucket(ctx, file.Bucket)
+	if err != nil {
+		logger.Warn("同步对象标签失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		return
+	}
+
+	var config models.OSSConfig
+	if err := h.DB.WithContext(ctx).First(&config, file.ConfigID).Error; err != nil {
+		logger.Warn("同步对象标签失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		return
+	}
+
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
+	if err != nil {
+		logger.Warn("同步对象标签失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		return
+	}
+
+	if err := storage.PutObjectTaggingToBucket(file.ObjectKey, regionCode, file.Bucket, tags); err != nil {
+		logger.Warn("同步对象标签失败",
+			zap.Uint("file_id", file.ID),
+			zap.String("object_key", file.ObjectKey),
+			zap.Error(err))
+	}
+}
+
+// applyUploadTags 保存上传请求头中携带的标签，并同步到对象存储
+func (h *OSSFileHandler) applyUploadTags(c *gin.Context, file *models.OSSFile) {
+	tags, err := parseTagHeader(c.GetHeader(tagHeader))
+	if err != nil || len(tags) == 0 {
+		return
+	}
+	if err := replaceFileTags(h.DB.WithContext(c), file.ID, tags); err != nil {
+		logger.Warn("保存上传标签失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		return
+	}
+	h.DB.WithContext(c).Where("file_id = ?", file.ID).Order("file_tags.key").Find(&file.Tags)
+	go h.syncObjectTags(c.Copy(), file, tags)
+}
+
+// applyTagFilters 按标签条件过滤文件，多个条件同时满足
+// 条件格式为 key=value（精确匹配）或 key（存在该标签即可）
+func applyTagFilters(query *gorm.DB, filters []string) *gorm.DB {
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, "=")
+		if key == "" {
+			continue
+		}
+		if hasValue {
+			query = query.Where("EXISTS (SELECT 1 FROM file_tags WHERE file_tags.file_id = oss_files.id AND file_tags.key = ? AND file_tags.value = ? AND file_tags.deleted_at IS NULL)", key, value)
+		} else {
+			query = query.Where("EXISTS (SELECT 1 FROM file_tags WHERE file_tags.file_id = oss_files.id AND file_tags.key = ? AND file_tags.deleted_at IS NULL)", key)
+		}
+	}
+	return query
+}
+
+// getTaggableFile 获取文件并检查访问权限，失败时已写入响应
+func (h *OSSFileHandler) getTaggableFile(c *gin.Context) (*models.OSSFile, bool) {
+	var file models.OSSFile
+	if err := h.DB.WithContext(c).Where("status = ?", "ACTIVE").First(&file, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeFileNotFound, "文件不存在")
+		return nil, false
+	}
+
+	regionCode, err := h.getRegionByBucket(c, file.Bucket)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储桶区域信息失败")
+		return nil, false
+	}
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), regionCode, file.Bucket) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return nil, false
+	}
+	return &file, true
+}
+
+// GetTags 获取文件标签
+func (h *OSSFileHandler) GetTags(c *gin.Context) {
+	file, ok := h.getTaggableFile(c)
+	if !ok {
+		return
+	}
+
+	var tags []models.FileTag
+	if err := h.DB.WithContext(c).Where("file_id = ?", file.ID).Order("file_tags.key").Find(&tags).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取文件标签失败")
+		return
+	}
+
+	h.Success(c, tags)
+}
+
+// UpdateTags 替换文件的全部标签，传入空对象表示清除标签
+func (h *OSSFileHandler) UpdateTags(c *gin.Context) {
+	var req struct {
+		Tags map[string]string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+	if err := validateTags(req.Tags); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
+	file, ok := h.getTaggableFile(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		return replaceFileTags(tx, file.ID, req.Tags)
+	}); err != nil {
+		logger.Error("更新文件标签失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "更新文件标签失败")
+		return
+	}
+	go h.syncObjectTags(c.Copy(), file, req.Tags)
+
+	var tags []models.FileTag
+	h.DB.WithContext(c).Where("file_id = ?", file.ID).Order("file_tags.key").Find(&tags)
+	h.Success(c, tags)
+}
+
+// DeleteTag 删除文件的单个标签
+func (h *OSSFileHandler) DeleteTag(c *gin.Context) {
+	file, ok := h.getTaggableFile(c)
+	if !ok {
+		return
+	}
+
+	result := h.DB.WithContext(c).Unscoped().Where("file_id = ? AND file_tags.key = ?", file.ID, c.Param("key")).Delete(&models.FileTag{})
+	if result.Error != nil {
+		h.Error(c, utils.CodeServerError, "删除文件标签失败")
+		return
+	}
+	if result.RowsAffected == 0 {
+		h.Error(c, utils.CodeNotFound, "标签不存在")
+		return
+	}
+
+	// 以数据库中剩余的标签为准同步到对象存储
+	var remaining []models.FileTag
+	if err := h.DB.WithContext(c).Where("file_id = ?", file.ID).Find(&remaining).Error; err != nil {
+		logger.Warn("获取剩余标签失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		h.Success(c, nil)
+		return
+	}
+	tags := make(map[string]string, len(remaining))
+	for _, tag := range remaining {
+		tags[tag.Key] = tag.Value
+	}
+	go h.syncObjectTags(c.Copy(), file, tags)
+
+	h.Success(c, nil)
+}
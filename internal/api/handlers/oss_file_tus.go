@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/policy"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/tus"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"go.uber.org/zap"
+)
+
+// tusError 按 tus 协议以 HTTP 状态码返回错误
+func tusError(c *gin.Context, status int, msg string) {
+	tus.WriteError(c, &tus.Error{Status: status, Message: msg})
+}
+
+// TusOptions 返回服务端支持的协议版本和扩展
+func (h *OSSFileHandler) TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tus.Version)
+	c.Header("Tus-Version", tus.Version)
+	c.Header("Tus-Extension", tus.Extensions)
+	c.Header("Tus-Checksum-Algorithm", tus.ChecksumAlgorithms)
+	c.Status(http.StatusNoContent)
+}
+
+// TusCreate 创建上传（creation 扩展），在存储端初始化分片上传并登记上传会话
+// Upload-Metadata 支持 filename（或 name）、bucket_name、region_code、path 和 overwrite
+func (h *OSSFileHandler) TusCreate(c *gin.Context) {
+	if !tus.Require(c) {
+		return
+	}
+	if h.sessionManager == nil {
+		tusError(c, http.StatusServiceUnavailable, "上传会话未启用")
+		return
+	}
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		tusError(c, http.StatusBadRequest, "不支持延迟指定上传长度")
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		tusError(c, http.StatusBadRequest, "请提供有效的 Upload-Length")
+		return
+	}
+	meta, err := tus.ParseMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := meta["filename"]
+	if filename == "" {
+		filename = meta["name"]
+	}
+	bucketName := meta["bucket_name"]
+	if filename == "" || bucketName == "" {
+		tusError(c, http.StatusBadRequest, "请在 Upload-Metadata 中提供 filename 和 bucket_name")
+		return
+	}
+	regionCode := meta["region_code"]
+	if regionCode == "" {
+		if regionCode, err = h.getRegionByBucket(c, bucketName); err != nil {
+			tusError(c, http.StatusBadRequest, "存储桶不存在")
+			return
+		}
+	}
+
+	userID := c.GetUint("userID")
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, regionCode, bucketName) {
+		tusError(c, http.StatusForbidden, "没有权限访问该存储桶")
+		return
+	}
+
+	// 获取存储配置
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
+		tusError(c, http.StatusInternalServerError, "获取默认存储配置失败")
+		return
+	}
+
+	// 传输前检查存储配额
+	if h.quotaManager != nil {
+		if err := h.quotaManager.Check(userID, bucketName, length); err != nil {
+			if errors.Is(err, quota.ErrQuotaExceeded) {
+				tusError(c, http.StatusRequestEntityTooLarge, err.Error())
+				return
+			}
+			logger.Error("检查存储配额失败", zap.Uint("user_id", userID), zap.String("bucket", bucketName), zap.Error(err))
+			tusError(c, http.StatusInternalServerError, "检查存储配额失败")
+			return
+		}
+	}
+
+	objectKey, err := resolveObjectKey(c.GetString("username"), meta["path"], filename)
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 检查存储桶上传策略，文件类型在收到文件头后校验
+	uploadPolicy, err := policy.Load(h.DB.WithContext(c), bucketName)
+	if err != nil {
+		logger.Error("获取存储桶上传策略失败", zap.String("bucket", bucketName), zap.Error(err))
+		tusError(c, http.StatusInternalServerError, "获取存储桶上传策略失败")
+		return
+	}
+	if err := policy.CheckFile(uploadPolicy, objectKey, length); err != nil {
+		h.tusPolicyError(c, err)
+		return
+	}
+
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, "获取存储服务失败")
+		return
+	}
+
+	// 非覆盖上传时检查同路径文件，覆盖时在写入对象前保留当前版本
+	if meta["overwrite"] != "true" {
+		var count int64
+		if err := h.DB.WithContext(c).Model(&models.OSSFile{}).Where("object_key = ? AND bucket = ? AND status = ?",
+			objectKey, bucketName, "ACTIVE").Count(&count).Error; err != nil {
+			tusError(c, http.StatusInternalServerError, "检查文件是否存在失败")
+			return
+		}
+		if count > 0 {
+			tusError(c, http.StatusConflict, "在相同路径下文件已存在，请确认是否要覆盖")
+			return
+		}
+	}
+
+	session := &models.UploadSession{
+		Mode:             models.UploadSessionModeTus,
+		StorageType:      config.StorageType,
+		RegionCode:       regionCode,
+		BucketName:       bucketName,
+		ObjectKey:        objectKey,
+		OriginalFilename: filename,
+		FileSize:         length,
+	}
+
+	if length == 0 {
+		// 空文件无需分片，直接写入存储端并完成
+		pending, err := h.preserveCurrentVersion(c, storage, objectKey, regionCode, bucketName)
+		if err != nil {
+			logger.Error("保留历史版本失败", zap.String("object_key", objectKey), zap.Error(err))
+			tusError(c, http.StatusInternalServerError, "保留历史版本失败")
+			return
+		}
+		uploadURL, err := storage.UploadToBucket(bytes.NewReader(nil), objectKey, regionCode, bucketName)
+		if err != nil {
+			pending.discard()
+			tusError(c, http.StatusInternalServerError, "上传文件失败")
+			return
+		}
+		if session = h.createUploadSession(c, session); session == nil {
+			tusError(c, http.StatusInternalServerError, "创建上传会话失败")
+			return
+		}
+		if _, err := h.createFileRecord(c, config, objectKey, filename, 0, bucketName, uploadURL, userID); err != nil {
+			tusError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.sessionManager.Complete(session)
+	} else {
+		uploadID, _, err := storage.InitMultipartUploadToBucket(objectKey, regionCode, bucketName)
+		if err != nil {
+			tusError(c, http.StatusInternalServerError, "初始化分片上传失败")
+			return
+		}
+		session.UploadID = uploadID
+		session.ChunkSize = h.sessionManager.PartSize(length)
+		session.TotalParts = int((length + session.ChunkSize - 1) / session.ChunkSize)
+		if session = h.createUploadSession(c, session); session == nil {
+			h.safeAbortMultipartUpload(storage, uploadID, objectKey, regionCode, bucketName)
+			tusError(c, http.StatusInternalServerError, "创建上传会话失败")
+			return
+		}
+		upload.DefaultManager.StartWithChunks(session.SessionID, length, true, session.TotalParts)
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+session.SessionID)
+	c.Status(http.StatusCreated)
+}
+
+// TusHead 查询上传偏移量
+func (h *OSSFileHandler) TusHead(c *gin.Context) {
+	if !tus.Require(c) {
+		return
+	}
+	session, ok := h.loadTusSession(c)
+	if !ok {
+		return
+	}
+	offset, err := tus.Offset(h.sessionManager, session)
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, "读取上传进度失败")
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.FileSize, 10))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// TusPatch 从指定偏移量追加数据，凑满分片后写入存储端，全部接收后合并并保存文件记录
+func (h *OSSFileHandler) TusPatch(c *gin.Context) {
+	if !tus.Require(c) {
+		return
+	}
+	if c.ContentType() != tus.ContentType {
+		tusError(c, http.StatusUnsupportedMediaType, "Content-Type 必须为 "+tus.ContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		tusError(c, http.StatusBadRequest, "请提供有效的 Upload-Offset")
+		return
+	}
+	var checksum *tus.Checksum
+	if header := c.GetHeader("Upload-Checksum"); header != "" {
+		if checksum, err = tus.ParseChecksum(header); err != nil {
+			tusError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// 同一上传的写入必须串行，否则偏移量会错乱
+	unlock := h.sessionManager.Lock(c.Param("id"))
+	defer unlock()
+
+	session, ok := h.loadTusSession(c)
+	if !ok {
+		return
+	}
+	current, err := tus.CheckOffset(h.sessionManager, session, offset)
+	if errors.Is(err, tus.ErrOffsetConflict) {
+		tus.WriteError(c, tus.ErrOffsetConflict)
+		return
+	}
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, "读取上传进度失败")
+		return
+	}
+	if session.Status == models.UploadSessionCompleted {
+		c.Header("Upload-Offset", strconv.FormatInt(current, 10))
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	storage, err := h.storageFactory.GetStorageService(session.StorageType)
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, "获取存储服务失败")
+		return
+	}
+
+	// 追加数据到暂存文件，携带校验值时先完整接收再校验
+	written, readErr := tus.Append(h.sessionManager, session, offset, c.Request.Body, checksum)
+	if readErr != nil && !errors.Is(readErr, tus.ErrIncompleteBody) {
+		var tusErr *tus.Error
+		if errors.As(readErr, &tusErr) {
+			tus.WriteError(c, tusErr)
+			return
+		}
+		logger.Error("保存 tus 上传数据失败", zap.String("session_id", session.SessionID), zap.Error(readErr))
+		tusError(c, http.StatusInternalServerError, "保存上传数据失败")
+		return
+	}
+
+	if _, ok := upload.DefaultManager.Get(session.SessionID); !ok {
+		// 服务重启后恢复进度追踪
+		upload.DefaultManager.StartWithChunks(session.SessionID, session.FileSize, true, session.TotalParts)
+	}
+	upload.DefaultManager.Update(session.SessionID, offset+written)
+
+	// 首次收到数据时按文件头校验文件类型
+	if offset == 0 && written > 0 && !h.checkTusContent(c, session) {
+		return
+	}
+
+	if err := tus.Flush(h.sessionManager, session, func(partNumber int, data []byte) error {
+		partURL, err := storage.GeneratePartUploadURL(session.ObjectKey, session.UploadID, partNumber, session.RegionCode, session.BucketName)
+		if err != nil {
+			return fmt.Errorf("获取分片 %d 上传URL失败: %w", partNumber, err)
+		}
+		if _, err := h.uploadChunk(storage, partURL, data, partNumber); err != nil {
+			return fmt.Errorf("上传分片 %d 失败: %w", partNumber, err)
+		}
+		return nil
+	}); err != nil {
+		logger.Error("写入 tus 上传分片失败", zap.String("session_id", session.SessionID), zap.Error(err))
+		h.sessionManager.Fail(session, err.Error())
+		tusError(c, http.StatusInternalServerError, "写入存储端分片失败")
+		return
+	}
+
+	if session.UploadedBytes == session.FileSize {
+		if err := h.completeTusUpload(c, storage, session); err != nil {
+			logger.Error("完成 tus 上传失败", zap.String("session_id", session.SessionID), zap.Error(err))
+			h.sessionManager.Fail(session, err.Error())
+			tusError(c, http.StatusInternalServerError, "完成分片上传失败")
+			return
+		}
+	}
+
+	if readErr != nil {
+		// 已接收的数据已保存，客户端可查询偏移量后续传
+		tus.WriteError(c, tus.ErrIncompleteBody)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(offset+written, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// TusTerminate 终止上传（termination 扩展），在存储端中止分片上传
+func (h *OSSFileHandler) TusTerminate(c *gin.Context) {
+	if !tus.Require(c) {
+		return
+	}
+	unlock := h.sessionManager.Lock(c.Param("id"))
+	defer unlock()
+
+	session, ok := h.loadTusSession(c)
+	if !ok {
+		return
+	}
+	if err := tus.Terminate(h.sessionManager, session); err != nil {
+		logger.Error("终止 tus 上传失败", zap.String("session_id", session.SessionID), zap.Error(err))
+		tusError(c, http.StatusInternalServerError, "终止上传失败")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadTusSession 获取当前用户的 tus 上传会话，已完成的会话也会返回，失败时已写入响应
+func (h *OSSFileHandler) loadTusSession(c *gin.Context) (*models.UploadSession, bool) {
+	if h.sessionManager == nil {
+		tusError(c, http.StatusServiceUnavailable, "上传会话未启用")
+		return nil, false
+	}
+	session, err := h.sessionManager.Get(c.Param("id"), c.GetUint("userID"))
+	switch {
+	case errors.Is(err, upload.ErrSessionNotFound):
+		tusError(c, http.StatusNotFound, "上传不存在")
+		return nil, false
+	case errors.Is(err, upload.ErrSessionClosed):
+		if session.Status != models.UploadSessionCompleted {
+			tusError(c, http.StatusGone, "上传已终止或已过期")
+			return nil, false
+		}
+	case err != nil:
+		tusError(c, http.StatusInternalServerError, "获取上传会话失败")
+		return nil, false
+	}
+	if session.Mode != models.UploadSessionModeTus {
+		tusError(c, http.StatusNotFound, "上传不存在")
+		return nil, false
+	}
+	return session, true
+}
+
+// checkTusContent 按文件头校验文件类型，不符合时终止上传，失败时已写入响应
+func (h *OSSFileHandler) checkTusContent(c *gin.Context, session *models.UploadSession) bool {
+	uploadPolicy, err := policy.Load(h.DB.WithContext(c), session.BucketName)
+	if err != nil {
+		logger.Error("获取存储桶上传策略失败", zap.String("bucket", session.BucketName), zap.Error(err))
+		tusError(c, http.StatusInternalServerError, "获取存储桶上传策略失败")
+		return false
+	}
+	if !policy.NeedsContent(uploadPolicy) {
+		return true
+	}
+
+	f, err := os.Open(h.sessionManager.SpoolPath(session.SessionID))
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, "读取上传数据失败")
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, policy.SniffLen)
+	n, _ := io.ReadFull(f, head)
+
+	if err := policy.CheckContent(uploadPolicy, head[:n]); err != nil {
+		if abortErr := h.sessionManager.Abort(session, err.Error()); abortErr != nil {
+			logger.Warn("终止 tus 上传失败", zap.String("session_id", session.SessionID), zap.Error(abortErr))
+		}
+		upload.DefaultManager.Fail(session.SessionID, err.Error())
+		h.tusPolicyError(c, err)
+		return false
+	}
+	return true
+}
+
+// tusPolicyError 返回上传策略检查错误
+func (h *OSSFileHandler) tusPolicyError(c *gin.Context, err error) {
+	if errors.Is(err, policy.ErrViolation) {
+		tusError(c, http.StatusForbidden, err.Error())
+		return
+	}
+	logger.Error("检查存储桶上传策略失败", zap.Error(err))
+	tusError(c, http.StatusInternalServerError, "检查存储桶上传策略失败")
+}
+
+// completeTusUpload 合并存储端分片并保存文件记录
+func (h *OSSFileHandler) completeTusUpload(c *gin.Context, storage oss.StorageService, session *models.UploadSession) error {
+	uploaded, err := storage.ListUploadedPartsToBucket(session.ObjectKey, session.UploadID, session.RegionCode, session.BucketName)
+	if err != nil {
+		return err
+	}
+	// 只合并会话记录的分片，忽略中断后残留的多余分片
+	parts := make([]oss.Part, 0, session.PartsDone)
+	for _, p := range uploaded {
+		if p.PartNumber <= session.PartsDone {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) != session.PartsDone {
+		return fmt.Errorf("存储端分片数量与会话记录不一致: %d/%d", len(parts), session.PartsDone)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
+		return fmt.Errorf("获取默认存储配置失败: %w", err)
+	}
+
+	// 合并后会覆盖同路径对象，先保留当前版本
+	pending, err := h.preserveCurrentVersion(c, storage, session.ObjectKey, session.RegionCode, session.BucketName)
+	if err != nil {
+		return err
+	}
+	uploadURL, err := storage.CompleteMultipartUploadToBucket(session.ObjectKey, session.UploadID, parts, session.RegionCode, session.BucketName)
+	if err != nil {
+		pending.discard()
+		return err
+	}
+	if _, err := h.createFileRecord(c, config, session.ObjectKey, session.OriginalFilename, session.FileSize, session.BucketName, uploadURL, session.OwnerID); err != nil {
+		return err
+	}
+
+	h.sessionManager.Complete(session)
+	session.Status = models.UploadSessionCompleted
+	upload.DefaultManager.Finish(session.SessionID)
+	return nil
+}
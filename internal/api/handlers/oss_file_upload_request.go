@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// uploadRequestChunkThreshold 上传链接流式上传切换为分片上传的阈值
+const uploadRequestChunkThreshold = int64(100 * 1024 * 1024)
+
+// CreateUploadRequestRequest 创建上传请求链接请求
+type CreateUploadRequestRequest struct {
+	RegionCode        string   `json:"region_code" binding:"required"`
+	BucketName        string   `json:"bucket_name" binding:"required"`
+	Prefix            string   `json:"prefix"`
+	Description       string   `json:"description"`
+	ExpireHours       int      `json:"expire_hours" binding:"required,min=1,max=720"`
+	MaxFileSize       int64    `json:"max_file_size" binding:"min=0"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	MaxFiles          int      `json:"max_files" binding:"min=0"`
+}
+
+// CreateUploadRequest 创建上传请求链接
+func (h *OSSFileHandler) CreateUploadRequest(c *gin.Context) {
+	var req CreateUploadRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	userID := c.GetUint("userID")
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, req.RegionCode, req.BucketName) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return
+	}
+
+	prefix := strings.Trim(req.Prefix, "/")
+	if strings.Contains(prefix, "..") || strings.ContainsAny(prefix, "\\<>:\"|?*") {
+		h.Error(c, utils.CodeInvalidParams, "目标路径包含非法字符")
+		return
+	}
+
+	extensions := make([]string, 0, len(req.AllowedExtensions))
+	for _, ext := range req.AllowedExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions = append(extensions, ext)
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "生成上传令牌失败")
+		return
+	}
+
+	uploadRequest := models.UploadRequest{
+		Token:             token,
+		CreatorID:         userID,
+		RegionCode:        req.RegionCode,
+		BucketName:        req.BucketName,
+		Prefix:            prefix,
+		Description:       req.Description,
+		ExpiresAt:         time.Now().Add(time.Duration(req.ExpireHours) * time.Hour),
+		MaxFileSize:       req.MaxFileSize,
+		AllowedExtensions: strings.Join(extensions, ","),
+		MaxFiles:          req.MaxFiles,
+	}
+	if err := h.DB.WithContext(c).Create(&uploadRequest).Error; err != nil {
+		logger.Error("创建上传链接失败", zap.Error(err))
+		h.Error(c, utils.CodeServerError, "创建上传链接失败")
+		return
+	}
+
+	h.Success(c, uploadRequest)
+}
+
+// ListUploadRequests 获取当前用户创建的上传请求链接
+func (h *OSSFileHandler) ListUploadRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.DB.WithContext(c).Model(&models.UploadRequest{}).Where("creator_id = ?", c.GetUint("userID"))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取上传链接总数失败")
+		return
+	}
+
+	var items []models.UploadRequest
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&items).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取上传链接列表失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": items,
+	})
+}
+
+// RevokeUploadRequest 撤销上传请求链接，仅创建者可操作
+func (h *OSSFileHandler) RevokeUploadRequest(c *gin.Context) {
+	var uploadRequest models.UploadRequest
+	if err := h.DB.WithContext(c).Where("creator_id = ?", c.GetUint("userID")).First(&uploadRequest, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeNotFound, "上传链接不存在")
+		return
+	}
+
+	if uploadRequest.RevokedAt == nil {
+		now := time.Now()
+		if err := h.DB.WithContext(c).Model(&uploadRequest).Update("revoked_at", &now).Error; err != nil {
+			h.Error(c, utils.CodeServerError, "撤销上传链接失败")
+			return
+		}
+	}
+
+	h.Success(c, nil)
+}
+
+// GetUploadRequestInfo 获取上传链接信息（公开访问）
+func (h *OSSFileHandler) GetUploadRequestInfo(c *gin.Context) {
+	uploadRequest, ok := h.getActiveUploadRequest(c)
+	if !ok {
+		return
+	}
+
+	remaining := -1
+	if uploadRequest.MaxFiles > 0 {
+		remaining = uploadRequest.MaxFiles - uploadRequest.UploadCount
+	}
+
+	h.Success(c, gin.H{
+		"description":        uploadRequest.Description,
+		"expires_at":         uploadRequest.ExpiresAt,
+		"max_file_size":      uploadRequest.MaxFileSize,
+		"allowed_extensions": uploadRequest.AllowedExtensions,
+		"remaining_files":    remaining,
+	})
+}
+
+// UploadRequestStream 通过上传链接流式上传文件（公开访问）
+func (h *OSSFileHandler) UploadRequestStream(c *gin.Context) {
+	uploadRequest, ok := h.getActiveUploadRequest(c)
+	if !ok {
+		return
+	}
+
+	// 不支持断点续传参数，避免外部用户指定任意对象键
+	if c.GetHeader("X-Upload-Id") != "" || c.Query("upload_id") != "" ||
+		c.GetHeader(uploadSessionHeader) != "" || c.Query("session_id") != "" {
+		h.Error(c, utils.CodeInvalidParams, "上传链接不支持断点续传参数")
+		return
+	}
+
+	filename := c.GetHeader("X-File-Name")
+	contentLength, err := strconv.ParseInt(c.GetHeader("Content-Length"), 10, 64)
+	if err != nil || contentLength <= 0 {
+		h.Error(c, utils.CodeInvalidParams, "请提供有效的文件大小（Content-Length header）")
+		return
+	}
+	if err := validateUploadRequestFile(uploadRequest, filename, contentLength); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
+	config, storage, ok := h.uploadRequestStorage(c)
+	if !ok {
+		return
+	}
+
+	objectKey := uploadRequest.ObjectKey(filename)
+	uploadPolicy, ok := h.checkUploadPolicy(c, uploadRequest.BucketName, objectKey, contentLength)
+	if !ok {
+		return
+	}
+	if !h.checkUploadRequestTarget(c, uploadRequest, objectKey) {
+		return
+	}
+	if !h.checkQuota(c, uploadRequest.CreatorID, uploadRequest.BucketName, contentLength) {
+		return
+	}
+	if !h.reserveUploadSlot(c, uploadRequest) {
+		return
+	}
+
+	taskID := c.GetHeader("Upload-Task-ID")
+	if taskID == "" {
+		taskID = uuid.NewString()
+	}
+
+	// 限制读取长度不超过声明的 Content-Length，并按文件头校验文件类型
+	body, ok := h.checkUploadStream(c, uploadPolicy, http.MaxBytesReader(c.Writer, c.Request.Body, contentLength))
+	if !ok {
+		h.releaseUploadSlot(c, uploadRequest)
+		return
+	}
+
+	var uploadURL string
+	if contentLength <= uploadRequestChunkThreshold {
+		upload.DefaultManager.Start(taskID, contentLength)
+		uploadURL, err = storage.UploadToBucketWithProgress(body, objectKey, uploadRequest.RegionCode, uploadRequest.BucketName, func(consumed, total int64) {
+			upload.DefaultManager.Update(taskID, consumed)
+		})
+	} else {
+		uploadURL, err = h.uploadFileWithChunks(c, storage, body, objectKey, uploadRequest.RegionCode, uploadRequest.BucketName, contentLength, taskID, filename)
+	}
+	upload.DefaultManager.Finish(taskID)
+	if err != nil {
+		h.releaseUploadSlot(c, uploadRequest)
+		logger.Error("上传链接上传文件失败",
+			zap.Uint("upload_request_id", uploadRequest.ID),
+			zap.String("object_key", objectKey),
+			zap.Error(err))
+		h.Error(c, utils.CodeServerError, "上传文件失败")
+		return
+	}
+
+	h.saveFileRecord(c, config, objectKey, filename, contentLength, uploadRequest.BucketName, uploadURL)
+}
+
+// UploadRequestInitMultipart 通过上传链接初始化分片上传（公开访问）
+func (h *OSSFileHandler) UploadRequestInitMultipart(c *gin.Context) {
+	var req struct {
+		FileName string `json:"file_name" binding:"required"`
+		FileSize int64  `json:"file_size" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+
+	uploadRequest, ok := h.getActiveUploadRequest(c)
+	if !ok {
+		return
+	}
+	if err := validateUploadRequestFile(uploadRequest, req.FileName, req.FileSize); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+
+	_, storage, ok := h.uploadRequestStorage(c)
+	if !ok {
+		return
+	}
+
+	objectKey := uploadRequest.ObjectKey(req.FileName)
+	if _, ok := h.checkUploadPolicy(c, uploadRequest.BucketName, objectKey, req.FileSize); !ok {
+		return
+	}
+	if !h.checkUploadRequestTarget(c, uploadRequest, objectKey) {
+		return
+	}
+	if !h.checkQuota(c, uploadRequest.CreatorID, uploadRequest.BucketName, req.FileSize) {
+		return
+	}
+
+	uploadID, urls, err := storage.InitMultipartUploadToBucket(objectKey, uploadRequest.RegionCode, uploadRequest.BucketName)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "初始化分片上传失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"upload_id":  uploadID,
+		"object_key": objectKey,
+		"urls":       urls,
+	})
+}
+
+// UploadRequestCompleteMultipart 通过上传链接完成分片上传（公开访问）
+func (h *OSSFileHandler) UploadRequestCompleteMultipart(c *gin.Context) {
+	var req struct {
+		FileName string   `json:"file_name" binding:"required"`
+		UploadID string   `json:"upload_id" binding:"required"`
+		Parts    []string `json:"parts" binding:"required"`
+		TaskID   string   `json:"task_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+
+	uploadRequest, ok := h.getActiveUploadRequest(c)
+	if !ok {
+		return
+	}
+
+	config, storage, ok := h.uploadRequestStorage(c)
+	if !ok {
+		return
+	}
+
+	// 对象键由链接和文件名决定，不接受客户端传入
+	objectKey := uploadRequest.ObjectKey(req.FileName)
+
+	// 文件大小以存储端已上传的分片为准，不使用客户端声明的大小
+	ossParts, fileSize, err := multipartParts(storage, objectKey, req.UploadID, uploadRequest.RegionCode, uploadRequest.BucketName, req.Parts)
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+	if err := validateUploadRequestFile(uploadRequest, req.FileName, fileSize); err != nil {
+		h.Error(c, utils.CodeInvalidParams, err.Error())
+		return
+	}
+	if _, ok := h.checkUploadPolicy(c, uploadRequest.BucketName, objectKey, fileSize); !ok {
+		return
+	}
+	if !h.checkUploadRequestTarget(c, uploadRequest, objectKey) {
+		return
+	}
+	if !h.checkQuota(c, uploadRequest.CreatorID, uploadRequest.BucketName, fileSize) {
+		return
+	}
+	if !h.reserveUploadSlot(c, uploadRequest) {
+		return
+	}
+
+	url, err := storage.CompleteMultipartUploadToBucket(objectKey, req.UploadID, ossParts, uploadRequest.RegionCode, uploadRequest.BucketName)
+	if err != nil {
+		h.releaseUploadSlot(c, uploadRequest)
+		if req.TaskID != "" {
+			upload.DefaultManager.Fail(req.TaskID, "完成分片上传失败")
+		}
+		h.Error(c, utils.CodeServerError, "完成分片上传失败")
+		return
+	}
+
+	h.saveFileRecordForMultipart(c, config, objectKey, req.FileName, fileSize, uploadRequest.BucketName, url)
+
+	if req.TaskID != "" {
+		upload.DefaultManager.Finish(req.TaskID)
+	}
+}
+
+// getActiveUploadRequest 根据令牌获取可用的上传链接，并将创建者设置为当前上传者
+// 失败时已写入响应
+func (h *OSSFileHandler) getActiveUploadRequest(c *gin.Context) (*models.UploadRequest, bool) {
+	var uploadRequest models.UploadRequest
+	if err := h.DB.WithContext(c).Where("token = ?", c.Param("token")).First(&uploadRequest).Error; err != nil {
+		h.Error(c, utils.CodeUploadRequestInvalid, "上传链接不存在或已失效")
+		return nil, false
+	}
+	if !uploadRequest.Available(time.Now()) {
+		h.Error(c, utils.CodeUploadRequestInvalid, "上传链接不存在或已失效")
+		return nil, false
+	}
+
+	// 创建者失去存储桶权限后链接随之失效
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), uploadRequest.CreatorID, uploadRequest.RegionCode, uploadRequest.BucketName) {
+		h.Error(c, utils.CodeUploadRequestInvalid, "上传链接不存在或已失效")
+		return nil, false
+	}
+
+	// 外部上传者按链接创建者所在的组织访问数据，组织禁用后链接随之失效
+	scope, err := auth.LoadScope(uploadRequest.CreatorID, 0)
+	if err != nil {
+		h.Error(c, utils.CodeUploadRequestInvalid, "上传链接不存在或已失效")
+		return nil, false
+	}
+	c.Set(tenant.ContextKey, &tenant.Scope{OrgID: scope.OrgID, UserOrgID: scope.UserOrgID})
+
+	// 文件记录归属于链接创建者（saveFileRecord 通过 userID 读取上传者）
+	c.Set("userID", uploadRequest.CreatorID)
+	return &uploadRequest, true
+}
+
+// uploadRequestStorage 获取默认存储配置和存储服务，失败时已写入响应
+func (h *OSSFileHandler) uploadRequestStorage(c *gin.Context) (models.OSSConfig, oss.StorageService, bool) {
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取默认存储配置失败")
+		return config, nil, false
+	}
+
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储服务失败")
+		return config, nil, false
+	}
+	return config, storage, true
+}
+
+// checkUploadRequestTarget 外部上传不允许覆盖已有文件，失败时已写入响应
+func (h *OSSFileHandler) checkUploadRequestTarget(c *gin.Context, uploadRequest *models.UploadRequest, objectKey string) bool {
+	var existingFile models.OSSFile
+	err := h.DB.WithContext(c).Where("object_key = ? AND bucket = ? AND status = ?",
+		objectKey, uploadRequest.BucketName, "ACTIVE").First(&existingFile).Error
+	if err == nil {
+		h.Error(c, utils.CodeFileExists, "同名文件已存在，请重命名后再上传")
+		return false
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		h.Error(c, utils.CodeServerError, "检查文件是否存在失败")
+		return false
+	}
+	return true
+}
+
+// reserveUploadSlot 原子占用一个上传名额，防止并发上传超出文件数上限，失败时已写入响应
+func (h *OSSFileHandler) reserveUploadSlot(c *gin.Context, uploadRequest *models.UploadRequest) bool {
+	result := h.DB.WithContext(c).Model(&models.UploadRequest{}).
+		Where("id = ? AND (max_files = 0 OR upload_count < max_files)", uploadRequest.ID).
+		Update("upload_count", gorm.Expr("upload_count + 1"))
+	if result.Error != nil {
+		h.Error(c, utils.CodeServerError, "更新上传次数失败")
+		return false
+	}
+	if result.RowsAffected == 0 {
+		h.Error(c, utils.CodeUploadRequestInvalid, "上传链接文件数已达上限")
+		return false
+	}
+	return true
+}
+
+// releaseUploadSlot 上传失败时归还占用的名额
+func (h *OSSFileHandler) releaseUploadSlot(c *gin.Context, uploadRequest *models.UploadRequest) {
+	if err := h.DB.WithContext(c).Model(&models.UploadRequest{}).
+		Where("id = ? AND upload_count > 0", uploadRequest.ID).
+		Update("upload_count", gorm.Expr("upload_count - 1")).Error; err != nil {
+		logger.Warn("归还上传名额失败", zap.Uint("upload_request_id", uploadRequest.ID), zap.Error(err))
+	}
+}
+
+// validateUploadRequestFile 校验文件名、大小和扩展名是否符合上传链接的限制
+func validateUploadRequestFile(uploadRequest *models.UploadRequest, filename string, size int64) error {
+	if filename == "" || filename == "." || filename == ".." ||
+		strings.ContainsAny(filename, "/\\<>:\"|?*") {
+		return errors.New("文件名为空或包含非法字符")
+	}
+	if uploadRequest.MaxFileSize > 0 && size > uploadRequest.MaxFileSize {
+		return fmt.Errorf("文件大小超过限制（最大 %d 字节）", uploadRequest.MaxFileSize)
+	}
+	if !uploadRequest.ExtensionAllowed(filename) {
+		return fmt.Errorf("不允许的文件类型，仅支持: %s", uploadRequest.AllowedExtensions)
+	}
+	return nil
+}
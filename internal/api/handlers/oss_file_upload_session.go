@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	uploadSessionHeader = "X-Upload-Session" // 续传时携带的上传会话ID，新建会话时也通过该响应头返回
+	uploadSessionKey    = "uploadSession"    // gin 上下文中保存续传会话的键
+)
+
+// sessionOwnerID 上传会话的归属用户，外部上传链接归属于链接创建者
+func sessionOwnerID(c *gin.Context) uint {
+	if userID := c.GetUint("userID"); userID != 0 {
+		return userID
+	}
+	return utils.GetUserID(c)
+}
+
+// loadResumeSession 读取请求中的上传会话ID并加载会话，未携带时返回 nil，失败时已写入响应
+func (h *OSSFileHandler) loadResumeSession(c *gin.Context) (*models.UploadSession, bool) {
+	sessionID := c.GetHeader(uploadSessionHeader)
+	if sessionID == "" {
+		sessionID = c.Query("session_id")
+	}
+	if sessionID == "" || h.sessionManager == nil {
+		return nil, true
+	}
+
+	session, ok := h.getOwnSession(c, sessionID)
+	if !ok {
+		return nil, false
+	}
+	if session.Mode != models.UploadSessionModeServer {
+		h.Error(c, utils.CodeInvalidParams, "该上传会话不能通过本接口续传")
+		return nil, false
+	}
+	c.Set(uploadSessionKey, session)
+	return session, true
+}
+
+// getOwnSession 获取当前用户可续传的上传会话，失败时已写入响应
+func (h *OSSFileHandler) getOwnSession(c *gin.Context, sessionID string) (*models.UploadSession, bool) {
+	userID := c.GetUint("userID")
+	if userID == 0 {
+		h.Error(c, utils.CodeForbidden, "未登录用户不能续传上传会话")
+		return nil, false
+	}
+
+	session, err := h.sessionManager.Get(sessionID, userID)
+	switch {
+	case errors.Is(err, upload.ErrSessionNotFound):
+		h.Error(c, utils.CodeNotFound, "上传会话不存在")
+		return nil, false
+	case errors.Is(err, upload.ErrSessionClosed):
+		h.Error(c, utils.CodeInvalidParams, "上传会话已结束，请重新上传")
+		return nil, false
+	case err != nil:
+		h.Error(c, utils.CodeServerError, "获取上传会话失败")
+		return nil, false
+	}
+	return session, true
+}
+
+// resumeSessionFromContext 获取本次请求正在续传的会话
+func resumeSessionFromContext(c *gin.Context) *models.UploadSession {
+	if v, ok := c.Get(uploadSessionKey); ok {
+		if session, ok := v.(*models.UploadSession); ok {
+			return session
+		}
+	}
+	return nil
+}
+
+// createUploadSession 登记新的上传会话并通过响应头返回会话ID，登记失败不影响上传
+func (h *OSSFileHandler) createUploadSession(c *gin.Context, session *models.UploadSession) *models.UploadSession {
+	if h.sessionManager == nil {
+		return nil
+	}
+	session.OwnerID = sessionOwnerID(c)
+	if err := h.sessionManager.Create(session); err != nil {
+		logger.Warn("登记上传会话失败", zap.String("object_key", session.ObjectKey), zap.Error(err))
+		return nil
+	}
+	c.Header(uploadSessionHeader, session.SessionID)
+	return session
+}
+
+// ListMyUploadSessions 获取当前用户未完成的上传会话
+func (h *OSSFileHandler) ListMyUploadSessions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	status := c.DefaultQuery("status", models.UploadSessionActive)
+	query := h.DB.WithContext(c).Model(&models.UploadSession{}).Where("owner_id = ? AND status = ?", c.GetUint("userID"), status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取上传会话总数失败")
+		return
+	}
+
+	var sessions []models.UploadSession
+	if err := query.Order("updated_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&sessions).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取上传会话列表失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": sessions,
+	})
+}
+
+// GetMyUploadSession 获取上传会话详情，并按存储端实际已上传的分片刷新进度
+func (h *OSSFileHandler) GetMyUploadSession(c *gin.Context) {
+	session, ok := h.getOwnSession(c, c.Param("session_id"))
+	if !ok {
+		return
+	}
+
+	storage, err := h.storageFactory.GetStorageService(session.StorageType)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储服务失败")
+		return
+	}
+	parts, err := storage.ListUploadedPartsToBucket(session.ObjectKey, session.UploadID, session.RegionCode, session.BucketName)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取已上传分片失败")
+		return
+	}
+	h.sessionManager.SyncParts(session, parts)
+
+	partNumbers := make([]int, len(parts))
+	for i, p := range parts {
+		partNumbers[i] = p.PartNumber
+	}
+
+	h.Success(c, gin.H{
+		"session": session,
+		"parts":   partNumbers,
+	})
+}
+
+// maxPartURLsPerRequest 单次可申请的分片上传链接数量上限
+const maxPartURLsPerRequest = 100
+
+// GetPartUploadURLs 为客户端分片上传会话重新签发指定分片的上传链接
+// 初始化时返回的链接有效期有限且数量固定，续传或分片较多时通过本接口补发
+func (h *OSSFileHandler) GetPartUploadURLs(c *gin.Context) {
+	var req struct {
+		SessionID   string `json:"session_id" binding:"required"`
+		PartNumbers []int  `json:"part_numbers" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || h.sessionManager == nil {
+		h.Error(c, utils.CodeInvalidParams, "参数错误")
+		return
+	}
+	if len(req.PartNumbers) > maxPartURLsPerRequest {
+		h.Error(c, utils.CodeInvalidParams, "单次最多申请 100 个分片链接")
+		return
+	}
+
+	session, ok := h.getOwnSession(c, req.SessionID)
+	if !ok {
+		return
+	}
+	if session.Mode != models.UploadSessionModeClient {
+		h.Error(c, utils.CodeInvalidParams, "该上传会话不能通过本接口续传")
+		return
+	}
+
+	storage, err := h.storageFactory.GetStorageService(session.StorageType)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储服务失败")
+		return
+	}
+
+	urls := make(map[string]string, len(req.PartNumbers))
+	for _, partNumber := range req.PartNumbers {
+		if partNumber < 1 || partNumber > 10000 {
+			h.Error(c, utils.CodeInvalidParams, "分片编号必须在 1 到 10000 之间")
+			return
+		}
+		url, err := storage.GeneratePartUploadURL(session.ObjectKey, session.UploadID, partNumber, session.RegionCode, session.BucketName)
+		if err != nil {
+			logger.Error("生成分片上传链接失败", zap.String("session_id", session.SessionID), zap.Int("part_number", partNumber), zap.Error(err))
+			h.Error(c, utils.CodeServerError, "生成分片上传链接失败")
+			return
+		}
+		urls[strconv.Itoa(partNumber)] = url
+	}
+
+	h.Success(c, gin.H{"urls": urls})
+}
+
+// AbortMyUploadSession 放弃上传会话，在存储端中止分片上传
+func (h *OSSFileHandler) AbortMyUploadSession(c *gin.Context) {
+	session, ok := h.getOwnSession(c, c.Param("session_id"))
+	if !ok {
+		return
+	}
+
+	if err := h.sessionManager.Abort(session, "用户取消上传"); err != nil {
+		logger.Error("中止上传会话失败", zap.String("session_id", session.SessionID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "中止上传会话失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
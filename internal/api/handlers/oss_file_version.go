@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// versionPrefix 历史版本对象在存储桶中的隐藏前缀
+const versionPrefix = ".versions/"
+
+// versionObjectKey 生成历史版本的对象键，例如 .versions/alice/a.pdf/20240101150405_12
+func versionObjectKey(file models.OSSFile) string {
+	return fmt.Sprintf("%s%s/%s_%d", versionPrefix, file.ObjectKey, time.Now().Format("20060102150405"), file.ID)
+}
+
+// pendingVersion 覆盖前保留的历史版本，新对象写入失败时需要调用 discard 清理
+type pendingVersion struct {
+	h          *OSSFileHandler
+	ctx        context.Context
+	storage    oss.StorageService
+	fileID     uint
+	objectKey  string
+	versionKey string
+	regionCode string
+	bucketName string
+}
+
+// preserveCurrentVersion 在覆盖前将当前对象复制到历史版本前缀下，并在当前记录上记下版本对象键
+// 不存在当前记录时返回 nil，新对象写入失败时调用返回值的 discard 删除副本
+func (h *OSSFileHandler) preserveCurrentVersion(ctx context.Context, storage oss.StorageService, objectKey, regionCode, bucketName string) (*pendingVersion, error) {
+	var current models.OSSFile
+	err := h.DB.WithContext(ctx).Where("object_key = ? AND bucket = ? AND status = ?", objectKey, bucketName, "ACTIVE").
+		First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询当前版本失败: %w", err)
+	}
+
+	versionKey := versionObjectKey(current)
+	if _, err := storage.CopyObjectToBucket(bucketName, objectKey, versionKey, regionCode, bucketName); err != nil {
+		return nil, fmt.Errorf("保留历史版本失败: %w", err)
+	}
+
+	if err := h.DB.WithContext(ctx).Model(&models.OSSFile{}).Where("id = ?", current.ID).
+		Update("version_key", versionKey).Error; err != nil {
+		if delErr := storage.DeleteObjectFromBucket(versionKey, regionCode, bucketName); delErr != nil {
+			logger.Warn("删除历史版本副本失败", zap.String("version_key", versionKey), zap.Error(delErr))
+		}
+		return nil, fmt.Errorf("记录历史版本失败: %w", err)
+	}
+
+	logger.Info("已保留历史版本",
+		zap.Uint("file_id", current.ID),
+		zap.String("object_key", objectKey),
+		zap.String("version_key", versionKey))
+	return &pendingVersion{
+		h:          h,
+		ctx:        ctx,
+		storage:    storage,
+		fileID:     current.ID,
+		objectKey:  objectKey,
+		versionKey: versionKey,
+		regionCode: regionCode,
+		bucketName: bucketName,
+	}, nil
+}
+
+// discard 新对象写入失败时删除历史版本副本，并清除当前记录上的版本对象键
+func (v *pendingVersion) discard() {
+	if v == nil {
+		return
+	}
+	if err := v.storage.DeleteObjectFromBucket(v.versionKey, v.regionCode, v.bucketName); err != nil {
+		logger.Warn("删除历史版本副本失败", zap.String("version_key", v.versionKey), zap.Error(err))
+	}
+	if err := v.h.DB.WithContext(v.ctx).Model(&models.OSSFile{}).Where("id = ? AND version_key = ?", v.fileID, v.versionKey).
+		Update("version_key", "").Error; err != nil {
+		logger.Warn("清除历史版本记录失败", zap.Uint("file_id", v.fileID), zap.Error(err))
+	}
+	logger.Info("上传失败，已删除历史版本副本", zap.Uint("file_id", v.fileID), zap.String("version_key", v.versionKey))
+}
+
+// restore 新对象写入后又被拒绝时，用历史版本副本恢复被覆盖的对象，再删除副本
+func (v *pendingVersion) restore() error {
+	if _, err := v.storage.CopyObjectToBucket(v.bucketName, v.versionKey, v.objectKey, v.regionCode, v.bucketName); err != nil {
+		return fmt.Errorf("恢复被覆盖的对象失败: %w", err)
+	}
+	v.discard()
+	return nil
+}
+
+// ListVersions 获取对象键的版本列表（当前版本在前，历史版本按时间倒序）
+func (h *OSSFileHandler) ListVersions(c *gin.Context) {
+	bucketName := c.Query("bucket_name")
+	objectKey := c.Query("object_key")
+	if bucketName == "" || objectKey == "" {
+		h.Error(c, utils.CodeInvalidParams, "请指定 bucket_name 和 object_key")
+		return
+	}
+
+	regionCode, err := h.getRegionByBucket(c, bucketName)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储桶区域信息失败")
+		return
+	}
+
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), regionCode, bucketName) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return
+	}
+
+	var versions []models.OSSFile
+	if err := h.DB.WithContext(c).Where("object_key = ? AND bucket = ?", objectKey, bucketName).
+		Where("status = ? OR (status = ? AND version_key <> '')", "ACTIVE", "REPLACED").
+		Order("created_at DESC").
+		Find(&versions).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取版本列表失败")
+		return
+	}
+
+	items := make([]gin.H, 0, len(versions))
+	for _, v := range versions {
+		items = append(items, gin.H{
+			"version_id":  v.ID,
+			"is_current":  v.Status == "ACTIVE",
+			"file_size":   v.FileSize,
+			"md5":         v.MD5,
+			"uploader_id": v.UploaderID,
+			"created_at":  v.CreatedAt,
+		})
+	}
+
+	h.Success(c, gin.H{
+		"bucket_name": bucketName,
+		"object_key":  objectKey,
+		"items":       items,
+	})
+}
+
+// getVersion 获取历史版本记录并检查访问权限，失败时已写入响应
+func (h *OSSFileHandler) getVersion(c *gin.Context) (*models.OSSFile, string, bool) {
+	versionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的版本ID")
+		return nil, "", false
+	}
+
+	var version models.OSSFile
+	if err := h.DB.WithContext(c).Where("status = ? AND version_key <> ''", "REPLACED").
+		First(&version, versionID).Error; err != nil {
+		h.Error(c, utils.CodeFileNotFound, "历史版本不存在")
+		return nil, "", false
+	}
+
+	regionCode, err := h.getRegionByBucket(c, version.Bucket)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储桶区域信息失败")
+		return nil, "", false
+	}
+
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), regionCode, version.Bucket) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return nil, "", false
+	}
+
+	return &version, regionCode, true
+}
+
+// GetVersionDownloadURL 获取历史版本的下载链接
+func (h *OSSFileHandler) GetVersionDownloadURL(c *gin.Context) {
+	version, regionCode, ok := h.getVersion(c)
+	if !ok {
+		return
+	}
+
+	storage, err := h.storageFactory.GetStorageService(version.StorageType)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储服务失败")
+		return
+	}
+
+	downloadURL, expires, err := storage.GenerateDownloadURLFromBucket(version.VersionKey, regionCode, version.Bucket, time.Hour)
+	if err != nil {
+		logger.Error("生成历史版本下载链接失败", zap.Uint("version_id", version.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "生成下载链接失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"download_url": downloadURL,
+		"expires":      expires,
+	})
+}
+
+// RestoreVersion 将历史版本恢复为当前版本，恢复前的当前版本会被保留为新的历史版本
+func (h *OSSFileHandler) RestoreVersion(c *gin.Context) {
+	version, regionCode, ok := h.getVersion(c)
+	if !ok {
+		return
+	}
+
+	storage, err := h.storageFactory.GetStorageService(version.StorageType)
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储服务失败")
+		return
+	}
+
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).First(&config, version.ConfigID).Error; err != nil {
+		h.Error(c, utils.CodeConfigNotFound, "存储配置不存在")
+		return
+	}
+
+	pending, err := h.preserveCurrentVersion(c, storage, version.ObjectKey, regionCode, version.Bucket)
+	if err != nil {
+		logger.Error("恢复前保留当前版本失败", zap.Uint("version_id", version.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "保留当前版本失败")
+		return
+	}
+
+	uploadURL, err := storage.CopyObjectToBucket(version.Bucket, version.VersionKey, version.ObjectKey, regionCode, version.Bucket)
+	if err != nil {
+		pending.discard()
+		h.Error(c, utils.CodeServerError, "恢复历史版本失败")
+		return
+	}
+
+	restored := models.OSSFile{
+		ConfigID:         version.ConfigID,
+		Filename:         version.Filename,
+		OriginalFilename: version.OriginalFilename,
+		FileSize:         version.FileSize,
+		MD5:              version.MD5,
+		MD5Status:        version.MD5Status,
+		StorageType:      version.StorageType,
+		Bucket:           version.Bucket,
+		ObjectKey:        version.ObjectKey,
+		DownloadURL:      uploadURL,
+		UploaderID:       utils.GetUserID(c),
+		UploadIP:         c.ClientIP(),
+		ExpiresAt:        fileRecordExpiresAt(config),
+		Status:           "ACTIVE",
+	}
+	if err := h.replaceActiveFileRecord(c, &restored); err != nil {
+		logger.Error("保存恢复的文件记录失败", zap.Uint("version_id", version.ID), zap.Error(err))
+		// 对象已被历史版本覆盖，恢复为与当前记录一致的内容
+		if pending != nil {
+			if restoreErr := pending.restore(); restoreErr != nil {
+				logger.Error("还原被覆盖的当前版本失败", zap.Uint("version_id", version.ID), zap.Error(restoreErr))
+			}
+		}
+		h.Error(c, utils.CodeServerError, "保存文件记录失败")
+		return
+	}
+
+	logger.Info("历史版本恢复成功",
+		zap.Uint("version_id", version.ID),
+		zap.Uint("file_id", restored.ID),
+		zap.String("object_key", restored.ObjectKey))
+
+	h.Success(c, restored)
+}
@@ -33,7 +33,7 @@ func (h *PermissionHandler) List(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
 	// 构建查询
-	query := db.GetDB().Model(&models.Permission{})
+	query := db.GetDB().WithContext(c).Model(&models.Permission{})
 
 	// 处理筛选条件
 	if name := c.Query("name"); name != "" {
@@ -84,7 +84,7 @@ func (h *PermissionHandler) Create(c *gin.Context) {
 
 	// 检查权限是否已存在
 	var count int64
-	if err := db.GetDB().Model(&models.Permission{}).
+	if err := db.GetDB().WithContext(c).Model(&models.Permission{}).
 		Where("resource = ? AND action = ?", req.Resource, req.Action).
 		Count(&count).Error; err != nil {
 		h.InternalError(c, "检查权限失败")
@@ -103,7 +103,7 @@ func (h *PermissionHandler) Create(c *gin.Context) {
 		Action:      req.Action,
 	}
 
-	if err := db.GetDB().Create(&permission).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Create(&permission).Error; err != nil {
 		h.InternalError(c, "创建权限失败")
 		return
 	}
@@ -119,7 +119,7 @@ func (h *PermissionHandler) Get(c *gin.Context) {
 	permissionID := c.Param("id")
 
 	var permission models.Permission
-	if err := db.GetDB().First(&permission, permissionID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&permission, permissionID).Error; err != nil {
 		h.NotFound(c, "权限不存在")
 		return
 	}
@@ -132,7 +132,7 @@ func (h *PermissionHandler) Update(c *gin.Context) {
 	permissionID := c.Param("id")
 
 	var permission models.Permission
-	if err := db.GetDB().First(&permission, permissionID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&permission, permissionID).Error; err != nil {
 		h.NotFound(c, "权限不存在")
 		return
 	}
@@ -151,7 +151,7 @@ func (h *PermissionHandler) Update(c *gin.Context) {
 
 	// 检查权限是否已被其他记录使用
 	var count int64
-	if err := db.GetDB().Model(&models.Permission{}).
+	if err := db.GetDB().WithContext(c).Model(&models.Permission{}).
 		Where("resource = ? AND action = ? AND id != ?", req.Resource, req.Action, permissionID).
 		Count(&count).Error; err != nil {
 		h.InternalError(c, "检查权限失败")
@@ -163,7 +163,7 @@ func (h *PermissionHandler) Update(c *gin.Context) {
 	}
 
 	// 开启事务
-	tx := db.GetDB().Begin()
+	tx := db.GetDB().WithContext(c).Begin()
 
 	// 更新权限信息
 	if err := tx.Model(&permission).Updates(models.Permission{
@@ -193,13 +193,13 @@ func (h *PermissionHandler) Delete(c *gin.Context) {
 	permissionID := c.Param("id")
 
 	var permission models.Permission
-	if err := db.GetDB().First(&permission, permissionID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).First(&permission, permissionID).Error; err != nil {
 		h.NotFound(c, "权限不存在")
 		return
 	}
 
 	// 开启事务
-	tx := db.GetDB().Begin()
+	tx := db.GetDB().WithContext(c).Begin()
 
 	// 清除角色权限关联
 	if err := tx.Model(&permission).Association("Roles").Clear(); err != nil {
@@ -243,7 +243,7 @@ func (h *PermissionHandler) createAuditLog(c *gin.Context, action, resourceType,
 		Status:       "SUCCESS",
 	}
 
-	if err := db.GetDB().Create(&auditLog).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Create(&auditLog).Error; err != nil {
 		logger.Error("创建审计日志失败",
 			zap.String("action", action),
 			zap.String("resource_type", resourceType),
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// QuotaHandler 存储配额处理器
+type QuotaHandler struct {
+	*BaseHandler
+	DB           *gorm.DB
+	quotaManager *quota.Manager
+}
+
+// NewQuotaHandler 创建存储配额处理器
+func NewQuotaHandler(db *gorm.DB, quotaManager *quota.Manager) *QuotaHandler {
+	return &QuotaHandler{
+		BaseHandler:  NewBaseHandler(),
+		DB:           db,
+		quotaManager: quotaManager,
+	}
+}
+
+// QuotaRequest 创建或更新配额请求
+type QuotaRequest struct {
+	ScopeType  string `json:"scope_type" binding:"required,oneof=USER ROLE BUCKET"`
+	ScopeID    uint   `json:"scope_id"`
+	BucketName string `json:"bucket_name"`
+	MaxBytes   int64  `json:"max_bytes" binding:"min=0"`
+	MaxObjects int64  `json:"max_objects" binding:"min=0"`
+}
+
+// validate 校验配额范围参数
+func (r *QuotaRequest) validate() string {
+	switch r.ScopeType {
+	case models.QuotaScopeUser, models.QuotaScopeRole:
+		if r.ScopeID == 0 {
+			return "用户或角色配额必须指定 scope_id"
+		}
+	case models.QuotaScopeBucket:
+		if r.BucketName == "" {
+			return "存储桶配额必须指定 bucket_name"
+		}
+		r.ScopeID = 0
+	}
+	return ""
+}
+
+// MyUsage 获取当前用户适用的配额及实时用量
+func (h *QuotaHandler) MyUsage(c *gin.Context) {
+	quotas, err := h.quotaManager.Applicable(c.GetUint("userID"), c.Query("bucket_name"))
+	if err != nil {
+		logger.Error("获取适用配额失败", zap.Error(err))
+		h.Error(c, utils.CodeServerError, "获取配额失败")
+		return
+	}
+
+	items := make([]gin.H, 0, len(quotas))
+	for _, q := range quotas {
+		usage, err := h.quotaManager.Usage(q)
+		if err != nil {
+			logger.Error("统计配额用量失败", zap.Uint("quota_id", q.ID), zap.Error(err))
+			h.Error(c, utils.CodeServerError, "统计配额用量失败")
+			return
+		}
+		items = append(items, gin.H{
+			"quota": q,
+			"usage": usage,
+		})
+	}
+
+	h.Success(c, items)
+}
+
+// List 获取配额列表（用量为最近一次统计结果）
+func (h *QuotaHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.DB.WithContext(c).Model(&models.StorageQuota{})
+	if scopeType := c.Query("scope_type"); scopeType != "" {
+		query = query.Where("scope_type = ?", scopeType)
+	}
+	if bucketName := c.Query("bucket_name"); bucketName != "" {
+		query = query.Where("bucket_name = ?", bucketName)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取配额总数失败")
+		return
+	}
+
+	var quotas []models.StorageQuota
+	if err := query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&quotas).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取配额列表失败")
+		return
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": quotas,
+	})
+}
+
+// Create 创建配额
+func (h *QuotaHandler) Create(c *gin.Context) {
+	if !h.requireGlobalAdmin(c) {
+		return
+	}
+	var req QuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		h.Error(c, utils.CodeInvalidParams, msg)
+		return
+	}
+
+	var count int64
+	h.DB.WithContext(c).Model(&models.StorageQuota{}).
+		Where("scope_type = ? AND scope_id = ? AND bucket_name = ?", req.ScopeType, req.ScopeID, req.BucketName).
+		Count(&count)
+	if count > 0 {
+		h.Error(c, utils.CodeInvalidParams, "该范围的配额已存在")
+		return
+	}
+
+	q := models.StorageQuota{
+		ScopeType:  req.ScopeType,
+		ScopeID:    req.ScopeID,
+		BucketName: req.BucketName,
+		MaxBytes:   req.MaxBytes,
+		MaxObjects: req.MaxObjects,
+	}
+	if err := h.DB.WithContext(c).Create(&q).Error; err != nil {
+		logger.Error("创建配额失败", zap.Error(err))
+		h.Error(c, utils.CodeServerError, "创建配额失败")
+		return
+	}
+
+	// 创建后立即统计一次用量
+	if err := h.quotaManager.Recalculate(&q); err != nil {
+		logger.Warn("统计配额用量失败", zap.Uint("quota_id", q.ID), zap.Error(err))
+	}
+
+	h.Success(c, q)
+}
+
+// Update 更新配额上限
+func (h *QuotaHandler) Update(c *gin.Context) {
+	if !h.requireGlobalAdmin(c) {
+		return
+	}
+	var q models.StorageQuota
+	if err := h.DB.WithContext(c).First(&q, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeNotFound, "配额不存在")
+		return
+	}
+
+	var req struct {
+		MaxBytes   int64 `json:"max_bytes" binding:"min=0"`
+		MaxObjects int64 `json:"max_objects" binding:"min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	if err := h.DB.WithContext(c).Model(&q).Updates(map[string]interface{}{
+		"max_bytes":   req.MaxBytes,
+		"max_objects": req.MaxObjects,
+	}).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "更新配额失败")
+		return
+	}
+
+	h.Success(c, q)
+}
+
+// Delete 删除配额（物理删除，以便同一范围可重新创建）
+func (h *QuotaHandler) Delete(c *gin.Context) {
+	if !h.requireGlobalAdmin(c) {
+		return
+	}
+	if err := h.DB.WithContext(c).Unscoped().Delete(&models.StorageQuota{}, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "删除配额失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
+
+// Recalculate 立即重新统计所有配额的用量
+func (h *QuotaHandler) Recalculate(c *gin.Context) {
+	count, err := h.quotaManager.RecalculateAll()
+	if err != nil {
+		logger.Error("重新统计配额用量失败", zap.Error(err))
+		h.Error(c, utils.CodeServerError, "重新统计配额用量失败")
+		return
+	}
+
+	h.Success(c, gin.H{"count": count})
+}
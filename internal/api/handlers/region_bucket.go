@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/policy"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
 	"github.com/myysophia/ossmanager-backend/internal/utils"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -28,7 +32,7 @@ func NewRegionBucketHandler(db *gorm.DB) *RegionBucketHandler {
 // GetRegionList 获取地域列表
 func (h *RegionBucketHandler) GetRegionList(c *gin.Context) {
 	var regions []string
-	if err := h.DB.Model(&models.RegionBucketMapping{}).
+	if err := h.DB.WithContext(c).Model(&models.RegionBucketMapping{}).
 		Distinct().
 		Pluck("region_code", &regions).
 		Error; err != nil {
@@ -48,7 +52,7 @@ func (h *RegionBucketHandler) GetBucketList(c *gin.Context) {
 	}
 
 	var buckets []string
-	if err := h.DB.Model(&models.RegionBucketMapping{}).
+	if err := h.DB.WithContext(c).Model(&models.RegionBucketMapping{}).
 		Where("region_code = ?", regionCode).
 		Pluck("bucket_name", &buckets).
 		Error; err != nil {
@@ -64,7 +68,7 @@ func (h *RegionBucketHandler) GetUserAccessibleBuckets(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	regionCode := c.Query("region_code")
 
-	query := h.DB.Model(&models.RegionBucketMapping{}).
+	query := h.DB.WithContext(c).Model(&models.RegionBucketMapping{}).
 		Joins("JOIN role_region_bucket_access ON role_region_bucket_access.region_bucket_mapping_id = region_bucket_mapping.id").
 		Joins("JOIN user_roles ON user_roles.role_id = role_region_bucket_access.role_id").
 		Where("user_roles.user_id = ?", userID)
@@ -110,7 +114,7 @@ func (h *RegionBucketHandler) List(c *gin.Context) {
 		zap.String("region_code", regionCode),
 		zap.String("bucket_name", bucketName))
 
-	query := h.DB.Model(&models.RegionBucketMapping{})
+	query := h.DB.WithContext(c).Model(&models.RegionBucketMapping{})
 
 	if regionCode != "" {
 		query = query.Where("region_code = ?", regionCode)
@@ -171,9 +175,9 @@ func (h *RegionBucketHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// 检查是否已存在相同的映射
+	// 检查是否已存在相同的映射（存储桶只能属于一个组织，检查时不按组织过滤）
 	var count int64
-	if err := h.DB.Model(&models.RegionBucketMapping{}).
+	if err := h.DB.WithContext(tenant.WithoutScope(c)).Model(&models.RegionBucketMapping{}).
 		Where("region_code = ? AND bucket_name = ?", input.RegionCode, input.BucketName).
 		Count(&count).Error; err != nil {
 		h.InternalError(c, "检查映射是否存在失败")
@@ -184,7 +188,7 @@ func (h *RegionBucketHandler) Create(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.Create(&input).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&input).Error; err != nil {
 		h.InternalError(c, "创建失败")
 		return
 	}
@@ -196,7 +200,7 @@ func (h *RegionBucketHandler) Create(c *gin.Context) {
 func (h *RegionBucketHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	var mapping models.RegionBucketMapping
-	if err := h.DB.First(&mapping, id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&mapping, id).Error; err != nil {
 		h.NotFound(c, "映射不存在")
 		return
 	}
@@ -208,7 +212,7 @@ func (h *RegionBucketHandler) Get(c *gin.Context) {
 func (h *RegionBucketHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var mapping models.RegionBucketMapping
-	if err := h.DB.First(&mapping, id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&mapping, id).Error; err != nil {
 		h.NotFound(c, "映射不存在")
 		return
 	}
@@ -219,9 +223,9 @@ func (h *RegionBucketHandler) Update(c *gin.Context) {
 		return
 	}
 
-	// 检查是否已存在相同的映射（排除当前记录）
+	// 检查是否已存在相同的映射（排除当前记录，存储桶只能属于一个组织，检查时不按组织过滤）
 	var count int64
-	if err := h.DB.Model(&models.RegionBucketMapping{}).
+	if err := h.DB.WithContext(tenant.WithoutScope(c)).Model(&models.RegionBucketMapping{}).
 		Where("region_code = ? AND bucket_name = ? AND id != ?", input.RegionCode, input.BucketName, id).
 		Count(&count).Error; err != nil {
 		h.InternalError(c, "检查映射是否存在失败")
@@ -235,7 +239,7 @@ func (h *RegionBucketHandler) Update(c *gin.Context) {
 	mapping.RegionCode = input.RegionCode
 	mapping.BucketName = input.BucketName
 
-	if err := h.DB.Save(&mapping).Error; err != nil {
+	if err := h.DB.WithContext(c).Save(&mapping).Error; err != nil {
 		h.InternalError(c, "更新失败")
 		return
 	}
@@ -247,13 +251,13 @@ func (h *RegionBucketHandler) Update(c *gin.Context) {
 func (h *RegionBucketHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	var mapping models.RegionBucketMapping
-	if err := h.DB.First(&mapping, id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&mapping, id).Error; err != nil {
 		h.NotFound(c, "映射不存在")
 		return
 	}
 
 	// 开启事务
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 
 	// 删除相关的角色访问权限
 	if err := tx.Where("region_bucket_mapping_id = ?", id).Delete(&models.RoleRegionBucketAccess{}).Error; err != nil {
@@ -262,6 +266,13 @@ func (h *RegionBucketHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// 删除存储桶上传策略
+	if err := tx.Unscoped().Where("region_bucket_mapping_id = ?", id).Delete(&models.BucketUploadPolicy{}).Error; err != nil {
+		tx.Rollback()
+		h.InternalError(c, "删除上传策略失败")
+		return
+	}
+
 	// 删除映射
 	if err := tx.Delete(&mapping).Error; err != nil {
 		tx.Rollback()
@@ -276,3 +287,113 @@ func (h *RegionBucketHandler) Delete(c *gin.Context) {
 
 	h.Success(c, nil)
 }
+
+// UploadPolicyRequest 存储桶上传策略请求
+type UploadPolicyRequest struct {
+	MaxFileSize       int64    `json:"max_file_size" binding:"min=0"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	AllowedMimeTypes  []string `json:"allowed_mime_types"`
+	PathPattern       string   `json:"path_pattern"`
+}
+
+// GetUploadPolicy 获取存储桶上传策略
+func (h *RegionBucketHandler) GetUploadPolicy(c *gin.Context) {
+	var mapping models.RegionBucketMapping
+	if err := h.DB.WithContext(c).First(&mapping, c.Param("id")).Error; err != nil {
+		h.NotFound(c, "映射不存在")
+		return
+	}
+
+	var uploadPolicy models.BucketUploadPolicy
+	if err := h.DB.WithContext(c).Where("region_bucket_mapping_id = ?", mapping.ID).First(&uploadPolicy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 未配置策略时返回空
+			h.Success(c, nil)
+			return
+		}
+		h.InternalError(c, "获取上传策略失败")
+		return
+	}
+
+	h.Success(c, uploadPolicy)
+}
+
+// UpdateUploadPolicy 创建或更新存储桶上传策略
+func (h *RegionBucketHandler) UpdateUploadPolicy(c *gin.Context) {
+	if !h.requireOrgAdmin(c) {
+		return
+	}
+	var mapping models.RegionBucketMapping
+	if err := h.DB.WithContext(c).First(&mapping, c.Param("id")).Error; err != nil {
+		h.NotFound(c, "映射不存在")
+		return
+	}
+
+	var input UploadPolicyRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.BadRequest(c, "参数错误")
+		return
+	}
+
+	var uploadPolicy models.BucketUploadPolicy
+	if err := h.DB.WithContext(c).Where("region_bucket_mapping_id = ?", mapping.ID).First(&uploadPolicy).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		h.InternalError(c, "获取上传策略失败")
+		return
+	}
+
+	uploadPolicy.RegionBucketMappingID = mapping.ID
+	uploadPolicy.MaxFileSize = input.MaxFileSize
+	uploadPolicy.AllowedExtensions = normalizeList(input.AllowedExtensions, ".")
+	uploadPolicy.AllowedMimeTypes = normalizeList(input.AllowedMimeTypes, "")
+	uploadPolicy.PathPattern = input.PathPattern
+	if err := policy.Validate(&uploadPolicy); err != nil {
+		h.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.DB.WithContext(c).Save(&uploadPolicy).Error; err != nil {
+		logger.Error("保存上传策略失败", zap.Uint("mapping_id", mapping.ID), zap.Error(err))
+		h.InternalError(c, "保存上传策略失败")
+		return
+	}
+
+	h.Success(c, uploadPolicy)
+}
+
+// DeleteUploadPolicy 删除存储桶上传策略
+func (h *RegionBucketHandler) DeleteUploadPolicy(c *gin.Context) {
+	if !h.requireOrgAdmin(c) {
+		return
+	}
+	// 策略表没有组织字段，先按组织范围确认映射存在
+	var mapping models.RegionBucketMapping
+	if err := h.DB.WithContext(c).First(&mapping, c.Param("id")).Error; err != nil {
+		h.NotFound(c, "映射不存在")
+		return
+	}
+
+	if err := h.DB.WithContext(c).Unscoped().Where("region_bucket_mapping_id = ?", mapping.ID).
+		Delete(&models.BucketUploadPolicy{}).Error; err != nil {
+		h.InternalError(c, "删除上传策略失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
+
+// normalizeList 将列表规范为小写、逗号分隔的字符串，prefix 非空时为缺少前缀的项补全
+func normalizeList(items []string, prefix string) string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(item, prefix) {
+			item = prefix + item
+		}
+		result = append(result, item)
+	}
+	return strings.Join(result, ",")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
 	"github.com/myysophia/ossmanager-backend/internal/utils"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -32,7 +33,7 @@ func (h *RoleHandler) List(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
 	// 构建查询
-	query := h.DB.Model(&models.Role{})
+	query := h.DB.WithContext(c).Model(&models.Role{})
 
 	// 处理筛选条件
 	if name := c.Query("name"); name != "" {
@@ -80,6 +81,7 @@ func (h *RoleHandler) Create(c *gin.Context) {
 	var req struct {
 		Name          string `json:"name" binding:"required,min=2,max=32"`
 		Description   string `json:"description"`
+		AdminScope    string `json:"admin_scope" binding:"omitempty,oneof=ORG GLOBAL"`
 		PermissionIDs []uint `json:"permission_ids"`
 	}
 
@@ -87,10 +89,14 @@ func (h *RoleHandler) Create(c *gin.Context) {
 		h.BadRequest(c, "参数错误")
 		return
 	}
+	if !canManageAdminScope(c, req.AdminScope) {
+		h.Forbidden(c, "只有全局管理员可以管理全局管理员角色")
+		return
+	}
 
 	// 检查角色名是否已存在
 	var count int64
-	if err := h.DB.Model(&models.Role{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+	if err := h.DB.WithContext(c).Model(&models.Role{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
 		h.InternalError(c, "检查角色名失败")
 		return
 	}
@@ -103,10 +109,11 @@ func (h *RoleHandler) Create(c *gin.Context) {
 	role := models.Role{
 		Name:        req.Name,
 		Description: req.Description,
+		AdminScope:  req.AdminScope,
 	}
 
 	// 开启事务
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 
 	if err := tx.Create(&role).Error; err != nil {
 		tx.Rollback()
@@ -146,7 +153,7 @@ func (h *RoleHandler) Get(c *gin.Context) {
 	roleID := c.Param("id")
 
 	var role models.Role
-	if err := h.DB.Preload("Permissions").First(&role, roleID).Error; err != nil {
+	if err := h.DB.WithContext(c).Preload("Permissions").First(&role, roleID).Error; err != nil {
 		h.NotFound(c, "角色不存在")
 		return
 	}
@@ -159,25 +166,30 @@ func (h *RoleHandler) Update(c *gin.Context) {
 	roleID := c.Param("id")
 
 	var role models.Role
-	if err := h.DB.First(&role, roleID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&role, roleID).Error; err != nil {
 		h.NotFound(c, "角色不存在")
 		return
 	}
 
 	var req struct {
-		Name          string `json:"name" binding:"required,min=2,max=32"`
-		Description   string `json:"description"`
-		PermissionIDs []uint `json:"permission_ids"`
+		Name          string  `json:"name" binding:"required,min=2,max=32"`
+		Description   string  `json:"description"`
+		AdminScope    *string `json:"admin_scope" binding:"omitempty,oneof=ORG GLOBAL ''"`
+		PermissionIDs []uint  `json:"permission_ids"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.BadRequest(c, "参数错误")
 		return
 	}
+	if !canManageAdminScope(c, role.AdminScope) || (req.AdminScope != nil && !canManageAdminScope(c, *req.AdminScope)) {
+		h.Forbidden(c, "只有全局管理员可以管理全局管理员角色")
+		return
+	}
 
 	// 检查角色名是否已被其他角色使用
 	var count int64
-	if err := h.DB.Model(&models.Role{}).Where("name = ? AND id != ?", req.Name, roleID).Count(&count).Error; err != nil {
+	if err := h.DB.WithContext(c).Model(&models.Role{}).Where("name = ? AND id != ?", req.Name, roleID).Count(&count).Error; err != nil {
 		h.InternalError(c, "检查角色名失败")
 		return
 	}
@@ -187,13 +199,17 @@ func (h *RoleHandler) Update(c *gin.Context) {
 	}
 
 	// 开启事务
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 
 	// 更新基本信息
-	if err := tx.Model(&role).Updates(models.Role{
-		Name:        req.Name,
-		Description: req.Description,
-	}).Error; err != nil {
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+	}
+	if req.AdminScope != nil {
+		updates["admin_scope"] = *req.AdminScope
+	}
+	if err := tx.Model(&role).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		h.InternalError(c, "更新角色失败")
 		return
@@ -224,7 +240,7 @@ func (h *RoleHandler) Update(c *gin.Context) {
 	h.createAuditLog(c, "UPDATE", "ROLE", roleID, "更新角色信息")
 
 	// 重新获取角色信息（包含权限）
-	if err := h.DB.Preload("Permissions").First(&role, roleID).Error; err != nil {
+	if err := h.DB.WithContext(c).Preload("Permissions").First(&role, roleID).Error; err != nil {
 		h.NotFound(c, "获取更新后的角色信息失败")
 		return
 	}
@@ -237,13 +253,17 @@ func (h *RoleHandler) Delete(c *gin.Context) {
 	roleID := c.Param("id")
 
 	var role models.Role
-	if err := h.DB.First(&role, roleID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&role, roleID).Error; err != nil {
 		h.NotFound(c, "角色不存在")
 		return
 	}
+	if !canManageAdminScope(c, role.AdminScope) {
+		h.Forbidden(c, "只有全局管理员可以管理全局管理员角色")
+		return
+	}
 
 	// 开启事务
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 
 	// 清除角色权限关联
 	if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
@@ -277,6 +297,25 @@ func (h *RoleHandler) Delete(c *gin.Context) {
 	h.Success(c, nil)
 }
 
+// canManageAdminScope 全局管理员角色只能由全局管理员创建、修改和删除
+func canManageAdminScope(c *gin.Context, adminScope string) bool {
+	if adminScope != models.AdminScopeGlobal {
+		return true
+	}
+	scope := tenant.FromContext(c)
+	return scope != nil && scope.GlobalAdmin
+}
+
+// canGrantRoles 检查当前用户能否分配这些角色，防止组织管理员授予全局管理员角色
+func canGrantRoles(c *gin.Context, roles []*models.Role) bool {
+	for _, role := range roles {
+		if !canManageAdminScope(c, role.AdminScope) {
+			return false
+		}
+	}
+	return true
+}
+
 // createAuditLog 创建审计日志
 func (h *RoleHandler) createAuditLog(c *gin.Context, action, resourceType, resourceID, details string) {
 	userID, _ := c.Get("userID")
@@ -294,7 +333,7 @@ func (h *RoleHandler) createAuditLog(c *gin.Context, action, resourceType, resou
 		Status:       "SUCCESS",
 	}
 
-	if err := h.DB.Create(&auditLog).Error; err != nil {
+	if err := h.DB.WithContext(c).Create(&auditLog).Error; err != nil {
 		logger.Error("创建审计日志失败",
 			zap.String("action", action),
 			zap.String("resource_type", resourceType),
@@ -307,7 +346,7 @@ func (h *RoleHandler) createAuditLog(c *gin.Context, action, resourceType, resou
 func (h *RoleHandler) GetRoleBucketAccess(c *gin.Context) {
 	roleID := c.Param("id")
 	var role models.Role
-	if err := h.DB.Preload("RegionBuckets").First(&role, roleID).Error; err != nil {
+	if err := h.DB.WithContext(c).Preload("RegionBuckets").First(&role, roleID).Error; err != nil {
 		h.NotFound(c, "角色不存在")
 		return
 	}
@@ -319,7 +358,7 @@ func (h *RoleHandler) GetRoleBucketAccess(c *gin.Context) {
 func (h *RoleHandler) UpdateRoleBucketAccess(c *gin.Context) {
 	roleID := c.Param("id")
 	var role models.Role
-	if err := h.DB.First(&role, roleID).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&role, roleID).Error; err != nil {
 		h.NotFound(c, "角色不存在")
 		return
 	}
@@ -334,7 +373,7 @@ func (h *RoleHandler) UpdateRoleBucketAccess(c *gin.Context) {
 	}
 
 	// 开启事务
-	tx := h.DB.Begin()
+	tx := h.DB.WithContext(c).Begin()
 	if tx.Error != nil {
 		h.InternalError(c, "开启事务失败")
 		return
@@ -376,7 +415,11 @@ func (h *RoleHandler) ListRoleBucketAccess(c *gin.Context) {
 	roleID := c.Query("role_id")
 	bucket := c.Query("bucket")
 
-	query := h.DB.Model(&models.RoleRegionBucketAccess{})
+	query := h.DB.WithContext(c).Model(&models.RoleRegionBucketAccess{})
+	// 关联表没有组织字段，通过角色所属组织过滤
+	if orgID := tenant.OrgID(c); orgID != 0 {
+		query = query.Where("role_id IN (?)", h.DB.Model(&models.Role{}).Select("id").Where("org_id = ?", orgID))
+	}
 
 	if roleID != "" {
 		query = query.Where("role_id = ?", roleID)
@@ -411,7 +454,17 @@ func (h *RoleHandler) CreateRoleBucketAccess(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.Create(&input).Error; err != nil {
+	// 角色与存储桶都必须属于当前组织
+	if err := h.DB.WithContext(c).First(&models.Role{}, input.RoleID).Error; err != nil {
+		h.NotFound(c, "角色不存在")
+		return
+	}
+	if err := h.DB.WithContext(c).First(&models.RegionBucketMapping{}, input.RegionBucketMappingID).Error; err != nil {
+		h.NotFound(c, "存储桶映射不存在")
+		return
+	}
+
+	if err := h.DB.WithContext(c).Create(&input).Error; err != nil {
 		h.InternalError(c, "创建失败")
 		return
 	}
@@ -423,12 +476,16 @@ func (h *RoleHandler) CreateRoleBucketAccess(c *gin.Context) {
 func (h *RoleHandler) DeleteRoleBucketAccess(c *gin.Context) {
 	id := c.Param("id")
 	var access models.RoleRegionBucketAccess
-	if err := h.DB.First(&access, id).Error; err != nil {
+	if err := h.DB.WithContext(c).First(&access, id).Error; err != nil {
+		h.NotFound(c, "访问权限不存在")
+		return
+	}
+	if err := h.DB.WithContext(c).First(&models.Role{}, access.RoleID).Error; err != nil {
 		h.NotFound(c, "访问权限不存在")
 		return
 	}
 
-	if err := h.DB.Delete(&access).Error; err != nil {
+	if err := h.DB.WithContext(c).Delete(&access).Error; err != nil {
 		h.InternalError(c, "删除失败")
 		return
 	}
@@ -443,7 +500,7 @@ func (h *RoleHandler) ListRegionBucketMappings(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "300"))
 
 	// 构建查询
-	query := h.DB.Model(&models.RegionBucketMapping{})
+	query := h.DB.WithContext(c).Model(&models.RegionBucketMapping{})
 
 	// 处理筛选条件
 	if regionCode := c.Query("region_code"); regionCode != "" {
@@ -0,0 +1,720 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/policy"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/s3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// s3MaxKeys ListObjects 单次返回的最大条目数
+	s3MaxKeys = 1000
+	// s3MaxDeleteBody DeleteObjects 请求体上限
+	s3MaxDeleteBody = 2 << 20
+	// s3DownloadURLTTL GetObject 代理下载时使用的预签名URL有效期
+	s3DownloadURLTTL = 15 * time.Minute
+)
+
+// s3Request 解析后的 S3 请求目标
+type s3Request struct {
+	bucket   string
+	key      string
+	region   string
+	userID   uint
+	username string
+}
+
+// S3Handler S3 兼容接口处理器，将路径风格的 S3 请求映射到存储服务和文件记录
+type S3Handler struct {
+	files *OSSFileHandler
+	DB    *gorm.DB
+}
+
+// NewS3Handler 创建 S3 兼容接口处理器，复用文件处理器的上传、版本和删除逻辑
+func NewS3Handler(files *OSSFileHandler) *S3Handler {
+	return &S3Handler{
+		files: files,
+		DB:    files.DB,
+	}
+}
+
+// Dispatch 按路径和查询参数分发 S3 请求：/s3/ 为服务级，/s3/{bucket} 为存储桶级，/s3/{bucket}/{key} 为对象级
+func (h *S3Handler) Dispatch(c *gin.Context) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(c.Param("path"), "/"), "/")
+	req := &s3Request{
+		bucket:   bucket,
+		key:      key,
+		userID:   c.GetUint("userID"),
+		username: c.GetString("username"),
+	}
+	query := c.Request.URL.Query()
+
+	if bucket == "" {
+		if c.Request.Method != http.MethodGet {
+			s3.WriteError(c, s3.ErrNotImplemented)
+			return
+		}
+		h.listBuckets(c, req)
+		return
+	}
+
+	if !h.resolveBucket(c, req) {
+		return
+	}
+
+	if key == "" {
+		switch {
+		case c.Request.Method == http.MethodHead:
+			c.Status(http.StatusOK)
+		case c.Request.Method == http.MethodGet && query.Has("location"):
+			s3.WriteXML(c, http.StatusOK, s3.NewLocationConstraint(req.region))
+		case c.Request.Method == http.MethodGet && !query.Has("uploads") && !query.Has("versioning") && !query.Has("policy"):
+			h.listObjects(c, req, query)
+		case c.Request.Method == http.MethodPost && query.Has("delete"):
+			h.deleteObjects(c, req)
+		default:
+			s3.WriteError(c, s3.ErrNotImplemented)
+		}
+		return
+	}
+
+	// 历史版本和回收站对象由服务自身管理，不通过 S3 接口暴露
+	if isReservedKey(key) {
+		s3.WriteError(c, s3.ErrAccessDenied.WithMessage("不允许访问系统保留路径"))
+		return
+	}
+	if len(key) > 1024 || strings.Contains(key, "..") {
+		s3.WriteError(c, s3.ErrInvalidArgument.WithMessage("对象键无效"))
+		return
+	}
+
+	uploadID := query.Get("uploadId")
+	switch c.Request.Method {
+	case http.MethodGet:
+		if uploadID != "" {
+			h.listParts(c, req, uploadID)
+			return
+		}
+		h.getObject(c, req)
+	case http.MethodHead:
+		h.headObject(c, req)
+	case http.MethodPut:
+		if c.GetHeader("X-Amz-Copy-Source") != "" {
+			s3.WriteError(c, s3.ErrNotImplemented.WithMessage("暂不支持复制对象"))
+			return
+		}
+		if uploadID != "" {
+			h.uploadPart(c, req, uploadID, query.Get("partNumber"))
+			return
+		}
+		h.putObject(c, req)
+	case http.MethodDelete:
+		if uploadID != "" {
+			h.abortMultipartUpload(c, req, uploadID)
+			return
+		}
+		h.deleteObject(c, req)
+	case http.MethodPost:
+		switch {
+		case query.Has("uploads"):
+			h.createMultipartUpload(c, req)
+		case uploadID != "":
+			h.completeMultipartUpload(c, req, uploadID)
+		default:
+			s3.WriteError(c, s3.ErrNotImplemented)
+		}
+	default:
+		s3.WriteError(c, s3.ErrNotImplemented)
+	}
+}
+
+// resolveBucket 查找存储桶所在区域并检查访问权限，失败时已写入响应
+func (h *S3Handler) resolveBucket(c *gin.Context, req *s3Request) bool {
+	region, err := h.files.getRegionByBucket(c, req.bucket)
+	if err != nil {
+		s3.WriteError(c, s3.ErrNoSuchBucket)
+		return false
+	}
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), req.userID, region, req.bucket) {
+		s3.WriteError(c, s3.ErrAccessDenied.WithMessage("没有权限访问该存储桶"))
+		return false
+	}
+	req.region = region
+	return true
+}
+
+// defaultStorage 获取默认存储配置和对应的存储服务
+func (h *S3Handler) defaultStorage(c *gin.Context) (models.OSSConfig, oss.StorageService, *s3.Error) {
+	config, storage, err := h.files.defaultStorage(c)
+	if err != nil {
+		logger.Error("S3 获取存储服务失败", zap.Error(err))
+		return config, nil, s3.ErrInternal.WithMessage("获取存储服务失败")
+	}
+	return config, storage, nil
+}
+
+// findObject 查找对象键当前的文件记录
+func (h *S3Handler) findObject(c *gin.Context, req *s3Request) (*models.OSSFile, error) {
+	var file models.OSSFile
+	err := h.DB.WithContext(c).Where("bucket = ? AND object_key = ? AND status = ?", req.bucket, req.key, "ACTIVE").
+		Order("id DESC").First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// listBuckets ListBuckets：返回用户有权访问的存储桶
+func (h *S3Handler) listBuckets(c *gin.Context, req *s3Request) {
+	names, err := auth.GetUserAccessibleBuckets(h.DB.WithContext(c), req.userID, "")
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal)
+		return
+	}
+
+	buckets := make([]s3.BucketInfo, 0, len(names))
+	if len(names) > 0 {
+		var mappings []models.RegionBucketMapping
+		if err := h.DB.WithContext(c).Where("bucket_name IN ?", names).Order("bucket_name").Find(&mappings).Error; err != nil {
+			s3.WriteError(c, s3.ErrInternal)
+			return
+		}
+		seen := make(map[string]bool, len(mappings))
+		for _, m := range mappings {
+			if seen[m.BucketName] {
+				continue
+			}
+			seen[m.BucketName] = true
+			buckets = append(buckets, s3.BucketInfo{Name: m.BucketName, CreationDate: m.CreatedAt.UTC()})
+		}
+	}
+
+	owner := s3.Owner{ID: strconv.FormatUint(uint64(req.userID), 10), DisplayName: req.username}
+	s3.WriteXML(c, http.StatusOK, s3.NewListAllMyBucketsResult(owner, buckets))
+}
+
+// listObjects ListObjects / ListObjectsV2：按对象键顺序列出当前文件记录，支持前缀、分隔符和分页
+func (h *S3Handler) listObjects(c *gin.Context, req *s3Request, query url.Values) {
+	v2 := query.Get("list-type") == "2"
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		s3.WriteError(c, s3.ErrInvalidArgument.WithMessage("encoding-type 仅支持 url"))
+		return
+	}
+
+	maxKeys := s3MaxKeys
+	if raw := query.Get("max-keys"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			s3.WriteError(c, s3.ErrInvalidArgument.WithMessage("max-keys 无效"))
+			return
+		}
+		if n < maxKeys {
+			maxKeys = n
+		}
+	}
+
+	result := s3.NewListBucketResult(req.bucket)
+	result.Prefix = prefix
+	result.Delimiter = delimiter
+	result.EncodingType = encodingType
+	result.MaxKeys = maxKeys
+
+	// V2 以 continuation-token（编码后的对象键）或 start-after 作为起点，V1 使用 marker
+	var marker string
+	if v2 {
+		result.StartAfter = query.Get("start-after")
+		marker = result.StartAfter
+		if token := query.Get("continuation-token"); token != "" {
+			decoded, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				s3.WriteError(c, s3.ErrInvalidArgument.WithMessage("continuation-token 无效"))
+				return
+			}
+			result.ContinuationToken = token
+			marker = string(decoded)
+		}
+	} else {
+		marker = query.Get("marker")
+		result.Marker = &marker
+	}
+
+	owner := &s3.Owner{ID: strconv.FormatUint(uint64(req.userID), 10), DisplayName: req.username}
+	count := 0
+	last := ""
+	cursor := marker
+	for maxKeys > 0 && !result.IsTruncated {
+		var files []models.OSSFile
+		q := h.DB.WithContext(c).Where("bucket = ? AND status = ?", req.bucket, "ACTIVE")
+		if prefix != "" {
+			q = q.Where("object_key LIKE ? ESCAPE '!'", escapeLike(prefix)+"%")
+		}
+		if cursor != "" {
+			q = q.Where("object_key > ?", cursor)
+		}
+		if err := q.Order("object_key").Limit(s3MaxKeys).Find(&files).Error; err != nil {
+			logger.Error("S3 列出对象失败", zap.String("bucket", req.bucket), zap.Error(err))
+			s3.WriteError(c, s3.ErrInternal)
+			return
+		}
+
+		for _, file := range files {
+			cursor = file.ObjectKey
+			// 起点为公共前缀时，跳过该前缀下的对象
+			if delimiter != "" && strings.HasSuffix(marker, delimiter) && strings.HasPrefix(file.ObjectKey, marker) {
+				continue
+			}
+
+			entry := file.ObjectKey
+			isPrefix := false
+			if delimiter != "" {
+				if i := strings.Index(file.ObjectKey[len(prefix):], delimiter); i >= 0 {
+					entry = file.ObjectKey[:len(prefix)+i+len(delimiter)]
+					isPrefix = true
+				}
+			}
+			if entry == last {
+				continue
+			}
+			if count == maxKeys {
+				result.IsTruncated = true
+				break
+			}
+
+			if isPrefix {
+				result.CommonPrefixes = append(result.CommonPrefixes, s3.CommonPrefix{Prefix: s3EncodeKey(entry, encodingType)})
+			} else {
+				object := s3.Object{
+					Key:          s3EncodeKey(entry, encodingType),
+					LastModified: file.UpdatedAt.UTC(),
+					ETag:         objectETag(&file),
+					Size:         file.FileSize,
+					StorageClass: "STANDARD",
+				}
+				if !v2 || query.Get("fetch-owner") == "true" {
+					object.Owner = owner
+				}
+				result.Contents = append(result.Contents, object)
+			}
+			last = entry
+			count++
+		}
+		if len(files) < s3MaxKeys {
+			break
+		}
+	}
+
+	if result.IsTruncated {
+		if v2 {
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+		} else if delimiter != "" {
+			result.NextMarker = s3EncodeKey(last, encodingType)
+		}
+	}
+	if v2 {
+		result.KeyCount = &count
+		result.Prefix = s3EncodeKey(prefix, encodingType)
+	}
+	s3.WriteXML(c, http.StatusOK, result)
+}
+
+// s3EncodeKey encoding-type=url 时对返回的对象键进行 URL 编码
+func s3EncodeKey(key, encodingType string) string {
+	if encodingType != "url" {
+		return key
+	}
+	return strings.ReplaceAll(url.QueryEscape(key), "+", "%20")
+}
+
+// objectETag 对象的 ETag：已知 MD5 时使用 MD5，否则使用由记录生成的不透明值
+// 不透明值包含 "-"，客户端不会将其当作内容 MD5 校验
+func objectETag(file *models.OSSFile) string {
+	if file.MD5Status == models.MD5StatusCompleted && file.MD5 != "" {
+		return `"` + file.MD5 + `"`
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("%d:%s:%d", file.ID, file.ObjectKey, file.FileSize)))
+	return `"` + hex.EncodeToString(sum[:]) + `-1"`
+}
+
+// setObjectHeaders 设置对象元数据响应头
+func setObjectHeaders(c *gin.Context, file *models.OSSFile) {
+	contentType := mime.TypeByExtension(filepath.Ext(file.ObjectKey))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("ETag", objectETag(file))
+	c.Header("Last-Modified", file.UpdatedAt.UTC().Format(http.TimeFormat))
+	c.Header("Accept-Ranges", "bytes")
+}
+
+// headObject HeadObject：根据文件记录返回对象元数据
+func (h *S3Handler) headObject(c *gin.Context, req *s3Request) {
+	file, err := h.findObject(c, req)
+	if err != nil {
+		s3.WriteError(c, s3.ErrNoSuchKey)
+		return
+	}
+	setObjectHeaders(c, file)
+	c.Header("Content-Length", strconv.FormatInt(file.FileSize, 10))
+	c.Status(http.StatusOK)
+}
+
+// getObject GetObject：通过预签名URL从存储服务读取对象并转发，支持 Range 和条件请求
+func (h *S3Handler) getObject(c *gin.Context, req *s3Request) {
+	file, err := h.findObject(c, req)
+	if err != nil {
+		s3.WriteError(c, s3.ErrNoSuchKey)
+		return
+	}
+	storage, err := h.files.storageForFile(c, file)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("获取存储服务失败"))
+		return
+	}
+	downloadURL, _, err := storage.GenerateDownloadURLFromBucket(file.ObjectKey, req.region, file.Bucket, s3DownloadURLTTL)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("生成下载链接失败"))
+		return
+	}
+
+	upstream, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, downloadURL, nil)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal)
+		return
+	}
+	for _, name := range []string{"Range", "If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+		if value := c.GetHeader(name); value != "" {
+			upstream.Header.Set(name, value)
+		}
+	}
+	resp, err := http.DefaultClient.Do(upstream)
+	if err != nil {
+		logger.Error("S3 读取对象失败", zap.String("object_key", file.ObjectKey), zap.Error(err))
+		s3.WriteError(c, s3.ErrInternal.WithMessage("读取对象失败"))
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+	case http.StatusNotModified, http.StatusPreconditionFailed:
+		c.Status(resp.StatusCode)
+		return
+	case http.StatusRequestedRangeNotSatisfiable:
+		s3.WriteError(c, &s3.Error{Code: "InvalidRange", Status: resp.StatusCode, Message: "请求的范围无效"})
+		return
+	case http.StatusNotFound:
+		s3.WriteError(c, s3.ErrNoSuchKey)
+		return
+	default:
+		s3.WriteError(c, s3.ErrInternal.WithMessage(fmt.Sprintf("读取对象失败，状态码: %d", resp.StatusCode)))
+		return
+	}
+
+	setObjectHeaders(c, file)
+	for _, name := range []string{"Content-Length", "Content-Range"} {
+		if value := resp.Header.Get(name); value != "" {
+			c.Header(name, value)
+		}
+	}
+	c.Status(resp.StatusCode)
+	if _, err := io.Copy(c.Writer, resp.Body); err != nil {
+		logger.Warn("S3 转发对象数据中断", zap.String("object_key", file.ObjectKey), zap.Error(err))
+		return
+	}
+	h.audit(c, req, "S3_GET_OBJECT", "SUCCESS", nil)
+}
+
+// s3Body 请求体读取器：解码 aws-chunked、统计长度并计算摘要，读完后校验 x-amz-content-sha256
+type s3Body struct {
+	reader   io.Reader
+	md5      hash.Hash
+	sha256   hash.Hash
+	expected string // x-amz-content-sha256 为十六进制摘要时的期望值
+	n        int64
+}
+
+func (b *s3Body) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// newS3Body 根据签名方式包装请求体，返回对象大小
+func newS3Body(c *gin.Context) (*s3Body, int64, *s3.Error) {
+	var sig *s3.Signature
+	if v, ok := c.Get(s3.SignatureContextKey); ok {
+		sig, _ = v.(*s3.Signature)
+	}
+
+	body := &s3Body{md5: md5.New()}
+	size := c.Request.ContentLength
+	var reader io.Reader = c.Request.Body
+	if sig != nil && sig.IsStreaming() {
+		decoded, err := strconv.ParseInt(c.GetHeader("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil || decoded < 0 {
+			return nil, 0, s3.ErrMissingContentLength.WithMessage("请提供 X-Amz-Decoded-Content-Length")
+		}
+		size = decoded
+		reader = s3.NewChunkedReader(c.Request.Body, sig)
+	} else if sig != nil && sig.PayloadHash != s3.UnsignedPayload {
+		body.expected = strings.ToLower(sig.PayloadHash)
+		body.sha256 = sha256.New()
+	}
+	if size < 0 {
+		return nil, 0, s3.ErrMissingContentLength
+	}
+
+	writers := []io.Writer{body.md5}
+	if body.sha256 != nil {
+		writers = append(writers, body.sha256)
+	}
+	body.reader = io.TeeReader(io.LimitReader(reader, size), io.MultiWriter(writers...))
+	return body, size, nil
+}
+
+// verify 数据读完后校验长度、x-amz-content-sha256 和 Content-MD5，返回内容 MD5（十六进制）
+func (b *s3Body) verify(c *gin.Context, size int64) (string, *s3.Error) {
+	if b.n != size {
+		return "", s3.ErrIncompleteBody
+	}
+	if b.sha256 != nil && hex.EncodeToString(b.sha256.Sum(nil)) != b.expected {
+		return "", s3.ErrContentSHA256Mismatch
+	}
+	sum := b.md5.Sum(nil)
+	if contentMD5 := c.GetHeader("Content-MD5"); contentMD5 != "" {
+		expected, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil || !bytes.Equal(expected, sum) {
+			return "", s3.ErrBadDigest
+		}
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// bodyError 将读取请求体的错误转换为 S3 错误
+func bodyError(err error) *s3.Error {
+	if errors.Is(err, s3.ErrChunkSignature) {
+		return s3.ErrSignatureDoesNotMatch.WithMessage(err.Error())
+	}
+	return s3.ErrIncompleteBody
+}
+
+// checkUpload 检查存储配额和上传策略，返回用于按文件头校验类型的策略
+func (h *S3Handler) checkUpload(c *gin.Context, req *s3Request, size int64) (*models.BucketUploadPolicy, *s3.Error) {
+	if h.files.quotaManager != nil {
+		if err := h.files.quotaManager.Check(req.userID, req.bucket, size); err != nil {
+			if errors.Is(err, quota.ErrQuotaExceeded) {
+				return nil, s3.ErrQuotaExceeded.WithMessage(err.Error())
+			}
+			logger.Error("检查存储配额失败", zap.Uint("user_id", req.userID), zap.String("bucket", req.bucket), zap.Error(err))
+			return nil, s3.ErrInternal.WithMessage("检查存储配额失败")
+		}
+	}
+
+	uploadPolicy, err := policy.Load(h.DB.WithContext(c), req.bucket)
+	if err != nil {
+		logger.Error("获取存储桶上传策略失败", zap.String("bucket", req.bucket), zap.Error(err))
+		return nil, s3.ErrInternal.WithMessage("获取存储桶上传策略失败")
+	}
+	if err := policy.CheckFile(uploadPolicy, req.key, size); err != nil {
+		return nil, policyError(err)
+	}
+	return uploadPolicy, nil
+}
+
+// policyError 将上传策略检查错误转换为 S3 错误
+func policyError(err error) *s3.Error {
+	if errors.Is(err, policy.ErrViolation) {
+		return s3.ErrAccessDenied.WithMessage(err.Error())
+	}
+	logger.Error("检查存储桶上传策略失败", zap.Error(err))
+	return s3.ErrInternal.WithMessage("检查存储桶上传策略失败")
+}
+
+// putObject PutObject：上传对象并创建文件记录，覆盖前保留历史版本
+func (h *S3Handler) putObject(c *gin.Context, req *s3Request) {
+	body, size, s3Err := newS3Body(c)
+	if s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+	uploadPolicy, s3Err := h.checkUpload(c, req, size)
+	if s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+	config, storage, s3Err := h.defaultStorage(c)
+	if s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+
+	var reader io.Reader = body
+	if policy.NeedsContent(uploadPolicy) {
+		br := bufio.NewReaderSize(body, policy.SniffLen)
+		head, err := br.Peek(policy.SniffLen)
+		if err != nil && err != io.EOF {
+			s3.WriteError(c, bodyError(err))
+			return
+		}
+		if err := policy.CheckContent(uploadPolicy, head); err != nil {
+			s3.WriteError(c, policyError(err))
+			return
+		}
+		reader = br
+	}
+
+	pending, err := h.files.preserveCurrentVersion(c, storage, req.key, req.region, req.bucket)
+	if err != nil {
+		logger.Error("保留历史版本失败", zap.String("object_key", req.key), zap.Error(err))
+		s3.WriteError(c, s3.ErrInternal.WithMessage("保留历史版本失败"))
+		return
+	}
+
+	uploadURL, err := storage.UploadToBucket(reader, req.key, req.region, req.bucket)
+	if err != nil {
+		pending.discard()
+		logger.Error("S3 上传对象失败", zap.String("object_key", req.key), zap.Error(err))
+		h.audit(c, req, "S3_PUT_OBJECT", "FAILED", map[string]interface{}{"reason": err.Error()})
+		if body.n < size {
+			s3.WriteError(c, bodyError(err))
+			return
+		}
+		s3.WriteError(c, s3.ErrInternal.WithMessage("上传对象失败"))
+		return
+	}
+
+	md5Hex, s3Err := body.verify(c, size)
+	if s3Err != nil {
+		h.rollbackPut(c, storage, req, pending)
+		h.audit(c, req, "S3_PUT_OBJECT", "FAILED", map[string]interface{}{"reason": s3Err.Code})
+		s3.WriteError(c, s3Err)
+		return
+	}
+
+	file, err := h.files.createFileRecord(c, config, req.key, path.Base(req.key), size, req.bucket, uploadURL, req.userID)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage(err.Error()))
+		return
+	}
+	if err := h.DB.WithContext(c).Model(file).Updates(map[string]interface{}{
+		"md5":        md5Hex,
+		"md5_status": models.MD5StatusCompleted,
+	}).Error; err != nil {
+		logger.Warn("保存文件MD5失败", zap.Uint("file_id", file.ID), zap.Error(err))
+	}
+
+	h.audit(c, req, "S3_PUT_OBJECT", "SUCCESS", map[string]interface{}{"file_id": file.ID, "size": size})
+	c.Header("ETag", `"`+md5Hex+`"`)
+	c.Status(http.StatusOK)
+}
+
+// rollbackPut 上传后校验失败时撤销写入：有本次保留的历史版本则恢复，否则删除对象
+func (h *S3Handler) rollbackPut(c *gin.Context, storage oss.StorageService, req *s3Request, pending *pendingVersion) {
+	if pending != nil {
+		if err := pending.restore(); err != nil {
+			logger.Error("恢复被覆盖的对象失败", zap.String("object_key", req.key), zap.Error(err))
+		}
+		return
+	}
+	if _, err := h.findObject(c, req); errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := storage.DeleteObjectFromBucket(req.key, req.region, req.bucket); err != nil {
+			logger.Error("删除校验失败的对象失败", zap.String("object_key", req.key), zap.Error(err))
+		}
+	}
+}
+
+// deleteObject DeleteObject：对象不存在时同样返回成功
+func (h *S3Handler) deleteObject(c *gin.Context, req *s3Request) {
+	if s3Err := h.removeObject(c, req); s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+	h.audit(c, req, "S3_DELETE_OBJECT", "SUCCESS", nil)
+	c.Status(http.StatusNoContent)
+}
+
+// removeObject 删除对象键当前的文件记录，启用回收站时移入回收站
+func (h *S3Handler) removeObject(c *gin.Context, req *s3Request) *s3.Error {
+	file, err := h.findObject(c, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return s3.ErrInternal
+	}
+	if err := h.files.removeFile(c, file, req.region, req.userID); err != nil {
+		logger.Error("S3 删除对象失败", zap.String("object_key", req.key), zap.Error(err))
+		return s3.ErrInternal.WithMessage("删除对象失败")
+	}
+	return nil
+}
+
+// deleteObjects DeleteObjects：批量删除，逐个返回结果
+func (h *S3Handler) deleteObjects(c *gin.Context, req *s3Request) {
+	var body s3.DeleteObjectsRequest
+	if err := xml.NewDecoder(io.LimitReader(c.Request.Body, s3MaxDeleteBody)).Decode(&body); err != nil || len(body.Objects) > s3MaxKeys {
+		s3.WriteError(c, s3.ErrMalformedXML)
+		return
+	}
+
+	result := s3.NewDeleteResult()
+	for _, object := range body.Objects {
+		item := *req
+		item.key = object.Key
+		var s3Err *s3.Error
+		if isReservedKey(item.key) {
+			s3Err = s3.ErrAccessDenied.WithMessage("不允许访问系统保留路径")
+		} else {
+			s3Err = h.removeObject(c, &item)
+		}
+		if s3Err != nil {
+			result.Errors = append(result.Errors, s3.DeleteError{Key: object.Key, Code: s3Err.Code, Message: s3Err.Message})
+			continue
+		}
+		if !body.Quiet {
+			result.Deleted = append(result.Deleted, s3.DeletedObject{Key: object.Key})
+		}
+	}
+
+	h.audit(c, req, "S3_DELETE_OBJECTS", "SUCCESS", map[string]interface{}{
+		"count":  len(body.Objects),
+		"failed": len(result.Errors),
+	})
+	s3.WriteXML(c, http.StatusOK, result)
+}
+
+// audit 异步记录 S3 操作审计日志
+func (h *S3Handler) audit(c *gin.Context, req *s3Request, action, status string, extra map[string]interface{}) {
+	recordObjectAudit(h.DB, c, "s3_object", action, status, req.bucket, req.key, extra)
+}
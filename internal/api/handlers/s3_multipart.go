@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/s3"
+	"go.uber.org/zap"
+)
+
+const (
+	// s3MaxParts 分片编号上限
+	s3MaxParts = 10000
+	// s3MaxCompleteBody CompleteMultipartUpload 请求体上限
+	s3MaxCompleteBody = 4 << 20
+)
+
+// createMultipartUpload CreateMultipartUpload：在存储服务初始化分片上传并登记上传会话，以会话ID作为 UploadId
+func (h *S3Handler) createMultipartUpload(c *gin.Context, req *s3Request) {
+	if h.files.sessionManager == nil {
+		s3.WriteError(c, s3.ErrNotImplemented.WithMessage("未启用上传会话"))
+		return
+	}
+	// 总大小在完成时才知道，此处只检查路径和扩展名
+	if _, s3Err := h.checkUpload(c, req, 0); s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+	config, storage, s3Err := h.defaultStorage(c)
+	if s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+
+	uploadID, _, err := storage.InitMultipartUploadToBucket(req.key, req.region, req.bucket)
+	if err != nil {
+		logger.Error("S3 初始化分片上传失败", zap.String("object_key", req.key), zap.Error(err))
+		s3.WriteError(c, s3.ErrInternal.WithMessage("初始化分片上传失败"))
+		return
+	}
+
+	session := &models.UploadSession{
+		OwnerID:          req.userID,
+		Mode:             models.UploadSessionModeS3,
+		StorageType:      config.StorageType,
+		RegionCode:       req.region,
+		BucketName:       req.bucket,
+		ObjectKey:        req.key,
+		OriginalFilename: path.Base(req.key),
+		UploadID:         uploadID,
+	}
+	if err := h.files.sessionManager.Create(session); err != nil {
+		logger.Error("创建上传会话失败", zap.String("object_key", req.key), zap.Error(err))
+		h.files.safeAbortMultipartUpload(storage, uploadID, req.key, req.region, req.bucket)
+		s3.WriteError(c, s3.ErrInternal.WithMessage("创建上传会话失败"))
+		return
+	}
+
+	s3.WriteXML(c, http.StatusOK, s3.NewInitiateMultipartUploadResult(req.bucket, req.key, session.SessionID))
+}
+
+// loadS3Session 按 UploadId 加载当前用户的 S3 上传会话，失败时已写入响应
+func (h *S3Handler) loadS3Session(c *gin.Context, req *s3Request, uploadID string) (*models.UploadSession, oss.StorageService, bool) {
+	if h.files.sessionManager == nil {
+		s3.WriteError(c, s3.ErrNoSuchUpload)
+		return nil, nil, false
+	}
+	session, err := h.files.sessionManager.Get(uploadID, req.userID)
+	if err != nil || session.Mode != models.UploadSessionModeS3 ||
+		session.BucketName != req.bucket || session.ObjectKey != req.key {
+		s3.WriteError(c, s3.ErrNoSuchUpload)
+		return nil, nil, false
+	}
+	storage, err := h.files.storageFactory.GetStorageService(session.StorageType)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("获取存储服务失败"))
+		return nil, nil, false
+	}
+	return session, storage, true
+}
+
+// uploadPart UploadPart：将分片数据流式转发到存储服务的分片预签名URL
+func (h *S3Handler) uploadPart(c *gin.Context, req *s3Request, uploadID, rawPartNumber string) {
+	partNumber, err := strconv.Atoi(rawPartNumber)
+	if err != nil || partNumber < 1 || partNumber > s3MaxParts {
+		s3.WriteError(c, s3.ErrInvalidArgument.WithMessage("partNumber 必须在 1 到 10000 之间"))
+		return
+	}
+	session, storage, ok := h.loadS3Session(c, req, uploadID)
+	if !ok {
+		return
+	}
+	body, size, s3Err := newS3Body(c)
+	if s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+	if _, s3Err := h.checkUpload(c, req, session.UploadedBytes+size); s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+
+	partURL, err := storage.GeneratePartUploadURL(session.ObjectKey, session.UploadID, partNumber, session.RegionCode, session.BucketName)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("生成分片上传链接失败"))
+		return
+	}
+	etag, err := putPart(c.Request.Context(), partURL, body, size)
+	if err != nil {
+		logger.Error("S3 上传分片失败",
+			zap.String("session_id", session.SessionID),
+			zap.Int("part_number", partNumber),
+			zap.Error(err))
+		if body.n < size {
+			s3.WriteError(c, bodyError(err))
+			return
+		}
+		s3.WriteError(c, s3.ErrInternal.WithMessage("上传分片失败"))
+		return
+	}
+	// 校验失败的分片已写入存储服务，客户端重传同一分片编号即可覆盖
+	if _, s3Err := body.verify(c, size); s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+
+	h.files.sessionManager.PartDone(session, size)
+	c.Header("ETag", `"`+etag+`"`)
+	c.Status(http.StatusOK)
+}
+
+// putPart 以流式请求体上传单个分片，返回去掉引号的 ETag
+func putPart(ctx context.Context, partURL string, body io.Reader, size int64) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, partURL, body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("上传分片失败，状态码: %d, 响应: %s", resp.StatusCode, string(data))
+	}
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if etag == "" {
+		return "", errors.New("无法获取分片ETag")
+	}
+	return etag, nil
+}
+
+// listParts ListParts：返回存储服务中已上传的分片
+func (h *S3Handler) listParts(c *gin.Context, req *s3Request, uploadID string) {
+	session, storage, ok := h.loadS3Session(c, req, uploadID)
+	if !ok {
+		return
+	}
+	parts, err := storage.ListUploadedPartsToBucket(session.ObjectKey, session.UploadID, session.RegionCode, session.BucketName)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("获取已上传分片失败"))
+		return
+	}
+
+	infos := make([]s3.PartInfo, 0, len(parts))
+	for _, p := range parts {
+		infos = append(infos, s3.PartInfo{PartNumber: p.PartNumber, ETag: `"` + p.ETag + `"`, Size: p.Size})
+	}
+	s3.WriteXML(c, http.StatusOK, s3.NewListPartsResult(req.bucket, req.key, uploadID, infos))
+}
+
+// completeMultipartUpload CompleteMultipartUpload：核对客户端提交的分片并合并，创建文件记录
+func (h *S3Handler) completeMultipartUpload(c *gin.Context, req *s3Request, uploadID string) {
+	session, storage, ok := h.loadS3Session(c, req, uploadID)
+	if !ok {
+		return
+	}
+	var body s3.CompleteMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(c.Request.Body, s3MaxCompleteBody)).Decode(&body); err != nil || len(body.Parts) == 0 {
+		s3.WriteError(c, s3.ErrMalformedXML)
+		return
+	}
+
+	uploaded, err := storage.ListUploadedPartsToBucket(session.ObjectKey, session.UploadID, session.RegionCode, session.BucketName)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("获取已上传分片失败"))
+		return
+	}
+	byNumber := make(map[int]oss.Part, len(uploaded))
+	for _, p := range uploaded {
+		byNumber[p.PartNumber] = p
+	}
+
+	parts := make([]oss.Part, 0, len(body.Parts))
+	var totalSize int64
+	for i, requested := range body.Parts {
+		if i > 0 && requested.PartNumber <= body.Parts[i-1].PartNumber {
+			s3.WriteError(c, s3.ErrInvalidPartOrder)
+			return
+		}
+		part, exists := byNumber[requested.PartNumber]
+		if !exists || !strings.EqualFold(strings.Trim(requested.ETag, `"`), part.ETag) {
+			s3.WriteError(c, s3.ErrInvalidPart.WithMessage(fmt.Sprintf("分片 %d 不存在或 ETag 不匹配", requested.PartNumber)))
+			return
+		}
+		parts = append(parts, part)
+		totalSize += part.Size
+	}
+
+	if _, s3Err := h.checkUpload(c, req, totalSize); s3Err != nil {
+		s3.WriteError(c, s3Err)
+		return
+	}
+	var config models.OSSConfig
+	if err := h.DB.WithContext(c).Where("is_default = ?", true).First(&config).Error; err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage("获取默认存储配置失败"))
+		return
+	}
+
+	pending, err := h.files.preserveCurrentVersion(c, storage, session.ObjectKey, session.RegionCode, session.BucketName)
+	if err != nil {
+		logger.Error("保留历史版本失败", zap.String("object_key", session.ObjectKey), zap.Error(err))
+		s3.WriteError(c, s3.ErrInternal.WithMessage("保留历史版本失败"))
+		return
+	}
+	uploadURL, err := storage.CompleteMultipartUploadToBucket(session.ObjectKey, session.UploadID, parts, session.RegionCode, session.BucketName)
+	if err != nil {
+		pending.discard()
+		logger.Error("S3 完成分片上传失败", zap.String("session_id", session.SessionID), zap.Error(err))
+		h.files.sessionManager.Fail(session, err.Error())
+		h.audit(c, req, "S3_COMPLETE_MULTIPART", "FAILED", map[string]interface{}{"reason": err.Error()})
+		s3.WriteError(c, s3.ErrInternal.WithMessage("完成分片上传失败"))
+		return
+	}
+
+	file, err := h.files.createFileRecord(c, config, session.ObjectKey, session.OriginalFilename, totalSize, session.BucketName, uploadURL, req.userID)
+	if err != nil {
+		s3.WriteError(c, s3.ErrInternal.WithMessage(err.Error()))
+		return
+	}
+	h.files.sessionManager.Complete(session)
+
+	h.audit(c, req, "S3_COMPLETE_MULTIPART", "SUCCESS", map[string]interface{}{
+		"file_id": file.ID,
+		"size":    totalSize,
+		"parts":   len(parts),
+	})
+	s3.WriteXML(c, http.StatusOK, s3.NewCompleteMultipartUploadResult(
+		c.Request.URL.Path, req.bucket, req.key, multipartETag(parts)))
+}
+
+// multipartETag 按 S3 约定计算分片对象的 ETag：各分片 MD5 拼接后再取 MD5，并附加分片数
+func multipartETag(parts []oss.Part) string {
+	hasher := md5.New()
+	for _, p := range parts {
+		if sum, err := hex.DecodeString(p.ETag); err == nil {
+			hasher.Write(sum)
+		} else {
+			hasher.Write([]byte(p.ETag))
+		}
+	}
+	return fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(hasher.Sum(nil)), len(parts))
+}
+
+// abortMultipartUpload AbortMultipartUpload：中止存储服务的分片上传并关闭会话
+func (h *S3Handler) abortMultipartUpload(c *gin.Context, req *s3Request, uploadID string) {
+	session, _, ok := h.loadS3Session(c, req, uploadID)
+	if !ok {
+		return
+	}
+	if err := h.files.sessionManager.Abort(session, "客户端中止"); err != nil {
+		logger.Error("S3 中止分片上传失败", zap.String("session_id", session.SessionID), zap.Error(err))
+		s3.WriteError(c, s3.ErrInternal.WithMessage("中止分片上传失败"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// shareURLExpiration 每次访问分享链接时签发的下载链接有效期
+const shareURLExpiration = 5 * time.Minute
+
+// 分享密码错误次数限制：同一 IP 对同一链接、以及同一链接总计在窗口期内的失败次数上限
+const (
+	sharePasswordWindow     = 15 * time.Minute
+	sharePasswordMaxPerIP   = 5
+	sharePasswordMaxPerLink = 20
+)
+
+// passwordAttempt 窗口期内的密码失败记录
+type passwordAttempt struct {
+	count int
+	since time.Time
+}
+
+// passwordLimiter 按分享令牌和 IP 统计密码失败次数，防止暴力破解分享密码
+type passwordLimiter struct {
+	mu       sync.Mutex
+	attempts map[string]*passwordAttempt
+}
+
+func newPasswordLimiter() *passwordLimiter {
+	return &passwordLimiter{attempts: make(map[string]*passwordAttempt)}
+}
+
+// count 返回窗口期内的失败次数，过期记录顺带清理
+func (l *passwordLimiter) count(key string, now time.Time) int {
+	a, ok := l.attempts[key]
+	if !ok {
+		return 0
+	}
+	if now.Sub(a.since) >= sharePasswordWindow {
+		delete(l.attempts, key)
+		return 0
+	}
+	return a.count
+}
+
+// Locked 判断该 IP 对该链接的密码校验是否已被锁定
+func (l *passwordLimiter) Locked(token, ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	return l.count(token+"|"+ip, now) >= sharePasswordMaxPerIP || l.count(token, now) >= sharePasswordMaxPerLink
+}
+
+// Fail 记录一次密码错误
+func (l *passwordLimiter) Fail(token, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	// 记录过多时清理已过期的条目，避免内存持续增长
+	if len(l.attempts) > 10000 {
+		for key := range l.attempts {
+			l.count(key, now)
+		}
+	}
+	for _, key := range []string{token + "|" + ip, token} {
+		if l.count(key, now) == 0 {
+			l.attempts[key] = &passwordAttempt{since: now}
+		}
+		l.attempts[key].count++
+	}
+}
+
+// Reset 密码校验通过后清除该 IP 的失败记录
+func (l *passwordLimiter) Reset(token, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, token+"|"+ip)
+}
+
+// ShareLinkHandler 分享链接处理器
+type ShareLinkHandler struct {
+	*BaseHandler
+	storageFactory oss.StorageFactory
+	DB             *gorm.DB
+	passwords      *passwordLimiter
+}
+
+// NewShareLinkHandler 创建分享链接处理器
+func NewShareLinkHandler(storageFactory oss.StorageFactory, db *gorm.DB) *ShareLinkHandler {
+	return &ShareLinkHandler{
+		BaseHandler:    NewBaseHandler(),
+		storageFactory: storageFactory,
+		DB:             db,
+		passwords:      newPasswordLimiter(),
+	}
+}
+
+// CreateShareLinkRequest 创建分享链接请求
+type CreateShareLinkRequest struct {
+	Password     string `json:"password"`
+	ExpireHours  int    `json:"expire_hours" binding:"min=0"`  // 0 表示永不过期
+	MaxDownloads int    `json:"max_downloads" binding:"min=0"` // 0 表示不限制
+}
+
+// AccessShareLinkRequest 访问分享链接请求
+type AccessShareLinkRequest struct {
+	Password string `json:"password"`
+}
+
+// newShareToken 生成随机分享令牌
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Create 为文件创建分享链接
+func (h *ShareLinkHandler) Create(c *gin.Context) {
+	var req CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Error(c, utils.CodeInvalidParams, "无效的请求参数")
+		return
+	}
+
+	var file models.OSSFile
+	if err := h.DB.WithContext(c).Where("status = ?", "ACTIVE").First(&file, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeFileNotFound, "文件不存在")
+		return
+	}
+
+	var mapping models.RegionBucketMapping
+	if err := h.DB.WithContext(c).Where("bucket_name = ?", file.Bucket).First(&mapping).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储桶区域信息失败")
+		return
+	}
+
+	userID := c.GetUint("userID")
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), userID, mapping.RegionCode, file.Bucket) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "生成分享令牌失败")
+		return
+	}
+
+	link := models.ShareLink{
+		Token:        token,
+		FileID:       file.ID,
+		CreatorID:    userID,
+		MaxDownloads: req.MaxDownloads,
+	}
+	if req.ExpireHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpireHours) * time.Hour)
+		link.ExpiresAt = &expiresAt
+	}
+	if err := link.SetPassword(req.Password); err != nil {
+		h.Error(c, utils.CodeServerError, "设置分享密码失败")
+		return
+	}
+
+	if err := h.DB.WithContext(c).Create(&link).Error; err != nil {
+		logger.Error("创建分享链接失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "创建分享链接失败")
+		return
+	}
+
+	h.Success(c, h.toResponse(link))
+}
+
+// List 获取当前用户创建的分享链接
+func (h *ShareLinkHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.DB.WithContext(c).Model(&models.ShareLink{}).Where("creator_id = ?", c.GetUint("userID"))
+	if fileID := c.Query("file_id"); fileID != "" {
+		query = query.Where("file_id = ?", fileID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取分享链接总数失败")
+		return
+	}
+
+	var links []models.ShareLink
+	if err := query.Preload("File").
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&links).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取分享链接列表失败")
+		return
+	}
+
+	items := make([]gin.H, 0, len(links))
+	for _, link := range links {
+		items = append(items, h.toResponse(link))
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": items,
+	})
+}
+
+// Revoke 撤销分享链接，仅创建者可操作
+func (h *ShareLinkHandler) Revoke(c *gin.Context) {
+	var link models.ShareLink
+	if err := h.DB.WithContext(c).Where("creator_id = ?", c.GetUint("userID")).First(&link, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeNotFound, "分享链接不存在")
+		return
+	}
+
+	if link.RevokedAt == nil {
+		now := time.Now()
+		if err := h.DB.WithContext(c).Model(&link).Update("revoked_at", &now).Error; err != nil {
+			h.Error(c, utils.CodeServerError, "撤销分享链接失败")
+			return
+		}
+	}
+
+	h.Success(c, nil)
+}
+
+// GetPublic 获取分享链接信息（公开访问）
+func (h *ShareLinkHandler) GetPublic(c *gin.Context) {
+	link, ok := h.getAvailableLink(c, "SHARE_VIEW")
+	if !ok {
+		return
+	}
+
+	h.recordAccess(c, link, "SHARE_VIEW", "SUCCESS", "")
+	h.Success(c, gin.H{
+		"file_name":         link.File.OriginalFilename,
+		"file_size":         link.File.FileSize,
+		"password_required": link.HasPassword(),
+		"expires_at":        link.ExpiresAt,
+		"remaining":         remainingDownloads(*link),
+	})
+}
+
+// Download 校验密码并签发新的短期下载链接（公开访问）
+func (h *ShareLinkHandler) Download(c *gin.Context) {
+	link, ok := h.getAvailableLink(c, "SHARE_DOWNLOAD")
+	if !ok {
+		return
+	}
+
+	var req AccessShareLinkRequest
+	_ = c.ShouldBindJSON(&req)
+	if link.HasPassword() {
+		if h.passwords.Locked(link.Token, c.ClientIP()) {
+			h.recordAccess(c, link, "SHARE_DOWNLOAD", "FAILED", "密码错误次数过多")
+			h.Error(c, utils.CodeShareLocked, "分享密码错误次数过多，请稍后再试")
+			return
+		}
+		if !link.CheckPassword(req.Password) {
+			h.passwords.Fail(link.Token, c.ClientIP())
+			h.recordAccess(c, link, "SHARE_DOWNLOAD", "FAILED", "密码错误")
+			h.Error(c, utils.CodeSharePasswordFail, "分享密码错误")
+			return
+		}
+		h.passwords.Reset(link.Token, c.ClientIP())
+	}
+
+	// 先签发下载链接，签发失败不消耗下载次数
+	downloadURL, expires, err := h.signDownloadURL(c, link.File)
+	if err != nil {
+		logger.Error("生成分享下载链接失败", zap.Uint("share_id", link.ID), zap.Error(err))
+		h.recordAccess(c, link, "SHARE_DOWNLOAD", "FAILED", "生成下载链接失败")
+		h.Error(c, utils.CodeServerError, "生成下载链接失败")
+		return
+	}
+
+	// 原子递增下载次数，防止并发访问超出上限
+	now := time.Now()
+	result := h.DB.WithContext(c).Model(&models.ShareLink{}).
+		Where("id = ? AND (max_downloads = 0 OR download_count < max_downloads)", link.ID).
+		Updates(map[string]interface{}{
+			"download_count":   gorm.Expr("download_count + 1"),
+			"last_accessed_at": now,
+		})
+	if result.Error != nil {
+		h.Error(c, utils.CodeServerError, "更新下载次数失败")
+		return
+	}
+	if result.RowsAffected == 0 {
+		h.recordAccess(c, link, "SHARE_DOWNLOAD", "FAILED", "下载次数已用完")
+		h.Error(c, utils.CodeShareLinkInvalid, "分享链接下载次数已用完")
+		return
+	}
+
+	h.recordAccess(c, link, "SHARE_DOWNLOAD", "SUCCESS", "")
+	h.Success(c, gin.H{
+		"file_name":    link.File.OriginalFilename,
+		"download_url": downloadURL,
+		"expires":      expires,
+	})
+}
+
+// getAvailableLink 根据令牌获取可用的分享链接，失败时已写入响应并记录审计
+func (h *ShareLinkHandler) getAvailableLink(c *gin.Context, action string) (*models.ShareLink, bool) {
+	var link models.ShareLink
+	if err := h.DB.WithContext(c).Preload("File").Where("token = ?", c.Param("token")).First(&link).Error; err != nil {
+		h.Error(c, utils.CodeShareLinkInvalid, "分享链接不存在或已失效")
+		return nil, false
+	}
+
+	if !link.Available(time.Now()) || link.File == nil || link.File.Status != "ACTIVE" {
+		h.recordAccess(c, &link, action, "FAILED", "链接已失效")
+		h.Error(c, utils.CodeShareLinkInvalid, "分享链接不存在或已失效")
+		return nil, false
+	}
+
+	return &link, true
+}
+
+// signDownloadURL 为分享的文件签发短期下载链接
+// 公开访问没有组织范围，存储桶映射和存储配置按文件所在的组织查询
+func (h *ShareLinkHandler) signDownloadURL(ctx context.Context, file *models.OSSFile) (string, time.Time, error) {
+	ctx = tenant.WithScope(ctx, &tenant.Scope{OrgID: file.OrgID, UserOrgID: file.OrgID})
+	var mapping models.RegionBucketMapping
+	if err := h.DB.WithContext(ctx).Where("bucket_name = ?", file.Bucket).First(&mapping).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	var config models.OSSConfig
+	if err := h.DB.WithContext(ctx).First(&config, file.ConfigID).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	storage, err := h.storageFactory.GetConfigStorageService(&config)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return storage.GenerateDownloadURLFromBucket(file.ObjectKey, mapping.RegionCode, file.Bucket, shareURLExpiration)
+}
+
+// recordAccess 异步记录分享链接访问审计日志
+func (h *ShareLinkHandler) recordAccess(c *gin.Context, link *models.ShareLink, action, status, reason string) {
+	details, err := json.Marshal(map[string]interface{}{
+		"token":   link.Token,
+		"file_id": link.FileID,
+		"reason":  reason,
+	})
+	if err != nil {
+		details = []byte("{}")
+	}
+
+	auditLog := &models.AuditLog{
+		OrgID:        h.shareOrgID(link),
+		UserID:       link.CreatorID,
+		Username:     "anonymous",
+		Action:       action,
+		ResourceType: "share_link",
+		ResourceID:   strconv.FormatUint(uint64(link.ID), 10),
+		Details:      string(details),
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Status:       status,
+	}
+
+	go func(log *models.AuditLog) {
+		if err := h.DB.Create(log).Error; err != nil {
+			logger.Error("保存分享访问审计日志失败", zap.Error(err), zap.String("action", log.Action))
+		}
+	}(auditLog)
+}
+
+// toResponse 转换为分享链接响应
+func (h *ShareLinkHandler) toResponse(link models.ShareLink) gin.H {
+	resp := gin.H{
+		"id":                link.ID,
+		"token":             link.Token,
+		"file_id":           link.FileID,
+		"password_required": link.HasPassword(),
+		"expires_at":        link.ExpiresAt,
+		"max_downloads":     link.MaxDownloads,
+		"download_count":    link.DownloadCount,
+		"last_accessed_at":  link.LastAccessedAt,
+		"revoked_at":        link.RevokedAt,
+		"available":         link.Available(time.Now()),
+		"created_at":        link.CreatedAt,
+	}
+	if link.File != nil {
+		resp["file_name"] = link.File.OriginalFilename
+	}
+	return resp
+}
+
+// remainingDownloads 剩余下载次数，-1 表示不限制
+func remainingDownloads(link models.ShareLink) int {
+	if link.MaxDownloads == 0 {
+		return -1
+	}
+	return link.MaxDownloads - link.DownloadCount
+}
+
+// shareOrgID 公开访问没有登录用户，审计日志归属分享文件所在的组织，文件已删除时取创建者所在的组织
+func (h *ShareLinkHandler) shareOrgID(link *models.ShareLink) uint {
+	if link.File != nil {
+		return link.File.OrgID
+	}
+	var creator models.User
+	if err := h.DB.Select("id", "org_id").First(&creator, link.CreatorID).Error; err != nil {
+		logger.Warn("查询分享链接创建者失败", zap.Uint("creator_id", link.CreatorID), zap.Error(err))
+		return models.DefaultOrgID
+	}
+	return creator.OrgID
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TrashHandler 回收站处理器
+type TrashHandler struct {
+	*BaseHandler
+	DB           *gorm.DB
+	trashManager *trash.Manager
+}
+
+// NewTrashHandler 创建回收站处理器
+func NewTrashHandler(db *gorm.DB, trashManager *trash.Manager) *TrashHandler {
+	return &TrashHandler{
+		BaseHandler:  NewBaseHandler(),
+		DB:           db,
+		trashManager: trashManager,
+	}
+}
+
+// List 获取回收站文件列表（仅包含用户可访问的存储桶）
+func (h *TrashHandler) List(c *gin.Context) {
+	buckets, err := auth.GetUserAccessibleBuckets(h.DB.WithContext(c), c.GetUint("userID"), "")
+	if err != nil {
+		h.Error(c, utils.CodeServerError, "获取可访问桶列表失败")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.DB.WithContext(c).Model(&models.OSSFile{}).
+		Where("status = ? AND bucket IN ?", trash.StatusTrashed, buckets)
+	if bucketName := c.Query("bucket_name"); bucketName != "" {
+		query = query.Where("bucket = ?", bucketName)
+	}
+	if uploaderID := c.Query("uploader_id"); uploaderID != "" {
+		query = query.Where("uploader_id = ?", uploaderID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取回收站文件总数失败")
+		return
+	}
+
+	var files []models.OSSFile
+	if err := query.Order("trashed_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&files).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取回收站文件列表失败")
+		return
+	}
+
+	retention := h.trashManager.Retention()
+	items := make([]gin.H, 0, len(files))
+	for _, f := range files {
+		item := gin.H{
+			"id":                f.ID,
+			"original_filename": f.OriginalFilename,
+			"object_key":        f.ObjectKey,
+			"bucket":            f.Bucket,
+			"file_size":         f.FileSize,
+			"uploader_id":       f.UploaderID,
+			"trashed_by":        f.TrashedBy,
+			"trashed_at":        f.TrashedAt,
+		}
+		if f.TrashedAt != nil {
+			item["purge_at"] = f.TrashedAt.Add(retention)
+		}
+		items = append(items, item)
+	}
+
+	h.Success(c, gin.H{
+		"total": total,
+		"items": items,
+	})
+}
+
+// getTrashedFile 获取回收站中的文件并检查访问权限，失败时已写入响应
+func (h *TrashHandler) getTrashedFile(c *gin.Context) (*models.OSSFile, bool) {
+	var file models.OSSFile
+	if err := h.DB.WithContext(c).Where("status = ?", trash.StatusTrashed).First(&file, c.Param("id")).Error; err != nil {
+		h.Error(c, utils.CodeFileNotFound, "回收站中不存在该文件")
+		return nil, false
+	}
+
+	var mapping models.RegionBucketMapping
+	if err := h.DB.WithContext(c).Where("bucket_name = ?", file.Bucket).First(&mapping).Error; err != nil {
+		h.Error(c, utils.CodeServerError, "获取存储桶区域信息失败")
+		return nil, false
+	}
+
+	if !auth.CheckBucketAccess(h.DB.WithContext(c), c.GetUint("userID"), mapping.RegionCode, file.Bucket) {
+		h.Error(c, utils.CodeForbidden, "没有权限访问该存储桶")
+		return nil, false
+	}
+
+	return &file, true
+}
+
+// Restore 从回收站恢复文件到原路径
+func (h *TrashHandler) Restore(c *gin.Context) {
+	file, ok := h.getTrashedFile(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashManager.Restore(file); err != nil {
+		if errors.Is(err, trash.ErrPathOccupied) {
+			h.Error(c, utils.CodeFileExists, "原路径已存在同名文件，无法恢复")
+			return
+		}
+		logger.Error("恢复回收站文件失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "恢复文件失败")
+		return
+	}
+
+	h.Success(c, file)
+}
+
+// Purge 彻底删除回收站中的单个文件
+func (h *TrashHandler) Purge(c *gin.Context) {
+	file, ok := h.getTrashedFile(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashManager.Purge(file); err != nil {
+		logger.Error("彻底删除回收站文件失败", zap.Uint("file_id", file.ID), zap.Error(err))
+		h.Error(c, utils.CodeServerError, "彻底删除文件失败")
+		return
+	}
+
+	h.Success(c, nil)
+}
+
+// Empty 按用户或存储桶清空回收站（仅管理员）
+func (h *TrashHandler) Empty(c *gin.Context) {
+	if !h.requireOrgAdmin(c) {
+		return
+	}
+	userID := c.Query("user_id")
+	bucketName := c.Query("bucket_name")
+	if userID == "" && bucketName == "" {
+		h.Error(c, utils.CodeInvalidParams, "请指定 user_id 或 bucket_name")
+		return
+	}
+
+	query := h.DB.WithContext(c)
+	if userID != "" {
+		query = query.Where("uploader_id = ?", userID)
+	}
+	if bucketName != "" {
+		query = query.Where("bucket = ?", bucketName)
+	}
+
+	purged, err := h.trashManager.PurgeWhere(query)
+	if err != nil {
+		logger.Error("清空回收站失败", zap.Error(err))
+		h.Error(c, utils.CodeServerError, "清空回收站失败")
+		return
+	}
+
+	h.Success(c, gin.H{"purged": purged})
+}
@@ -31,7 +31,7 @@ func (h *UserHandler) List(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
 	// 构建查询
-	query := db.GetDB().Model(&models.User{})
+	query := db.GetDB().WithContext(c).Model(&models.User{})
 
 	// 处理筛选条件
 	if username := c.Query("username"); username != "" {
@@ -81,6 +81,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// 用户名与邮箱全局唯一，检查时不按组织过滤
 	// 检查用户名是否已存在
 	var count int64
 	if err := db.GetDB().Model(&models.User{}).Where("username = ?", req.Username).Count(&count).Error; err != nil {
@@ -119,7 +120,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 	}
 
 	// 开启事务
-	tx := db.GetDB().Begin()
+	tx := db.GetDB().WithContext(c).Begin()
 
 	if err := tx.Create(&user).Error; err != nil {
 		tx.Rollback()
@@ -129,12 +130,17 @@ func (h *UserHandler) Create(c *gin.Context) {
 
 	// 分配角色
 	if len(req.RoleIDs) > 0 {
-		var roles []models.Role
+		var roles []*models.Role
 		if err := tx.Where("id IN ?", req.RoleIDs).Find(&roles).Error; err != nil {
 			tx.Rollback()
 			h.InternalError(c, "获取角色失败")
 			return
 		}
+		if !canGrantRoles(c, roles) {
+			tx.Rollback()
+			h.Forbidden(c, "只有全局管理员可以分配全局管理员角色")
+			return
+		}
 
 		if err := tx.Model(&user).Association("Roles").Replace(roles); err != nil {
 			tx.Rollback()
@@ -159,7 +165,7 @@ func (h *UserHandler) Get(c *gin.Context) {
 	userID := c.Param("id")
 
 	var user models.User
-	if err := db.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Preload("Roles").First(&user, userID).Error; err != nil {
 		h.NotFound(c, "用户不存在")
 		return
 	}
@@ -172,10 +178,15 @@ func (h *UserHandler) Update(c *gin.Context) {
 	userID := c.Param("id")
 
 	var user models.User
-	if err := db.GetDB().First(&user, userID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Preload("Roles").First(&user, userID).Error; err != nil {
 		h.NotFound(c, "用户不存在")
 		return
 	}
+	// 全局管理员只能由全局管理员修改
+	if !canGrantRoles(c, user.Roles) {
+		h.Forbidden(c, "只有全局管理员可以修改全局管理员账号")
+		return
+	}
 
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
@@ -201,7 +212,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 	}
 
 	// 开启事务
-	tx := db.GetDB().Begin()
+	tx := db.GetDB().WithContext(c).Begin()
 
 	// 更新基本信息
 	updates := map[string]interface{}{
@@ -220,12 +231,17 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	// 更新角色
 	if len(req.RoleIDs) > 0 {
-		var roles []models.Role
+		var roles []*models.Role
 		if err := tx.Where("id IN ?", req.RoleIDs).Find(&roles).Error; err != nil {
 			tx.Rollback()
 			h.InternalError(c, "获取角色失败")
 			return
 		}
+		if !canGrantRoles(c, roles) {
+			tx.Rollback()
+			h.Forbidden(c, "只有全局管理员可以分配全局管理员角色")
+			return
+		}
 
 		if err := tx.Model(&user).Association("Roles").Replace(roles); err != nil {
 			tx.Rollback()
@@ -243,7 +259,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 	h.createAuditLog(c, "UPDATE", "USER", userID, "更新用户信息")
 
 	// 重新获取用户信息（包含角色）
-	if err := db.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Preload("Roles").First(&user, userID).Error; err != nil {
 		h.NotFound(c, "获取更新后的用户信息失败")
 		return
 	}
@@ -256,13 +272,18 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	userID := c.Param("id")
 
 	var user models.User
-	if err := db.GetDB().First(&user, userID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Preload("Roles").First(&user, userID).Error; err != nil {
 		h.NotFound(c, "用户不存在")
 		return
 	}
+	// 全局管理员只能由全局管理员修改
+	if !canGrantRoles(c, user.Roles) {
+		h.Forbidden(c, "只有全局管理员可以修改全局管理员账号")
+		return
+	}
 
 	// 开启事务
-	tx := db.GetDB().Begin()
+	tx := db.GetDB().WithContext(c).Begin()
 
 	// 清除用户角色关联
 	if err := tx.Model(&user).Association("Roles").Clear(); err != nil {
@@ -306,7 +327,7 @@ func (h *UserHandler) createAuditLog(c *gin.Context, action, resourceType, resou
 		Status:       "SUCCESS",
 	}
 
-	if err := db.GetDB().Create(&auditLog).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Create(&auditLog).Error; err != nil {
 		logger.Error("创建审计日志失败",
 			zap.String("action", action),
 			zap.String("resource_type", resourceType),
@@ -321,7 +342,7 @@ func (h *UserHandler) GetUserBucketAccess(c *gin.Context) {
 
 	// 获取用户信息
 	var user models.User
-	if err := db.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := db.GetDB().WithContext(c).Preload("Roles").First(&user, userID).Error; err != nil {
 		h.NotFound(c, "用户不存在")
 		return
 	}
@@ -340,7 +361,7 @@ func (h *UserHandler) GetUserBucketAccess(c *gin.Context) {
 
 	// 查询用户角色关联的所有存储桶
 	var buckets []models.RegionBucketMapping
-	if err := db.GetDB().
+	if err := db.GetDB().WithContext(c).
 		Joins("JOIN role_region_bucket_access ON role_region_bucket_access.region_bucket_mapping_id = region_bucket_mapping.id").
 		Where("role_region_bucket_access.role_id IN ?", roleIDs).
 		Distinct().
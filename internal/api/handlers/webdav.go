@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/policy"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"go.uber.org/zap"
+	"golang.org/x/net/webdav"
+	"gorm.io/gorm"
+)
+
+// DavPrefix WebDAV 挂载路径
+const DavPrefix = "/dav"
+
+// DavMethods WebDAV 需要路由的请求方法
+var DavMethods = []string{
+	http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+	"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+}
+
+// DavHandler WebDAV 处理器，将用户可访问的存储桶挂载为网络驱动器
+type DavHandler struct {
+	files      *OSSFileHandler
+	DB         *gorm.DB
+	lockSystem webdav.LockSystem
+}
+
+// NewDavHandler 创建 WebDAV 处理器，复用文件处理器的上传、版本和删除逻辑
+func NewDavHandler(files *OSSFileHandler) *DavHandler {
+	return &DavHandler{
+		files:      files,
+		DB:         files.DB,
+		lockSystem: webdav.NewMemLS(),
+	}
+}
+
+// Serve 处理 WebDAV 请求，文件系统按当前用户创建
+func (h *DavHandler) Serve(c *gin.Context) {
+	fs := newDavFS(h.files, c)
+	if c.Request.Method == http.MethodPut && !h.checkPut(c, fs) {
+		return
+	}
+
+	handler := &webdav.Handler{
+		Prefix:     DavPrefix,
+		FileSystem: fs,
+		LockSystem: h.lockSystem,
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				logger.Debug("WebDAV 请求失败",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Error(err))
+			}
+		},
+	}
+	handler.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkPut 按 Content-Length 预先检查配额和上传策略，避免接收完数据才拒绝
+// 分块传输的请求无法预知大小，由写入完成时的检查兜底
+func (h *DavHandler) checkPut(c *gin.Context, fs *davFS) bool {
+	bucket, key := splitDavPath(c.Request.URL.Path[len(DavPrefix):])
+	if bucket == "" || key == "" || c.Request.ContentLength < 0 {
+		return true
+	}
+	if _, err := fs.bucketRegion(bucket); err != nil {
+		return true
+	}
+
+	_, err := fs.checkLimits(bucket, key, c.Request.ContentLength)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, quota.ErrQuotaExceeded):
+		c.String(http.StatusInsufficientStorage, err.Error())
+	case errors.Is(err, policy.ErrViolation):
+		c.String(http.StatusForbidden, err.Error())
+	default:
+		logger.Error("WebDAV 上传检查失败", zap.String("bucket", bucket), zap.String("key", key), zap.Error(err))
+		c.String(http.StatusInternalServerError, "上传检查失败")
+	}
+	c.Abort()
+	return false
+}
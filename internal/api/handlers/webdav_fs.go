@@ -0,0 +1,714 @@
+package handlers
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/policy"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
+	"go.uber.org/zap"
+	"golang.org/x/net/webdav"
+	"gorm.io/gorm"
+)
+
+const (
+	// davMaxEntries 单个目录列出的最大条目数
+	davMaxEntries = 10000
+	// davDownloadURLTTL 读取对象时使用的预签名URL有效期
+	davDownloadURLTTL = time.Hour
+)
+
+// davFS 将用户可访问的存储桶映射为 WebDAV 文件系统：根目录列出存储桶，目录对应对象键前缀
+// 每个请求创建一个实例，绑定当前用户和请求上下文
+type davFS struct {
+	h       *OSSFileHandler
+	c       *gin.Context
+	userID  uint
+	regions map[string]string      // 可访问的存储桶 -> 区域，首次使用时加载
+	stats   map[string]os.FileInfo // Readdir 结果缓存，PROPFIND 会逐个 Stat 子项
+}
+
+func newDavFS(h *OSSFileHandler, c *gin.Context) *davFS {
+	return &davFS{
+		h:      h,
+		c:      c,
+		userID: c.GetUint("userID"),
+		stats:  make(map[string]os.FileInfo),
+	}
+}
+
+// splitDavPath 将 /bucket/a/b 拆分为存储桶和对象键
+func splitDavPath(name string) (string, string) {
+	bucket, key, _ := strings.Cut(strings.Trim(path.Clean("/"+name), "/"), "/")
+	return bucket, key
+}
+
+// isReservedKey 是否为历史版本和回收站使用的保留路径，这些对象不通过 WebDAV 暴露，也不允许直接写入
+func isReservedKey(key string) bool {
+	return strings.HasPrefix(key+"/", versionPrefix) || strings.HasPrefix(key+"/", trash.KeyPrefix)
+}
+
+// accessibleBuckets 加载用户可访问的存储桶及其区域
+func (fs *davFS) accessibleBuckets() (map[string]string, error) {
+	if fs.regions != nil {
+		return fs.regions, nil
+	}
+	names, err := auth.GetUserAccessibleBuckets(fs.h.DB.WithContext(fs.c), fs.userID, "")
+	if err != nil {
+		return nil, err
+	}
+	regions := make(map[string]string, len(names))
+	if len(names) > 0 {
+		var mappings []models.RegionBucketMapping
+		if err := fs.h.DB.WithContext(fs.c).Where("bucket_name IN ?", names).Find(&mappings).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range mappings {
+			regions[m.BucketName] = m.RegionCode
+		}
+	}
+	fs.regions = regions
+	return regions, nil
+}
+
+// bucketRegion 返回存储桶的区域，无权访问时按不存在处理
+func (fs *davFS) bucketRegion(bucket string) (string, error) {
+	regions, err := fs.accessibleBuckets()
+	if err != nil {
+		return "", err
+	}
+	region, ok := regions[bucket]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return region, nil
+}
+
+// findFile 查找对象键当前的文件记录
+func (fs *davFS) findFile(bucket, key string) (*models.OSSFile, error) {
+	var file models.OSSFile
+	err := fs.h.DB.WithContext(fs.c).Where("bucket = ? AND object_key = ? AND status = ?", bucket, key, "ACTIVE").
+		Order("id DESC").First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// isDir 前缀下存在文件或显式创建的目录时视为目录
+func (fs *davFS) isDir(bucket, key string) (bool, error) {
+	prefix := escapeLike(key+"/") + "%"
+	var count int64
+	if err := fs.h.DB.WithContext(fs.c).Model(&models.OSSFile{}).
+		Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucket, "ACTIVE", prefix).
+		Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := fs.h.DB.WithContext(fs.c).Model(&models.VirtualFolder{}).
+		Where("bucket = ? AND (path = ? OR path LIKE ? ESCAPE '!')", bucket, key, prefix).
+		Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Stat 获取文件或目录信息
+func (fs *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	name = path.Clean("/" + name)
+	if info, ok := fs.stats[name]; ok {
+		return info, nil
+	}
+	bucket, key := splitDavPath(name)
+	if bucket == "" {
+		return &davFileInfo{name: "/", dir: true}, nil
+	}
+	if _, err := fs.bucketRegion(bucket); err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return &davFileInfo{name: bucket, dir: true}, nil
+	}
+	if isReservedKey(key) {
+		return nil, os.ErrNotExist
+	}
+
+	file, err := fs.findFile(bucket, key)
+	if err == nil {
+		return newDavFileInfo(file), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	dir, err := fs.isDir(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if !dir {
+		return nil, os.ErrNotExist
+	}
+	return &davFileInfo{name: path.Base(key), dir: true}, nil
+}
+
+// OpenFile 打开文件或目录；写入模式下数据先暂存到本地，关闭时上传
+func (fs *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	bucket, key := splitDavPath(name)
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		if bucket == "" || key == "" || isReservedKey(key) {
+			return nil, os.ErrPermission
+		}
+		if _, err := normalizeFolderPath(key); err != nil {
+			return nil, os.ErrPermission
+		}
+		region, err := fs.bucketRegion(bucket)
+		if err != nil {
+			return nil, err
+		}
+		if parent := path.Dir(key); parent != "." {
+			if info, err := fs.Stat(ctx, "/"+bucket+"/"+parent); err != nil || !info.IsDir() {
+				return nil, os.ErrNotExist
+			}
+		}
+		return fs.newWriter(bucket, key, region)
+	}
+
+	info, err := fs.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &davDir{fs: fs, name: path.Clean("/" + name), info: info}, nil
+	}
+	region, _ := fs.bucketRegion(bucket)
+	return &davReader{fs: fs, info: info.(*davFileInfo), region: region}, nil
+}
+
+// Mkdir 创建空目录，父目录必须已存在
+func (fs *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	bucket, key := splitDavPath(name)
+	if bucket == "" || key == "" || isReservedKey(key) {
+		return os.ErrPermission
+	}
+	folderPath, err := normalizeFolderPath(key)
+	if err != nil {
+		return os.ErrPermission
+	}
+	if _, err := fs.Stat(ctx, name); err == nil {
+		return os.ErrExist
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if parent := path.Dir(folderPath); parent != "." {
+		if info, err := fs.Stat(ctx, "/"+bucket+"/"+parent); err != nil || !info.IsDir() {
+			return os.ErrNotExist
+		}
+	}
+
+	folder := models.VirtualFolder{Bucket: bucket, Path: folderPath, CreatorID: fs.userID}
+	if err := fs.h.DB.WithContext(fs.c).Where("bucket = ? AND path = ?", folder.Bucket, folder.Path).FirstOrCreate(&folder).Error; err != nil {
+		return err
+	}
+	fs.audit("WEBDAV_MKCOL", bucket, folderPath, nil)
+	return nil
+}
+
+// RemoveAll 删除文件或递归删除目录，启用回收站时移入回收站
+func (fs *davFS) RemoveAll(ctx context.Context, name string) error {
+	bucket, key := splitDavPath(name)
+	if bucket == "" || key == "" || isReservedKey(key) {
+		return os.ErrPermission
+	}
+	region, err := fs.bucketRegion(bucket)
+	if err != nil {
+		return err
+	}
+
+	file, err := fs.findFile(bucket, key)
+	if err == nil {
+		if err := fs.h.removeFile(fs.c, file, region, fs.userID); err != nil {
+			return err
+		}
+		fs.audit("WEBDAV_DELETE", bucket, key, map[string]interface{}{"file_id": file.ID})
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	prefix := folderPrefix(key)
+	var files []models.OSSFile
+	if err := fs.h.DB.WithContext(fs.c).Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucket, "ACTIVE", escapeLike(prefix)+"%").
+		Find(&files).Error; err != nil {
+		return err
+	}
+	for i := range files {
+		if err := fs.h.removeFile(fs.c, &files[i], region, fs.userID); err != nil {
+			return fmt.Errorf("删除文件 %s 失败: %w", files[i].ObjectKey, err)
+		}
+	}
+	if err := fs.h.DB.WithContext(fs.c).Unscoped().Where("bucket = ? AND (path = ? OR path LIKE ? ESCAPE '!')", bucket, key, escapeLike(prefix)+"%").
+		Delete(&models.VirtualFolder{}).Error; err != nil {
+		logger.Warn("删除虚拟目录失败", zap.String("path", key), zap.Error(err))
+	}
+	fs.audit("WEBDAV_DELETE", bucket, key, map[string]interface{}{"deleted": len(files)})
+	return nil
+}
+
+// Rename 在同一存储桶内移动文件或目录
+func (fs *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	bucket, oldKey := splitDavPath(oldName)
+	newBucket, newKey := splitDavPath(newName)
+	if bucket != newBucket || oldKey == "" || newKey == "" || isReservedKey(newKey) {
+		return os.ErrPermission
+	}
+	if _, err := normalizeFolderPath(newKey); err != nil {
+		return os.ErrPermission
+	}
+	region, err := fs.bucketRegion(bucket)
+	if err != nil {
+		return err
+	}
+
+	file, err := fs.findFile(bucket, oldKey)
+	if err == nil {
+		if err := fs.h.moveFile(fs.c, file, region, newKey); err != nil {
+			return err
+		}
+		fs.audit("WEBDAV_MOVE", bucket, oldKey, map[string]interface{}{"file_id": file.ID, "destination": newKey})
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if strings.HasPrefix(newKey+"/", oldKey+"/") {
+		return os.ErrPermission
+	}
+	oldPrefix, newPrefix := folderPrefix(oldKey), folderPrefix(newKey)
+	var files []models.OSSFile
+	if err := fs.h.DB.WithContext(fs.c).Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucket, "ACTIVE", escapeLike(oldPrefix)+"%").
+		Order("object_key").Find(&files).Error; err != nil {
+		return err
+	}
+	for i := range files {
+		target := newPrefix + strings.TrimPrefix(files[i].ObjectKey, oldPrefix)
+		if err := fs.h.moveFile(fs.c, &files[i], region, target); err != nil {
+			return fmt.Errorf("移动文件 %s 失败: %w", files[i].ObjectKey, err)
+		}
+	}
+	if err := fs.h.DB.WithContext(fs.c).Model(&models.VirtualFolder{}).
+		Where("bucket = ? AND (path = ? OR path LIKE ? ESCAPE '!')", bucket, oldKey, escapeLike(oldPrefix)+"%").
+		Update("path", gorm.Expr("CONCAT(?, substr(path, ?))", newKey, len(oldKey)+1)).Error; err != nil {
+		logger.Warn("更新虚拟目录失败", zap.String("path", oldKey), zap.Error(err))
+	}
+	fs.audit("WEBDAV_MOVE", bucket, oldKey, map[string]interface{}{"destination": newKey, "moved": len(files)})
+	return nil
+}
+
+// readdir 列出目录的直接子项，并缓存供随后的 Stat 使用
+func (fs *davFS) readdir(name string) ([]os.FileInfo, error) {
+	bucket, key := splitDavPath(name)
+	var infos []os.FileInfo
+
+	if bucket == "" {
+		regions, err := fs.accessibleBuckets()
+		if err != nil {
+			return nil, err
+		}
+		for b := range regions {
+			infos = append(infos, &davFileInfo{name: b, dir: true})
+		}
+	} else {
+		prefix := folderPrefix(key)
+
+		var folders []struct {
+			Name    string
+			ModTime time.Time
+		}
+		if err := fs.h.DB.WithContext(fs.c).Model(&models.OSSFile{}).
+			Select(firstSegment(fs.h.DB.WithContext(fs.c), "object_key", len(prefix)+1)+" AS name, MAX(updated_at) AS mod_time").
+			Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucket, "ACTIVE", escapeLike(prefix)+"%/%").
+			Group("name").
+			Scan(&folders).Error; err != nil {
+			return nil, err
+		}
+		var virtualPaths []string
+		if err := fs.h.DB.WithContext(fs.c).Model(&models.VirtualFolder{}).
+			Where("bucket = ? AND path LIKE ? ESCAPE '!'", bucket, escapeLike(prefix)+"%").
+			Pluck("path", &virtualPaths).Error; err != nil {
+			return nil, err
+		}
+
+		seen := make(map[string]bool, len(folders))
+		for _, f := range folders {
+			if f.Name != "" && !isReservedKey(prefix+f.Name) {
+				infos = append(infos, &davFileInfo{name: f.Name, dir: true, modTime: f.ModTime})
+				seen[f.Name] = true
+			}
+		}
+		for _, p := range virtualPaths {
+			n, _, _ := strings.Cut(strings.TrimPrefix(p, prefix), "/")
+			if n != "" && !seen[n] {
+				infos = append(infos, &davFileInfo{name: n, dir: true})
+				seen[n] = true
+			}
+		}
+
+		var files []models.OSSFile
+		if err := fs.h.DB.WithContext(fs.c).Where("bucket = ? AND status = ? AND object_key LIKE ? ESCAPE '!'", bucket, "ACTIVE", escapeLike(prefix)+"%").
+			Where("object_key NOT LIKE ? ESCAPE '!'", escapeLike(prefix)+"%/%").
+			Order("object_key").Limit(davMaxEntries).Find(&files).Error; err != nil {
+			return nil, err
+		}
+		if len(files) == davMaxEntries {
+			logger.Warn("WebDAV 目录文件过多，列表已截断", zap.String("bucket", bucket), zap.String("path", key))
+		}
+		for i := range files {
+			if !seen[path.Base(files[i].ObjectKey)] {
+				infos = append(infos, newDavFileInfo(&files[i]))
+			}
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	for _, info := range infos {
+		fs.stats[path.Join(name, info.Name())] = info
+	}
+	return infos, nil
+}
+
+// newWriter 创建写入暂存文件
+func (fs *davFS) newWriter(bucket, key, region string) (*davWriter, error) {
+	spoolDir := filepath.Join(os.TempDir(), "ossmanager-uploads")
+	if cfg := config.GetConfig(); cfg != nil {
+		spoolDir = cfg.Upload.GetSpoolDir()
+	}
+	if err := os.MkdirAll(spoolDir, 0o700); err != nil {
+		return nil, fmt.Errorf("创建上传暂存目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(spoolDir, "webdav-*.part")
+	if err != nil {
+		return nil, fmt.Errorf("创建上传暂存文件失败: %w", err)
+	}
+	return &davWriter{fs: fs, bucket: bucket, key: key, region: region, tmp: tmp, md5: md5.New()}, nil
+}
+
+// checkLimits 检查存储配额和存储桶上传策略，返回的策略用于按文件头校验类型
+func (fs *davFS) checkLimits(bucket, key string, size int64) (*models.BucketUploadPolicy, error) {
+	if fs.h.quotaManager != nil {
+		if err := fs.h.quotaManager.Check(fs.userID, bucket, size); err != nil {
+			return nil, err
+		}
+	}
+	uploadPolicy, err := policy.Load(fs.h.DB.WithContext(fs.c), bucket)
+	if err != nil {
+		return nil, fmt.Errorf("获取存储桶上传策略失败: %w", err)
+	}
+	return uploadPolicy, policy.CheckFile(uploadPolicy, key, size)
+}
+
+// commit 校验配额和上传策略后上传暂存文件，覆盖前保留历史版本，并创建文件记录
+func (fs *davFS) commit(w *davWriter) error {
+	uploadPolicy, err := fs.checkLimits(w.bucket, w.key, w.size)
+	if err != nil {
+		return err
+	}
+	if policy.NeedsContent(uploadPolicy) {
+		head := make([]byte, policy.SniffLen)
+		n, err := w.tmp.ReadAt(head, 0)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err := policy.CheckContent(uploadPolicy, head[:n]); err != nil {
+			return err
+		}
+	}
+
+	config, storage, err := fs.h.defaultStorage(fs.c)
+	if err != nil {
+		return err
+	}
+	if _, err := w.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// 客户端常在写入前先锁定并创建空文件，覆盖空文件时不保留历史版本
+	var pending *pendingVersion
+	if current, err := fs.findFile(w.bucket, w.key); err == nil && current.FileSize > 0 {
+		if pending, err = fs.h.preserveCurrentVersion(fs.c, storage, w.key, w.region, w.bucket); err != nil {
+			return err
+		}
+	}
+
+	uploadURL, err := storage.UploadToBucket(w.tmp, w.key, w.region, w.bucket)
+	if err != nil {
+		pending.discard()
+		return fmt.Errorf("上传文件失败: %w", err)
+	}
+	file, err := fs.h.createFileRecord(fs.c, config, w.key, path.Base(w.key), w.size, w.bucket, uploadURL, fs.userID)
+	if err != nil {
+		return err
+	}
+	if err := fs.h.DB.WithContext(fs.c).Model(file).Updates(map[string]interface{}{
+		"md5":        hex.EncodeToString(w.md5.Sum(nil)),
+		"md5_status": models.MD5StatusCompleted,
+	}).Error; err != nil {
+		logger.Warn("保存文件MD5失败", zap.Uint("file_id", file.ID), zap.Error(err))
+	}
+
+	fs.audit("WEBDAV_PUT", w.bucket, w.key, map[string]interface{}{"file_id": file.ID, "size": w.size})
+	return nil
+}
+
+// audit 记录 WebDAV 写操作审计日志
+func (fs *davFS) audit(action, bucket, key string, extra map[string]interface{}) {
+	recordObjectAudit(fs.h.DB, fs.c, "webdav_object", action, "SUCCESS", bucket, key, extra)
+}
+
+// davFileInfo 文件或目录信息
+type davFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	etag    string
+	file    *models.OSSFile
+}
+
+func newDavFileInfo(file *models.OSSFile) *davFileInfo {
+	return &davFileInfo{
+		name:    path.Base(file.ObjectKey),
+		size:    file.FileSize,
+		modTime: file.UpdatedAt,
+		etag:    objectETag(file),
+		file:    file,
+	}
+}
+
+func (fi *davFileInfo) Name() string       { return fi.name }
+func (fi *davFileInfo) Size() int64        { return fi.size }
+func (fi *davFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *davFileInfo) IsDir() bool        { return fi.dir }
+func (fi *davFileInfo) Sys() interface{}   { return nil }
+
+func (fi *davFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+// ETag 使用与 S3 接口一致的 ETag，避免按修改时间推算
+func (fi *davFileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.etag, nil
+}
+
+// ContentType 按扩展名推断类型，避免读取对象内容
+func (fi *davFileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.dir {
+		return "", webdav.ErrNotImplemented
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(fi.name)); contentType != "" {
+		return contentType, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// davDir 目录句柄
+type davDir struct {
+	fs      *davFS
+	name    string
+	info    os.FileInfo
+	entries []os.FileInfo
+	loaded  bool
+}
+
+func (d *davDir) Close() error                                 { return nil }
+func (d *davDir) Read(p []byte) (int, error)                   { return 0, errors.New("不能读取目录") }
+func (d *davDir) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d *davDir) Write(p []byte) (int, error)                  { return 0, os.ErrPermission }
+func (d *davDir) Stat() (os.FileInfo, error)                   { return d.info, nil }
+
+func (d *davDir) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.loaded {
+		entries, err := d.fs.readdir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.loaded = entries, true
+	}
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(d.entries) {
+		count = len(d.entries)
+	}
+	entries := d.entries[:count]
+	d.entries = d.entries[count:]
+	return entries, nil
+}
+
+// davReader 按需通过预签名URL读取对象，Seek 后从新位置发起 Range 请求
+type davReader struct {
+	fs     *davFS
+	info   *davFileInfo
+	region string
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *davReader) Stat() (os.FileInfo, error)               { return r.info, nil }
+func (r *davReader) Readdir(count int) ([]os.FileInfo, error) { return nil, errors.New("不是目录") }
+func (r *davReader) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+
+func (r *davReader) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+func (r *davReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.info.size
+	}
+	if offset < 0 {
+		return 0, errors.New("无效的偏移量")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *davReader) Read(p []byte) (int, error) {
+	if r.offset >= r.info.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// open 从当前偏移量开始读取对象
+func (r *davReader) open() error {
+	file := r.info.file
+	storage, err := r.fs.h.storageForFile(r.fs.c, file)
+	if err != nil {
+		return err
+	}
+	downloadURL, _, err := storage.GenerateDownloadURLFromBucket(file.ObjectKey, r.region, file.Bucket, davDownloadURLTTL)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(r.fs.c.Request.Context(), http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	if r.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode == http.StatusOK:
+		// 存储服务忽略了 Range 时跳过已读部分
+		if _, err := io.CopyN(io.Discard, resp.Body, r.offset); err != nil {
+			resp.Body.Close()
+			return err
+		}
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("读取对象失败，状态码: %d", resp.StatusCode)
+	}
+	r.body = resp.Body
+	return nil
+}
+
+// davWriter 写入句柄，数据暂存在本地文件中，关闭时上传
+type davWriter struct {
+	fs     *davFS
+	bucket string
+	key    string
+	region string
+	tmp    *os.File
+	md5    hash.Hash
+	size   int64
+}
+
+func (w *davWriter) Read(p []byte) (int, error) { return 0, errors.New("文件以只写方式打开") }
+func (w *davWriter) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("不支持随机写入")
+}
+func (w *davWriter) Readdir(count int) ([]os.FileInfo, error) { return nil, errors.New("不是目录") }
+
+func (w *davWriter) Write(p []byte) (int, error) {
+	n, err := w.tmp.Write(p)
+	w.md5.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *davWriter) Stat() (os.FileInfo, error) {
+	return &davFileInfo{
+		name:    path.Base(w.key),
+		size:    w.size,
+		modTime: time.Now(),
+		etag:    `"` + hex.EncodeToString(w.md5.Sum(nil)) + `"`,
+	}, nil
+}
+
+func (w *davWriter) Close() error {
+	defer func() {
+		w.tmp.Close()
+		os.Remove(w.tmp.Name())
+	}()
+	if err := w.fs.commit(w); err != nil {
+		logger.Error("WebDAV 上传文件失败",
+			zap.String("bucket", w.bucket),
+			zap.String("object_key", w.key),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/myysophia/ossmanager-backend/internal/db"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
 	"go.uber.org/zap"
 )
 
@@ -54,6 +55,7 @@ func AuditLogMiddleware() gin.HandlerFunc {
 		auditLog := &models.AuditLog{}
 
 		// 手动设置审计日志的各个字段
+		auditLog.OrgID = tenant.OrgID(c)
 		auditLog.UserID = userID.(uint)
 		auditLog.Username = username.(string)
 		auditLog.Action = method
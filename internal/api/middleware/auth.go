@@ -74,6 +74,11 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 将用户信息保存到上下文
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		if err := setTenantScope(c, claims.UserID); err != nil {
+			utils.ResponseError(c, scopeErrorCode(err), err)
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
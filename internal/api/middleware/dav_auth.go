@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// davCredentialTTL Basic 认证结果的缓存时间
+// WebDAV 客户端每个请求都携带密码，缓存避免对每个请求做 bcrypt 校验
+const davCredentialTTL = 5 * time.Minute
+
+// davIdentity 认证通过的用户
+type davIdentity struct {
+	userID   uint
+	username string
+	expires  time.Time
+}
+
+// DavAuthMiddleware WebDAV 认证中间件
+// 支持 Basic 认证（用户名和登录密码，或访问密钥ID和密钥）以及 Bearer JWT 令牌
+func DavAuthMiddleware(gdb *gorm.DB) gin.HandlerFunc {
+	var cache sync.Map // 凭证摘要 -> davIdentity
+
+	return func(c *gin.Context) {
+		var identity *davIdentity
+		var err error
+
+		authHeader := c.GetHeader("Authorization")
+		if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
+			jwtConfig := config.GetConfig().JWT
+			var claims *auth.Claims
+			if claims, err = auth.ParseToken(token, &jwtConfig); err == nil {
+				identity = &davIdentity{userID: claims.UserID, username: claims.Username}
+			}
+		} else if username, password, ok := c.Request.BasicAuth(); ok {
+			sum := sha256.Sum256([]byte(username + "\x00" + password))
+			cacheKey := hex.EncodeToString(sum[:])
+			if v, ok := cache.Load(cacheKey); ok && time.Now().Before(v.(davIdentity).expires) {
+				cached := v.(davIdentity)
+				identity = &cached
+			} else if identity, err = davBasicAuth(gdb, username, password); err == nil {
+				identity.expires = time.Now().Add(davCredentialTTL)
+				cache.Store(cacheKey, *identity)
+			}
+		} else {
+			err = errors.New("未提供认证信息")
+		}
+
+		if err == nil {
+			err = auth.CheckUserStatus(identity.userID)
+		}
+		if err == nil {
+			err = setTenantScope(c, identity.userID)
+		}
+		if err != nil {
+			logger.Debug("WebDAV 认证失败", zap.String("path", c.Request.URL.Path), zap.Error(err))
+			c.Header("WWW-Authenticate", `Basic realm="OSS Manager", charset="UTF-8"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		c.Set("userID", identity.userID)
+		c.Set("username", identity.username)
+		c.Next()
+	}
+}
+
+// davBasicAuth 校验 Basic 认证凭证：先按访问密钥匹配，再按用户名和登录密码匹配
+func davBasicAuth(gdb *gorm.DB, username, password string) (*davIdentity, error) {
+	var key models.AccessKey
+	err := gdb.Preload("User").
+		Where("access_key_id = ? AND status = ?", username, models.AccessKeyActive).
+		First(&key).Error
+	if err == nil && key.User != nil {
+		if subtle.ConstantTimeCompare([]byte(key.SecretKey), []byte(password)) != 1 {
+			return nil, errors.New("访问密钥不匹配")
+		}
+		return &davIdentity{userID: key.UserID, username: key.User.Username}, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var user models.User
+	if err := gdb.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, errors.New("用户名或密码错误")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, errors.New("用户名或密码错误")
+	}
+	return &davIdentity{userID: user.ID, username: user.Username}, nil
+}
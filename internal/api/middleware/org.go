@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/myysophia/ossmanager-backend/internal/utils"
+)
+
+// OrgHeader 全局管理员切换当前组织的请求头，值为组织ID
+const OrgHeader = "X-Org-ID"
+
+// setTenantScope 加载用户的组织范围并保存到上下文，之后通过 WithContext(c) 执行的查询只涉及当前组织
+func setTenantScope(c *gin.Context, userID uint) error {
+	var orgID uint
+	if value := c.GetHeader(OrgHeader); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || id == 0 {
+			return auth.ErrOrgNotFound
+		}
+		orgID = uint(id)
+	}
+	scope, err := auth.LoadScope(userID, orgID)
+	if err != nil {
+		return err
+	}
+	c.Set(tenant.ContextKey, scope)
+	c.Set("orgID", scope.OrgID)
+	// WebDAV 等使用请求上下文的处理器也能取到组织范围
+	c.Request = c.Request.WithContext(tenant.WithScope(c.Request.Context(), scope))
+	return nil
+}
+
+// scopeErrorCode 加载组织范围失败时返回的错误码
+func scopeErrorCode(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrDatabaseOperation):
+		return utils.CodeInternalError
+	case errors.Is(err, auth.ErrUserNotFound):
+		return utils.CodeUnauthorized
+	default:
+		return utils.CodeForbidden
+	}
+}
+
+// adminMiddleware 按组织范围检查管理员身份
+func adminMiddleware(allowed func(scope *tenant.Scope) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := tenant.FromContext(c)
+		if scope == nil {
+			utils.ResponseError(c, utils.CodeUnauthorized, errors.New("未登录"))
+			c.Abort()
+			return
+		}
+		if !allowed(scope) {
+			utils.ResponseError(c, utils.CodeForbidden, errors.New("没有权限执行此操作"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminMiddleware 管理员检查中间件，需要当前组织的管理员或全局管理员
+func AdminMiddleware() gin.HandlerFunc {
+	return adminMiddleware(func(scope *tenant.Scope) bool { return scope.OrgAdmin })
+}
+
+// GlobalAdminMiddleware 全局管理员检查中间件，用于组织管理等跨组织的操作
+func GlobalAdminMiddleware() gin.HandlerFunc {
+	return adminMiddleware(func(scope *tenant.Scope) bool { return scope.GlobalAdmin })
+}
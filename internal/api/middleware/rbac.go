@@ -70,11 +70,6 @@ func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// AdminMiddleware 管理员检查中间件
-func AdminMiddleware() gin.HandlerFunc {
-	return RoleMiddleware()
-}
-
 // checkPermissions 检查用户是否拥有指定权限
 func checkPermissions(userID uint, permissions ...string) (bool, error) {
 	if len(permissions) == 0 {
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/s3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// S3AuthMiddleware S3 兼容接口认证中间件，使用用户访问密钥校验 SigV4 签名
+func S3AuthMiddleware(gdb *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sig, s3Err := s3.ParseRequest(c.Request)
+		if s3Err != nil {
+			s3.WriteError(c, s3Err)
+			return
+		}
+
+		var key models.AccessKey
+		err := gdb.Preload("User").
+			Where("access_key_id = ? AND status = ?", sig.AccessKeyID, models.AccessKeyActive).
+			First(&key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && key.User == nil) {
+			s3.WriteError(c, s3.ErrInvalidAccessKeyID)
+			return
+		}
+		if err != nil {
+			logger.Error("查询访问密钥失败", zap.String("access_key_id", sig.AccessKeyID), zap.Error(err))
+			s3.WriteError(c, s3.ErrInternal)
+			return
+		}
+		if err := auth.CheckUserStatus(key.UserID); err != nil {
+			s3.WriteError(c, s3.ErrAccessDenied.WithMessage("用户不存在或已禁用"))
+			return
+		}
+
+		if s3Err := sig.Verify(c.Request, string(key.SecretKey), time.Now().UTC()); s3Err != nil {
+			logger.Warn("S3 请求签名校验失败",
+				zap.String("access_key_id", sig.AccessKeyID),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("code", s3Err.Code))
+			s3.WriteError(c, s3Err)
+			return
+		}
+
+		// 异步记录最近使用时间，不影响请求处理
+		go func(id uint) {
+			if err := gdb.Model(&models.AccessKey{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error; err != nil {
+				logger.Warn("更新访问密钥使用时间失败", zap.Uint("id", id), zap.Error(err))
+			}
+		}(key.ID)
+
+		c.Set("userID", key.UserID)
+		c.Set("username", key.User.Username)
+		c.Set(s3.SignatureContextKey, sig)
+		if err := setTenantScope(c, key.UserID); err != nil {
+			s3.WriteError(c, s3.ErrAccessDenied.WithMessage(err.Error()))
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,16 +1,22 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/myysophia/ossmanager-backend/internal/api/handlers"
 	"github.com/myysophia/ossmanager-backend/internal/api/middleware"
 	"github.com/myysophia/ossmanager-backend/internal/function"
+	"github.com/myysophia/ossmanager-backend/internal/jobs"
 	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
 	"gorm.io/gorm"
 )
 
 // SetupRouter 设置路由
-func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5Calculator, db *gorm.DB) *gin.Engine {
+func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5Calculator, trashManager *trash.Manager, quotaManager *quota.Manager, jobManager *jobs.Manager, sessionManager *upload.SessionManager, db *gorm.DB) *gin.Engine {
 	// 创建Gin实例
 	router := gin.New()
 
@@ -24,7 +30,7 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 
 	// 创建处理器
 	authHandler := handlers.NewAuthHandler()
-	ossFileHandler := handlers.NewOSSFileHandler(storageFactory, db)
+	ossFileHandler := handlers.NewOSSFileHandler(storageFactory, db, trashManager, quotaManager, jobManager, sessionManager)
 	ossConfigHandler := handlers.NewOSSConfigHandler(storageFactory)
 	md5Handler := handlers.NewMD5Handler(md5Calculator)
 	auditLogHandler := handlers.NewAuditLogHandler()           // 审计日志处理器
@@ -33,6 +39,14 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 	permissionHandler := handlers.NewPermissionHandler(db)     // 权限管理处理器
 	regionBucketHandler := handlers.NewRegionBucketHandler(db) // 区域存储桶处理器
 	uploadProgressHandler := handlers.NewUploadProgressHandler()
+	trashHandler := handlers.NewTrashHandler(db, trashManager)           // 回收站处理器
+	shareLinkHandler := handlers.NewShareLinkHandler(storageFactory, db) // 分享链接处理器
+	quotaHandler := handlers.NewQuotaHandler(db, quotaManager)           // 存储配额处理器
+	jobHandler := handlers.NewJobHandler(db, jobManager)                 // 后台任务处理器
+	accessKeyHandler := handlers.NewAccessKeyHandler(db)                 // 访问密钥处理器
+	s3Handler := handlers.NewS3Handler(ossFileHandler)                   // S3 兼容接口处理器
+	davHandler := handlers.NewDavHandler(ossFileHandler)                 // WebDAV 处理器
+	organizationHandler := handlers.NewOrganizationHandler(db)           // 组织管理处理器
 
 	// 公开路由
 	public := router.Group("/api/v1")
@@ -53,6 +67,45 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 			uploads.GET("/:id/progress", uploadProgressHandler.GetProgress)
 			uploads.GET("/:id/stream", uploadProgressHandler.StreamProgress)
 		}
+
+		// 上传请求链接（外部用户按令牌上传，无需登录）
+		uploadRequests := public.Group("/upload-requests/:token")
+		{
+			uploadRequests.GET("", ossFileHandler.GetUploadRequestInfo)
+			uploadRequests.POST("/files", ossFileHandler.UploadRequestStream)
+			uploadRequests.POST("/multipart/init", ossFileHandler.UploadRequestInitMultipart)
+			uploadRequests.POST("/multipart/complete", ossFileHandler.UploadRequestCompleteMultipart)
+		}
+
+		// tus 协议能力查询（无需登录）
+		public.OPTIONS("/tus", ossFileHandler.TusOptions)
+		public.OPTIONS("/tus/:id", ossFileHandler.TusOptions)
+	}
+
+	// 公开分享链接（无需登录，按令牌访问）
+	share := router.Group("/s")
+	{
+		share.GET("/:token", shareLinkHandler.GetPublic)
+		share.POST("/:token/download", shareLinkHandler.Download)
+	}
+
+	// S3 兼容接口（路径风格，按访问密钥 SigV4 签名认证）
+	s3Group := router.Group("/s3")
+	s3Group.Use(middleware.S3AuthMiddleware(db))
+	{
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPost, http.MethodDelete} {
+			s3Group.Handle(method, "/*path", s3Handler.Dispatch)
+		}
+	}
+
+	// WebDAV（挂载为网络驱动器，Basic 认证或 Bearer 令牌）
+	dav := router.Group(handlers.DavPrefix)
+	dav.Use(middleware.DavAuthMiddleware(db))
+	{
+		for _, method := range handlers.DavMethods {
+			dav.Handle(method, "", davHandler.Serve)
+			dav.Handle(method, "/*path", davHandler.Serve)
+		}
 	}
 
 	// 需要认证的路由
@@ -65,27 +118,38 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 		// 用户相关
 		authorized.GET("/user/current", authHandler.GetCurrentUser)
 
-		// 用户管理
+		// 组织管理（仅全局管理员可访问）
+		orgs := authorized.Group("/orgs")
+		orgs.Use(middleware.GlobalAdminMiddleware())
+		{
+			orgs.GET("", organizationHandler.List)
+			orgs.POST("", organizationHandler.Create)
+			orgs.GET("/:id", organizationHandler.Get)
+			orgs.PUT("/:id", organizationHandler.Update)
+			orgs.DELETE("/:id", organizationHandler.Delete)
+		}
+
+		// 用户管理（修改需要组织管理员权限）
 		users := authorized.Group("/users")
 		{
 			users.GET("", userHandler.List)
-			users.POST("", userHandler.Create)
+			users.POST("", middleware.AdminMiddleware(), userHandler.Create)
 			users.GET("/:id", userHandler.Get)
-			users.PUT("/:id", userHandler.Update)
-			users.DELETE("/:id", userHandler.Delete)
+			users.PUT("/:id", middleware.AdminMiddleware(), userHandler.Update)
+			users.DELETE("/:id", middleware.AdminMiddleware(), userHandler.Delete)
 			users.GET("/:id/bucket-access", userHandler.GetUserBucketAccess)
 		}
 
-		// 角色管理
+		// 角色管理（修改需要组织管理员权限）
 		roles := authorized.Group("/roles")
 		{
 			roles.GET("", roleHandler.List)
-			roles.POST("", roleHandler.Create)
+			roles.POST("", middleware.AdminMiddleware(), roleHandler.Create)
 			roles.GET("/:id", roleHandler.Get)
-			roles.PUT("/:id", roleHandler.Update)
-			roles.DELETE("/:id", roleHandler.Delete)
+			roles.PUT("/:id", middleware.AdminMiddleware(), roleHandler.Update)
+			roles.DELETE("/:id", middleware.AdminMiddleware(), roleHandler.Delete)
 			roles.GET("/:id/bucket-access", roleHandler.GetRoleBucketAccess)
-			roles.PUT("/:id/bucket-access", roleHandler.UpdateRoleBucketAccess)
+			roles.PUT("/:id/bucket-access", middleware.AdminMiddleware(), roleHandler.UpdateRoleBucketAccess)
 		}
 
 		// 添加 region-bucket-mappings 路由组
@@ -94,14 +158,14 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 			regionBucketMappings.GET("", roleHandler.ListRegionBucketMappings)
 		}
 
-		// 权限管理
+		// 权限管理（权限定义全局共享，修改需要全局管理员权限）
 		permissions := authorized.Group("/permissions")
 		{
 			permissions.GET("", permissionHandler.List)
-			permissions.POST("", permissionHandler.Create)
+			permissions.POST("", middleware.GlobalAdminMiddleware(), permissionHandler.Create)
 			permissions.GET("/:id", permissionHandler.Get)
-			permissions.PUT("/:id", permissionHandler.Update)
-			permissions.DELETE("/:id", permissionHandler.Delete)
+			permissions.PUT("/:id", middleware.GlobalAdminMiddleware(), permissionHandler.Update)
+			permissions.DELETE("/:id", middleware.GlobalAdminMiddleware(), permissionHandler.Delete)
 		}
 
 		// OSS文件管理
@@ -110,6 +174,41 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 		authorized.DELETE("/oss/files/:id", ossFileHandler.Delete)
 		authorized.GET("/oss/files/:id/download", ossFileHandler.GetDownloadURL)
 		authorized.GET("/oss/files/check-duplicate", ossFileHandler.CheckDuplicateFile)
+		authorized.POST("/oss/files/instant", ossFileHandler.InstantUpload) // 秒传
+
+		// 虚拟目录
+		authorized.GET("/oss/folders", ossFileHandler.ListFolder)
+		authorized.POST("/oss/folders", ossFileHandler.CreateFolder)
+		authorized.POST("/oss/folders/rename", ossFileHandler.RenameFolder)
+		authorized.DELETE("/oss/folders", ossFileHandler.DeleteFolder)
+
+		// 批量文件操作
+		authorized.POST("/oss/bulk/delete", ossFileHandler.BulkDelete)
+		authorized.POST("/oss/bulk/move", ossFileHandler.BulkMove)
+		authorized.POST("/oss/bulk/tag", ossFileHandler.BulkTag)
+		authorized.POST("/oss/bulk/download-urls", ossFileHandler.BulkDownloadURLs)
+		authorized.GET("/oss/bulk", ossFileHandler.ListBulkOperations)
+		authorized.GET("/oss/bulk/:id", ossFileHandler.GetBulkOperation)
+
+		// 后台任务（进度可通过 /uploads/:task_id/stream 订阅）
+		jobGroup := authorized.Group("/jobs")
+		{
+			jobGroup.GET("", jobHandler.List)
+			jobGroup.GET("/all", middleware.AdminMiddleware(), jobHandler.ListAll)
+			jobGroup.GET("/:id", jobHandler.Get)
+			jobGroup.POST("/:id/cancel", jobHandler.Cancel)
+			jobGroup.POST("/:id/retry", jobHandler.Retry)
+		}
+
+		// 文件标签
+		authorized.GET("/oss/files/:id/tags", ossFileHandler.GetTags)
+		authorized.PUT("/oss/files/:id/tags", ossFileHandler.UpdateTags)
+		authorized.DELETE("/oss/files/:id/tags/:key", ossFileHandler.DeleteTag)
+
+		// 文件版本历史
+		authorized.GET("/oss/files/versions", ossFileHandler.ListVersions)
+		authorized.GET("/oss/files/versions/:id/download", ossFileHandler.GetVersionDownloadURL)
+		authorized.POST("/oss/files/versions/:id/restore", ossFileHandler.RestoreVersion)
 		//authorized.GET("/oss/files/by-filename", ossFileHandler.GetByOriginalFilename)
 
 		// 分片上传
@@ -117,6 +216,58 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 		authorized.POST("/oss/multipart/complete", ossFileHandler.CompleteMultipartUpload)
 		authorized.DELETE("/oss/multipart/abort", ossFileHandler.AbortMultipartUpload)
 		authorized.GET("/oss/multipart/parts", ossFileHandler.ListUploadedParts)
+		authorized.POST("/oss/multipart/urls", ossFileHandler.GetPartUploadURLs)
+
+		// 可续传的上传会话
+		authorized.GET("/uploads/mine", ossFileHandler.ListMyUploadSessions)
+		authorized.GET("/uploads/mine/:session_id", ossFileHandler.GetMyUploadSession)
+		authorized.DELETE("/uploads/mine/:session_id", ossFileHandler.AbortMyUploadSession)
+
+		// tus 可续传上传协议
+		authorized.POST("/tus", ossFileHandler.TusCreate)
+		authorized.HEAD("/tus/:id", ossFileHandler.TusHead)
+		authorized.PATCH("/tus/:id", ossFileHandler.TusPatch)
+		authorized.DELETE("/tus/:id", ossFileHandler.TusTerminate)
+
+		// S3 兼容接口访问密钥
+		accessKeys := authorized.Group("/access-keys")
+		{
+			accessKeys.GET("", accessKeyHandler.List)
+			accessKeys.POST("", accessKeyHandler.Create)
+			accessKeys.PUT("/:id", accessKeyHandler.Update)
+			accessKeys.DELETE("/:id", accessKeyHandler.Delete)
+		}
+
+		// 分享链接
+		authorized.POST("/oss/files/:id/share", shareLinkHandler.Create)
+		authorized.GET("/oss/shares", shareLinkHandler.List)
+		authorized.DELETE("/oss/shares/:id", shareLinkHandler.Revoke)
+
+		// 上传请求链接管理
+		authorized.POST("/oss/upload-requests", ossFileHandler.CreateUploadRequest)
+		authorized.GET("/oss/upload-requests", ossFileHandler.ListUploadRequests)
+		authorized.DELETE("/oss/upload-requests/:id", ossFileHandler.RevokeUploadRequest)
+
+		// 存储配额
+		authorized.GET("/oss/quotas/usage", quotaHandler.MyUsage)
+		quotas := authorized.Group("/oss/quotas")
+		quotas.Use(middleware.GlobalAdminMiddleware()) // 配额不区分组织，仅全局管理员可管理
+		{
+			quotas.GET("", quotaHandler.List)
+			quotas.POST("", quotaHandler.Create)
+			quotas.PUT("/:id", quotaHandler.Update)
+			quotas.DELETE("/:id", quotaHandler.Delete)
+			quotas.POST("/recalculate", quotaHandler.Recalculate)
+		}
+
+		// 回收站
+		trashGroup := authorized.Group("/oss/trash")
+		{
+			trashGroup.GET("", trashHandler.List)
+			trashGroup.POST("/:id/restore", trashHandler.Restore)
+			trashGroup.DELETE("/:id", trashHandler.Purge)
+			trashGroup.DELETE("", middleware.AdminMiddleware(), trashHandler.Empty) // 按用户或存储桶清空（仅管理员）
+		}
 
 		// MD5计算相关
 		authorized.POST("/oss/files/:id/md5", md5Handler.TriggerCalculation)
@@ -132,6 +283,8 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 			configs.GET("", ossConfigHandler.GetConfigList)
 			configs.GET("/:id", ossConfigHandler.GetConfig)
 			configs.PUT("/:id/default", ossConfigHandler.SetDefaultConfig)
+			configs.POST("/test", ossConfigHandler.Test)
+			configs.POST("/:id/test", ossConfigHandler.Test)
 		}
 
 		// 审计日志管理（仅管理员可访问）
@@ -145,10 +298,13 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 		regionBuckets := authorized.Group("/oss/region-buckets")
 		{
 			regionBuckets.GET("", regionBucketHandler.List)
-			regionBuckets.POST("", regionBucketHandler.Create)
+			regionBuckets.POST("", middleware.AdminMiddleware(), regionBucketHandler.Create)
 			regionBuckets.GET("/:id", regionBucketHandler.Get)
-			regionBuckets.PUT("/:id", regionBucketHandler.Update)
-			regionBuckets.DELETE("/:id", regionBucketHandler.Delete)
+			regionBuckets.PUT("/:id", middleware.AdminMiddleware(), regionBucketHandler.Update)
+			regionBuckets.DELETE("/:id", middleware.AdminMiddleware(), regionBucketHandler.Delete)
+			regionBuckets.GET("/:id/policy", regionBucketHandler.GetUploadPolicy)
+			regionBuckets.PUT("/:id/policy", middleware.AdminMiddleware(), regionBucketHandler.UpdateUploadPolicy)
+			regionBuckets.DELETE("/:id/policy", middleware.AdminMiddleware(), regionBucketHandler.DeleteUploadPolicy)
 			regionBuckets.GET("/regions", regionBucketHandler.GetRegionList)
 			regionBuckets.GET("/buckets", regionBucketHandler.GetBucketList)
 			regionBuckets.GET("/user-accessible", regionBucketHandler.GetUserAccessibleBuckets)
@@ -158,10 +314,10 @@ func SetupRouter(storageFactory oss.StorageFactory, md5Calculator *function.MD5C
 		roleBucketAccess := authorized.Group("/oss/role-bucket-access")
 		{
 			roleBucketAccess.GET("", roleHandler.ListRoleBucketAccess)
-			roleBucketAccess.POST("", roleHandler.CreateRoleBucketAccess)
+			roleBucketAccess.POST("", middleware.AdminMiddleware(), roleHandler.CreateRoleBucketAccess)
 			roleBucketAccess.GET("/:id", roleHandler.GetRoleBucketAccess)
-			roleBucketAccess.PUT("/:id", roleHandler.UpdateRoleBucketAccess)
-			roleBucketAccess.DELETE("/:id", roleHandler.DeleteRoleBucketAccess)
+			roleBucketAccess.PUT("/:id", middleware.AdminMiddleware(), roleHandler.UpdateRoleBucketAccess)
+			roleBucketAccess.DELETE("/:id", middleware.AdminMiddleware(), roleHandler.DeleteRoleBucketAccess)
 		}
 
 	}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/myysophia/ossmanager-backend/internal/db"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 组织相关错误
+var (
+	ErrOrgNotFound = errors.New("组织不存在")
+	ErrOrgDisabled = errors.New("组织已禁用")
+)
+
+// LoadScope 根据用户所属的组织和角色的管理员范围生成请求的组织范围
+// orgID 不为 0 且不是用户所属的组织时切换到该组织，只有全局管理员可以切换
+func LoadScope(userID, orgID uint) (*tenant.Scope, error) {
+	var user models.User
+	if err := db.GetDB().Preload("Roles").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		logger.Error("查询用户失败", zap.Uint("userID", userID), zap.Error(err))
+		return nil, ErrDatabaseOperation
+	}
+
+	scope := &tenant.Scope{OrgID: user.OrgID, UserOrgID: user.OrgID}
+	for _, role := range user.Roles {
+		switch role.AdminScope {
+		case models.AdminScopeGlobal:
+			scope.GlobalAdmin = true
+		case models.AdminScopeOrg:
+			scope.OrgAdmin = true
+		}
+	}
+	if scope.GlobalAdmin {
+		scope.OrgAdmin = true
+	}
+	if orgID != 0 && orgID != user.OrgID {
+		if !scope.GlobalAdmin {
+			logger.Warn("非全局管理员尝试切换组织", zap.Uint("userID", userID), zap.Uint("orgID", orgID))
+			return nil, ErrPermissionDenied
+		}
+		scope.OrgID = orgID
+	}
+
+	var org models.Organization
+	if err := db.GetDB().First(&org, scope.OrgID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		logger.Error("查询组织失败", zap.Uint("orgID", scope.OrgID), zap.Error(err))
+		return nil, ErrDatabaseOperation
+	}
+	// 组织禁用后只有全局管理员可以访问
+	if !org.Status && !scope.GlobalAdmin {
+		return nil, ErrOrgDisabled
+	}
+	return scope, nil
+}
@@ -52,21 +52,16 @@ func CheckPermission(userID uint, resource string, action string) error {
 		return err
 	}
 
-	gormDB := db.GetDB()
-
-	// 使用原生SQL查询权限，因为关联查询较为复杂
-	// 检查用户通过角色获得的权限
+	// 检查用户通过角色获得的权限，拥有该资源的 "管理" 权限时也认为有操作权限
+	// 使用查询构造器而不是原生 SQL，由 gorm 处理不同数据库的标识符引用，并排除已删除的角色和权限
 	var count int64
-	err := gormDB.Raw(`
-		SELECT COUNT(*) FROM permissions p
-		JOIN role_permissions rp ON p.id = rp.permission_id
-		JOIN roles r ON rp.role_id = r.id
-		JOIN user_roles ur ON r.id = ur.role_id
-		WHERE ur.user_id = ? 
-		AND p.resource = ? 
-		AND p.action = ?
-	`, userID, resource, action).Count(&count).Error
-
+	err := db.GetDB().Model(&models.Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND permissions.resource = ? AND permissions.action IN ?",
+			userID, resource, []string{action, "manage"}).
+		Count(&count).Error
 	if err != nil {
 		logger.Error("查询用户权限失败",
 			zap.Uint("userID", userID),
@@ -81,30 +76,6 @@ func CheckPermission(userID uint, resource string, action string) error {
 		return nil
 	}
 
-	// 如果用户直接拥有 "管理" 权限，也认为有操作权限
-	err = gormDB.Raw(`
-		SELECT COUNT(*) FROM permissions p
-		JOIN role_permissions rp ON p.id = rp.permission_id
-		JOIN roles r ON rp.role_id = r.id
-		JOIN user_roles ur ON r.id = ur.role_id
-		WHERE ur.user_id = ? 
-		AND p.resource = ? 
-		AND p.action = 'manage'
-	`, userID, resource).Count(&count).Error
-
-	if err != nil {
-		logger.Error("查询用户管理权限失败",
-			zap.Uint("userID", userID),
-			zap.String("resource", resource),
-			zap.Error(err))
-		return ErrDatabaseOperation
-	}
-
-	// 如果有管理权限
-	if count > 0 {
-		return nil
-	}
-
 	// 权限不足
 	logger.Warn("用户权限不足",
 		zap.Uint("userID", userID),
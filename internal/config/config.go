@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/viper"
@@ -15,6 +16,12 @@ type Config struct {
 	Database DatabaseConfig
 	Log      LogConfig
 	OSS      OSSConfig
+	Trash    TrashConfig
+	Quota    QuotaConfig
+	Jobs     JobsConfig
+	Upload   UploadConfig
+	Reload   ReloadConfig
+	Secrets  SecretsConfig
 }
 
 type AppConfig struct {
@@ -38,16 +45,58 @@ type JWTConfig struct {
 }
 
 type DatabaseConfig struct {
-	Driver          string
+	Driver          string // postgres（默认）、mysql 或 sqlite
+	DSN             string `mapstructure:"dsn"` // 完整的连接字符串，设置后忽略 host、port 等连接参数
 	Host            string
 	Port            int
 	Username        string
 	Password        string
-	DBName          string `mapstructure:"dbname"`
+	DBName          string `mapstructure:"dbname"` // sqlite 时为数据库文件路径，:memory: 表示内存数据库
 	SSLMode         string `mapstructure:"sslmode"`
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
+	AutoMigrate     bool   `mapstructure:"auto_migrate"` // 启动时执行尚未执行的数据库迁移
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	Enabled       bool `mapstructure:"enabled"`        // 是否启用回收站，关闭时删除即彻底删除
+	RetentionDays int  `mapstructure:"retention_days"` // 回收站保留天数，默认30天
+	PurgeInterval int  `mapstructure:"purge_interval"` // 过期清理的执行间隔（秒），默认3600秒
+}
+
+// UploadConfig 分片上传会话配置
+type UploadConfig struct {
+	SessionTTL      int    `mapstructure:"session_ttl"`      // 上传会话无活动后的保留时长（秒），超时后在存储端中止，默认86400秒
+	CleanupInterval int    `mapstructure:"cleanup_interval"` // 过期会话清理的执行间隔（秒），默认600秒
+	ChunkSize       int64  `mapstructure:"chunk_size"`       // tus 上传写入存储端的分片大小（字节），默认10MB
+	SpoolDir        string `mapstructure:"spool_dir"`        // 暂存不足一个分片的已接收数据的本地目录，默认系统临时目录
+}
+
+// JobsConfig 后台任务配置
+type JobsConfig struct {
+	Workers      int `mapstructure:"workers"`       // 并发执行任务的工作协程数量，默认4
+	MaxAttempts  int `mapstructure:"max_attempts"`  // 任务最大尝试次数，默认3
+	RetryBackoff int `mapstructure:"retry_backoff"` // 重试基础等待时间（秒），按尝试次数线性递增，默认30秒
+	PollInterval int `mapstructure:"poll_interval"` // 轮询待执行任务的间隔（秒），默认5秒
+}
+
+// QuotaConfig 存储配额配置
+type QuotaConfig struct {
+	RecalculateInterval int `mapstructure:"recalculate_interval"` // 用量重新统计的执行间隔（秒），默认3600秒
+}
+
+// ReloadConfig 配置热加载，修改配置文件或存储配置后无需重启服务
+type ReloadConfig struct {
+	Enabled      bool `mapstructure:"enabled"`       // 是否监听配置文件和存储配置表的变化
+	PollInterval int  `mapstructure:"poll_interval"` // 检查存储配置表变化的间隔（秒），默认30秒
+}
+
+// SecretsConfig 数据库中存储凭据的加密密钥，也可以通过环境变量 OSSMANAGER_SECRET_KEYS 设置
+type SecretsConfig struct {
+	PrimaryKeyID string            `mapstructure:"primary_key_id"` // 加密新数据使用的密钥ID，只配置一个密钥时可以省略
+	Keys         map[string]string `mapstructure:"keys"`           // 密钥ID -> base64 编码的 32 字节密钥，轮换后保留旧密钥直到重新加密完成
 }
 
 type LogConfig struct {
@@ -104,7 +153,7 @@ type CloudflareR2Config struct {
 	URLExpireTime   int    `mapstructure:"url_expire_time"`
 }
 
-var globalConfig *Config
+var globalConfig atomic.Pointer[Config]
 
 // LoadConfig 加载配置文件
 func LoadConfig(configPath string) (*Config, error) {
@@ -134,7 +183,7 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("解析 OSS 配置文件失败: %w", err)
 	}
 
-	globalConfig = config
+	globalConfig.Store(config)
 	return config, nil
 }
 
@@ -146,41 +195,13 @@ func LoadConfigWithEnv(configPath string, env string) (*Config, error) {
 		env = "dev" // 默认使用开发环境
 	}
 
-	v := viper.New()
-
-	// 尝试多个可能的配置路径
-	configPaths := []string{
-		configPath,      // 原始传入路径
-		"./configs",     // 相对于运行目录
-		"../configs",    // 上一级目录
-		"../../configs", // 上两级目录
-	}
-
-	configFound := false
-	var configFile string
-
-	// 尝试查找配置文件
-	for _, path := range configPaths {
-		if isDir(path) {
-			baseConfigFile := fmt.Sprintf("%s/app.yaml", path)
-			if fileExists(baseConfigFile) {
-				v.AddConfigPath(path)
-				v.SetConfigName("app")
-				configFile = baseConfigFile
-				configFound = true
-				break
-			}
-		} else if fileExists(path) {
-			v.SetConfigFile(path)
-			configFile = path
-			configFound = true
-			break
-		}
+	configFile, err := findConfigFile(configPath)
+	if err != nil {
+		return nil, err
 	}
 
-	if !configFound {
-		return nil, fmt.Errorf("无法找到配置文件，已尝试路径: %v", configPaths)
-	}
+	v := viper.New()
+	v.SetConfigFile(configFile)
 
 	// 输出找到的配置文件路径，便于调试
 	fmt.Printf("使用配置文件: %s\n", configFile)
@@ -263,10 +284,32 @@ func LoadConfigWithEnv(configPath string, env string) (*Config, error) {
 		fmt.Printf("找不到OSS配置文件: %s，将使用默认OSS配置\n", ossConfigFile)
 	}
 
-	globalConfig = config
+	globalConfig.Store(config)
 	return config, nil
 }
 
+// findConfigFile 查找基本配置文件，configPath 可以是目录或配置文件路径，找不到时依次尝试常用的配置目录
+func findConfigFile(configPath string) (string, error) {
+	configPaths := []string{
+		configPath,      // 原始传入路径
+		"./configs",     // 相对于运行目录
+		"../configs",    // 上一级目录
+		"../../configs", // 上两级目录
+	}
+
+	for _, path := range configPaths {
+		if isDir(path) {
+			baseConfigFile := fmt.Sprintf("%s/app.yaml", path)
+			if fileExists(baseConfigFile) {
+				return baseConfigFile, nil
+			}
+		} else if fileExists(path) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("无法找到配置文件，已尝试路径: %v", configPaths)
+}
+
 // 检查是否是目录
 func isDir(path string) bool {
 	info, err := os.Stat(path)
@@ -282,13 +325,25 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-// GetConfig 获取全局配置
+// GetConfig 获取全局配置，配置热加载后返回新的配置
 func GetConfig() *Config {
-	return globalConfig
+	return globalConfig.Load()
 }
 
 // GetDSN 获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+	switch c.Driver {
+	case "sqlite":
+		// 开启外键约束和区分大小写的 LIKE，与 PostgreSQL 行为一致；WAL 模式下读写互不阻塞，并发写入时等待锁而不是立即失败
+		return fmt.Sprintf("file:%s?_foreign_keys=on&_case_sensitive_like=on&_busy_timeout=5000&_journal_mode=WAL", c.DBName)
+	case "mysql":
+		// 迁移脚本包含多条语句，需要开启 multiStatements
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=true&loc=Local&multiStatements=true",
+			c.Username, c.Password, c.Host, c.Port, c.DBName)
+	}
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.Username, c.Password, c.DBName, c.SSLMode)
 }
@@ -298,11 +353,108 @@ func (c *DatabaseConfig) GetConnMaxLifetime() time.Duration {
 	return time.Duration(c.ConnMaxLifetime) * time.Second
 }
 
+// GetPollInterval 获取检查存储配置表变化的间隔
+func (c *ReloadConfig) GetPollInterval() time.Duration {
+	if c.PollInterval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.PollInterval) * time.Second
+}
+
 // GetJWTExpiration 获取 JWT 过期时间
 func (c *JWTConfig) GetJWTExpiration() time.Duration {
 	return time.Duration(c.ExpiresIn) * time.Second
 }
 
+// GetRetention 获取回收站保留时长
+func (c *TrashConfig) GetRetention() time.Duration {
+	days := c.RetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetPurgeInterval 获取回收站过期清理间隔
+func (c *TrashConfig) GetPurgeInterval() time.Duration {
+	if c.PurgeInterval <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.PurgeInterval) * time.Second
+}
+
+// GetRecalculateInterval 获取配额用量重新统计间隔
+func (c *QuotaConfig) GetRecalculateInterval() time.Duration {
+	if c.RecalculateInterval <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.RecalculateInterval) * time.Second
+}
+
+// GetSessionTTL 获取上传会话保留时长
+func (c *UploadConfig) GetSessionTTL() time.Duration {
+	if c.SessionTTL <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(c.SessionTTL) * time.Second
+}
+
+// GetCleanupInterval 获取过期上传会话清理间隔
+func (c *UploadConfig) GetCleanupInterval() time.Duration {
+	if c.CleanupInterval <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.CleanupInterval) * time.Second
+}
+
+// GetChunkSize 获取 tus 上传的分片大小
+func (c *UploadConfig) GetChunkSize() int64 {
+	if c.ChunkSize <= 0 {
+		return 10 * 1024 * 1024
+	}
+	return c.ChunkSize
+}
+
+// GetSpoolDir 获取上传暂存目录
+func (c *UploadConfig) GetSpoolDir() string {
+	if c.SpoolDir == "" {
+		return filepath.Join(os.TempDir(), "ossmanager-uploads")
+	}
+	return c.SpoolDir
+}
+
+// GetWorkers 获取后台任务工作协程数量
+func (c *JobsConfig) GetWorkers() int {
+	if c.Workers <= 0 {
+		return 4
+	}
+	return c.Workers
+}
+
+// GetMaxAttempts 获取后台任务最大尝试次数
+func (c *JobsConfig) GetMaxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return 3
+	}
+	return c.MaxAttempts
+}
+
+// GetRetryBackoff 获取后台任务重试基础等待时间
+func (c *JobsConfig) GetRetryBackoff() time.Duration {
+	if c.RetryBackoff <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.RetryBackoff) * time.Second
+}
+
+// GetPollInterval 获取待执行任务轮询间隔
+func (c *JobsConfig) GetPollInterval() time.Duration {
+	if c.PollInterval <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(c.PollInterval) * time.Second
+}
+
 // GetOSSURLExpiration 获取对象存储 URL 过期时间
 func (c *AliyunOSSConfig) GetOSSURLExpiration() time.Duration {
 	return time.Duration(c.URLExpireTime) * time.Second
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce 文件变化后等待的时间，编辑器保存时通常会连续产生多个事件
+const watchDebounce = 500 * time.Millisecond
+
+// Watcher 监听配置文件变化，文件修改后重新加载全部配置
+type Watcher struct {
+	configPath string
+	env        string
+	onChange   func(cfg *Config, err error)
+	watcher    *fsnotify.Watcher
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewWatcher 创建配置文件监听器，参数与 LoadConfigWithEnv 相同
+// 配置重新加载后调用 onChange，加载失败时 cfg 为 nil，此时应继续使用原来的配置
+func NewWatcher(configPath, env string, onChange func(cfg *Config, err error)) *Watcher {
+	if env == "" {
+		env = "dev"
+	}
+	return &Watcher{
+		configPath: configPath,
+		env:        env,
+		onChange:   onChange,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start 开始监听配置目录
+// 监听的是目录而不是文件，编辑器通过重命名替换文件或 Kubernetes 更新 ConfigMap 时同样能收到事件
+func (w *Watcher) Start() error {
+	configFile, err := findConfigFile(w.configPath)
+	if err != nil {
+		return err
+	}
+	configDir := filepath.Dir(configFile)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建配置文件监听器失败: %w", err)
+	}
+	if err := watcher.Add(configDir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("监听配置目录失败: %w", err)
+	}
+	w.watcher = watcher
+
+	// 与 LoadConfigWithEnv 读取的文件保持一致，..data 是 Kubernetes ConfigMap 更新时替换的目录链接
+	files := map[string]bool{
+		filepath.Base(configFile):         true,
+		fmt.Sprintf("app.%s.yaml", w.env): true,
+		"oss.yaml":                        true,
+		fmt.Sprintf("oss.%s.yaml", w.env): true,
+		"..data":                          true,
+	}
+
+	w.wg.Add(1)
+	go w.loop(files)
+	return nil
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() {
+	if w.watcher == nil {
+		return
+	}
+	close(w.done)
+	w.watcher.Close()
+	w.wg.Wait()
+}
+
+// loop 处理文件事件，同一批修改只重新加载一次
+func (w *Watcher) loop(files map[string]bool) {
+	defer w.wg.Done()
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !files[filepath.Base(event.Name)] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			timer.Reset(watchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.onChange(nil, fmt.Errorf("监听配置文件失败: %w", err))
+		case <-timer.C:
+			cfg, err := LoadConfigWithEnv(w.configPath, w.env)
+			if err != nil {
+				w.onChange(nil, err)
+				continue
+			}
+			w.onChange(cfg, nil)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package db_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/auth"
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// testDatabases 返回需要测试的数据库，SQLite 总是测试
+// PostgreSQL 和 MySQL 通过 TEST_POSTGRES_DSN、TEST_MYSQL_DSN 指定，测试会回滚全部迁移后重建，请使用专门的测试库
+func testDatabases(t *testing.T) map[string]*config.DatabaseConfig {
+	databases := map[string]*config.DatabaseConfig{
+		"sqlite": {Driver: "sqlite", DBName: filepath.Join(t.TempDir(), "ossmanager.db")},
+	}
+	for driver, env := range map[string]string{"postgres": "TEST_POSTGRES_DSN", "mysql": "TEST_MYSQL_DSN"} {
+		if dsn := os.Getenv(env); dsn != "" {
+			databases[driver] = &config.DatabaseConfig{Driver: driver, DSN: dsn}
+		} else {
+			t.Logf("未设置 %s，跳过 %s", env, driver)
+		}
+	}
+	return databases
+}
+
+// openTestDatabase 连接数据库并重建表结构
+func openTestDatabase(t *testing.T, cfg *config.DatabaseConfig) *gorm.DB {
+	cfg.MaxIdleConns, cfg.MaxOpenConns = 2, 4
+	require.NoError(t, db.Init(cfg))
+	conn := db.GetDB()
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	applied, err := db.AppliedMigrations(conn)
+	require.NoError(t, err)
+	if len(applied) > 0 {
+		_, err = db.Rollback(conn, len(applied), false)
+		require.NoError(t, err)
+	}
+	_, err = db.Migrate(conn, false)
+	require.NoError(t, err)
+	return conn
+}
+
+func TestDialects(t *testing.T) {
+	for driver, cfg := range testDatabases(t) {
+		t.Run(driver, func(t *testing.T) {
+			conn := openTestDatabase(t, cfg)
+
+			t.Run("seed", func(t *testing.T) {
+				var admin models.User
+				require.NoError(t, conn.Preload("Roles").Where("username = ?", "admin").First(&admin).Error)
+				require.Len(t, admin.Roles, 1)
+				assert.Equal(t, "admin", admin.Roles[0].Name)
+			})
+
+			t.Run("permission", func(t *testing.T) {
+				var admin models.User
+				require.NoError(t, conn.Where("username = ?", "admin").First(&admin).Error)
+				// 拥有 manage 权限即可执行该资源的其他操作
+				assert.NoError(t, auth.CheckPermission(admin.ID, "file", "read"))
+				assert.ErrorIs(t, auth.CheckPermission(admin.ID, "audit", "read"), auth.ErrPermissionDenied)
+
+				user := &models.User{OrgID: models.DefaultOrgID, Username: "alice", Password: "x", Email: "alice@example.com", Status: true}
+				require.NoError(t, conn.Create(user).Error)
+				assert.ErrorIs(t, auth.CheckPermission(user.ID, "file", "read"), auth.ErrPermissionDenied)
+
+				// 已删除的角色不再授予权限
+				var role models.Role
+				require.NoError(t, conn.Where("name = ?", "admin").First(&role).Error)
+				require.NoError(t, conn.Model(user).Association("Roles").Append(&role))
+				assert.NoError(t, auth.CheckPermission(user.ID, "file", "read"))
+				require.NoError(t, conn.Delete(&role).Error)
+				assert.ErrorIs(t, auth.CheckPermission(user.ID, "file", "read"), auth.ErrPermissionDenied)
+				require.NoError(t, conn.Unscoped().Model(&role).Update("deleted_at", nil).Error)
+			})
+
+			t.Run("like", func(t *testing.T) {
+				for _, key := range []string{"Docs/a.txt", "a_b/c.txt", "axb/c.txt"} {
+					require.NoError(t, conn.Create(&models.OSSFile{OrgID: models.DefaultOrgID, Filename: key, OriginalFilename: key, FileSize: 1,
+						StorageType: "AWS_S3", Bucket: "like", ObjectKey: key, UploaderID: 1, Status: "ACTIVE"}).Error)
+				}
+				count := func(pattern string) int64 {
+					var n int64
+					require.NoError(t, conn.Model(&models.OSSFile{}).
+						Where("bucket = ? AND object_key LIKE ? ESCAPE '!'", "like", pattern).Count(&n).Error)
+					return n
+				}
+				// LIKE 区分大小写，'!' 转义通配符
+				assert.EqualValues(t, 0, count("docs/%"))
+				assert.EqualValues(t, 1, count("Docs/%"))
+				assert.EqualValues(t, 1, count("a!_b/%"))
+				assert.EqualValues(t, 2, count("a_b/%"))
+			})
+
+			t.Run("constraints", func(t *testing.T) {
+				assert.Error(t, conn.Create(&models.FileTag{FileID: 1 << 30, Key: "k"}).Error, "外键约束")
+
+				// 已删除的地域-桶映射不参与唯一约束
+				mapping := &models.RegionBucketMapping{OrgID: models.DefaultOrgID, RegionCode: "cn-hangzhou", BucketName: "unique"}
+				require.NoError(t, conn.Create(mapping).Error)
+				assert.Error(t, conn.Create(&models.RegionBucketMapping{OrgID: models.DefaultOrgID, RegionCode: "cn-hangzhou", BucketName: "unique"}).Error)
+				require.NoError(t, conn.Delete(mapping).Error)
+				assert.NoError(t, conn.Create(&models.RegionBucketMapping{OrgID: models.DefaultOrgID, RegionCode: "cn-hangzhou", BucketName: "unique"}).Error)
+			})
+
+			t.Run("audit log", func(t *testing.T) {
+				log := &models.AuditLog{OrgID: models.DefaultOrgID, UserID: 1, Action: "UPLOAD", Details: `{"file_id":1}`, Status: "SUCCESS"}
+				require.NoError(t, conn.Create(log).Error)
+				var loaded models.AuditLog
+				require.NoError(t, conn.First(&loaded, log.ID).Error)
+				assert.JSONEq(t, log.Details, loaded.Details)
+			})
+
+			t.Run("organizations", func(t *testing.T) {
+				var admin models.Role
+				require.NoError(t, conn.Where("name = ?", "admin").First(&admin).Error)
+				assert.Equal(t, models.DefaultOrgID, admin.OrgID)
+				assert.Equal(t, models.AdminScopeGlobal, admin.AdminScope)
+
+				// 角色名在组织内唯一，不同组织可以同名
+				org := &models.Organization{Name: "acme", Status: true}
+				require.NoError(t, conn.Create(org).Error)
+				role := &models.Role{OrgID: org.ID, Name: "admin", AdminScope: models.AdminScopeOrg}
+				require.NoError(t, conn.Create(role).Error)
+				assert.Error(t, conn.Create(&models.Role{OrgID: org.ID, Name: "admin"}).Error)
+
+				// 按组织范围查询只能看到本组织的角色
+				ctx := tenant.WithScope(context.Background(), &tenant.Scope{OrgID: org.ID, UserOrgID: org.ID})
+				var roles []models.Role
+				require.NoError(t, conn.WithContext(ctx).Where("name = ?", "admin").Find(&roles).Error)
+				require.Len(t, roles, 1)
+				assert.Equal(t, role.ID, roles[0].ID)
+
+				// 回滚迁移要求角色名全局唯一，清理本组织的数据
+				require.NoError(t, conn.Unscoped().Delete(role).Error)
+				require.NoError(t, conn.Unscoped().Delete(org).Error)
+			})
+
+			t.Run("rollback", func(t *testing.T) {
+				migrations, err := db.Migrate(conn, false)
+				require.NoError(t, err)
+				assert.Empty(t, migrations)
+
+				rolledBack, err := db.Rollback(conn, 1, false)
+				require.NoError(t, err)
+				require.Len(t, rolledBack, 1)
+				migrations, err = db.Migrate(conn, false)
+				require.NoError(t, err)
+				assert.Len(t, migrations, 1)
+			})
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 每种数据库的迁移位于 migrations/<数据库类型> 目录，数据库类型与 gorm 方言名称一致
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// 迁移使用的数据库锁，多个实例同时启动时只有一个执行迁移：PostgreSQL 使用咨询锁，MySQL 使用命名锁
+// SQLite 写操作本身互斥，不需要额外加锁
+const (
+	migrationLockID   int64 = 0x6f73736d6967 // "ossmig"
+	migrationLockName       = "ossmanager_migrate"
+)
+
+// Migration 一个版本的迁移，文件名格式为 <版本>_<名称>.up.sql 和 <版本>_<名称>.down.sql
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   string    `gorm:"size:20;primaryKey" json:"version"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrations 返回指定数据库类型内置的全部迁移，按版本号升序
+func Migrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("不支持的数据库类型: %s", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := cutDirection(file)
+		version, name, found := strings.Cut(base, "_")
+		if !ok || !found || entry.IsDir() {
+			return nil, fmt.Errorf("迁移文件名不合法: %s", file)
+		}
+		content, err := migrationFiles.ReadFile(path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("迁移版本 %s 重复: %s 和 %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("迁移 %s_%s 缺少 up 或 down 脚本", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// cutDirection 拆分文件名中的迁移方向
+func cutDirection(file string) (string, string, bool) {
+	for _, direction := range []string{"up", "down"} {
+		if base, ok := strings.CutSuffix(file, "."+direction+".sql"); ok {
+			return base, direction, true
+		}
+	}
+	return "", "", false
+}
+
+// AppliedMigrations 返回已执行的迁移记录，按版本号升序，会在需要时创建 schema_migrations 表
+func AppliedMigrations(tx *gorm.DB) ([]SchemaMigration, error) {
+	if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`).Error; err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	var applied []SchemaMigration
+	if err := tx.Order("version").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	return applied, nil
+}
+
+// pendingMigrations 返回尚未执行的迁移
+func pendingMigrations(tx *gorm.DB) ([]Migration, error) {
+	applied, err := AppliedMigrations(tx)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	all, err := Migrations(tx.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range all {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// withMigrationLock 在同一个连接上持有迁移锁执行 fn，其他实例会等待锁释放
+func withMigrationLock(tx *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return tx.Connection(func(conn *gorm.DB) error {
+		var lock, unlock string
+		var arg interface{}
+		switch conn.Dialector.Name() {
+		case "postgres":
+			lock, unlock, arg = "SELECT pg_advisory_lock(?)", "SELECT pg_advisory_unlock(?)", migrationLockID
+		case "mysql":
+			// 超时为 -1 表示一直等待
+			lock, unlock, arg = "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)", migrationLockName
+		default:
+			return fn(conn)
+		}
+
+		if err := conn.Exec(lock, arg).Error; err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		defer func() {
+			if err := conn.Exec(unlock, arg).Error; err != nil {
+				logger.Warn("释放迁移锁失败", zap.Error(err))
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// Migrate 按顺序执行尚未执行的迁移，每个迁移在单独的事务中执行，返回已执行（试运行时为待执行）的迁移
+// 迁移文件均可重复执行，已手工执行过迁移的数据库也可以直接使用
+func Migrate(tx *gorm.DB, dryRun bool) ([]Migration, error) {
+	var done []Migration
+	err := withMigrationLock(tx, func(conn *gorm.DB) error {
+		pending, err := pendingMigrations(conn)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			done = pending
+			return nil
+		}
+
+		for _, m := range pending {
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("执行迁移 %s_%s 失败: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+			logger.Info("数据库迁移完成", zap.String("version", m.Version), zap.String("name", m.Name))
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Rollback 按版本号倒序回滚最近执行的 steps 个迁移，返回已回滚（试运行时为待回滚）的迁移
+func Rollback(tx *gorm.DB, steps int, dryRun bool) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("回滚数量必须大于 0")
+	}
+
+	var done []Migration
+	err := withMigrationLock(tx, func(conn *gorm.DB) error {
+		applied, err := AppliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		all, err := Migrations(conn.Dialector.Name())
+		if err != nil {
+			return err
+		}
+		known := make(map[string]Migration, len(all))
+		for _, m := range all {
+			known[m.Version] = m
+		}
+
+		var targets []Migration
+		for i := len(applied) - 1; i >= 0 && len(targets) < steps; i-- {
+			m, ok := known[applied[i].Version]
+			if !ok {
+				return fmt.Errorf("迁移 %s 已执行，但当前版本中没有对应的迁移文件", applied[i].Version)
+			}
+			targets = append(targets, m)
+		}
+		if dryRun {
+			done = targets
+			return nil
+		}
+
+		for _, m := range targets {
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Down).Error; err != nil {
+					return err
+				}
+				return tx.Where("version = ?", m.Version).Delete(&SchemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("回滚迁移 %s_%s 失败: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+			logger.Info("数据库迁移已回滚", zap.String("version", m.Version), zap.String("name", m.Name))
+		}
+		return nil
+	})
+	return done, err
+}
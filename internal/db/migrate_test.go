@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	latest := make(map[string]string)
+	for _, dialect := range []string{"postgres", "mysql", "sqlite"} {
+		migrations, err := Migrations(dialect)
+		require.NoError(t, err, dialect)
+		require.NotEmpty(t, migrations, dialect)
+
+		seen := make(map[string]bool)
+		for i, m := range migrations {
+			assert.False(t, seen[m.Version], "重复的迁移版本 %s", m.Version)
+			seen[m.Version] = true
+			assert.NotEmpty(t, m.Up, m.Name)
+			assert.NotEmpty(t, m.Down, m.Name)
+			if i > 0 {
+				assert.Less(t, migrations[i-1].Version, m.Version)
+			}
+		}
+		latest[dialect] = migrations[len(migrations)-1].Version
+	}
+
+	postgres, _ := Migrations("postgres")
+	assert.Equal(t, "001", postgres[0].Version)
+	// 新增迁移需要同时提供各数据库的版本
+	assert.Equal(t, latest["postgres"], latest["mysql"])
+	assert.Equal(t, latest["postgres"], latest["sqlite"])
+
+	_, err := Migrations("oracle")
+	assert.Error(t, err)
+}
@@ -0,0 +1,364 @@
+-- MySQL 基线结构，对应 PostgreSQL 迁移 001 至 017 执行后的结构，需要 MySQL 8.0 及以上版本
+-- 之后的迁移需要同时提供 postgres、mysql 和 sqlite 三个版本
+-- 统一使用 utf8mb4_bin 排序规则，使比较和唯一约束与 PostgreSQL 一样区分大小写
+-- 外键写在表级别，MySQL 会忽略列定义中的 REFERENCES
+
+CREATE TABLE IF NOT EXISTS users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    email VARCHAR(100) UNIQUE NOT NULL,
+    real_name VARCHAR(100),
+    status BOOLEAN DEFAULT true,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_users_username (username),
+    INDEX idx_users_email (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS roles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    resource VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT,
+    role_id INT,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    UNIQUE(user_id, role_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    role_id INT,
+    permission_id INT,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    UNIQUE(role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS oss_files (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    filename VARCHAR(255) NOT NULL,
+    original_filename VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL,
+    md5 VARCHAR(32),
+    md5_status VARCHAR(20) DEFAULT 'PENDING',
+    storage_type VARCHAR(20) NOT NULL,
+    bucket VARCHAR(100) NOT NULL,
+    object_key VARCHAR(255) NOT NULL,
+    version_key VARCHAR(512),
+    download_url TEXT,
+    expires_at DATETIME(3),
+    uploader_id INT,
+    upload_ip VARCHAR(50),
+    config_id INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'ACTIVE',
+    trash_key VARCHAR(512),
+    trashed_at DATETIME(3),
+    trashed_by INT,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_oss_files_filename (filename),
+    INDEX idx_oss_files_md5 (md5),
+    INDEX idx_oss_files_uploader_id (uploader_id),
+    INDEX idx_oss_files_created_at (created_at),
+    INDEX idx_oss_files_bucket_object_key (bucket, object_key),
+    INDEX idx_oss_files_trashed_at (trashed_at),
+    INDEX idx_oss_files_status_created_at (status, created_at DESC, id DESC),
+    INDEX idx_oss_files_status_file_size (status, file_size, id),
+    INDEX idx_oss_files_bucket_status_created_at (bucket, status, created_at DESC, id DESC),
+    FOREIGN KEY (uploader_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS oss_configs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    storage_type VARCHAR(20) NOT NULL,
+    access_key VARCHAR(255) NOT NULL,
+    secret_key VARCHAR(255) NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    bucket VARCHAR(100) NOT NULL,
+    region VARCHAR(50),
+    is_default BOOLEAN DEFAULT false,
+    url_expire_time INT DEFAULT 86400,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT,
+    username VARCHAR(50),
+    action VARCHAR(50) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(100),
+    details TEXT,
+    ip_address VARCHAR(50),
+    user_agent TEXT,
+    status VARCHAR(20) NOT NULL,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_audit_logs_user_id (user_id),
+    INDEX idx_audit_logs_action (action),
+    INDEX idx_audit_logs_created_at (created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS share_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    file_id INT NOT NULL,
+    creator_id INT NOT NULL,
+    password_hash VARCHAR(255),
+    expires_at DATETIME(3),
+    max_downloads INT DEFAULT 0,
+    download_count INT NOT NULL DEFAULT 0,
+    last_accessed_at DATETIME(3),
+    revoked_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_share_links_file_id (file_id),
+    INDEX idx_share_links_creator_id (creator_id),
+    FOREIGN KEY (file_id) REFERENCES oss_files(id),
+    FOREIGN KEY (creator_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS upload_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    creator_id INT NOT NULL,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(255),
+    description TEXT,
+    expires_at DATETIME(3) NOT NULL,
+    max_file_size BIGINT DEFAULT 0,
+    allowed_extensions VARCHAR(255),
+    max_files INT DEFAULT 0,
+    upload_count INT NOT NULL DEFAULT 0,
+    revoked_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_upload_requests_creator_id (creator_id),
+    FOREIGN KEY (creator_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    scope_type VARCHAR(20) NOT NULL,
+    scope_id INT NOT NULL DEFAULT 0,
+    bucket_name VARCHAR(100) NOT NULL DEFAULT '',
+    max_bytes BIGINT DEFAULT 0,
+    max_objects BIGINT DEFAULT 0,
+    used_bytes BIGINT DEFAULT 0,
+    used_objects BIGINT DEFAULT 0,
+    usage_calculated_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    UNIQUE INDEX idx_storage_quota_scope (scope_type, scope_id, bucket_name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS bucket_upload_policies (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    region_bucket_mapping_id INT UNIQUE NOT NULL,
+    max_file_size BIGINT DEFAULT 0,
+    allowed_extensions VARCHAR(500),
+    allowed_mime_types VARCHAR(500),
+    path_pattern VARCHAR(255),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS file_tags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    file_id INT NOT NULL,
+    `key` VARCHAR(128) NOT NULL,
+    value VARCHAR(256) NOT NULL DEFAULT '',
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    UNIQUE INDEX idx_file_tags_file_key (file_id, `key`),
+    INDEX idx_file_tags_key_value (`key`, value),
+    FOREIGN KEY (file_id) REFERENCES oss_files(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS virtual_folders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    bucket VARCHAR(100) NOT NULL,
+    path VARCHAR(512) NOT NULL,
+    creator_id INT NOT NULL,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    UNIQUE INDEX idx_virtual_folders_bucket_path (bucket, path),
+    FOREIGN KEY (creator_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_id VARCHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    creator_id INT,
+    params TEXT,
+    result TEXT,
+    error TEXT,
+    total BIGINT NOT NULL DEFAULT 0,
+    done BIGINT NOT NULL DEFAULT 0,
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 1,
+    run_after DATETIME(3),
+    started_at DATETIME(3),
+    finished_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    UNIQUE INDEX idx_jobs_task_id (task_id),
+    INDEX idx_jobs_type (type),
+    INDEX idx_jobs_status_run_after (status, run_after),
+    INDEX idx_jobs_creator_id (creator_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS bulk_operations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    creator_id INT NOT NULL,
+    job_id INT,
+    action VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    params TEXT,
+    total INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    skipped INT NOT NULL DEFAULT 0,
+    results TEXT,
+    error TEXT,
+    started_at DATETIME(3),
+    finished_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_bulk_operations_creator_id (creator_id),
+    INDEX idx_bulk_operations_job_id (job_id),
+    FOREIGN KEY (creator_id) REFERENCES users(id),
+    FOREIGN KEY (job_id) REFERENCES jobs(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    owner_id INT NOT NULL,
+    mode VARCHAR(10) NOT NULL,
+    storage_type VARCHAR(20) NOT NULL,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(100) NOT NULL,
+    object_key VARCHAR(512) NOT NULL,
+    original_filename VARCHAR(255),
+    upload_id VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    chunk_size BIGINT NOT NULL DEFAULT 0,
+    total_parts INT NOT NULL DEFAULT 0,
+    parts_done INT NOT NULL DEFAULT 0,
+    uploaded_bytes BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    last_error TEXT,
+    expires_at DATETIME(3) NOT NULL,
+    completed_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    UNIQUE INDEX idx_upload_sessions_session_id (session_id),
+    INDEX idx_upload_sessions_owner_id (owner_id),
+    INDEX idx_upload_sessions_status (status),
+    INDEX idx_upload_sessions_expires_at (expires_at),
+    FOREIGN KEY (owner_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS access_keys (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    access_key_id VARCHAR(32) NOT NULL,
+    secret_key VARCHAR(64) NOT NULL,
+    description VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    last_used_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    UNIQUE INDEX idx_access_keys_access_key_id (access_key_id),
+    INDEX idx_access_keys_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS region_bucket_mapping (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(255) NOT NULL,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    -- MySQL 不支持部分索引，未删除的记录为 1、已删除的记录为 NULL，唯一索引不比较 NULL
+    active TINYINT GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) VIRTUAL,
+    INDEX idx_region_bucket_mapping_region_code (region_code),
+    INDEX idx_region_bucket_mapping_bucket_name (bucket_name),
+    UNIQUE INDEX idx_region_bucket_mapping_region_bucket (region_code, bucket_name, active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+CREATE TABLE IF NOT EXISTS role_region_bucket_access (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    role_id INT NOT NULL,
+    region_bucket_mapping_id INT NOT NULL,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3),
+    INDEX idx_role_region_bucket_access_role_id (role_id),
+    INDEX idx_role_region_bucket_access_mapping_id (region_bucket_mapping_id),
+    UNIQUE INDEX idx_role_region_bucket_access_role_mapping (role_id, region_bucket_mapping_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (region_bucket_mapping_id) REFERENCES region_bucket_mapping(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- 基础数据（管理员密码：admin123）
+INSERT INTO roles (name, description) VALUES ('admin', '系统管理员');
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('user_manage', '用户管理', 'user', 'manage'),
+('role_manage', '角色管理', 'role', 'manage'),
+('file_manage', '文件管理', 'file', 'manage'),
+('oss_config', 'OSS配置管理', 'oss_config', 'manage');
+
+INSERT INTO users (username, password, email, real_name, status) VALUES
+('admin', '$2a$10$N.zmdr9k7uOCQb376NoUnuTJ8iAt6Z5EHsM8lE9lBpwTTyU3VxqW', 'admin@example.com', '系统管理员', true);
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r WHERE u.username = 'admin' AND r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p WHERE r.name = 'admin';
@@ -0,0 +1,5 @@
+-- 已加密的凭据超过原来的列长度，回滚会失败，回滚前需要重新录入明文凭据
+ALTER TABLE access_keys MODIFY COLUMN secret_key VARCHAR(64) NOT NULL;
+ALTER TABLE oss_configs
+    MODIFY COLUMN secret_key VARCHAR(255) NOT NULL,
+    MODIFY COLUMN access_key VARCHAR(255) NOT NULL;
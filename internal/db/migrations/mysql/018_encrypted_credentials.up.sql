@@ -0,0 +1,5 @@
+-- 凭据改为加密保存，密文长度超过原来的列长度
+ALTER TABLE oss_configs
+    MODIFY COLUMN access_key VARCHAR(512) NOT NULL,
+    MODIFY COLUMN secret_key VARCHAR(512) NOT NULL;
+ALTER TABLE access_keys MODIFY COLUMN secret_key VARCHAR(512) NOT NULL;
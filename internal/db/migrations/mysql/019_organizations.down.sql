@@ -0,0 +1,20 @@
+-- 不同组织中有同名角色时回滚会失败，回滚前需要先重命名
+ALTER TABLE roles DROP FOREIGN KEY fk_roles_org;
+ALTER TABLE roles
+    DROP INDEX idx_roles_org_name,
+    DROP COLUMN admin_scope,
+    DROP COLUMN org_id,
+    ADD UNIQUE INDEX name (name);
+
+ALTER TABLE audit_logs DROP FOREIGN KEY fk_audit_logs_org;
+ALTER TABLE audit_logs DROP COLUMN org_id;
+ALTER TABLE oss_files DROP FOREIGN KEY fk_oss_files_org;
+ALTER TABLE oss_files DROP COLUMN org_id;
+ALTER TABLE region_bucket_mapping DROP FOREIGN KEY fk_region_bucket_mapping_org;
+ALTER TABLE region_bucket_mapping DROP COLUMN org_id;
+ALTER TABLE oss_configs DROP FOREIGN KEY fk_oss_configs_org;
+ALTER TABLE oss_configs DROP COLUMN org_id;
+ALTER TABLE users DROP FOREIGN KEY fk_users_org;
+ALTER TABLE users DROP COLUMN org_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,44 @@
+-- 组织（租户），用户、角色、存储配置、地域-桶映射、文件和审计日志归属于组织，组织之间的数据互相隔离
+CREATE TABLE IF NOT EXISTS organizations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    status BOOLEAN DEFAULT true,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    deleted_at DATETIME(3)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- 已有数据归属默认组织
+INSERT IGNORE INTO organizations (id, name, description) VALUES (1, 'default', '默认组织');
+
+ALTER TABLE users
+    ADD COLUMN org_id INT NOT NULL DEFAULT 1,
+    ADD INDEX idx_users_org_id (org_id),
+    ADD CONSTRAINT fk_users_org FOREIGN KEY (org_id) REFERENCES organizations(id);
+ALTER TABLE oss_configs
+    ADD COLUMN org_id INT NOT NULL DEFAULT 1,
+    ADD INDEX idx_oss_configs_org_id (org_id),
+    ADD CONSTRAINT fk_oss_configs_org FOREIGN KEY (org_id) REFERENCES organizations(id);
+ALTER TABLE region_bucket_mapping
+    ADD COLUMN org_id INT NOT NULL DEFAULT 1,
+    ADD INDEX idx_region_bucket_mapping_org_id (org_id),
+    ADD CONSTRAINT fk_region_bucket_mapping_org FOREIGN KEY (org_id) REFERENCES organizations(id);
+ALTER TABLE oss_files
+    ADD COLUMN org_id INT NOT NULL DEFAULT 1,
+    ADD INDEX idx_oss_files_org_id (org_id),
+    ADD CONSTRAINT fk_oss_files_org FOREIGN KEY (org_id) REFERENCES organizations(id);
+ALTER TABLE audit_logs
+    ADD COLUMN org_id INT NOT NULL DEFAULT 1,
+    ADD INDEX idx_audit_logs_org_id (org_id),
+    ADD CONSTRAINT fk_audit_logs_org FOREIGN KEY (org_id) REFERENCES organizations(id);
+
+-- 角色名称改为组织内唯一
+-- 管理员角色：ORG 为组织管理员，GLOBAL 为全局管理员，内置的 admin 角色为全局管理员
+ALTER TABLE roles
+    DROP INDEX name,
+    ADD COLUMN org_id INT NOT NULL DEFAULT 1,
+    ADD COLUMN admin_scope VARCHAR(20),
+    ADD UNIQUE INDEX idx_roles_org_name (org_id, name),
+    ADD CONSTRAINT fk_roles_org FOREIGN KEY (org_id) REFERENCES organizations(id);
+UPDATE roles SET admin_scope = 'GLOBAL' WHERE name = 'admin' AND org_id = 1;
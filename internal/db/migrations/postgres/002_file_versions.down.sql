@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_oss_files_bucket_object_key;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS version_key;
@@ -0,0 +1,5 @@
+-- 文件版本历史：被覆盖的记录保留历史版本对象键
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS version_key VARCHAR(512);
+
+-- 按路径查询版本列表
+CREATE INDEX IF NOT EXISTS idx_oss_files_bucket_object_key ON oss_files(bucket, object_key);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_oss_files_trashed_at;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS trashed_by;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS trashed_at;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS trash_key;
@@ -0,0 +1,6 @@
+-- 回收站：删除的文件先移入回收站前缀，保留期后再彻底清理
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS trash_key VARCHAR(512);
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS trashed_at TIMESTAMP;
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS trashed_by INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_oss_files_trashed_at ON oss_files(trashed_at);
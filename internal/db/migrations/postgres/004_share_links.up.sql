@@ -0,0 +1,19 @@
+-- 公开分享链接：每次访问时重新签发短期下载链接
+CREATE TABLE IF NOT EXISTS share_links (
+    id SERIAL PRIMARY KEY,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    file_id INTEGER NOT NULL REFERENCES oss_files(id),
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    password_hash VARCHAR(255),
+    expires_at TIMESTAMP,
+    max_downloads INTEGER DEFAULT 0,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_file_id ON share_links(file_id);
+CREATE INDEX IF NOT EXISTS idx_share_links_creator_id ON share_links(creator_id);
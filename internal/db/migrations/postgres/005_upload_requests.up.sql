@@ -0,0 +1,21 @@
+-- 上传请求链接：外部用户无需账号即可向指定存储桶目录上传文件
+CREATE TABLE IF NOT EXISTS upload_requests (
+    id SERIAL PRIMARY KEY,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(255),
+    description TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    max_file_size BIGINT DEFAULT 0,
+    allowed_extensions VARCHAR(255),
+    max_files INTEGER DEFAULT 0,
+    upload_count INTEGER NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_requests_creator_id ON upload_requests(creator_id);
@@ -0,0 +1,17 @@
+-- 存储配额：按用户、角色或存储桶限制容量和对象数
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    id SERIAL PRIMARY KEY,
+    scope_type VARCHAR(20) NOT NULL,
+    scope_id INTEGER NOT NULL DEFAULT 0,
+    bucket_name VARCHAR(100) NOT NULL DEFAULT '',
+    max_bytes BIGINT DEFAULT 0,
+    max_objects BIGINT DEFAULT 0,
+    used_bytes BIGINT DEFAULT 0,
+    used_objects BIGINT DEFAULT 0,
+    usage_calculated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_storage_quota_scope ON storage_quotas(scope_type, scope_id, bucket_name);
@@ -0,0 +1,12 @@
+-- 存储桶上传策略：文件大小、扩展名和 MIME 白名单以及路径规则
+CREATE TABLE IF NOT EXISTS bucket_upload_policies (
+    id SERIAL PRIMARY KEY,
+    region_bucket_mapping_id INTEGER UNIQUE NOT NULL,
+    max_file_size BIGINT DEFAULT 0,
+    allowed_extensions VARCHAR(500),
+    allowed_mime_types VARCHAR(500),
+    path_pattern VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- 文件标签：键值对形式，用于标记和按标签检索文件
+CREATE TABLE IF NOT EXISTS file_tags (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL REFERENCES oss_files(id) ON DELETE CASCADE,
+    key VARCHAR(128) NOT NULL,
+    value VARCHAR(256) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_tags_file_key ON file_tags(file_id, key);
+CREATE INDEX IF NOT EXISTS idx_file_tags_key_value ON file_tags(key, value);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_oss_files_object_key_pattern;
+DROP INDEX IF EXISTS idx_oss_files_bucket_status_created_at;
+DROP INDEX IF EXISTS idx_oss_files_status_file_size;
+DROP INDEX IF EXISTS idx_oss_files_status_created_at;
@@ -0,0 +1,5 @@
+-- 文件列表查询索引：按状态排序的游标分页、存储桶内路径前缀匹配
+CREATE INDEX IF NOT EXISTS idx_oss_files_status_created_at ON oss_files(status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_oss_files_status_file_size ON oss_files(status, file_size, id);
+CREATE INDEX IF NOT EXISTS idx_oss_files_bucket_status_created_at ON oss_files(bucket, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_oss_files_object_key_pattern ON oss_files(bucket, object_key text_pattern_ops);
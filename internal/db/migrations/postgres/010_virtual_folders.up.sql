@@ -0,0 +1,12 @@
+-- 虚拟目录：记录用户显式创建的空目录，含有文件的目录由对象键前缀推导
+CREATE TABLE IF NOT EXISTS virtual_folders (
+    id SERIAL PRIMARY KEY,
+    bucket VARCHAR(100) NOT NULL,
+    path VARCHAR(512) NOT NULL,
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_virtual_folders_bucket_path ON virtual_folders(bucket, path);
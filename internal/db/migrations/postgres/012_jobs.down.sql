@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_bulk_operations_job_id;
+ALTER TABLE bulk_operations DROP COLUMN IF EXISTS job_id;
+DROP TABLE IF EXISTS jobs;
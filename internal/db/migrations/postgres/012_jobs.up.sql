@@ -0,0 +1,30 @@
+-- 通用后台任务：批量操作等长时间任务的持久化队列
+CREATE TABLE IF NOT EXISTS jobs (
+    id SERIAL PRIMARY KEY,
+    task_id VARCHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    creator_id INTEGER,
+    params TEXT,
+    result TEXT,
+    error TEXT,
+    total BIGINT NOT NULL DEFAULT 0,
+    done BIGINT NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 1,
+    run_after TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_task_id ON jobs(task_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs(type);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_run_after ON jobs(status, run_after);
+CREATE INDEX IF NOT EXISTS idx_jobs_creator_id ON jobs(creator_id);
+
+-- 批量操作改由后台任务执行
+ALTER TABLE bulk_operations ADD COLUMN IF NOT EXISTS job_id INTEGER REFERENCES jobs(id);
+CREATE INDEX IF NOT EXISTS idx_bulk_operations_job_id ON bulk_operations(job_id);
@@ -0,0 +1,30 @@
+-- 持久化分片上传会话：支持服务重启后续传，超时未完成的会话在存储端中止
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id SERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    owner_id INTEGER NOT NULL REFERENCES users(id),
+    mode VARCHAR(10) NOT NULL,
+    storage_type VARCHAR(20) NOT NULL,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(100) NOT NULL,
+    object_key VARCHAR(512) NOT NULL,
+    original_filename VARCHAR(255),
+    upload_id VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    chunk_size BIGINT NOT NULL DEFAULT 0,
+    total_parts INTEGER NOT NULL DEFAULT 0,
+    parts_done INTEGER NOT NULL DEFAULT 0,
+    uploaded_bytes BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    last_error TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upload_sessions_session_id ON upload_sessions(session_id);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_owner_id ON upload_sessions(owner_id);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_status ON upload_sessions(status);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
@@ -0,0 +1,16 @@
+-- S3 兼容接口访问密钥：每个用户可创建多对密钥，请求按 SigV4 签名校验
+CREATE TABLE IF NOT EXISTS access_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    access_key_id VARCHAR(32) NOT NULL,
+    secret_key VARCHAR(64) NOT NULL,
+    description VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_keys_access_key_id ON access_keys(access_key_id);
+CREATE INDEX IF NOT EXISTS idx_access_keys_user_id ON access_keys(user_id);
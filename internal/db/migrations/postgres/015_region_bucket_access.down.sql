@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS role_region_bucket_access;
+DROP TABLE IF EXISTS region_bucket_mapping;
@@ -0,0 +1,24 @@
+-- 地域-桶映射及角色访问权限表，此前仅由手工脚本创建
+CREATE TABLE IF NOT EXISTS region_bucket_mapping (
+    id SERIAL PRIMARY KEY,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_region_bucket_mapping_region_code ON region_bucket_mapping(region_code);
+CREATE INDEX IF NOT EXISTS idx_region_bucket_mapping_bucket_name ON region_bucket_mapping(bucket_name);
+
+CREATE TABLE IF NOT EXISTS role_region_bucket_access (
+    id SERIAL PRIMARY KEY,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    region_bucket_mapping_id INTEGER NOT NULL REFERENCES region_bucket_mapping(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_region_bucket_access_role_id ON role_region_bucket_access(role_id);
+CREATE INDEX IF NOT EXISTS idx_role_region_bucket_access_mapping_id ON role_region_bucket_access(region_bucket_mapping_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_role_region_bucket_access_role_mapping;
+DROP INDEX IF EXISTS idx_region_bucket_mapping_region_bucket;
@@ -0,0 +1,68 @@
+-- 按 doc/bucket-access.md 手工创建的表没有 deleted_at 列
+ALTER TABLE region_bucket_mapping ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE role_region_bucket_access ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- 合并重复的地域-桶映射：访问权限归到 ID 最小的映射，其余映射软删除
+-- 同一角色对同一地域-桶只保留一条访问权限，避免归并后重复
+WITH k AS (
+    SELECT id, MIN(id) OVER (PARTITION BY region_code, bucket_name) AS keep_id
+    FROM region_bucket_mapping
+    WHERE deleted_at IS NULL
+)
+DELETE FROM role_region_bucket_access a
+USING k ka, role_region_bucket_access b, k kb
+WHERE a.region_bucket_mapping_id = ka.id
+  AND b.region_bucket_mapping_id = kb.id
+  AND ka.keep_id = kb.keep_id
+  AND a.role_id = b.role_id
+  AND b.region_bucket_mapping_id < a.region_bucket_mapping_id;
+
+WITH k AS (
+    SELECT id, MIN(id) OVER (PARTITION BY region_code, bucket_name) AS keep_id
+    FROM region_bucket_mapping
+    WHERE deleted_at IS NULL
+)
+UPDATE role_region_bucket_access a
+SET region_bucket_mapping_id = k.keep_id
+FROM k
+WHERE a.region_bucket_mapping_id = k.id AND k.id <> k.keep_id;
+
+-- 上传策略每个映射只能有一条，保留映射没有策略时才从重复映射中迁移一条
+WITH k AS (
+    SELECT id, MIN(id) OVER (PARTITION BY region_code, bucket_name) AS keep_id
+    FROM region_bucket_mapping
+    WHERE deleted_at IS NULL
+), moved AS (
+    SELECT DISTINCT ON (k.keep_id) o.id, k.keep_id
+    FROM bucket_upload_policies o
+    JOIN k ON o.region_bucket_mapping_id = k.id
+    WHERE k.id <> k.keep_id
+      AND NOT EXISTS (SELECT 1 FROM bucket_upload_policies e WHERE e.region_bucket_mapping_id = k.keep_id)
+    ORDER BY k.keep_id, k.id
+)
+UPDATE bucket_upload_policies p
+SET region_bucket_mapping_id = moved.keep_id
+FROM moved
+WHERE p.id = moved.id;
+
+UPDATE region_bucket_mapping m
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE m.deleted_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM region_bucket_mapping k
+    WHERE k.region_code = m.region_code AND k.bucket_name = m.bucket_name
+      AND k.deleted_at IS NULL AND k.id < m.id
+  );
+
+-- 删除重复的访问权限，权限校验不区分软删除记录，因此直接删除
+DELETE FROM role_region_bucket_access a
+USING role_region_bucket_access b
+WHERE a.role_id = b.role_id
+  AND a.region_bucket_mapping_id = b.region_bucket_mapping_id
+  AND a.id > b.id;
+
+-- 软删除的映射不参与唯一约束，删除后可以重新添加
+CREATE UNIQUE INDEX IF NOT EXISTS idx_region_bucket_mapping_region_bucket
+    ON region_bucket_mapping(region_code, bucket_name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_region_bucket_access_role_mapping
+    ON role_region_bucket_access(role_id, region_bucket_mapping_id);
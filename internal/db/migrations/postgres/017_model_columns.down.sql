@@ -0,0 +1,3 @@
+ALTER TABLE oss_configs DROP COLUMN IF EXISTS url_expire_time;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS md5_status;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS config_id;
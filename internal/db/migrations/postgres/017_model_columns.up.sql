@@ -0,0 +1,4 @@
+-- 此前由 AutoMigrate 创建、迁移文件中缺少的列
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS config_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS md5_status VARCHAR(20) DEFAULT 'PENDING';
+ALTER TABLE oss_configs ADD COLUMN IF NOT EXISTS url_expire_time INTEGER DEFAULT 86400;
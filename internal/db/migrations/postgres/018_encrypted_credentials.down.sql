@@ -0,0 +1,4 @@
+-- 已加密的凭据超过原来的列长度，回滚会失败，回滚前需要重新录入明文凭据
+ALTER TABLE access_keys ALTER COLUMN secret_key TYPE VARCHAR(64);
+ALTER TABLE oss_configs ALTER COLUMN secret_key TYPE VARCHAR(255);
+ALTER TABLE oss_configs ALTER COLUMN access_key TYPE VARCHAR(255);
@@ -0,0 +1,4 @@
+-- 凭据改为加密保存，密文长度超过原来的列长度
+ALTER TABLE oss_configs ALTER COLUMN access_key TYPE VARCHAR(512);
+ALTER TABLE oss_configs ALTER COLUMN secret_key TYPE VARCHAR(512);
+ALTER TABLE access_keys ALTER COLUMN secret_key TYPE VARCHAR(512);
@@ -0,0 +1,13 @@
+-- 不同组织中有同名角色时回滚会失败，回滚前需要先重命名
+ALTER TABLE roles DROP COLUMN IF EXISTS admin_scope;
+DROP INDEX IF EXISTS idx_roles_org_name;
+ALTER TABLE roles ADD CONSTRAINT roles_name_key UNIQUE (name);
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS org_id;
+ALTER TABLE oss_files DROP COLUMN IF EXISTS org_id;
+ALTER TABLE region_bucket_mapping DROP COLUMN IF EXISTS org_id;
+ALTER TABLE oss_configs DROP COLUMN IF EXISTS org_id;
+ALTER TABLE roles DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,36 @@
+-- 组织（租户），用户、角色、存储配置、地域-桶映射、文件和审计日志归属于组织，组织之间的数据互相隔离
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    status BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+-- 已有数据归属默认组织
+INSERT INTO organizations (id, name, description) VALUES (1, 'default', '默认组织') ON CONFLICT (id) DO NOTHING;
+SELECT setval('organizations_id_seq', (SELECT MAX(id) FROM organizations));
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE oss_configs ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE region_bucket_mapping ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE oss_files ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+CREATE INDEX IF NOT EXISTS idx_users_org_id ON users(org_id);
+CREATE INDEX IF NOT EXISTS idx_oss_configs_org_id ON oss_configs(org_id);
+CREATE INDEX IF NOT EXISTS idx_region_bucket_mapping_org_id ON region_bucket_mapping(org_id);
+CREATE INDEX IF NOT EXISTS idx_oss_files_org_id ON oss_files(org_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_org_id ON audit_logs(org_id);
+
+-- 角色名称改为组织内唯一
+ALTER TABLE roles DROP CONSTRAINT IF EXISTS roles_name_key;
+DROP INDEX IF EXISTS idx_roles_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_org_name ON roles(org_id, name);
+
+-- 管理员角色：ORG 为组织管理员，GLOBAL 为全局管理员，内置的 admin 角色为全局管理员
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS admin_scope VARCHAR(20);
+UPDATE roles SET admin_scope = 'GLOBAL' WHERE name = 'admin' AND org_id = 1;
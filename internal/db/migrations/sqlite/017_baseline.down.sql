@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS role_region_bucket_access;
+DROP TABLE IF EXISTS region_bucket_mapping;
+DROP TABLE IF EXISTS access_keys;
+DROP TABLE IF EXISTS upload_sessions;
+DROP TABLE IF EXISTS bulk_operations;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS virtual_folders;
+DROP TABLE IF EXISTS file_tags;
+DROP TABLE IF EXISTS bucket_upload_policies;
+DROP TABLE IF EXISTS storage_quotas;
+DROP TABLE IF EXISTS upload_requests;
+DROP TABLE IF EXISTS share_links;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS oss_configs;
+DROP TABLE IF EXISTS oss_files;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS users;
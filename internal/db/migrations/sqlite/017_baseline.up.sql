@@ -0,0 +1,356 @@
+-- SQLite 基线结构，对应 PostgreSQL 迁移 001 至 017 执行后的结构
+-- 之后的迁移需要同时提供 postgres、mysql 和 sqlite 三个版本
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    email VARCHAR(100) UNIQUE NOT NULL,
+    real_name VARCHAR(100),
+    status BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    resource VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, role_id)
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    role_id INTEGER REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER REFERENCES permissions(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS oss_files (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename VARCHAR(255) NOT NULL,
+    original_filename VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL,
+    md5 VARCHAR(32),
+    md5_status VARCHAR(20) DEFAULT 'PENDING',
+    storage_type VARCHAR(20) NOT NULL,
+    bucket VARCHAR(100) NOT NULL,
+    object_key VARCHAR(255) NOT NULL,
+    version_key VARCHAR(512),
+    download_url TEXT,
+    expires_at TIMESTAMP,
+    uploader_id INTEGER REFERENCES users(id),
+    upload_ip VARCHAR(50),
+    config_id INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'ACTIVE',
+    trash_key VARCHAR(512),
+    trashed_at TIMESTAMP,
+    trashed_by INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS oss_configs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    storage_type VARCHAR(20) NOT NULL,
+    access_key VARCHAR(255) NOT NULL,
+    secret_key VARCHAR(255) NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    bucket VARCHAR(100) NOT NULL,
+    region VARCHAR(50),
+    is_default BOOLEAN DEFAULT false,
+    url_expire_time INTEGER DEFAULT 86400,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users(id),
+    username VARCHAR(50),
+    action VARCHAR(50) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(100),
+    details TEXT,
+    ip_address VARCHAR(50),
+    user_agent TEXT,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_oss_files_filename ON oss_files(filename);
+CREATE INDEX IF NOT EXISTS idx_oss_files_md5 ON oss_files(md5);
+CREATE INDEX IF NOT EXISTS idx_oss_files_uploader_id ON oss_files(uploader_id);
+CREATE INDEX IF NOT EXISTS idx_oss_files_created_at ON oss_files(created_at);
+CREATE INDEX IF NOT EXISTS idx_oss_files_bucket_object_key ON oss_files(bucket, object_key);
+CREATE INDEX IF NOT EXISTS idx_oss_files_trashed_at ON oss_files(trashed_at);
+CREATE INDEX IF NOT EXISTS idx_oss_files_status_created_at ON oss_files(status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_oss_files_status_file_size ON oss_files(status, file_size, id);
+CREATE INDEX IF NOT EXISTS idx_oss_files_bucket_status_created_at ON oss_files(bucket, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+
+CREATE TABLE IF NOT EXISTS share_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    file_id INTEGER NOT NULL REFERENCES oss_files(id),
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    password_hash VARCHAR(255),
+    expires_at TIMESTAMP,
+    max_downloads INTEGER DEFAULT 0,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_file_id ON share_links(file_id);
+CREATE INDEX IF NOT EXISTS idx_share_links_creator_id ON share_links(creator_id);
+
+CREATE TABLE IF NOT EXISTS upload_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(255),
+    description TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    max_file_size BIGINT DEFAULT 0,
+    allowed_extensions VARCHAR(255),
+    max_files INTEGER DEFAULT 0,
+    upload_count INTEGER NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_requests_creator_id ON upload_requests(creator_id);
+
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope_type VARCHAR(20) NOT NULL,
+    scope_id INTEGER NOT NULL DEFAULT 0,
+    bucket_name VARCHAR(100) NOT NULL DEFAULT '',
+    max_bytes BIGINT DEFAULT 0,
+    max_objects BIGINT DEFAULT 0,
+    used_bytes BIGINT DEFAULT 0,
+    used_objects BIGINT DEFAULT 0,
+    usage_calculated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_storage_quota_scope ON storage_quotas(scope_type, scope_id, bucket_name);
+
+CREATE TABLE IF NOT EXISTS bucket_upload_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    region_bucket_mapping_id INTEGER UNIQUE NOT NULL,
+    max_file_size BIGINT DEFAULT 0,
+    allowed_extensions VARCHAR(500),
+    allowed_mime_types VARCHAR(500),
+    path_pattern VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS file_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_id INTEGER NOT NULL REFERENCES oss_files(id) ON DELETE CASCADE,
+    key VARCHAR(128) NOT NULL,
+    value VARCHAR(256) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_tags_file_key ON file_tags(file_id, key);
+CREATE INDEX IF NOT EXISTS idx_file_tags_key_value ON file_tags(key, value);
+
+CREATE TABLE IF NOT EXISTS virtual_folders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket VARCHAR(100) NOT NULL,
+    path VARCHAR(512) NOT NULL,
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_virtual_folders_bucket_path ON virtual_folders(bucket, path);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id VARCHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    creator_id INTEGER,
+    params TEXT,
+    result TEXT,
+    error TEXT,
+    total BIGINT NOT NULL DEFAULT 0,
+    done BIGINT NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 1,
+    run_after TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_task_id ON jobs(task_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs(type);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_run_after ON jobs(status, run_after);
+CREATE INDEX IF NOT EXISTS idx_jobs_creator_id ON jobs(creator_id);
+
+CREATE TABLE IF NOT EXISTS bulk_operations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id INTEGER NOT NULL REFERENCES users(id),
+    job_id INTEGER REFERENCES jobs(id),
+    action VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    params TEXT,
+    total INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    results TEXT,
+    error TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_operations_creator_id ON bulk_operations(creator_id);
+CREATE INDEX IF NOT EXISTS idx_bulk_operations_job_id ON bulk_operations(job_id);
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id VARCHAR(36) NOT NULL,
+    owner_id INTEGER NOT NULL REFERENCES users(id),
+    mode VARCHAR(10) NOT NULL,
+    storage_type VARCHAR(20) NOT NULL,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(100) NOT NULL,
+    object_key VARCHAR(512) NOT NULL,
+    original_filename VARCHAR(255),
+    upload_id VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    chunk_size BIGINT NOT NULL DEFAULT 0,
+    total_parts INTEGER NOT NULL DEFAULT 0,
+    parts_done INTEGER NOT NULL DEFAULT 0,
+    uploaded_bytes BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    last_error TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upload_sessions_session_id ON upload_sessions(session_id);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_owner_id ON upload_sessions(owner_id);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_status ON upload_sessions(status);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
+
+CREATE TABLE IF NOT EXISTS access_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    access_key_id VARCHAR(32) NOT NULL,
+    secret_key VARCHAR(64) NOT NULL,
+    description VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_keys_access_key_id ON access_keys(access_key_id);
+CREATE INDEX IF NOT EXISTS idx_access_keys_user_id ON access_keys(user_id);
+
+CREATE TABLE IF NOT EXISTS region_bucket_mapping (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    region_code VARCHAR(50) NOT NULL,
+    bucket_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_region_bucket_mapping_region_code ON region_bucket_mapping(region_code);
+CREATE INDEX IF NOT EXISTS idx_region_bucket_mapping_bucket_name ON region_bucket_mapping(bucket_name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_region_bucket_mapping_region_bucket
+    ON region_bucket_mapping(region_code, bucket_name) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS role_region_bucket_access (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    region_bucket_mapping_id INTEGER NOT NULL REFERENCES region_bucket_mapping(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_region_bucket_access_role_id ON role_region_bucket_access(role_id);
+CREATE INDEX IF NOT EXISTS idx_role_region_bucket_access_mapping_id ON role_region_bucket_access(region_bucket_mapping_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_region_bucket_access_role_mapping
+    ON role_region_bucket_access(role_id, region_bucket_mapping_id);
+
+-- 基础数据（管理员密码：admin123）
+INSERT INTO roles (name, description) VALUES ('admin', '系统管理员');
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('user_manage', '用户管理', 'user', 'manage'),
+('role_manage', '角色管理', 'role', 'manage'),
+('file_manage', '文件管理', 'file', 'manage'),
+('oss_config', 'OSS配置管理', 'oss_config', 'manage');
+
+INSERT INTO users (username, password, email, real_name, status) VALUES
+('admin', '$2a$10$N.zmdr9k7uOCQb376NoUnuTJ8iAt6Z5EHsM8lE9lBpwTTyU3VxqW', 'admin@example.com', '系统管理员', true);
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r WHERE u.username = 'admin' AND r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p WHERE r.name = 'admin';
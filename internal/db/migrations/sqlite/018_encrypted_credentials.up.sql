@@ -0,0 +1,2 @@
+-- 凭据改为加密保存，SQLite 不限制 VARCHAR 的长度，不需要修改表结构
+SELECT 1;
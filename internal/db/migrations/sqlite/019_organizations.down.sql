@@ -0,0 +1,37 @@
+-- 不同组织中有同名角色时回滚会失败，回滚前需要先重命名
+CREATE TEMP TABLE user_roles_backup AS SELECT * FROM user_roles;
+CREATE TEMP TABLE role_permissions_backup AS SELECT * FROM role_permissions;
+CREATE TEMP TABLE role_region_bucket_access_backup AS SELECT * FROM role_region_bucket_access;
+
+CREATE TABLE roles_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+INSERT INTO roles_old (id, name, description, created_at, updated_at, deleted_at)
+SELECT id, name, description, created_at, updated_at, deleted_at FROM roles;
+DROP TABLE roles;
+ALTER TABLE roles_old RENAME TO roles;
+
+INSERT INTO user_roles SELECT * FROM user_roles_backup;
+INSERT INTO role_permissions SELECT * FROM role_permissions_backup;
+INSERT INTO role_region_bucket_access SELECT * FROM role_region_bucket_access_backup;
+DROP TABLE user_roles_backup;
+DROP TABLE role_permissions_backup;
+DROP TABLE role_region_bucket_access_backup;
+
+DROP INDEX IF EXISTS idx_audit_logs_org_id;
+DROP INDEX IF EXISTS idx_oss_files_org_id;
+DROP INDEX IF EXISTS idx_region_bucket_mapping_org_id;
+DROP INDEX IF EXISTS idx_oss_configs_org_id;
+DROP INDEX IF EXISTS idx_users_org_id;
+ALTER TABLE audit_logs DROP COLUMN org_id;
+ALTER TABLE oss_files DROP COLUMN org_id;
+ALTER TABLE region_bucket_mapping DROP COLUMN org_id;
+ALTER TABLE oss_configs DROP COLUMN org_id;
+ALTER TABLE users DROP COLUMN org_id;
+
+DROP TABLE IF EXISTS organizations;
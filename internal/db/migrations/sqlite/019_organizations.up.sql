@@ -0,0 +1,58 @@
+-- 组织（租户），用户、角色、存储配置、地域-桶映射、文件和审计日志归属于组织，组织之间的数据互相隔离
+CREATE TABLE IF NOT EXISTS organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    status BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+-- 已有数据归属默认组织
+-- SQLite 添加带 REFERENCES 的列时默认值必须为 NULL，这里不声明外键
+INSERT OR IGNORE INTO organizations (id, name, description) VALUES (1, 'default', '默认组织');
+
+ALTER TABLE users ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE oss_configs ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE region_bucket_mapping ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE oss_files ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE audit_logs ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_users_org_id ON users(org_id);
+CREATE INDEX IF NOT EXISTS idx_oss_configs_org_id ON oss_configs(org_id);
+CREATE INDEX IF NOT EXISTS idx_region_bucket_mapping_org_id ON region_bucket_mapping(org_id);
+CREATE INDEX IF NOT EXISTS idx_oss_files_org_id ON oss_files(org_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_org_id ON audit_logs(org_id);
+
+-- 角色名称改为组织内唯一，SQLite 无法删除列定义中的唯一约束，需要重建角色表
+-- 删除角色表会级联删除关联记录，重建前先备份关联表
+CREATE TEMP TABLE user_roles_backup AS SELECT * FROM user_roles;
+CREATE TEMP TABLE role_permissions_backup AS SELECT * FROM role_permissions;
+CREATE TEMP TABLE role_region_bucket_access_backup AS SELECT * FROM role_region_bucket_access;
+
+CREATE TABLE roles_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id),
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    admin_scope VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+INSERT INTO roles_new (id, name, description, created_at, updated_at, deleted_at)
+SELECT id, name, description, created_at, updated_at, deleted_at FROM roles;
+DROP TABLE roles;
+ALTER TABLE roles_new RENAME TO roles;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_org_name ON roles(org_id, name);
+
+INSERT INTO user_roles SELECT * FROM user_roles_backup;
+INSERT INTO role_permissions SELECT * FROM role_permissions_backup;
+INSERT INTO role_region_bucket_access SELECT * FROM role_region_bucket_access_backup;
+DROP TABLE user_roles_backup;
+DROP TABLE role_permissions_backup;
+DROP TABLE role_region_bucket_access_backup;
+
+-- 管理员角色：ORG 为组织管理员，GLOBAL 为全局管理员，内置的 admin 角色为全局管理员
+UPDATE roles SET admin_scope = 'GLOBAL' WHERE name = 'admin' AND org_id = 1;
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
+)
+
+// 访问密钥状态
+const (
+	AccessKeyActive   = "ACTIVE"
+	AccessKeyDisabled = "DISABLED"
+)
+
+// AccessKey 用户的 S3 兼容接口访问密钥，请求以 SigV4 签名，需保存密钥原文用于校验，因此加密保存
+type AccessKey struct {
+	Model
+	UserID      uint           `gorm:"index;not null" json:"user_id"`
+	AccessKeyID string         `gorm:"size:32;uniqueIndex;not null" json:"access_key_id"`
+	SecretKey   secrets.String `gorm:"size:512;not null" json:"-"`
+	Description string         `gorm:"size:255" json:"description"`
+	Status      string         `gorm:"size:20;not null;default:'ACTIVE'" json:"status"`
+	LastUsedAt  *time.Time     `json:"last_used_at"`
+	User        *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName 指定表名
+func (AccessKey) TableName() string {
+	return "access_keys"
+}
@@ -0,0 +1,16 @@
+package models
+
+// BucketUploadPolicy 存储桶上传策略，每个地域-桶映射至多一条
+type BucketUploadPolicy struct {
+	Model
+	RegionBucketMappingID uint   `gorm:"uniqueIndex;not null" json:"region_bucket_mapping_id"`
+	MaxFileSize           int64  `gorm:"default:0" json:"max_file_size"`     // 单文件大小上限（字节），0 表示不限制
+	AllowedExtensions     string `gorm:"size:500" json:"allowed_extensions"` // 逗号分隔，例如 .jpg,.png，为空表示不限制
+	AllowedMimeTypes      string `gorm:"size:500" json:"allowed_mime_types"` // 逗号分隔，支持 image/* 通配，按文件头识别
+	PathPattern           string `gorm:"size:255" json:"path_pattern"`       // 对象键必须匹配的正则，例如 ^projects/[a-z0-9-]+/
+}
+
+// TableName 指定表名
+func (BucketUploadPolicy) TableName() string {
+	return "bucket_upload_policies"
+}
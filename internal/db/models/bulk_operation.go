@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 批量操作类型
+const (
+	BulkActionDelete       = "DELETE"
+	BulkActionMove         = "MOVE"
+	BulkActionTag          = "TAG"
+	BulkActionDownloadURLs = "DOWNLOAD_URLS"
+)
+
+// 批量操作状态
+const (
+	BulkStatusPending   = "PENDING"
+	BulkStatusRunning   = "RUNNING"
+	BulkStatusCompleted = "COMPLETED"
+	BulkStatusFailed    = "FAILED"
+	BulkStatusCancelled = "CANCELLED"
+)
+
+// 单个文件的处理结果
+const (
+	BulkItemPlanned = "planned" // 仅预演时使用
+	BulkItemSuccess = "success"
+	BulkItemFailed  = "failed"
+	BulkItemSkipped = "skipped"
+)
+
+// BulkItemResult 批量操作中单个文件的处理结果
+type BulkItemResult struct {
+	FileID    uint   `json:"file_id"`
+	Bucket    string `json:"bucket"`
+	ObjectKey string `json:"object_key"`
+	Target    string `json:"target,omitempty"` // 移动操作的目标对象键
+	URL       string `json:"url,omitempty"`    // 下载链接操作生成的链接
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkOperation 批量文件操作，在后台执行并记录每个文件的结果
+type BulkOperation struct {
+	Model
+	CreatorID  uint       `gorm:"index;not null" json:"creator_id"`
+	JobID      uint       `gorm:"index" json:"job_id"` // 执行该操作的后台任务
+	Action     string     `gorm:"size:20;not null" json:"action"`
+	Status     string     `gorm:"size:20;not null;default:'PENDING'" json:"status"`
+	Params     string     `gorm:"type:text" json:"params"` // 请求参数（JSON）
+	Total      int        `gorm:"not null;default:0" json:"total"`
+	Succeeded  int        `gorm:"not null;default:0" json:"succeeded"`
+	Failed     int        `gorm:"not null;default:0" json:"failed"`
+	Skipped    int        `gorm:"not null;default:0" json:"skipped"`
+	Results    string     `gorm:"type:text" json:"-"` // []BulkItemResult（JSON）
+	Error      string     `gorm:"type:text" json:"error"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// TableName 指定表名
+func (BulkOperation) TableName() string {
+	return "bulk_operations"
+}
+
+// SetResults 保存逐项结果并更新计数
+func (o *BulkOperation) SetResults(results []BulkItemResult) error {
+	o.Succeeded, o.Failed, o.Skipped = 0, 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case BulkItemSuccess:
+			o.Succeeded++
+		case BulkItemFailed:
+			o.Failed++
+		case BulkItemSkipped:
+			o.Skipped++
+		}
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	o.Results = string(data)
+	return nil
+}
+
+// GetResults 解析逐项结果
+func (o *BulkOperation) GetResults() ([]BulkItemResult, error) {
+	var results []BulkItemResult
+	if o.Results == "" {
+		return results, nil
+	}
+	err := json.Unmarshal([]byte(o.Results), &results)
+	return results, err
+}
@@ -0,0 +1,14 @@
+package models
+
+// FileTag 文件标签，同一文件的标签键唯一
+type FileTag struct {
+	Model
+	FileID uint   `gorm:"not null;uniqueIndex:idx_file_tags_file_key" json:"file_id"`
+	Key    string `gorm:"size:128;not null;uniqueIndex:idx_file_tags_file_key;index:idx_file_tags_key_value" json:"key"`
+	Value  string `gorm:"size:256;not null;default:'';index:idx_file_tags_key_value" json:"value"`
+}
+
+// TableName 指定表名
+func (FileTag) TableName() string {
+	return "file_tags"
+}
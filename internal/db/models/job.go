@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// 后台任务状态
+const (
+	JobStatusPending   = "PENDING"
+	JobStatusRunning   = "RUNNING"
+	JobStatusCompleted = "COMPLETED"
+	JobStatusFailed    = "FAILED"
+	JobStatusCancelled = "CANCELLED"
+)
+
+// Job 持久化的后台任务，由 jobs.Manager 调度执行
+type Job struct {
+	Model
+	TaskID      string     `gorm:"size:36;uniqueIndex;not null" json:"task_id"` // 进度订阅ID，可通过 /uploads/:id/stream 订阅
+	Type        string     `gorm:"size:50;index;not null" json:"type"`
+	Status      string     `gorm:"size:20;index;not null;default:'PENDING'" json:"status"`
+	CreatorID   uint       `gorm:"index" json:"creator_id"` // 0 表示系统任务
+	Params      string     `gorm:"type:text" json:"params"`
+	Result      string     `gorm:"type:text" json:"result,omitempty"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	Total       int64      `gorm:"not null;default:0" json:"total"`
+	Done        int64      `gorm:"not null;default:0" json:"done"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null;default:1" json:"max_attempts"`
+	RunAfter    *time.Time `json:"run_after"` // 重试时的最早执行时间
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// TableName 指定表名
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// Finished 任务是否已处于终态
+func (j *Job) Finished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
@@ -0,0 +1,26 @@
+package models
+
+// DefaultOrgID 默认组织ID，多租户之前的数据和自助注册的用户都归属默认组织
+const DefaultOrgID uint = 1
+
+// OrgAdminRoleName 创建组织时自动创建的组织管理员角色名
+const OrgAdminRoleName = "admin"
+
+// 角色的管理员范围
+const (
+	AdminScopeOrg    = "ORG"    // 组织管理员，管理本组织的用户、角色、存储配置和存储桶
+	AdminScopeGlobal = "GLOBAL" // 全局管理员，管理所有组织
+)
+
+// Organization 组织模型，组织之间的用户、角色、存储配置、存储桶、文件和审计日志互相隔离
+type Organization struct {
+	Model
+	Name        string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	Status      bool   `gorm:"default:true" json:"status"`
+}
+
+// TableName 指定表名
+func (Organization) TableName() string {
+	return "organizations"
+}
@@ -1,17 +1,20 @@
 package models
 
+import "github.com/myysophia/ossmanager-backend/internal/secrets"
+
 // OSSConfig OSS 配置模型
 type OSSConfig struct {
 	Model
-	Name          string `gorm:"size:100;not null" json:"name"`
-	StorageType   string `gorm:"size:20;not null" json:"storage_type"` // ALIYUN_OSS, AWS_S3, CLOUDFLARE_R2
-	AccessKey     string `gorm:"size:255;not null" json:"-"`
-	SecretKey     string `gorm:"size:255;not null" json:"-"`
-	Endpoint      string `gorm:"size:255;not null" json:"endpoint"`
-	Bucket        string `gorm:"size:100;not null" json:"bucket"`
-	Region        string `gorm:"size:50" json:"region"`
-	IsDefault     bool   `gorm:"default:false" json:"is_default"`
-	URLExpireTime int    `gorm:"default:86400" json:"url_expire_time"` // URL过期时间（秒），默认24小时
+	OrgID         uint           `gorm:"not null;index" json:"org_id"`
+	Name          string         `gorm:"size:100;not null" json:"name"`
+	StorageType   string         `gorm:"size:20;not null" json:"storage_type"` // ALIYUN_OSS, AWS_S3, CLOUDFLARE_R2
+	AccessKey     secrets.String `gorm:"size:512;not null" json:"-"`           // 加密保存
+	SecretKey     secrets.String `gorm:"size:512;not null" json:"-"`           // 加密保存
+	Endpoint      string         `gorm:"size:255;not null" json:"endpoint"`
+	Bucket        string         `gorm:"size:100;not null" json:"bucket"`
+	Region        string         `gorm:"size:50" json:"region"`
+	IsDefault     bool           `gorm:"default:false" json:"is_default"`
+	URLExpireTime int            `gorm:"default:86400" json:"url_expire_time"` // URL过期时间（秒），默认24小时
 }
 
 // TableName 指定表名
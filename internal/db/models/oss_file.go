@@ -13,21 +13,27 @@ const (
 // OSSFile OSS 文件模型
 type OSSFile struct {
 	Model
-	Filename         string    `gorm:"size:255;not null" json:"filename"`
-	OriginalFilename string    `gorm:"size:255;not null" json:"original_filename"`
-	FileSize         int64     `gorm:"not null" json:"file_size"`
-	MD5              string    `gorm:"size:32" json:"md5"`
-	MD5Status        string    `gorm:"size:20;default:'PENDING'" json:"md5_status"` // PENDING, CALCULATING, COMPLETED, FAILED
-	StorageType      string    `gorm:"size:20;not null" json:"storage_type"`        // ALIYUN_OSS, AWS_S3, CLOUDFLARE_R2
-	Bucket           string    `gorm:"size:100;not null" json:"bucket"`
-	ObjectKey        string    `gorm:"size:255;not null" json:"object_key"`
-	DownloadURL      string    `gorm:"type:text" json:"download_url,omitempty"`
-	ExpiresAt        time.Time `json:"expires_at,omitempty"`
-	UploaderID       uint      `gorm:"not null" json:"uploader_id"`
-	Uploader         *User     `json:"uploader,omitempty"`
-	UploadIP         string    `gorm:"size:50" json:"upload_ip"`
-	Status           string    `gorm:"size:20;default:ACTIVE" json:"status"` // ACTIVE, DELETED
-	ConfigID         uint      `gorm:"not null" json:"config_id"`            // 存储配置ID
+	OrgID            uint       `gorm:"not null;index" json:"org_id"`
+	Filename         string     `gorm:"size:255;not null" json:"filename"`
+	OriginalFilename string     `gorm:"size:255;not null" json:"original_filename"`
+	FileSize         int64      `gorm:"not null" json:"file_size"`
+	MD5              string     `gorm:"size:32" json:"md5"`
+	MD5Status        string     `gorm:"size:20;default:'PENDING'" json:"md5_status"` // PENDING, CALCULATING, COMPLETED, FAILED
+	StorageType      string     `gorm:"size:20;not null" json:"storage_type"`        // ALIYUN_OSS, AWS_S3, CLOUDFLARE_R2
+	Bucket           string     `gorm:"size:100;not null" json:"bucket"`
+	ObjectKey        string     `gorm:"size:255;not null" json:"object_key"`
+	DownloadURL      string     `gorm:"type:text" json:"download_url,omitempty"`
+	ExpiresAt        time.Time  `json:"expires_at,omitempty"`
+	UploaderID       uint       `gorm:"not null" json:"uploader_id"`
+	Uploader         *User      `json:"uploader,omitempty"`
+	UploadIP         string     `gorm:"size:50" json:"upload_ip"`
+	Status           string     `gorm:"size:20;default:ACTIVE" json:"status"`  // ACTIVE, REPLACED, TRASHED
+	ConfigID         uint       `gorm:"not null" json:"config_id"`             // 存储配置ID
+	VersionKey       string     `gorm:"size:512" json:"version_key,omitempty"` // 历史版本对象键，仅被覆盖的记录有值
+	TrashKey         string     `gorm:"size:512" json:"trash_key,omitempty"`   // 回收站对象键，仅回收站中的记录有值
+	TrashedAt        *time.Time `gorm:"index" json:"trashed_at,omitempty"`     // 移入回收站时间
+	TrashedBy        uint       `json:"trashed_by,omitempty"`                  // 移入回收站的操作用户
+	Tags             []FileTag  `gorm:"foreignKey:FileID" json:"tags,omitempty"`
 }
 
 // TableName 指定表名
@@ -3,6 +3,7 @@ package models
 // RegionBucketMapping 地域-桶映射模型
 type RegionBucketMapping struct {
 	Model
+	OrgID      uint    `gorm:"not null;index" json:"org_id"`
 	RegionCode string  `gorm:"size:50;not null;index" json:"region_code"`  // 地域代码 (e.g., 'us-east-1', 'cn-north-1')
 	BucketName string  `gorm:"size:255;not null;index" json:"bucket_name"` // 桶的名称
 	Roles      []*Role `gorm:"many2many:role_region_bucket_access;" json:"roles,omitempty"`
@@ -3,8 +3,10 @@ package models
 // Role 角色模型
 type Role struct {
 	Model
-	Name          string                 `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	OrgID         uint                   `gorm:"not null;uniqueIndex:idx_roles_org_name" json:"org_id"`
+	Name          string                 `gorm:"size:50;uniqueIndex:idx_roles_org_name;not null" json:"name"` // 组织内唯一
 	Description   string                 `gorm:"type:text" json:"description"`
+	AdminScope    string                 `gorm:"size:20" json:"admin_scope"` // 为空表示普通角色，ORG 或 GLOBAL 表示管理员角色
 	Users         []*User                `gorm:"many2many:user_roles;" json:"users,omitempty"`
 	Permissions   []*Permission          `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
 	RegionBuckets []*RegionBucketMapping `gorm:"many2many:role_region_bucket_access;" json:"region_buckets,omitempty"`
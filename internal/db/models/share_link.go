@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ShareLink 文件公开分享链接
+type ShareLink struct {
+	Model
+	Token          string     `gorm:"size:64;uniqueIndex;not null" json:"token"`
+	FileID         uint       `gorm:"index;not null" json:"file_id"`
+	CreatorID      uint       `gorm:"index;not null" json:"creator_id"`
+	PasswordHash   string     `gorm:"size:255" json:"-"`
+	ExpiresAt      *time.Time `json:"expires_at"`                     // 为空表示永不过期
+	MaxDownloads   int        `gorm:"default:0" json:"max_downloads"` // 0 表示不限制
+	DownloadCount  int        `gorm:"default:0;not null" json:"download_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	File           *OSSFile   `gorm:"foreignKey:FileID" json:"file,omitempty"`
+}
+
+// SetPassword 设置访问密码，传入空字符串表示不需要密码
+func (s *ShareLink) SetPassword(password string) error {
+	if password == "" {
+		s.PasswordHash = ""
+		return nil
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	s.PasswordHash = string(hashedPassword)
+	return nil
+}
+
+// HasPassword 是否设置了访问密码
+func (s *ShareLink) HasPassword() bool {
+	return s.PasswordHash != ""
+}
+
+// CheckPassword 验证访问密码，未设置密码时始终通过
+func (s *ShareLink) CheckPassword(password string) bool {
+	if !s.HasPassword() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.PasswordHash), []byte(password)) == nil
+}
+
+// Available 链接当前是否可用（未撤销、未过期、未达到下载次数上限）
+func (s *ShareLink) Available(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	if s.ExpiresAt != nil && now.After(*s.ExpiresAt) {
+		return false
+	}
+	if s.MaxDownloads > 0 && s.DownloadCount >= s.MaxDownloads {
+		return false
+	}
+	return true
+}
+
+// TableName 指定表名
+func (ShareLink) TableName() string {
+	return "share_links"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// 配额作用范围
+const (
+	QuotaScopeUser   = "USER"   // 单个用户上传的文件
+	QuotaScopeRole   = "ROLE"   // 角色下所有成员上传的文件（共享额度）
+	QuotaScopeBucket = "BUCKET" // 存储桶内的所有文件
+)
+
+// StorageQuota 存储配额
+type StorageQuota struct {
+	Model
+	ScopeType         string     `gorm:"size:20;not null;uniqueIndex:idx_storage_quota_scope" json:"scope_type"`
+	ScopeID           uint       `gorm:"not null;default:0;uniqueIndex:idx_storage_quota_scope" json:"scope_id"`              // 用户ID或角色ID，存储桶配额为0
+	BucketName        string     `gorm:"size:100;not null;default:'';uniqueIndex:idx_storage_quota_scope" json:"bucket_name"` // 为空表示不限存储桶
+	MaxBytes          int64      `gorm:"default:0" json:"max_bytes"`                                                          // 0 表示不限制
+	MaxObjects        int64      `gorm:"default:0" json:"max_objects"`                                                        // 0 表示不限制
+	UsedBytes         int64      `gorm:"default:0" json:"used_bytes"`                                                         // 最近一次统计的已用字节数
+	UsedObjects       int64      `gorm:"default:0" json:"used_objects"`                                                       // 最近一次统计的已用对象数
+	UsageCalculatedAt *time.Time `json:"usage_calculated_at"`
+}
+
+// TableName 指定表名
+func (StorageQuota) TableName() string {
+	return "storage_quotas"
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UploadRequest 上传请求链接，供无账号的外部用户向指定目录投递文件
+type UploadRequest struct {
+	Model
+	Token             string     `gorm:"size:64;uniqueIndex;not null" json:"token"`
+	CreatorID         uint       `gorm:"index;not null" json:"creator_id"`
+	RegionCode        string     `gorm:"size:50;not null" json:"region_code"`
+	BucketName        string     `gorm:"size:100;not null" json:"bucket_name"`
+	Prefix            string     `gorm:"size:255" json:"prefix"`
+	Description       string     `gorm:"type:text" json:"description"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	MaxFileSize       int64      `gorm:"default:0" json:"max_file_size"`     // 单文件大小上限（字节），0 表示不限制
+	AllowedExtensions string     `gorm:"size:255" json:"allowed_extensions"` // 逗号分隔，例如 .pdf,.zip，为空表示不限制
+	MaxFiles          int        `gorm:"default:0" json:"max_files"`         // 0 表示不限制
+	UploadCount       int        `gorm:"default:0;not null" json:"upload_count"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+}
+
+// Available 链接当前是否可用（未撤销、未过期、未达到文件数上限）
+func (r *UploadRequest) Available(now time.Time) bool {
+	if r.RevokedAt != nil || now.After(r.ExpiresAt) {
+		return false
+	}
+	return r.MaxFiles == 0 || r.UploadCount < r.MaxFiles
+}
+
+// ExtensionAllowed 文件扩展名是否在允许列表中
+func (r *UploadRequest) ExtensionAllowed(filename string) bool {
+	if r.AllowedExtensions == "" {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range strings.Split(r.AllowedExtensions, ",") {
+		if strings.ToLower(strings.TrimSpace(allowed)) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectKey 生成上传文件在存储桶中的对象键
+func (r *UploadRequest) ObjectKey(filename string) string {
+	if r.Prefix == "" {
+		return filename
+	}
+	return r.Prefix + "/" + filename
+}
+
+// TableName 指定表名
+func (UploadRequest) TableName() string {
+	return "upload_requests"
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// 上传会话状态
+const (
+	UploadSessionActive    = "ACTIVE"
+	UploadSessionCompleted = "COMPLETED"
+	UploadSessionAborted   = "ABORTED"
+)
+
+// 上传会话模式
+const (
+	UploadSessionModeServer = "SERVER" // 经本服务中转的分片上传
+	UploadSessionModeClient = "CLIENT" // 客户端使用预签名URL直传分片
+	UploadSessionModeTus    = "TUS"    // 通过 tus 协议接收数据
+	UploadSessionModeS3     = "S3"     // 通过 S3 兼容接口的分片上传
+)
+
+// UploadSession 持久化的分片上传会话，服务重启后仍可凭会话ID续传
+type UploadSession struct {
+	Model
+	SessionID        string     `gorm:"size:36;uniqueIndex;not null" json:"session_id"`
+	OwnerID          uint       `gorm:"index;not null" json:"owner_id"`
+	Mode             string     `gorm:"size:10;not null" json:"mode"`
+	StorageType      string     `gorm:"size:20;not null" json:"storage_type"`
+	RegionCode       string     `gorm:"size:50;not null" json:"region_code"`
+	BucketName       string     `gorm:"size:100;not null" json:"bucket_name"`
+	ObjectKey        string     `gorm:"size:512;not null" json:"object_key"`
+	OriginalFilename string     `gorm:"size:255" json:"original_filename"`
+	UploadID         string     `gorm:"size:255;not null" json:"upload_id"`
+	FileSize         int64      `gorm:"not null;default:0" json:"file_size"`
+	ChunkSize        int64      `gorm:"not null;default:0" json:"chunk_size"`
+	TotalParts       int        `gorm:"not null;default:0" json:"total_parts"`
+	PartsDone        int        `gorm:"not null;default:0" json:"parts_done"`
+	UploadedBytes    int64      `gorm:"not null;default:0" json:"uploaded_bytes"`
+	Status           string     `gorm:"size:20;index;not null;default:'ACTIVE'" json:"status"`
+	LastError        string     `gorm:"type:text" json:"last_error,omitempty"`
+	ExpiresAt        time.Time  `gorm:"index;not null" json:"expires_at"` // 超过后由清理任务在存储端中止
+	CompletedAt      *time.Time `json:"completed_at"`
+}
+
+// TableName 指定表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
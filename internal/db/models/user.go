@@ -7,6 +7,7 @@ import (
 // User 用户模型
 type User struct {
 	Model
+	OrgID    uint    `gorm:"not null;index" json:"org_id"`
 	Username string  `gorm:"size:50;uniqueIndex;not null" json:"username"`
 	Password string  `gorm:"size:255;not null" json:"-"`
 	Email    string  `gorm:"size:100;uniqueIndex;not null" json:"email"`
@@ -0,0 +1,15 @@
+package models
+
+// VirtualFolder 用户显式创建的目录
+// 对象存储没有真正的目录，含有文件的目录由对象键前缀推导，这里只记录空目录
+type VirtualFolder struct {
+	Model
+	Bucket    string `gorm:"size:100;not null;uniqueIndex:idx_virtual_folders_bucket_path" json:"bucket"`
+	Path      string `gorm:"size:512;not null;uniqueIndex:idx_virtual_folders_bucket_path" json:"path"` // 不含首尾斜杠，例如 docs/2024
+	CreatorID uint   `gorm:"not null" json:"creator_id"`
+}
+
+// TableName 指定表名
+func (VirtualFolder) TableName() string {
+	return "virtual_folders"
+}
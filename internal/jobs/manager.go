@@ -0,0 +1,406 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownType 任务类型未注册处理器
+	ErrUnknownType = errors.New("未注册的任务类型")
+	// ErrJobFinished 任务已结束
+	ErrJobFinished = errors.New("任务已结束")
+	// ErrJobNotFinished 任务尚未结束
+	ErrJobNotFinished = errors.New("任务尚未结束")
+	// ErrNotRunningHere 任务不在当前实例执行
+	ErrNotRunningHere = errors.New("任务不在当前实例执行，无法取消")
+)
+
+// Handler 任务处理器，ctx 在任务被取消或服务关闭时结束
+// 返回普通错误时按配置重试，返回 Permanent 包装的错误时直接失败
+type Handler func(ctx context.Context, task *Task) error
+
+// runningJob 正在本实例执行的任务
+type runningJob struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// Manager 后台任务管理器：持久化任务队列、工作协程池、取消与重试
+// 执行进度通过 upload.Manager 推送，可使用任务的 TaskID 订阅 SSE 进度流
+type Manager struct {
+	db       *gorm.DB
+	cfg      config.JobsConfig
+	progress *upload.Manager
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	running  map[uint]*runningJob
+
+	slots  chan struct{}
+	wake   chan struct{}
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewManager 创建后台任务管理器
+func NewManager(db *gorm.DB, progress *upload.Manager, cfg config.JobsConfig) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		db:       db,
+		cfg:      cfg,
+		progress: progress,
+		handlers: make(map[string]Handler),
+		running:  make(map[uint]*runningJob),
+		slots:    make(chan struct{}, cfg.GetWorkers()),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register 注册任务类型的处理器，应在 Start 之前完成
+func (m *Manager) Register(jobType string, handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[jobType] = handler
+}
+
+// Start 恢复上次中断的任务并启动调度协程
+func (m *Manager) Start() {
+	// 上次退出时仍在执行的任务重新排队，不计入尝试次数
+	result := m.db.Model(&models.Job{}).
+		Where("status = ?", models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":   models.JobStatusPending,
+			"attempts": gorm.Expr("CASE WHEN attempts > 0 THEN attempts - 1 ELSE 0 END"),
+		})
+	if result.Error != nil {
+		logger.Error("恢复中断的后台任务失败", zap.Error(result.Error))
+	} else if result.RowsAffected > 0 {
+		logger.Info("已恢复中断的后台任务", zap.Int64("count", result.RowsAffected))
+	}
+
+	m.wg.Add(1)
+	go m.dispatchLoop()
+	logger.Info("后台任务调度已启动",
+		zap.Int("workers", m.cfg.GetWorkers()),
+		zap.Int("max_attempts", m.cfg.GetMaxAttempts()))
+}
+
+// Stop 停止调度并等待执行中的任务退出，被中断的任务在下次启动时恢复
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+	logger.Info("后台任务调度已停止")
+}
+
+// Enqueue 创建任务并加入队列
+func (m *Manager) Enqueue(jobType string, creatorID uint, params interface{}) (*models.Job, error) {
+	m.mu.Lock()
+	_, ok := m.handlers[jobType]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+
+	job := &models.Job{
+		TaskID:      uuid.NewString(),
+		Type:        jobType,
+		Status:      models.JobStatusPending,
+		CreatorID:   creatorID,
+		Params:      string(data),
+		MaxAttempts: m.cfg.GetMaxAttempts(),
+	}
+	if err := m.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	m.progress.StartTask(job.TaskID, job.Type, 0)
+	m.notify()
+
+	logger.Info("后台任务已创建",
+		zap.Uint("job_id", job.ID),
+		zap.String("type", job.Type),
+		zap.Uint("creator_id", creatorID))
+	return job, nil
+}
+
+// Cancel 取消任务：排队中的任务直接取消，执行中的任务通知处理器退出
+func (m *Manager) Cancel(id uint) error {
+	var job models.Job
+	if err := m.db.First(&job, id).Error; err != nil {
+		return err
+	}
+	if job.Finished() {
+		return ErrJobFinished
+	}
+
+	now := time.Now()
+	result := m.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, models.JobStatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusCancelled,
+			"finished_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		m.progress.Close(job.TaskID, upload.StatusCancelled, "任务已取消")
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rj, ok := m.running[id]
+	if !ok {
+		return ErrNotRunningHere
+	}
+	rj.cancelled = true
+	rj.cancel()
+	return nil
+}
+
+// Retry 重新执行失败或已取消的任务
+func (m *Manager) Retry(id uint) error {
+	var job models.Job
+	if err := m.db.First(&job, id).Error; err != nil {
+		return err
+	}
+	if job.Status != models.JobStatusFailed && job.Status != models.JobStatusCancelled {
+		return ErrJobNotFinished
+	}
+
+	if err := m.db.Model(&job).Updates(map[string]interface{}{
+		"status":      models.JobStatusPending,
+		"attempts":    0,
+		"error":       "",
+		"run_after":   nil,
+		"finished_at": nil,
+	}).Error; err != nil {
+		return err
+	}
+
+	m.progress.StartTask(job.TaskID, job.Type, job.Total)
+	m.notify()
+	return nil
+}
+
+// notify 唤醒调度协程
+func (m *Manager) notify() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchLoop 在有空闲工作协程时领取待执行任务
+func (m *Manager) dispatchLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.GetPollInterval())
+	defer ticker.Stop()
+
+	for {
+		for m.dispatchOne() {
+		}
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+	}
+}
+
+// dispatchOne 领取一个任务交给工作协程，没有空闲协程或待执行任务时返回 false
+func (m *Manager) dispatchOne() bool {
+	if m.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		return false
+	}
+
+	job, err := m.claim()
+	if err != nil {
+		logger.Error("领取后台任务失败", zap.Error(err))
+	}
+	if job == nil {
+		<-m.slots
+		return false
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			<-m.slots
+			m.notify()
+		}()
+		m.run(job)
+	}()
+	return true
+}
+
+// claim 以条件更新的方式领取最早的待执行任务，避免多个实例重复执行
+func (m *Manager) claim() (*models.Job, error) {
+	var job models.Job
+	err := m.db.Where("status = ? AND (run_after IS NULL OR run_after <= ?)", models.JobStatusPending, time.Now()).
+		Order("id").First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := m.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"started_at": now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	job.Status = models.JobStatusRunning
+	job.Attempts++
+	job.StartedAt = &now
+	return &job, nil
+}
+
+// run 执行任务并根据结果更新状态
+func (m *Manager) run(job *models.Job) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	rj := &runningJob{cancel: cancel}
+
+	m.mu.Lock()
+	handler, ok := m.handlers[job.Type]
+	m.running[job.ID] = rj
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+		cancel()
+	}()
+
+	if _, exists := m.progress.Get(job.TaskID); !exists {
+		m.progress.StartTask(job.TaskID, job.Type, job.Total)
+	}
+	m.progress.SetStatus(job.TaskID, upload.StatusRunning, "")
+
+	logger.Info("开始执行后台任务",
+		zap.Uint("job_id", job.ID),
+		zap.String("type", job.Type),
+		zap.Int("attempt", job.Attempts))
+
+	task := &Task{Job: job, m: m, running: rj}
+	var err error
+	if !ok {
+		err = Permanent(fmt.Errorf("%w: %s", ErrUnknownType, job.Type))
+	} else {
+		err = invoke(ctx, handler, task)
+	}
+	task.persist(true)
+
+	m.mu.Lock()
+	cancelled := rj.cancelled
+	m.mu.Unlock()
+
+	switch {
+	case cancelled:
+		m.finish(job, models.JobStatusCancelled, "任务已取消")
+		m.progress.Close(job.TaskID, upload.StatusCancelled, "任务已取消")
+	case err == nil:
+		m.finish(job, models.JobStatusCompleted, "")
+		m.progress.Close(job.TaskID, upload.StatusCompleted, "")
+	case m.ctx.Err() != nil:
+		// 服务关闭导致中断，重新排队且不计入尝试次数
+		m.db.Model(job).Updates(map[string]interface{}{
+			"status":   models.JobStatusPending,
+			"attempts": job.Attempts - 1,
+		})
+		m.progress.SetStatus(job.TaskID, upload.StatusPending, "服务重启，任务将自动恢复")
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		logger.Error("后台任务执行失败",
+			zap.Uint("job_id", job.ID),
+			zap.String("type", job.Type),
+			zap.Int("attempt", job.Attempts),
+			zap.Error(err))
+		m.finish(job, models.JobStatusFailed, err.Error())
+		m.progress.Close(job.TaskID, upload.StatusFailed, err.Error())
+	default:
+		runAfter := time.Now().Add(m.cfg.GetRetryBackoff() * time.Duration(job.Attempts))
+		logger.Warn("后台任务执行失败，稍后重试",
+			zap.Uint("job_id", job.ID),
+			zap.String("type", job.Type),
+			zap.Int("attempt", job.Attempts),
+			zap.Time("run_after", runAfter),
+			zap.Error(err))
+		m.db.Model(job).Updates(map[string]interface{}{
+			"status":    models.JobStatusPending,
+			"error":     err.Error(),
+			"run_after": runAfter,
+		})
+		m.progress.SetStatus(job.TaskID, upload.StatusPending,
+			fmt.Sprintf("第 %d 次执行失败，将于 %s 重试: %v", job.Attempts, runAfter.Format(time.RFC3339), err))
+	}
+}
+
+// finish 将任务标记为终态
+func (m *Manager) finish(job *models.Job, status string, errMsg string) {
+	now := time.Now()
+	if err := m.db.Model(job).Updates(map[string]interface{}{
+		"status":      status,
+		"error":       errMsg,
+		"finished_at": now,
+	}).Error; err != nil {
+		logger.Error("保存任务状态失败", zap.Uint("job_id", job.ID), zap.Error(err))
+		return
+	}
+	logger.Info("后台任务结束",
+		zap.Uint("job_id", job.ID),
+		zap.String("type", job.Type),
+		zap.String("status", status))
+}
+
+// invoke 调用处理器，处理器 panic 时视为不可重试的错误
+func invoke(ctx context.Context, handler Handler, task *Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("任务异常退出: %v", r))
+		}
+	}()
+	return handler(ctx, task)
+}
@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestManager(t *testing.T, cfg config.JobsConfig) *Manager {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&models.Job{}))
+	return NewManager(conn, upload.NewManager(), cfg)
+}
+
+func loadJob(t *testing.T, m *Manager, id uint) models.Job {
+	var job models.Job
+	require.NoError(t, m.db.First(&job, id).Error)
+	return job
+}
+
+func TestClaim(t *testing.T) {
+	m := newTestManager(t, config.JobsConfig{})
+	m.Register("noop", func(ctx context.Context, task *Task) error { return nil })
+
+	_, err := m.Enqueue("unknown", 1, nil)
+	assert.ErrorIs(t, err, ErrUnknownType)
+
+	first, err := m.Enqueue("noop", 1, nil)
+	require.NoError(t, err)
+	second, err := m.Enqueue("noop", 1, nil)
+	require.NoError(t, err)
+
+	// 按创建顺序领取，已领取的任务不会被再次领取
+	job, err := m.claim()
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, first.ID, job.ID)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, models.JobStatusRunning, loadJob(t, m, first.ID).Status)
+
+	job, err = m.claim()
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, second.ID, job.ID)
+
+	job, err = m.claim()
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestRunProgress(t *testing.T) {
+	m := newTestManager(t, config.JobsConfig{})
+	m.Register("count", func(ctx context.Context, task *Task) error {
+		var params struct {
+			N int64 `json:"n"`
+		}
+		if err := task.Bind(&params); err != nil {
+			return err
+		}
+		task.SetTotal(params.N)
+		task.SetMessage("处理中")
+		p, _ := m.progress.Get(task.Job.TaskID)
+		assert.Equal(t, upload.StatusRunning, p.Status)
+		task.Advance(params.N)
+		return task.SetResult(map[string]int64{"done": params.N})
+	})
+
+	created, err := m.Enqueue("count", 1, map[string]int64{"n": 3})
+	require.NoError(t, err)
+	job, err := m.claim()
+	require.NoError(t, err)
+	m.run(job)
+
+	saved := loadJob(t, m, created.ID)
+	assert.Equal(t, models.JobStatusCompleted, saved.Status)
+	assert.EqualValues(t, 3, saved.Total)
+	assert.EqualValues(t, 3, saved.Done)
+	assert.JSONEq(t, `{"done":3}`, saved.Result)
+	assert.NotNil(t, saved.FinishedAt)
+	p, ok := m.progress.Get(created.TaskID)
+	require.True(t, ok)
+	assert.Equal(t, upload.StatusCompleted, p.Status)
+}
+
+func TestRetry(t *testing.T) {
+	m := newTestManager(t, config.JobsConfig{MaxAttempts: 2})
+	calls := 0
+	m.Register("flaky", func(ctx context.Context, task *Task) error {
+		calls++
+		if calls == 1 {
+			return errors.New("临时错误")
+		}
+		return nil
+	})
+	m.Register("broken", func(ctx context.Context, task *Task) error {
+		return Permanent(errors.New("参数错误"))
+	})
+
+	// 普通错误在退避时间后重试
+	created, err := m.Enqueue("flaky", 1, nil)
+	require.NoError(t, err)
+	job, err := m.claim()
+	require.NoError(t, err)
+	m.run(job)
+
+	saved := loadJob(t, m, created.ID)
+	assert.Equal(t, models.JobStatusPending, saved.Status)
+	assert.Equal(t, "临时错误", saved.Error)
+	require.NotNil(t, saved.RunAfter)
+	assert.True(t, saved.RunAfter.After(time.Now()))
+	job, err = m.claim()
+	require.NoError(t, err)
+	assert.Nil(t, job, "退避时间内不应再次领取")
+
+	require.NoError(t, m.db.Model(&models.Job{}).Where("id = ?", created.ID).Update("run_after", time.Now().Add(-time.Second)).Error)
+	job, err = m.claim()
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	m.run(job)
+	saved = loadJob(t, m, created.ID)
+	assert.Equal(t, models.JobStatusCompleted, saved.Status)
+	assert.Equal(t, 2, saved.Attempts)
+
+	// 不可重试的错误直接失败，手动重试后重新排队
+	created, err = m.Enqueue("broken", 1, nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Retry(created.ID), ErrJobNotFinished)
+	job, err = m.claim()
+	require.NoError(t, err)
+	m.run(job)
+	saved = loadJob(t, m, created.ID)
+	assert.Equal(t, models.JobStatusFailed, saved.Status)
+	assert.Equal(t, 1, saved.Attempts)
+
+	require.NoError(t, m.Retry(created.ID))
+	saved = loadJob(t, m, created.ID)
+	assert.Equal(t, models.JobStatusPending, saved.Status)
+	assert.Equal(t, 0, saved.Attempts)
+	assert.Empty(t, saved.Error)
+}
+
+func TestCancel(t *testing.T) {
+	m := newTestManager(t, config.JobsConfig{})
+	started := make(chan struct{})
+	m.Register("wait", func(ctx context.Context, task *Task) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// 排队中的任务直接取消
+	queued, err := m.Enqueue("wait", 1, nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Cancel(queued.ID))
+	assert.Equal(t, models.JobStatusCancelled, loadJob(t, m, queued.ID).Status)
+	assert.ErrorIs(t, m.Cancel(queued.ID), ErrJobFinished)
+
+	// 执行中的任务通知处理器退出
+	running, err := m.Enqueue("wait", 1, nil)
+	require.NoError(t, err)
+	job, err := m.claim()
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		m.run(job)
+		close(done)
+	}()
+	<-started
+	require.NoError(t, m.Cancel(running.ID))
+	<-done
+
+	saved := loadJob(t, m, running.ID)
+	assert.Equal(t, models.JobStatusCancelled, saved.Status)
+	p, ok := m.progress.Get(running.TaskID)
+	require.True(t, ok)
+	assert.Equal(t, upload.StatusCancelled, p.Status)
+}
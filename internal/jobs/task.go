@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"go.uber.org/zap"
+)
+
+// persistInterval 进度写回数据库的最小间隔，推送给订阅者不受此限制
+const persistInterval = 2 * time.Second
+
+// permanentError 不应重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装不可重试的错误，任务处理器返回后直接标记为失败
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 错误是否不可重试
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// Task 任务处理器执行时使用的句柄，用于读取参数、上报进度和保存结果
+type Task struct {
+	Job *models.Job
+
+	m           *Manager
+	running     *runningJob
+	mu          sync.Mutex
+	lastPersist time.Time
+}
+
+// Cancelled 任务是否被用户取消；ctx 结束但未取消时表示服务正在关闭
+func (t *Task) Cancelled() bool {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	return t.running != nil && t.running.cancelled
+}
+
+// Bind 将任务参数解析到 v
+func (t *Task) Bind(v interface{}) error {
+	if t.Job.Params == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(t.Job.Params), v)
+}
+
+// SetTotal 设置任务总量
+func (t *Task) SetTotal(total int64) {
+	t.mu.Lock()
+	t.Job.Total = total
+	t.mu.Unlock()
+	t.m.progress.SetTotal(t.Job.TaskID, total)
+	t.persist(true)
+}
+
+// Advance 增加已完成数量并推送进度
+func (t *Task) Advance(n int64) {
+	t.mu.Lock()
+	t.Job.Done += n
+	done := t.Job.Done
+	t.mu.Unlock()
+	t.m.progress.Update(t.Job.TaskID, done)
+	t.persist(false)
+}
+
+// SetMessage 更新任务状态说明
+func (t *Task) SetMessage(message string) {
+	t.m.progress.SetStatus(t.Job.TaskID, upload.StatusRunning, message)
+}
+
+// SetResult 保存任务结果，可在执行过程中多次调用以保存中间结果
+func (t *Task) SetResult(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.Job.Result = string(data)
+	t.mu.Unlock()
+	return t.m.db.Model(&models.Job{}).Where("id = ?", t.Job.ID).Update("result", string(data)).Error
+}
+
+// persist 将进度写回数据库，force 为 false 时按间隔节流
+func (t *Task) persist(force bool) {
+	t.mu.Lock()
+	if !force && time.Since(t.lastPersist) < persistInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastPersist = time.Now()
+	total, done := t.Job.Total, t.Job.Done
+	t.mu.Unlock()
+
+	if err := t.m.db.Model(&models.Job{}).Where("id = ?", t.Job.ID).
+		Updates(map[string]interface{}{"total": total, "done": done}).Error; err != nil {
+		logger.Warn("保存任务进度失败", zap.Uint("job_id", t.Job.ID), zap.Error(err))
+	}
+}
@@ -11,21 +11,28 @@ import (
 
 var globalLogger *zap.Logger
 
-// InitLogger 初始化日志
-func InitLogger(cfg *config.LogConfig) error {
-	var level zapcore.Level
-	switch strings.ToLower(cfg.Level) {
+// level 日志级别，所有输出共用，运行时可以通过 SetLevel 修改
+var level = zap.NewAtomicLevel()
+
+// parseLevel 解析配置中的日志级别，无法识别时使用 info
+func parseLevel(s string) zapcore.Level {
+	switch strings.ToLower(s) {
 	case "debug":
-		level = zapcore.DebugLevel
+		return zapcore.DebugLevel
 	case "info":
-		level = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	case "warn":
-		level = zapcore.WarnLevel
+		return zapcore.WarnLevel
 	case "error":
-		level = zapcore.ErrorLevel
+		return zapcore.ErrorLevel
 	default:
-		level = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	}
+}
+
+// InitLogger 初始化日志
+func InitLogger(cfg *config.LogConfig) error {
+	level.SetLevel(parseLevel(cfg.Level))
 
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
@@ -85,6 +92,16 @@ func InitLogger(cfg *config.LogConfig) error {
 	return nil
 }
 
+// SetLevel 修改日志级别，立即对所有输出生效
+func SetLevel(s string) {
+	level.SetLevel(parseLevel(s))
+}
+
+// Level 获取当前日志级别
+func Level() string {
+	return level.String()
+}
+
 // GetLogger 获取全局日志实例
 func GetLogger() *zap.Logger {
 	if globalLogger == nil {
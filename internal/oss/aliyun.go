@@ -26,8 +26,8 @@ type AliyunOSSService struct {
 }
 
 // NewAliyunOSSService 创建阿里云OSS存储服务
-func NewAliyunOSSService(cfg *config.AliyunOSSConfig) (*AliyunOSSService, error) {
-	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret)
+func NewAliyunOSSService(cfg *config.AliyunOSSConfig, options ...oss.ClientOption) (*AliyunOSSService, error) {
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret, options...)
 	if err != nil {
 		return nil, fmt.Errorf("初始化阿里云OSS客户端失败: %w", err)
 	}
@@ -276,6 +276,7 @@ func (s *AliyunOSSService) ListUploadedPartsToBucket(objectKey string, uploadID
 			uploadedParts = append(uploadedParts, Part{
 				PartNumber: part.PartNumber,
 				ETag:       strings.Trim(part.ETag, "\""),
+				Size:       int64(part.Size),
 			})
 		}
 
@@ -717,6 +718,25 @@ func (s *AliyunOSSService) GenerateDownloadURLWithBucket(objectKey string, downl
 	return signedURL, expires, nil
 }
 
+// GenerateDownloadURLFromBucket 生成指定存储桶中对象的下载URL
+func (s *AliyunOSSService) GenerateDownloadURLFromBucket(objectKey string, regionCode string, bucketName string, expiration time.Duration) (string, time.Time, error) {
+	endpoint := s.getEndpoint(regionCode)
+	client, err := oss.New(endpoint, s.config.AccessKeyID, s.config.AccessKeySecret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("创建OSS客户端失败: %w", err)
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("获取存储桶失败: %w", err)
+	}
+	expires := time.Now().Add(expiration)
+	signedURL, err := bucket.SignURL(objectKey, oss.HTTPGet, int64(expiration.Seconds()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("生成文件URL失败: %w", err)
+	}
+	return signedURL, expires, nil
+}
+
 // DeleteObjectFromBucket 删除指定存储桶中的文件
 func (s *AliyunOSSService) DeleteObjectFromBucket(objectKey string, regionCode string, bucketName string) error {
 	logger.Info("开始删除指定存储桶中的文件",
@@ -789,3 +809,118 @@ func (s *AliyunOSSService) DeleteObjectFromBucket(objectKey string, regionCode s
 
 	return nil
 }
+
+// DeleteObjectsFromBucket 批量删除指定存储桶中的文件，每次请求最多删除1000个对象
+func (s *AliyunOSSService) DeleteObjectsFromBucket(objectKeys []string, regionCode string, bucketName string) ([]string, error) {
+	if len(objectKeys) == 0 {
+		return nil, nil
+	}
+
+	endpoint := s.getEndpoint(regionCode)
+
+	client, err := oss.New(endpoint, s.config.AccessKeyID, s.config.AccessKeySecret)
+	if err != nil {
+		return nil, fmt.Errorf("创建OSS客户端失败: %w", err)
+	}
+
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("获取存储桶失败: %w", err)
+	}
+
+	const batchSize = 1000
+	deleted := make([]string, 0, len(objectKeys))
+	for start := 0; start < len(objectKeys); start += batchSize {
+		end := start + batchSize
+		if end > len(objectKeys) {
+			end = len(objectKeys)
+		}
+		result, err := bucket.DeleteObjects(objectKeys[start:end])
+		if err != nil {
+			logger.Error("批量删除文件失败",
+				zap.String("bucketName", bucketName),
+				zap.Int("count", end-start),
+				zap.Error(err))
+			return deleted, fmt.Errorf("批量删除文件失败: %w", err)
+		}
+		deleted = append(deleted, result.DeletedObjects...)
+	}
+
+	logger.Info("批量删除文件成功",
+		zap.String("bucketName", bucketName),
+		zap.Int("count", len(deleted)))
+
+	return deleted, nil
+}
+
+// CopyObjectToBucket 在服务端将源对象复制到指定存储桶
+func (s *AliyunOSSService) CopyObjectToBucket(srcBucketName string, srcObjectKey string, objectKey string, regionCode string, bucketName string) (string, error) {
+	logger.Info("开始服务端复制对象",
+		zap.String("srcBucketName", srcBucketName),
+		zap.String("srcObjectKey", srcObjectKey),
+		zap.String("objectKey", objectKey),
+		zap.String("regionCode", regionCode),
+		zap.String("bucketName", bucketName))
+
+	// 获取正确的endpoint（考虑传输加速）
+	endpoint := s.getEndpoint(regionCode)
+
+	client, err := oss.New(endpoint, s.config.AccessKeyID, s.config.AccessKeySecret)
+	if err != nil {
+		return "", fmt.Errorf("创建OSS客户端失败: %w", err)
+	}
+
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return "", fmt.Errorf("获取存储桶失败: %w", err)
+	}
+
+	// 同地域内复制，元数据（包括Content-Disposition）随对象一起复制
+	if _, err := bucket.CopyObjectFrom(srcBucketName, srcObjectKey, objectKey); err != nil {
+		logger.Error("服务端复制对象失败",
+			zap.String("srcBucketName", srcBucketName),
+			zap.String("srcObjectKey", srcObjectKey),
+			zap.String("objectKey", objectKey),
+			zap.String("bucketName", bucketName),
+			zap.Error(err))
+		return "", fmt.Errorf("服务端复制对象失败: %w", err)
+	}
+
+	url, err := bucket.SignURL(objectKey, oss.HTTPGet, 24*3600)
+	if err != nil {
+		return "", fmt.Errorf("生成文件URL失败: %w", err)
+	}
+
+	return url, nil
+}
+
+// PutObjectTaggingToBucket 设置指定存储桶中对象的标签
+func (s *AliyunOSSService) PutObjectTaggingToBucket(objectKey string, regionCode string, bucketName string, tags map[string]string) error {
+	endpoint := s.getEndpoint(regionCode)
+
+	client, err := oss.New(endpoint, s.config.AccessKeyID, s.config.AccessKeySecret)
+	if err != nil {
+		return fmt.Errorf("创建OSS客户端失败: %w", err)
+	}
+
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return fmt.Errorf("获取存储桶失败: %w", err)
+	}
+
+	if len(tags) == 0 {
+		if err := bucket.DeleteObjectTagging(objectKey); err != nil {
+			return fmt.Errorf("清除对象标签失败: %w", err)
+		}
+		return nil
+	}
+
+	tagging := oss.Tagging{Tags: make([]oss.Tag, 0, len(tags))}
+	for key, value := range tags {
+		tagging.Tags = append(tagging.Tags, oss.Tag{Key: key, Value: value})
+	}
+	if err := bucket.PutObjectTagging(objectKey, tagging); err != nil {
+		return fmt.Errorf("设置对象标签失败: %w", err)
+	}
+	return nil
+}
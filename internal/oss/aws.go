@@ -218,6 +218,12 @@ func (s *AWSS3Service) GenerateDownloadURL(objectKey string, expiration time.Dur
 	return presignResult.URL, expires, nil
 }
 
+// GenerateDownloadURLFromBucket 生成指定存储桶中对象的下载URL
+func (s *AWSS3Service) GenerateDownloadURLFromBucket(objectKey string, regionCode string, bucketName string, expiration time.Duration) (string, time.Time, error) {
+	// AWS S3暂未实现指定存储桶下载链接功能
+	return "", time.Time{}, fmt.Errorf("AWS S3暂未实现指定存储桶下载链接功能")
+}
+
 // DeleteObject 删除对象
 func (s *AWSS3Service) DeleteObject(objectKey string) error {
 	fullObjectKey := s.getObjectKey(objectKey)
@@ -241,6 +247,24 @@ func (s *AWSS3Service) DeleteObjectFromBucket(objectKey string, regionCode strin
 	return fmt.Errorf("AWS S3暂未实现指定存储桶删除功能")
 }
 
+// DeleteObjectsFromBucket 批量删除指定存储桶中的文件
+func (s *AWSS3Service) DeleteObjectsFromBucket(objectKeys []string, regionCode string, bucketName string) ([]string, error) {
+	// AWS S3暂未实现批量删除功能
+	return nil, fmt.Errorf("AWS S3暂未实现批量删除功能")
+}
+
+// CopyObjectToBucket 在服务端将源对象复制到指定存储桶
+func (s *AWSS3Service) CopyObjectToBucket(srcBucketName string, srcObjectKey string, objectKey string, regionCode string, bucketName string) (string, error) {
+	// AWS S3暂未实现指定存储桶复制功能
+	return "", fmt.Errorf("AWS S3暂未实现指定存储桶复制功能")
+}
+
+// PutObjectTaggingToBucket 设置指定存储桶中对象的标签
+func (s *AWSS3Service) PutObjectTaggingToBucket(objectKey string, regionCode string, bucketName string, tags map[string]string) error {
+	// AWS S3暂未实现对象标签功能
+	return fmt.Errorf("AWS S3暂未实现对象标签功能")
+}
+
 // GetObjectInfo 获取对象信息
 func (s *AWSS3Service) GetObjectInfo(objectKey string) (int64, error) {
 	objectKey = s.getObjectKey(objectKey)
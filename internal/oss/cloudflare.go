@@ -232,6 +232,12 @@ func (s *CloudflareR2Service) GenerateDownloadURL(objectKey string, expiration t
 	return presignResult.URL, expires, nil
 }
 
+// GenerateDownloadURLFromBucket 生成指定存储桶中对象的下载URL
+func (s *CloudflareR2Service) GenerateDownloadURLFromBucket(objectKey string, regionCode string, bucketName string, expiration time.Duration) (string, time.Time, error) {
+	// Cloudflare R2暂未实现指定存储桶下载链接功能
+	return "", time.Time{}, fmt.Errorf("Cloudflare R2暂未实现指定存储桶下载链接功能")
+}
+
 // DeleteObject 删除对象
 func (s *CloudflareR2Service) DeleteObject(objectKey string) error {
 	fullObjectKey := s.getObjectKey(objectKey)
@@ -255,6 +261,24 @@ func (s *CloudflareR2Service) DeleteObjectFromBucket(objectKey string, regionCod
 	return fmt.Errorf("Cloudflare R2暂未实现指定存储桶删除功能")
 }
 
+// DeleteObjectsFromBucket 批量删除指定存储桶中的文件
+func (s *CloudflareR2Service) DeleteObjectsFromBucket(objectKeys []string, regionCode string, bucketName string) ([]string, error) {
+	// Cloudflare R2暂未实现批量删除功能
+	return nil, fmt.Errorf("Cloudflare R2暂未实现批量删除功能")
+}
+
+// CopyObjectToBucket 在服务端将源对象复制到指定存储桶
+func (s *CloudflareR2Service) CopyObjectToBucket(srcBucketName string, srcObjectKey string, objectKey string, regionCode string, bucketName string) (string, error) {
+	// Cloudflare R2暂未实现指定存储桶复制功能
+	return "", fmt.Errorf("Cloudflare R2暂未实现指定存储桶复制功能")
+}
+
+// PutObjectTaggingToBucket 设置指定存储桶中对象的标签
+func (s *CloudflareR2Service) PutObjectTaggingToBucket(objectKey string, regionCode string, bucketName string, tags map[string]string) error {
+	// Cloudflare R2暂未实现对象标签功能
+	return fmt.Errorf("Cloudflare R2暂未实现对象标签功能")
+}
+
 // GetObjectInfo 获取对象信息
 func (s *CloudflareR2Service) GetObjectInfo(objectKey string) (int64, error) {
 	objectKey = s.getObjectKey(objectKey)
@@ -1,33 +1,59 @@
 package oss
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
 	"github.com/myysophia/ossmanager-backend/internal/config"
-	"github.com/myysophia/ossmanager-backend/internal/db"
 	"github.com/myysophia/ossmanager-backend/internal/db/models"
 	"github.com/myysophia/ossmanager-backend/internal/logger"
 	"go.uber.org/zap"
-	"sync"
+	"gorm.io/gorm"
 )
 
 // DefaultStorageFactory 默认存储服务工厂
+// 存储配置表中有对应类型的配置时使用表中的凭据，没有时使用配置文件中的配置
 type DefaultStorageFactory struct {
-	ossConfig     *config.OSSConfig
-	serviceCache  map[string]StorageService
-	lock          sync.RWMutex
-	defaultConfig *models.OSSConfig
+	db             *gorm.DB
+	ossConfig      *config.OSSConfig
+	serviceCache   map[string]StorageService    // 存储类型 -> 按配置文件创建的服务
+	configServices map[uint]*configService      // 存储配置ID -> 按配置表记录创建的服务
+	typeConfigs    map[string]*models.OSSConfig // 存储类型 -> 该类型使用的配置记录，nil 表示表中没有该类型的配置
+	lock           sync.RWMutex
+	defaultConfig  *models.OSSConfig
 }
 
-// NewStorageFactory 创建存储服务工厂
-func NewStorageFactory(ossConfig *config.OSSConfig) *DefaultStorageFactory {
+// configService 按存储配置记录创建的服务，记录修改后重建
+type configService struct {
+	service     StorageService
+	storageType string
+	updatedAt   time.Time
+}
+
+// NewStorageFactory 创建存储服务工厂，db 为 nil 时只使用配置文件中的配置
+func NewStorageFactory(ossConfig *config.OSSConfig, db *gorm.DB) *DefaultStorageFactory {
 	return &DefaultStorageFactory{
-		ossConfig:    ossConfig,
-		serviceCache: make(map[string]StorageService),
+		db:             db,
+		ossConfig:      ossConfig,
+		serviceCache:   make(map[string]StorageService),
+		configServices: make(map[uint]*configService),
+		typeConfigs:    make(map[string]*models.OSSConfig),
 	}
 }
 
-// GetStorageService 获取存储服务
+// GetStorageService 获取存储服务，优先使用存储配置表中该类型的默认配置
 func (f *DefaultStorageFactory) GetStorageService(storageType string) (StorageService, error) {
+	cfg, err := f.typeConfig(storageType)
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		return f.GetConfigStorageService(cfg)
+	}
+
 	// 先从缓存中获取
 	f.lock.RLock()
 	service, ok := f.serviceCache[storageType]
@@ -47,39 +73,135 @@ func (f *DefaultStorageFactory) GetStorageService(storageType string) (StorageSe
 	}
 
 	// 创建存储服务
-	var err error
+	service, err = newStorageService(f.ossConfig, storageType)
+	if err != nil {
+		logger.Error("创建存储服务失败", zap.String("storageType", storageType), zap.Error(err))
+		return nil, err
+	}
+
+	// 加入缓存
+	f.serviceCache[storageType] = service
+	return service, nil
+}
+
+// typeConfig 获取存储类型使用的配置记录，默认配置优先，表中没有该类型的配置时返回 nil
+func (f *DefaultStorageFactory) typeConfig(storageType string) (*models.OSSConfig, error) {
+	if f.db == nil {
+		return nil, nil
+	}
+	f.lock.RLock()
+	cfg, ok := f.typeConfigs[storageType]
+	f.lock.RUnlock()
+	if ok {
+		return cfg, nil
+	}
+
+	var row models.OSSConfig
+	err := f.db.Where("storage_type = ?", storageType).Order("is_default DESC, id").First(&row).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("读取存储配置失败", zap.String("storageType", storageType), zap.Error(err))
+		return nil, fmt.Errorf("读取存储配置失败: %w", err)
+	}
+	if err == nil {
+		cfg = &row
+	}
+
+	f.lock.Lock()
+	f.typeConfigs[storageType] = cfg
+	f.lock.Unlock()
+	return cfg, nil
+}
+
+// GetConfigStorageService 按存储配置表中的记录获取存储服务，以配置ID缓存，传入的记录比缓存的更新时重建
+func (f *DefaultStorageFactory) GetConfigStorageService(cfg *models.OSSConfig) (StorageService, error) {
+	f.lock.RLock()
+	cached, ok := f.configServices[cfg.ID]
+	f.lock.RUnlock()
+	if ok && !cfg.UpdatedAt.After(cached.updatedAt) {
+		return cached.service, nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	cached, ok = f.configServices[cfg.ID]
+	if ok && !cfg.UpdatedAt.After(cached.updatedAt) {
+		return cached.service, nil
+	}
+
+	service, err := newConfigStorageService(f.ossConfig, cfg)
+	if err != nil {
+		logger.Error("按存储配置创建存储服务失败",
+			zap.Uint("configID", cfg.ID), zap.String("storageType", cfg.StorageType), zap.Error(err))
+		return nil, err
+	}
+	f.configServices[cfg.ID] = &configService{service: service, storageType: cfg.StorageType, updatedAt: cfg.UpdatedAt}
+	return service, nil
+}
+
+// newConfigStorageService 使用存储配置记录中的凭据、访问域名和存储桶创建存储服务，其余选项沿用配置文件
+func newConfigStorageService(ossConfig *config.OSSConfig, cfg *models.OSSConfig) (StorageService, error) {
+	switch cfg.StorageType {
+	case StorageTypeAliyunOSS:
+		aliyun := ossConfig.AliyunOSS
+		aliyun.AccessKeyID = string(cfg.AccessKey)
+		aliyun.AccessKeySecret = string(cfg.SecretKey)
+		aliyun.Endpoint = cfg.Endpoint
+		aliyun.Bucket = cfg.Bucket
+		if cfg.Region != "" {
+			aliyun.Region = cfg.Region
+		}
+		if cfg.URLExpireTime > 0 {
+			aliyun.URLExpireTime = cfg.URLExpireTime
+		}
+		return NewAliyunOSSService(&aliyun)
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", cfg.StorageType)
+	}
+}
+
+// newStorageService 根据配置创建存储服务，服务会持有配置的指针，因此配置创建后不能再修改
+func newStorageService(ossConfig *config.OSSConfig, storageType string) (StorageService, error) {
 	switch storageType {
 	case StorageTypeAliyunOSS:
-		service, err = NewAliyunOSSService(&f.ossConfig.AliyunOSS)
+		return NewAliyunOSSService(&ossConfig.AliyunOSS)
 	//case StorageTypeAWSS3:
-	//	service, err = NewAWSS3Service(&f.ossConfig.AWSS3)
+	//	return NewAWSS3Service(&ossConfig.AWSS3)
 	//case StorageTypeR2:
-	//	service, err = NewCloudflareR2Service(&f.ossConfig.CloudflareR2)
+	//	return NewCloudflareR2Service(&ossConfig.CloudflareR2)
 	default:
 		return nil, fmt.Errorf("不支持的存储类型: %s", storageType)
 	}
+}
 
-	if err != nil {
-		logger.Error("创建存储服务失败", zap.String("storageType", storageType), zap.Error(err))
-		return nil, err
+// storageConfig 获取存储类型对应的配置，用于判断配置是否发生变化
+func storageConfig(ossConfig *config.OSSConfig, storageType string) interface{} {
+	switch storageType {
+	case StorageTypeAliyunOSS:
+		return ossConfig.AliyunOSS
+	case StorageTypeAWSS3:
+		return ossConfig.AWSS3
+	case StorageTypeR2:
+		return ossConfig.CloudflareR2
 	}
-
-	// 加入缓存
-	f.serviceCache[storageType] = service
-	return service, nil
+	return nil
 }
 
 // GetDefaultStorageService 获取默认存储服务
 func (f *DefaultStorageFactory) GetDefaultStorageService() (StorageService, error) {
 	// 如果已有默认配置，直接使用
-	if f.defaultConfig != nil {
-		return f.GetStorageService(f.defaultConfig.StorageType)
+	f.lock.RLock()
+	defaultConfig := f.defaultConfig
+	f.lock.RUnlock()
+	if defaultConfig != nil {
+		return f.GetConfigStorageService(defaultConfig)
 	}
 
 	// 从数据库中获取默认配置
-	gormDB := db.GetDB()
 	var ossConfig models.OSSConfig
-	err := gormDB.Where("is_default = ?", true).First(&ossConfig).Error
+	err := errors.New("未配置数据库")
+	if f.db != nil {
+		err = f.db.Where("is_default = ?", true).First(&ossConfig).Error
+	}
 	if err != nil {
 		logger.Error("从数据库获取默认OSS配置失败", zap.Error(err))
 
@@ -88,21 +210,12 @@ func (f *DefaultStorageFactory) GetDefaultStorageService() (StorageService, erro
 		return f.GetStorageService(StorageTypeAliyunOSS)
 	}
 
+	f.lock.Lock()
 	f.defaultConfig = &ossConfig
+	f.lock.Unlock()
 
 	// 根据配置创建存储服务
-	var service StorageService
-	switch ossConfig.StorageType {
-	case StorageTypeAliyunOSS:
-		service, err = f.GetStorageService(StorageTypeAliyunOSS)
-	case StorageTypeAWSS3:
-		service, err = f.GetStorageService(StorageTypeAWSS3)
-	case StorageTypeR2:
-		service, err = f.GetStorageService(StorageTypeR2)
-	default:
-		return nil, fmt.Errorf("不支持的存储类型: %s", ossConfig.StorageType)
-	}
-
+	service, err := f.GetConfigStorageService(&ossConfig)
 	if err != nil {
 		logger.Error("创建默认存储服务失败", zap.String("storageType", ossConfig.StorageType), zap.Error(err))
 		return nil, err
@@ -116,5 +229,70 @@ func (f *DefaultStorageFactory) ClearCache() {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.serviceCache = make(map[string]StorageService)
+	f.configServices = make(map[uint]*configService)
+	f.typeConfigs = make(map[string]*models.OSSConfig)
+	f.defaultConfig = nil
+}
+
+// Reload 使用新的配置文件内容，重建配置发生变化的存储服务，返回重建的存储类型
+// 旧的存储服务不会关闭，已经取得旧服务的上传、下载等操作会使用原来的客户端继续完成；
+// 新配置无法创建存储服务时保留旧服务和该类型的旧配置，修正配置后再次加载即可
+func (f *DefaultStorageFactory) Reload(ossConfig *config.OSSConfig) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	merged := *ossConfig
+	var reloaded []string
+	for storageType := range f.serviceCache {
+		if reflect.DeepEqual(storageConfig(f.ossConfig, storageType), storageConfig(ossConfig, storageType)) {
+			continue
+		}
+		service, err := newStorageService(ossConfig, storageType)
+		if err != nil {
+			logger.Error("使用新配置创建存储服务失败，继续使用原来的配置",
+				zap.String("storageType", storageType), zap.Error(err))
+			copyStorageConfig(&merged, f.ossConfig, storageType)
+			continue
+		}
+		f.serviceCache[storageType] = service
+		reloaded = append(reloaded, storageType)
+		logger.Info("存储服务已使用新配置重建", zap.String("storageType", storageType))
+	}
+	// 按配置记录创建的服务沿用配置文件中的其他选项，配置变化后下次使用时重建
+	for id, cached := range f.configServices {
+		if !reflect.DeepEqual(storageConfig(f.ossConfig, cached.storageType), storageConfig(&merged, cached.storageType)) {
+			delete(f.configServices, id)
+		}
+	}
+	f.ossConfig = &merged
+	return reloaded
+}
+
+// copyStorageConfig 将指定存储类型的配置从 src 复制到 dst
+func copyStorageConfig(dst, src *config.OSSConfig, storageType string) {
+	switch storageType {
+	case StorageTypeAliyunOSS:
+		dst.AliyunOSS = src.AliyunOSS
+	case StorageTypeAWSS3:
+		dst.AWSS3 = src.AWSS3
+	case StorageTypeR2:
+		dst.CloudflareR2 = src.CloudflareR2
+	}
+}
+
+// Invalidate 存储配置表发生变化后调用，移除指定类型的缓存服务并重新读取配置记录，下次使用时重建
+// 与 Reload 一样，已经取得旧服务的操作不受影响
+func (f *DefaultStorageFactory) Invalidate(storageTypes ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, storageType := range storageTypes {
+		delete(f.serviceCache, storageType)
+		delete(f.typeConfigs, storageType)
+		for id, cached := range f.configServices {
+			if cached.storageType == storageType {
+				delete(f.configServices, id)
+			}
+		}
+	}
 	f.defaultConfig = nil
 }
@@ -3,6 +3,8 @@ package oss
 import (
 	"io"
 	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
 )
 
 // 存储类型枚举
@@ -16,6 +18,7 @@ const (
 type Part struct {
 	PartNumber int    `json:"part_number"`
 	ETag       string `json:"etag"`
+	Size       int64  `json:"size,omitempty"`
 }
 
 // StorageService 存储服务接口
@@ -73,6 +76,13 @@ type StorageService interface {
 	// 返回：下载URL, 过期时间, 错误
 	GenerateDownloadURL(objectKey string, expiration time.Duration) (string, time.Time, error)
 
+	// GenerateDownloadURLFromBucket 生成指定存储桶中对象的下载URL
+	// objectKey: 对象键
+	// regionCode, bucketName: 指定的地域和存储桶
+	// expiration: 过期时间
+	// 返回：下载URL, 过期时间, 错误
+	GenerateDownloadURLFromBucket(objectKey string, regionCode string, bucketName string, expiration time.Duration) (string, time.Time, error)
+
 	// DeleteObject 删除文件
 	DeleteObject(objectKey string) error
 
@@ -83,6 +93,24 @@ type StorageService interface {
 	// 返回：错误
 	DeleteObjectFromBucket(objectKey string, regionCode string, bucketName string) error
 
+	// DeleteObjectsFromBucket 批量删除指定存储桶中的文件
+	// objectKeys: 对象键列表
+	// regionCode, bucketName: 指定的地域和存储桶
+	// 返回：删除成功的对象键, 错误
+	DeleteObjectsFromBucket(objectKeys []string, regionCode string, bucketName string) ([]string, error)
+
+	// CopyObjectToBucket 在服务端复制对象，数据不经过本服务
+	// srcBucketName, srcObjectKey: 源存储桶和对象键（需与目标位于同一地域）
+	// objectKey, regionCode, bucketName: 目标对象键、地域和存储桶
+	// 返回：目标对象的访问URL, 错误
+	CopyObjectToBucket(srcBucketName string, srcObjectKey string, objectKey string, regionCode string, bucketName string) (string, error)
+
+	// PutObjectTaggingToBucket 设置指定存储桶中对象的标签，会覆盖对象原有标签
+	// objectKey: 对象键
+	// regionCode, bucketName: 指定的地域和存储桶
+	// tags: 标签键值对，为空时清除对象标签
+	PutObjectTaggingToBucket(objectKey string, regionCode string, bucketName string, tags map[string]string) error
+
 	// GetObjectInfo 获取对象信息
 	// objectKey: 对象键
 	// 返回：对象大小, 错误
@@ -109,6 +137,9 @@ type StorageFactory interface {
 	// storageType: 存储类型
 	GetStorageService(storageType string) (StorageService, error)
 
+	// GetConfigStorageService 按存储配置表中的记录获取存储服务
+	GetConfigStorageService(cfg *models.OSSConfig) (StorageService, error)
+
 	// GetDefaultStorageService 获取默认存储服务
 	GetDefaultStorageService() (StorageService, error)
 
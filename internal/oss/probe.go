@@ -0,0 +1,249 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+)
+
+// 连通性测试失败原因
+const (
+	ProbeErrorConfig        = "config"         // 配置无效，无法创建客户端
+	ProbeErrorAuth          = "auth"           // 访问密钥不存在、已禁用或签名错误
+	ProbeErrorNetwork       = "network"        // 无法连接、DNS 解析失败或超时
+	ProbeErrorPermission    = "permission"     // 密钥有效但没有对应操作的权限
+	ProbeErrorBucketMissing = "bucket_missing" // 存储桶不存在
+	ProbeErrorUnknown       = "unknown"
+)
+
+// 连通性测试步骤状态
+const (
+	ProbeStepOK      = "ok"
+	ProbeStepFailed  = "failed"
+	ProbeStepSkipped = "skipped"
+)
+
+// probeTimeout 测试中每个请求的超时时间（秒）
+const probeTimeout = 10
+
+// probeSteps 测试依次执行的步骤，任一步骤失败后跳过后续步骤，但上传成功后总会删除测试对象
+var probeSteps = []string{"put", "head", "get", "presign", "delete"}
+
+// ProbeStep 单个测试步骤的结果
+type ProbeStep struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	ErrorKind  string `json:"error_kind,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ProbeResult 连通性测试结果
+type ProbeResult struct {
+	Success   bool        `json:"success"`
+	ObjectKey string      `json:"object_key"`
+	Steps     []ProbeStep `json:"steps"`
+}
+
+// NewProbeService 根据存储配置记录创建临时存储服务，只用于连通性测试，不会加入工厂缓存
+// 请求使用较短的超时时间，避免网络不通时长时间等待
+func NewProbeService(cfg *models.OSSConfig) (StorageService, error) {
+	switch cfg.StorageType {
+	case StorageTypeAliyunOSS:
+		return NewAliyunOSSService(&config.AliyunOSSConfig{
+			AccessKeyID:     string(cfg.AccessKey),
+			AccessKeySecret: string(cfg.SecretKey),
+			Endpoint:        cfg.Endpoint,
+			Bucket:          cfg.Bucket,
+			Region:          cfg.Region,
+			URLExpireTime:   cfg.URLExpireTime,
+		}, oss.Timeout(probeTimeout, probeTimeout))
+	default:
+		return nil, fmt.Errorf("暂不支持测试存储类型: %s", cfg.StorageType)
+	}
+}
+
+// Probe 使用测试对象依次执行上传、获取元数据、下载、预签名 URL 下载和删除，记录每一步的耗时和失败原因
+func Probe(service StorageService) *ProbeResult {
+	result := &ProbeResult{ObjectKey: probeObjectKey()}
+	content := []byte(fmt.Sprintf("ossmanager connectivity probe %s\n", time.Now().Format(time.RFC3339Nano)))
+
+	run := map[string]func() error{
+		"put": func() error {
+			_, err := service.Upload(bytes.NewReader(content), result.ObjectKey)
+			return err
+		},
+		"head": func() error {
+			size, err := service.GetObjectInfo(result.ObjectKey)
+			if err != nil {
+				return err
+			}
+			if size != int64(len(content)) {
+				return fmt.Errorf("对象大小不一致: 上传 %d 字节，读取到 %d 字节", len(content), size)
+			}
+			return nil
+		},
+		"get": func() error {
+			body, err := service.GetObject(result.ObjectKey)
+			if err != nil {
+				return err
+			}
+			defer body.Close()
+			return checkProbeContent(body, content)
+		},
+		"presign": func() error {
+			signedURL, _, err := service.GenerateDownloadURL(result.ObjectKey, time.Minute)
+			if err != nil {
+				return err
+			}
+			return fetchProbeURL(signedURL, content)
+		},
+		"delete": func() error {
+			return service.DeleteObject(result.ObjectKey)
+		},
+	}
+
+	failed, uploaded := false, false
+	for _, name := range probeSteps {
+		if failed && !(name == "delete" && uploaded) {
+			result.Steps = append(result.Steps, ProbeStep{Name: name, Status: ProbeStepSkipped})
+			continue
+		}
+		step := runProbeStep(name, run[name])
+		result.Steps = append(result.Steps, step)
+		if step.Status == ProbeStepFailed {
+			failed = true
+		} else if name == "put" {
+			uploaded = true
+		}
+	}
+	result.Success = !failed
+	return result
+}
+
+// ProbeInitFailed 创建存储服务失败时的测试结果
+func ProbeInitFailed(err error) *ProbeResult {
+	result := &ProbeResult{Steps: []ProbeStep{{
+		Name:      "init",
+		Status:    ProbeStepFailed,
+		ErrorKind: ProbeErrorConfig,
+		Error:     err.Error(),
+	}}}
+	for _, name := range probeSteps {
+		result.Steps = append(result.Steps, ProbeStep{Name: name, Status: ProbeStepSkipped})
+	}
+	return result
+}
+
+// runProbeStep 执行测试步骤并记录耗时
+func runProbeStep(name string, fn func() error) ProbeStep {
+	start := time.Now()
+	err := fn()
+	step := ProbeStep{Name: name, Status: ProbeStepOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		step.Status = ProbeStepFailed
+		step.ErrorKind = ClassifyError(err)
+		step.Error = err.Error()
+	}
+	return step
+}
+
+// probeObjectKey 生成不会与业务文件冲突的测试对象键
+func probeObjectKey() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf(".ossmanager-probe/%d-%s", time.Now().UnixNano(), hex.EncodeToString(buf))
+}
+
+// checkProbeContent 校验读取到的内容与上传的内容一致
+func checkProbeContent(r io.Reader, content []byte) error {
+	data, err := io.ReadAll(io.LimitReader(r, int64(len(content))+1))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, content) {
+		return errors.New("下载的内容与上传的内容不一致")
+	}
+	return nil
+}
+
+// fetchProbeURL 通过预签名 URL 下载测试对象，验证签名可以被存储服务接受
+func fetchProbeURL(signedURL string, content []byte) error {
+	client := &http.Client{Timeout: probeTimeout * time.Second}
+	resp, err := client.Get(signedURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// 存储服务的错误响应为 XML，解析出错误码用于分类
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		serviceErr := oss.ServiceError{StatusCode: resp.StatusCode}
+		_ = xml.Unmarshal(body, &serviceErr)
+		if serviceErr.Message == "" {
+			serviceErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("预签名 URL 下载失败: %w", serviceErr)
+	}
+	return checkProbeContent(resp.Body, content)
+}
+
+// ClassifyError 根据存储服务返回的错误判断失败原因
+func ClassifyError(err error) string {
+	code, status := "", 0
+	var serviceErr oss.ServiceError
+	var apiErr interface{ ErrorCode() string }
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &serviceErr) {
+		code, status = serviceErr.Code, serviceErr.StatusCode
+	} else if errors.As(err, &apiErr) {
+		// AWS SDK 返回的错误
+		code = apiErr.ErrorCode()
+		if errors.As(err, &httpErr) {
+			status = httpErr.HTTPStatusCode()
+		}
+	}
+
+	switch code {
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch", "InvalidSecurityToken", "SecurityTokenExpired",
+		"ExpiredToken", "InvalidToken", "UserDisable", "RequestTimeTooSkewed":
+		return ProbeErrorAuth
+	case "AccessDenied", "AllAccessDisabled", "AccountProblem":
+		return ProbeErrorPermission
+	case "NoSuchBucket":
+		return ProbeErrorBucketMissing
+	}
+	switch status {
+	case http.StatusUnauthorized:
+		return ProbeErrorAuth
+	case http.StatusForbidden:
+		return ProbeErrorPermission
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ProbeErrorNetwork
+	}
+	// 部分 SDK 只返回错误信息，没有保留原始的网络错误
+	msg := err.Error()
+	for _, s := range []string{"connection refused", "no such host", "i/o timeout", "connection reset", "TLS handshake"} {
+		if strings.Contains(msg, s) {
+			return ProbeErrorNetwork
+		}
+	}
+	return ProbeErrorUnknown
+}
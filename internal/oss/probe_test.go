@@ -0,0 +1,125 @@
+package oss
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeStorage 只实现连通性测试用到的方法，对象保存在内存中，预签名 URL 指向测试服务器
+type probeStorage struct {
+	StorageService
+	objects map[string][]byte
+	server  *httptest.Server
+	errs    map[string]error
+}
+
+func newProbeStorage(t *testing.T) *probeStorage {
+	s := &probeStorage{objects: make(map[string][]byte), errs: make(map[string]error)}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.errs["presign"] != nil {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>SignatureDoesNotMatch</Code><Message>signature mismatch</Message></Error>`)
+			return
+		}
+		w.Write(s.objects[strings.TrimPrefix(r.URL.Path, "/")])
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *probeStorage) Upload(file io.Reader, objectKey string) (string, error) {
+	if err := s.errs["put"]; err != nil {
+		return "", fmt.Errorf("上传文件失败: %w", err)
+	}
+	data, _ := io.ReadAll(file)
+	s.objects[objectKey] = data
+	return "", nil
+}
+
+func (s *probeStorage) GetObjectInfo(objectKey string) (int64, error) {
+	return int64(len(s.objects[objectKey])), s.errs["head"]
+}
+
+func (s *probeStorage) GetObject(objectKey string) (io.ReadCloser, error) {
+	if err := s.errs["get"]; err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(s.objects[objectKey])), nil
+}
+
+func (s *probeStorage) GenerateDownloadURL(objectKey string, expiration time.Duration) (string, time.Time, error) {
+	return s.server.URL + "/" + objectKey, time.Now().Add(expiration), nil
+}
+
+func (s *probeStorage) DeleteObject(objectKey string) error {
+	delete(s.objects, objectKey)
+	return nil
+}
+
+func stepStatuses(result *ProbeResult) map[string]string {
+	statuses := make(map[string]string)
+	for _, step := range result.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+func TestProbe(t *testing.T) {
+	storage := newProbeStorage(t)
+	result := Probe(storage)
+	assert.True(t, result.Success)
+	require.Len(t, result.Steps, len(probeSteps))
+	for _, step := range result.Steps {
+		assert.Equal(t, ProbeStepOK, step.Status, step.Name)
+	}
+	assert.Empty(t, storage.objects, "测试对象应当被删除")
+}
+
+func TestProbeFailures(t *testing.T) {
+	// 上传失败时没有需要清理的对象
+	storage := newProbeStorage(t)
+	storage.errs["put"] = oss.ServiceError{Code: "InvalidAccessKeyId", StatusCode: http.StatusForbidden}
+	result := Probe(storage)
+	assert.False(t, result.Success)
+	assert.Equal(t, ProbeErrorAuth, result.Steps[0].ErrorKind)
+	assert.Equal(t, map[string]string{"put": "failed", "head": "skipped", "get": "skipped", "presign": "skipped", "delete": "skipped"}, stepStatuses(result))
+
+	// 上传成功后的步骤失败时仍然删除测试对象
+	storage = newProbeStorage(t)
+	storage.errs["presign"] = errors.New("forbidden")
+	result = Probe(storage)
+	assert.False(t, result.Success)
+	assert.Equal(t, map[string]string{"put": "ok", "head": "ok", "get": "ok", "presign": "failed", "delete": "ok"}, stepStatuses(result))
+	assert.Equal(t, ProbeErrorAuth, result.Steps[3].ErrorKind)
+	assert.Empty(t, storage.objects)
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		kind string
+	}{
+		{oss.ServiceError{Code: "SignatureDoesNotMatch", StatusCode: 403}, ProbeErrorAuth},
+		{fmt.Errorf("获取对象失败: %w", oss.ServiceError{Code: "AccessDenied", StatusCode: 403}), ProbeErrorPermission},
+		{oss.ServiceError{Code: "NoSuchBucket", StatusCode: 404}, ProbeErrorBucketMissing},
+		{oss.ServiceError{StatusCode: 403}, ProbeErrorPermission},
+		{&net.DNSError{Err: "no such host", Name: "oss.invalid"}, ProbeErrorNetwork},
+		{errors.New("dial tcp 10.0.0.1:443: connect: connection refused"), ProbeErrorNetwork},
+		{errors.New("其他错误"), ProbeErrorUnknown},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.kind, ClassifyError(c.err), c.err.Error())
+	}
+}
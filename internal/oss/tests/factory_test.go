@@ -1 +1,134 @@
 package oss
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func testOSSConfig(accessKeyID string) *config.OSSConfig {
+	cfg := &config.OSSConfig{}
+	cfg.AliyunOSS.Endpoint = "oss-cn-hangzhou.aliyuncs.com"
+	cfg.AliyunOSS.Bucket = "test-bucket"
+	cfg.AliyunOSS.AccessKeyID = accessKeyID
+	cfg.AliyunOSS.AccessKeySecret = "secret"
+	return cfg
+}
+
+// accessKeyID 从离线签名的分片上传URL中读取服务使用的访问密钥
+func accessKeyID(t *testing.T, service oss.StorageService) string {
+	signed, err := service.GeneratePartUploadURL("a.txt", "upload-id", 1, "cn-hangzhou", "bucket")
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	return u.Query().Get("OSSAccessKeyId")
+}
+
+func TestStorageFactoryReload(t *testing.T) {
+	factory := oss.NewStorageFactory(testOSSConfig("old-key"), nil)
+	old, err := factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+
+	// 其他存储类型的配置变化不影响已创建的服务
+	unchanged := testOSSConfig("old-key")
+	unchanged.AWSS3.Bucket = "other-bucket"
+	assert.Empty(t, factory.Reload(unchanged))
+	service, err := factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.Same(t, old, service)
+
+	// 访问密钥变化后重建服务，旧服务仍然使用原来的配置
+	assert.Equal(t, []string{oss.StorageTypeAliyunOSS}, factory.Reload(testOSSConfig("new-key")))
+	service, err = factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.NotSame(t, old, service)
+	assert.Equal(t, "new-key", accessKeyID(t, service))
+	assert.Equal(t, "old-key", accessKeyID(t, old))
+
+	// 新配置无法创建服务时保留旧服务
+	broken := testOSSConfig("broken-key")
+	broken.AliyunOSS.Endpoint = "http://invalid endpoint"
+	assert.Empty(t, factory.Reload(broken))
+	kept, err := factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.Same(t, service, kept)
+
+	// 失败的存储类型保留旧配置，缓存清除后仍能用旧配置重建
+	factory.Invalidate(oss.StorageTypeAliyunOSS)
+	rebuilt, err := factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.Equal(t, "new-key", accessKeyID(t, rebuilt))
+
+	// 修正后再次加载会重建服务
+	assert.Equal(t, []string{oss.StorageTypeAliyunOSS}, factory.Reload(testOSSConfig("fixed-key")))
+}
+
+func TestStorageFactoryConfigRows(t *testing.T) {
+	require.NoError(t, secrets.Init(config.SecretsConfig{Keys: map[string]string{
+		"k1": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+	}}))
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&models.OSSConfig{}))
+
+	factory := oss.NewStorageFactory(testOSSConfig("file-key"), conn)
+	watcher := oss.NewConfigWatcher(conn, factory, config.ReloadConfig{})
+	_, err = watcher.Check()
+	require.NoError(t, err)
+
+	// 配置表中没有该类型的配置时使用配置文件
+	fallback, err := factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.Equal(t, "file-key", accessKeyID(t, fallback))
+
+	// 新增配置后使用表中解密后的凭据和存储桶
+	row := models.OSSConfig{
+		OrgID: 1, Name: "aliyun", StorageType: oss.StorageTypeAliyunOSS,
+		AccessKey: "db-key-1", SecretKey: "db-secret", Endpoint: "oss-cn-hangzhou.aliyuncs.com",
+		Bucket: "db-bucket", IsDefault: true,
+	}
+	require.NoError(t, conn.Create(&row).Error)
+	var stored string
+	require.NoError(t, conn.Raw("SELECT access_key FROM oss_configs WHERE id = ?", row.ID).Scan(&stored).Error)
+	assert.True(t, secrets.IsEncrypted(stored))
+
+	changed, err := watcher.Check()
+	require.NoError(t, err)
+	assert.Equal(t, []string{oss.StorageTypeAliyunOSS}, changed)
+	service, err := factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.Equal(t, "db-key-1", accessKeyID(t, service))
+	assert.Equal(t, "db-bucket", service.GetBucketName())
+	same, err := factory.GetDefaultStorageService()
+	require.NoError(t, err)
+	assert.Same(t, service, same)
+
+	// 通过配置接口轮换密钥后，传入新记录即按配置ID重建，旧记录不会换回旧服务
+	previous := row
+	row.AccessKey = "db-key-2"
+	require.NoError(t, conn.Save(&row).Error)
+	var reloaded models.OSSConfig
+	require.NoError(t, conn.First(&reloaded, row.ID).Error)
+	rotated, err := factory.GetConfigStorageService(&reloaded)
+	require.NoError(t, err)
+	assert.NotSame(t, service, rotated)
+	assert.Equal(t, "db-key-2", accessKeyID(t, rotated))
+	kept, err := factory.GetConfigStorageService(&previous)
+	require.NoError(t, err)
+	assert.Same(t, rotated, kept)
+
+	// 监听到配置表变化后，按存储类型获取的服务同样使用新凭据
+	_, err = watcher.Check()
+	require.NoError(t, err)
+	service, err = factory.GetStorageService(oss.StorageTypeAliyunOSS)
+	require.NoError(t, err)
+	assert.Equal(t, "db-key-2", accessKeyID(t, service))
+}
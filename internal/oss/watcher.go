@@ -0,0 +1,133 @@
+package oss
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ConfigWatcher 定期检查存储配置表，配置新增、修改或删除后让存储服务工厂重建对应的存储服务
+// 其他实例通过管理接口修改配置时，本实例同样可以感知到
+type ConfigWatcher struct {
+	db       *gorm.DB
+	factory  *DefaultStorageFactory
+	cfg      config.ReloadConfig
+	versions map[string]string
+	wg       sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewConfigWatcher 创建存储配置表监听器
+func NewConfigWatcher(db *gorm.DB, factory *DefaultStorageFactory, cfg config.ReloadConfig) *ConfigWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConfigWatcher{
+		db:      db,
+		factory: factory,
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start 记录当前的配置版本并启动检查协程
+func (w *ConfigWatcher) Start() {
+	versions, err := w.load()
+	if err != nil {
+		logger.Error("读取存储配置版本失败", zap.Error(err))
+	}
+	w.versions = versions
+
+	w.wg.Add(1)
+	go w.loop()
+	logger.Info("存储配置监听已启动", zap.Duration("interval", w.cfg.GetPollInterval()))
+}
+
+// Stop 停止检查协程
+func (w *ConfigWatcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+	logger.Info("存储配置监听已停止")
+}
+
+// loop 定期检查存储配置是否变化
+func (w *ConfigWatcher) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.cfg.GetPollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := w.Check()
+			if err != nil {
+				logger.Error("检查存储配置变化失败", zap.Error(err))
+				continue
+			}
+			if len(changed) > 0 {
+				logger.Info("存储配置已变化，重建存储服务", zap.Strings("storageTypes", changed))
+			}
+		}
+	}
+}
+
+// Check 比较存储配置的版本，返回发生变化的存储类型，并让工厂在下次使用时重建这些类型的存储服务
+func (w *ConfigWatcher) Check() ([]string, error) {
+	versions, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for storageType, version := range versions {
+		if w.versions[storageType] != version {
+			changed = append(changed, storageType)
+		}
+	}
+	for storageType := range w.versions {
+		if _, ok := versions[storageType]; !ok {
+			changed = append(changed, storageType)
+		}
+	}
+	w.versions = versions
+
+	if len(changed) > 0 {
+		w.factory.Invalidate(changed...)
+	}
+	return changed, nil
+}
+
+// load 按存储类型读取配置版本，由记录数和最近的修改、删除时间组成，包含已软删除的记录
+func (w *ConfigWatcher) load() (map[string]string, error) {
+	rows, err := w.db.Unscoped().Model(&models.OSSConfig{}).
+		Select("storage_type, COUNT(*), MAX(updated_at), MAX(deleted_at)").
+		Group("storage_type").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("查询存储配置失败: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string]string)
+	for rows.Next() {
+		var storageType string
+		var count int64
+		var updatedAt, deletedAt sql.NullString
+		if err := rows.Scan(&storageType, &count, &updatedAt, &deletedAt); err != nil {
+			return nil, fmt.Errorf("读取存储配置失败: %w", err)
+		}
+		versions[storageType] = fmt.Sprintf("%d/%s/%s", count, updatedAt.String, deletedAt.String)
+	}
+	return versions, rows.Err()
+}
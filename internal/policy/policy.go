@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// SniffLen 识别文件类型需要读取的文件头长度
+const SniffLen = 512
+
+// ErrViolation 不符合存储桶上传策略
+var ErrViolation = errors.New("不符合存储桶上传策略")
+
+// violation 生成带原因的策略错误，可通过 errors.Is(err, ErrViolation) 判断
+func violation(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrViolation, fmt.Sprintf(format, args...))
+}
+
+// Load 获取存储桶的上传策略，未配置时返回 nil
+func Load(db *gorm.DB, bucketName string) (*models.BucketUploadPolicy, error) {
+	var p models.BucketUploadPolicy
+	err := db.Joins("JOIN region_bucket_mapping ON region_bucket_mapping.id = bucket_upload_policies.region_bucket_mapping_id").
+		Where("region_bucket_mapping.bucket_name = ? AND region_bucket_mapping.deleted_at IS NULL", bucketName).
+		First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取存储桶上传策略失败: %w", err)
+	}
+	return &p, nil
+}
+
+// Validate 校验策略配置本身是否有效（路径规则需为合法正则）
+func Validate(p *models.BucketUploadPolicy) error {
+	if p.PathPattern != "" {
+		if _, err := regexp.Compile(p.PathPattern); err != nil {
+			return fmt.Errorf("路径规则不是有效的正则表达式: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckFile 按对象键和文件大小检查上传是否符合策略，size 未知时传 0
+// 全局配置 app.max_file_size 对所有存储桶生效，p 为 nil 时只检查全局限制
+func CheckFile(p *models.BucketUploadPolicy, objectKey string, size int64) error {
+	if cfg := config.GetConfig(); cfg != nil && cfg.App.MaxFileSize > 0 && size > cfg.App.MaxFileSize {
+		return violation("文件大小超过系统上限 %d 字节", cfg.App.MaxFileSize)
+	}
+	if p == nil {
+		return nil
+	}
+
+	if p.MaxFileSize > 0 && size > p.MaxFileSize {
+		return violation("文件大小超过存储桶上限 %d 字节", p.MaxFileSize)
+	}
+
+	if p.AllowedExtensions != "" {
+		ext := strings.ToLower(filepath.Ext(objectKey))
+		if !matchList(p.AllowedExtensions, ext, false) {
+			return violation("不允许的文件扩展名，仅支持: %s", p.AllowedExtensions)
+		}
+	}
+
+	if p.PathPattern != "" {
+		re, err := regexp.Compile(p.PathPattern)
+		if err != nil {
+			return fmt.Errorf("存储桶路径规则无效: %w", err)
+		}
+		if !re.MatchString(objectKey) {
+			return violation("文件路径不符合存储桶命名规则: %s", p.PathPattern)
+		}
+	}
+	return nil
+}
+
+// CheckContent 根据文件头（魔数）识别的 MIME 类型检查是否在白名单中
+func CheckContent(p *models.BucketUploadPolicy, head []byte) error {
+	if p == nil || p.AllowedMimeTypes == "" {
+		return nil
+	}
+	mimeType := DetectContentType(head)
+	if !matchList(p.AllowedMimeTypes, mimeType, true) {
+		return violation("不允许的文件类型 %s，仅支持: %s", mimeType, p.AllowedMimeTypes)
+	}
+	return nil
+}
+
+// NeedsContent 是否需要读取文件头进行类型检查
+func NeedsContent(p *models.BucketUploadPolicy) bool {
+	return p != nil && p.AllowedMimeTypes != ""
+}
+
+// DetectContentType 根据文件头识别 MIME 类型，去掉 charset 等参数
+func DetectContentType(head []byte) string {
+	mimeType := http.DetectContentType(head)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.TrimSpace(mimeType)
+}
+
+// matchList 判断值是否在逗号分隔的列表中，wildcard 为 true 时支持 image/* 形式的通配
+func matchList(list, value string, wildcard bool) bool {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if item == value {
+			return true
+		}
+		if wildcard && strings.HasSuffix(item, "/*") && strings.HasPrefix(value, strings.TrimSuffix(item, "*")) {
+			return true
+		}
+	}
+	return false
+}
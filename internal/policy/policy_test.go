@@ -0,0 +1,56 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFile(t *testing.T) {
+	p := &models.BucketUploadPolicy{
+		MaxFileSize:       1024,
+		AllowedExtensions: ".jpg,.png",
+		PathPattern:       `^projects/[a-z0-9-]+/`,
+	}
+
+	// 符合策略
+	assert.NoError(t, CheckFile(p, "projects/demo/a.PNG", 100))
+
+	// 未配置策略时不限制
+	assert.NoError(t, CheckFile(nil, "any/a.exe", 1<<40))
+
+	// 超出大小、扩展名不允许、路径不匹配
+	for _, tc := range []struct {
+		key  string
+		size int64
+	}{
+		{"projects/demo/a.jpg", 2048},
+		{"projects/demo/a.exe", 100},
+		{"other/demo/a.jpg", 100},
+	} {
+		err := CheckFile(p, tc.key, tc.size)
+		assert.True(t, errors.Is(err, ErrViolation), tc.key)
+	}
+}
+
+func TestCheckContent(t *testing.T) {
+	p := &models.BucketUploadPolicy{AllowedMimeTypes: "image/*,application/pdf"}
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+
+	assert.NoError(t, CheckContent(p, png))
+	assert.NoError(t, CheckContent(p, []byte("%PDF-1.7")))
+
+	// 扩展名伪装成图片的文本文件按文件头识别为 text/plain
+	err := CheckContent(p, []byte("echo hello"))
+	assert.True(t, errors.Is(err, ErrViolation))
+
+	assert.True(t, NeedsContent(p))
+	assert.False(t, NeedsContent(&models.BucketUploadPolicy{}))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&models.BucketUploadPolicy{PathPattern: `^a/`}))
+	assert.Error(t, Validate(&models.BucketUploadPolicy{PathPattern: `^(a`}))
+}
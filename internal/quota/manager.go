@@ -0,0 +1,223 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrQuotaExceeded 存储配额不足
+var ErrQuotaExceeded = errors.New("存储配额不足")
+
+// countedFilesCondition 计入用量的文件记录：当前文件、回收站文件以及保留了历史版本的旧记录
+const countedFilesCondition = "(status IN ('ACTIVE', 'TRASHED') OR (status = 'REPLACED' AND version_key <> ''))"
+
+// Usage 配额用量
+type Usage struct {
+	Bytes   int64 `json:"bytes"`
+	Objects int64 `json:"objects"`
+}
+
+// ExceededError 超出配额的详细信息
+type ExceededError struct {
+	Quota models.StorageQuota
+	Usage Usage
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("超出%s配额（已用 %d 字节 / %d 个对象，上限 %d 字节 / %d 个对象）",
+		scopeName(e.Quota.ScopeType), e.Usage.Bytes, e.Usage.Objects, e.Quota.MaxBytes, e.Quota.MaxObjects)
+}
+
+// Unwrap 使 errors.Is(err, ErrQuotaExceeded) 成立
+func (e *ExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Manager 存储配额管理器，负责上传前检查和定期重新统计用量
+type Manager struct {
+	db     *gorm.DB
+	cfg    config.QuotaConfig
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewManager 创建存储配额管理器
+func NewManager(db *gorm.DB, cfg config.QuotaConfig) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		db:     db,
+		cfg:    cfg,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start 启动用量重新统计协程
+func (m *Manager) Start() {
+	m.wg.Add(1)
+	go m.recalculateLoop()
+	logger.Info("配额用量统计任务已启动", zap.Duration("interval", m.cfg.GetRecalculateInterval()))
+}
+
+// Stop 停止用量重新统计协程
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+	logger.Info("配额用量统计任务已停止")
+}
+
+// recalculateLoop 定期重新统计所有配额的用量
+func (m *Manager) recalculateLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.GetRecalculateInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.RecalculateAll(); err != nil {
+				logger.Error("重新统计配额用量失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Check 检查用户向存储桶新增一个指定大小的对象后是否超出任一适用配额
+// 超出时返回 *ExceededError
+func (m *Manager) Check(userID uint, bucketName string, fileSize int64) error {
+	quotas, err := m.Applicable(userID, bucketName)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range quotas {
+		if q.MaxBytes <= 0 && q.MaxObjects <= 0 {
+			continue
+		}
+		usage, err := m.Usage(q)
+		if err != nil {
+			return err
+		}
+		if (q.MaxBytes > 0 && usage.Bytes+fileSize > q.MaxBytes) ||
+			(q.MaxObjects > 0 && usage.Objects+1 > q.MaxObjects) {
+			return &ExceededError{Quota: q, Usage: usage}
+		}
+	}
+	return nil
+}
+
+// Applicable 获取对用户在存储桶中上传生效的所有配额
+// bucketName 为空时返回不限存储桶的用户和角色配额
+func (m *Manager) Applicable(userID uint, bucketName string) ([]models.StorageQuota, error) {
+	var roleIDs []uint
+	if err := m.db.Table("user_roles").Where("user_id = ?", userID).Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+
+	bucketScope := []string{""}
+	if bucketName != "" {
+		bucketScope = append(bucketScope, bucketName)
+	}
+
+	query := m.db.Where("scope_type = ? AND scope_id = ? AND bucket_name IN ?", models.QuotaScopeUser, userID, bucketScope)
+	if len(roleIDs) > 0 {
+		query = query.Or("scope_type = ? AND scope_id IN ? AND bucket_name IN ?", models.QuotaScopeRole, roleIDs, bucketScope)
+	}
+	if bucketName != "" {
+		query = query.Or("scope_type = ? AND bucket_name = ?", models.QuotaScopeBucket, bucketName)
+	}
+
+	var quotas []models.StorageQuota
+	if err := query.Find(&quotas).Error; err != nil {
+		return nil, fmt.Errorf("查询配额失败: %w", err)
+	}
+	return quotas, nil
+}
+
+// Usage 实时统计配额范围内的用量
+func (m *Manager) Usage(q models.StorageQuota) (Usage, error) {
+	query := m.db.Model(&models.OSSFile{}).Where(countedFilesCondition)
+	switch q.ScopeType {
+	case models.QuotaScopeUser:
+		query = query.Where("uploader_id = ?", q.ScopeID)
+	case models.QuotaScopeRole:
+		query = query.Where("uploader_id IN (?)", m.db.Table("user_roles").Select("user_id").Where("role_id = ?", q.ScopeID))
+	case models.QuotaScopeBucket:
+	default:
+		return Usage{}, fmt.Errorf("未知的配额范围: %s", q.ScopeType)
+	}
+	if q.BucketName != "" {
+		query = query.Where("bucket = ?", q.BucketName)
+	}
+
+	var usage Usage
+	if err := query.Select("COALESCE(SUM(file_size), 0) AS bytes, COUNT(*) AS objects").Scan(&usage).Error; err != nil {
+		return Usage{}, fmt.Errorf("统计配额用量失败: %w", err)
+	}
+	return usage, nil
+}
+
+// Recalculate 重新统计单个配额的用量并保存
+func (m *Manager) Recalculate(q *models.StorageQuota) error {
+	usage, err := m.Usage(*q)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := m.db.Model(q).Updates(map[string]interface{}{
+		"used_bytes":          usage.Bytes,
+		"used_objects":        usage.Objects,
+		"usage_calculated_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("保存配额用量失败: %w", err)
+	}
+
+	q.UsedBytes = usage.Bytes
+	q.UsedObjects = usage.Objects
+	q.UsageCalculatedAt = &now
+	return nil
+}
+
+// RecalculateAll 重新统计所有配额的用量，返回处理的配额数量
+func (m *Manager) RecalculateAll() (int, error) {
+	var quotas []models.StorageQuota
+	if err := m.db.Find(&quotas).Error; err != nil {
+		return 0, fmt.Errorf("查询配额失败: %w", err)
+	}
+
+	for i := range quotas {
+		if err := m.Recalculate(&quotas[i]); err != nil {
+			logger.Warn("重新统计配额用量失败", zap.Uint("quota_id", quotas[i].ID), zap.Error(err))
+		}
+	}
+
+	logger.Info("配额用量统计完成", zap.Int("count", len(quotas)))
+	return len(quotas), nil
+}
+
+// scopeName 配额范围的中文名称
+func scopeName(scopeType string) string {
+	switch scopeType {
+	case models.QuotaScopeUser:
+		return "用户"
+	case models.QuotaScopeRole:
+		return "角色"
+	case models.QuotaScopeBucket:
+		return "存储桶"
+	}
+	return scopeType
+}
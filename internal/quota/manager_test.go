@@ -0,0 +1,98 @@
+package quota_test
+
+import (
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/quota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&models.User{}, &models.OSSFile{}, &models.StorageQuota{}))
+	return conn
+}
+
+func createFile(t *testing.T, conn *gorm.DB, uploaderID uint, bucket, status string, size int64) {
+	require.NoError(t, conn.Create(&models.OSSFile{
+		Filename:         "a.txt",
+		OriginalFilename: "a.txt",
+		FileSize:         size,
+		StorageType:      "ALIYUN_OSS",
+		Bucket:           bucket,
+		ObjectKey:        "a.txt",
+		UploaderID:       uploaderID,
+		Status:           status,
+		ConfigID:         1,
+	}).Error)
+}
+
+func TestUserQuota(t *testing.T) {
+	conn := openDB(t)
+	m := quota.NewManager(conn, config.QuotaConfig{})
+	q := models.StorageQuota{ScopeType: models.QuotaScopeUser, ScopeID: 42, MaxBytes: 1000}
+	require.NoError(t, conn.Create(&q).Error)
+
+	// 当前文件、回收站文件和保留了版本的旧记录计入用量，其余记录不计入
+	createFile(t, conn, 42, "bucket", "ACTIVE", 300)
+	createFile(t, conn, 42, "bucket", "TRASHED", 200)
+	createFile(t, conn, 42, "bucket", "REPLACED", 400)
+	createFile(t, conn, 7, "bucket", "ACTIVE", 900)
+
+	usage, err := m.Usage(q)
+	require.NoError(t, err)
+	assert.EqualValues(t, 500, usage.Bytes)
+	assert.EqualValues(t, 2, usage.Objects)
+
+	assert.NoError(t, m.Check(42, "bucket", 500))
+	var exceeded *quota.ExceededError
+	assert.ErrorAs(t, m.Check(42, "bucket", 501), &exceeded)
+	assert.ErrorIs(t, m.Check(42, "bucket", 501), quota.ErrQuotaExceeded)
+	assert.NoError(t, m.Check(7, "bucket", 501))
+}
+
+func TestRoleQuota(t *testing.T) {
+	conn := openDB(t)
+	m := quota.NewManager(conn, config.QuotaConfig{})
+	require.NoError(t, conn.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (1, 5), (2, 5)").Error)
+	require.NoError(t, conn.Create(&models.StorageQuota{
+		ScopeType: models.QuotaScopeRole, ScopeID: 5, BucketName: "b1", MaxBytes: 1000,
+	}).Error)
+
+	// 角色配额统计角色内所有用户在指定存储桶中的用量
+	createFile(t, conn, 1, "b1", "ACTIVE", 300)
+	createFile(t, conn, 2, "b1", "ACTIVE", 500)
+	createFile(t, conn, 2, "b2", "ACTIVE", 900)
+	createFile(t, conn, 3, "b1", "ACTIVE", 900)
+
+	assert.NoError(t, m.Check(1, "b1", 200))
+	assert.ErrorIs(t, m.Check(1, "b1", 201), quota.ErrQuotaExceeded)
+	assert.ErrorIs(t, m.Check(2, "b1", 201), quota.ErrQuotaExceeded)
+	// 其他存储桶和角色外的用户不受限制
+	assert.NoError(t, m.Check(1, "b2", 201))
+	assert.NoError(t, m.Check(3, "b1", 201))
+}
+
+func TestBucketQuota(t *testing.T) {
+	conn := openDB(t)
+	m := quota.NewManager(conn, config.QuotaConfig{})
+	require.NoError(t, conn.Create(&models.StorageQuota{
+		ScopeType: models.QuotaScopeBucket, BucketName: "b1", MaxObjects: 2,
+	}).Error)
+
+	// 存储桶配额统计所有用户的对象数量
+	createFile(t, conn, 1, "b1", "ACTIVE", 100)
+	assert.NoError(t, m.Check(2, "b1", 100))
+	createFile(t, conn, 2, "b1", "ACTIVE", 100)
+
+	var exceeded *quota.ExceededError
+	require.ErrorAs(t, m.Check(3, "b1", 100), &exceeded)
+	assert.EqualValues(t, 2, exceeded.Usage.Objects)
+	assert.NoError(t, m.Check(3, "b2", 100))
+}
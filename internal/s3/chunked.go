@@ -0,0 +1,125 @@
+package s3
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxChunkSize 单个 aws-chunked 分块的最大长度，防止恶意请求耗尽内存
+const maxChunkSize = 16 << 20
+
+// ErrChunkSignature 分块签名不匹配
+var ErrChunkSignature = errors.New("分块签名不匹配")
+
+// IsStreaming 请求体是否为 aws-chunked 编码
+func (s *Signature) IsStreaming() bool {
+	return strings.HasPrefix(s.PayloadHash, "STREAMING-")
+}
+
+// chunkedReader 解码 aws-chunked 请求体：每块为 "十六进制长度[;chunk-signature=签名]\r\n数据\r\n"，
+// 以长度为 0 的分块结束，之后可能附带校验和 trailer
+type chunkedReader struct {
+	r       *bufio.Reader
+	sig     *Signature // 为 nil 时不校验分块签名
+	prevSig string
+	chunk   []byte
+	done    bool
+	err     error
+}
+
+// NewChunkedReader 创建 aws-chunked 解码器，签名类载荷会逐块校验签名
+func NewChunkedReader(body io.Reader, sig *Signature) io.Reader {
+	cr := &chunkedReader{r: bufio.NewReader(body)}
+	if sig.PayloadHash == StreamingPayload || sig.PayloadHash == StreamingPayloadTrailer {
+		cr.sig = sig
+		cr.prevSig = sig.Signature
+	}
+	return cr
+}
+
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	for len(cr.chunk) == 0 {
+		if cr.err != nil {
+			return 0, cr.err
+		}
+		if cr.done {
+			return 0, io.EOF
+		}
+		cr.err = cr.next()
+	}
+	n := copy(p, cr.chunk)
+	cr.chunk = cr.chunk[n:]
+	return n, nil
+}
+
+// next 读取并校验下一个分块
+func (cr *chunkedReader) next() error {
+	line, err := cr.readLine()
+	if err != nil {
+		return err
+	}
+	sizeHex, ext, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeHex), 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return errors.New("分块长度无效")
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if cr.sig != nil {
+		signature := strings.TrimPrefix(strings.TrimSpace(ext), "chunk-signature=")
+		if !cr.verify(data, signature) {
+			return ErrChunkSignature
+		}
+	}
+
+	if size == 0 {
+		// 跳过 trailer，直到空行
+		for {
+			line, err := cr.readLine()
+			if err != nil || line == "" {
+				break
+			}
+		}
+		cr.done = true
+		return nil
+	}
+	if line, err := cr.readLine(); err != nil || line != "" {
+		return errors.New("分块格式错误")
+	}
+	cr.chunk = data
+	return nil
+}
+
+// verify 校验分块签名，签名链以请求签名为起点
+func (cr *chunkedReader) verify(data []byte, signature string) bool {
+	sum := sha256.Sum256(data)
+	stringToSign := signAlgorithm + "-PAYLOAD\n" + cr.sig.AmzDate + "\n" + cr.sig.Scope() + "\n" +
+		cr.prevSig + "\n" + emptySHA256 + "\n" + hex.EncodeToString(sum[:])
+	expected := hex.EncodeToString(hmacSHA256(cr.sig.signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return false
+	}
+	cr.prevSig = signature
+	return true
+}
+
+// readLine 读取以 \r\n 结尾的一行
+func (cr *chunkedReader) readLine() (string, error) {
+	line, err := cr.r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
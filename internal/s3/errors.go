@@ -0,0 +1,87 @@
+package s3
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Error S3 错误，按 S3 错误码和 HTTP 状态码返回给客户端
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// WithMessage 返回替换了错误信息的副本
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Code: e.Code, Status: e.Status, Message: message}
+}
+
+// 常用 S3 错误
+var (
+	ErrAccessDenied           = &Error{"AccessDenied", http.StatusForbidden, "没有权限执行该操作"}
+	ErrInvalidAccessKeyID     = &Error{"InvalidAccessKeyId", http.StatusForbidden, "访问密钥不存在或已禁用"}
+	ErrSignatureDoesNotMatch  = &Error{"SignatureDoesNotMatch", http.StatusForbidden, "请求签名不匹配"}
+	ErrRequestTimeTooSkewed   = &Error{"RequestTimeTooSkewed", http.StatusForbidden, "请求时间与服务器时间相差过大"}
+	ErrExpiredRequest         = &Error{"AccessDenied", http.StatusForbidden, "预签名请求已过期"}
+	ErrMalformedAuthorization = &Error{"AuthorizationHeaderMalformed", http.StatusBadRequest, "签名格式错误"}
+	ErrNoSuchBucket           = &Error{"NoSuchBucket", http.StatusNotFound, "存储桶不存在"}
+	ErrNoSuchKey              = &Error{"NoSuchKey", http.StatusNotFound, "对象不存在"}
+	ErrNoSuchUpload           = &Error{"NoSuchUpload", http.StatusNotFound, "分片上传不存在或已结束"}
+	ErrInvalidArgument        = &Error{"InvalidArgument", http.StatusBadRequest, "参数错误"}
+	ErrInvalidRequest         = &Error{"InvalidRequest", http.StatusBadRequest, "请求无效"}
+	ErrMalformedXML           = &Error{"MalformedXML", http.StatusBadRequest, "请求体不是合法的 XML"}
+	ErrInvalidPart            = &Error{"InvalidPart", http.StatusBadRequest, "分片不存在或 ETag 不匹配"}
+	ErrInvalidPartOrder       = &Error{"InvalidPartOrder", http.StatusBadRequest, "分片必须按编号升序排列"}
+	ErrBadDigest              = &Error{"BadDigest", http.StatusBadRequest, "Content-MD5 与数据不一致"}
+	ErrContentSHA256Mismatch  = &Error{"XAmzContentSHA256Mismatch", http.StatusBadRequest, "x-amz-content-sha256 与数据不一致"}
+	ErrIncompleteBody         = &Error{"IncompleteBody", http.StatusBadRequest, "请求体不完整"}
+	ErrMissingContentLength   = &Error{"MissingContentLength", http.StatusLengthRequired, "请提供 Content-Length"}
+	ErrQuotaExceeded          = &Error{"QuotaExceeded", http.StatusForbidden, "超出存储配额"}
+	ErrNotImplemented         = &Error{"NotImplemented", http.StatusNotImplemented, "不支持该操作"}
+	ErrInternal               = &Error{"InternalError", http.StatusInternalServerError, "服务器内部错误"}
+)
+
+// errorResponse S3 错误响应体
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource,omitempty"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// WriteError 返回 S3 错误，HEAD 请求不返回响应体
+func WriteError(c *gin.Context, err *Error) {
+	requestID := uuid.NewString()
+	c.Header("X-Amz-Request-Id", requestID)
+	if c.Request.Method == http.MethodHead {
+		c.AbortWithStatus(err.Status)
+		return
+	}
+	WriteXML(c, err.Status, errorResponse{
+		Code:      err.Code,
+		Message:   err.Message,
+		Resource:  c.Request.URL.Path,
+		RequestID: requestID,
+	})
+	c.Abort()
+}
+
+// WriteXML 返回 XML 响应
+func WriteXML(c *gin.Context, status int, body interface{}) {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "application/xml", append([]byte(xml.Header), data...))
+}
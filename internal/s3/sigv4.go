@@ -0,0 +1,256 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4（https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html）
+const (
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	timeFormat    = "20060102T150405Z"
+	maxClockSkew  = 15 * time.Minute
+	maxPresignAge = 7 * 24 * time.Hour
+
+	// UnsignedPayload 请求体不参与签名
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// StreamingPayload 请求体按 aws-chunked 分块逐块签名
+	StreamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	// StreamingPayloadTrailer 逐块签名并在末尾附带校验和
+	StreamingPayloadTrailer = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	// StreamingUnsignedTrailer 分块不签名，末尾附带校验和
+	StreamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// SignatureContextKey 校验通过的签名在 gin 上下文中的键，aws-chunked 请求体需用它校验分块签名
+const SignatureContextKey = "s3Signature"
+
+// Signature 解析出的请求签名
+type Signature struct {
+	AccessKeyID   string
+	Date          string // 凭证日期 yyyymmdd
+	Region        string
+	Service       string
+	SignedHeaders []string
+	Signature     string
+	AmzDate       string // 请求时间 yyyymmddThhmmssZ
+	PayloadHash   string
+	Presigned     bool
+	expires       time.Duration
+
+	signingKey []byte // 校验通过后保存，用于校验分块签名
+}
+
+// Scope 凭证范围，例如 20240101/us-east-1/s3/aws4_request
+func (s *Signature) Scope() string {
+	return s.Date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+}
+
+// ParseRequest 从 Authorization 头或预签名查询参数中解析签名
+func ParseRequest(r *http.Request) (*Signature, *Error) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return parseAuthorization(r, auth)
+	}
+	if r.URL.Query().Get("X-Amz-Algorithm") != "" {
+		return parsePresigned(r)
+	}
+	return nil, ErrAccessDenied.WithMessage("请求未签名")
+}
+
+// parseAuthorization 解析 Authorization 头：AWS4-HMAC-SHA256 Credential=..., SignedHeaders=..., Signature=...
+func parseAuthorization(r *http.Request, auth string) (*Signature, *Error) {
+	algorithm, rest, _ := strings.Cut(auth, " ")
+	if algorithm != signAlgorithm {
+		return nil, ErrMalformedAuthorization.WithMessage("仅支持 " + signAlgorithm + " 签名")
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(rest, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		fields[key] = value
+	}
+
+	sig := &Signature{
+		Signature:   fields["Signature"],
+		AmzDate:     r.Header.Get("X-Amz-Date"),
+		PayloadHash: r.Header.Get("X-Amz-Content-Sha256"),
+	}
+	if sig.AmzDate == "" {
+		if t, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+			sig.AmzDate = t.UTC().Format(timeFormat)
+		}
+	}
+	if sig.PayloadHash == "" {
+		sig.PayloadHash = UnsignedPayload
+	}
+	if err := sig.parseCredential(fields["Credential"], fields["SignedHeaders"]); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// parsePresigned 解析预签名 URL 的查询参数
+func parsePresigned(r *http.Request) (*Signature, *Error) {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != signAlgorithm {
+		return nil, ErrMalformedAuthorization.WithMessage("仅支持 " + signAlgorithm + " 签名")
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil || expires <= 0 || time.Duration(expires)*time.Second > maxPresignAge {
+		return nil, ErrMalformedAuthorization.WithMessage("X-Amz-Expires 无效")
+	}
+
+	sig := &Signature{
+		Signature:   query.Get("X-Amz-Signature"),
+		AmzDate:     query.Get("X-Amz-Date"),
+		PayloadHash: UnsignedPayload,
+		Presigned:   true,
+		expires:     time.Duration(expires) * time.Second,
+	}
+	if hash := query.Get("X-Amz-Content-Sha256"); hash != "" {
+		sig.PayloadHash = hash
+	}
+	if err := sig.parseCredential(query.Get("X-Amz-Credential"), query.Get("X-Amz-SignedHeaders")); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// parseCredential 解析 AccessKeyID/日期/地域/服务/aws4_request 形式的凭证和签名头列表
+func (s *Signature) parseCredential(credential, signedHeaders string) *Error {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || parts[0] == "" {
+		return ErrMalformedAuthorization.WithMessage("Credential 格式错误")
+	}
+	s.AccessKeyID, s.Date, s.Region, s.Service = parts[0], parts[1], parts[2], parts[3]
+	if signedHeaders == "" || s.Signature == "" || s.AmzDate == "" {
+		return ErrMalformedAuthorization.WithMessage("缺少签名参数")
+	}
+	s.SignedHeaders = strings.Split(signedHeaders, ";")
+	return nil
+}
+
+// Verify 使用密钥校验请求签名和时间
+func (s *Signature) Verify(r *http.Request, secretKey string, now time.Time) *Error {
+	requestTime, err := time.Parse(timeFormat, s.AmzDate)
+	if err != nil || !strings.HasPrefix(s.AmzDate, s.Date) {
+		return ErrMalformedAuthorization.WithMessage("请求时间格式错误")
+	}
+	if s.Presigned {
+		if now.After(requestTime.Add(s.expires)) {
+			return ErrExpiredRequest
+		}
+	} else if now.Sub(requestTime) > maxClockSkew || requestTime.Sub(now) > maxClockSkew {
+		return ErrRequestTimeTooSkewed
+	}
+
+	key := signingKey(secretKey, s.Date, s.Region, s.Service)
+	// 客户端可能按原始路径或规范编码后的路径签名，任一匹配即可
+	for _, path := range []string{encodePath(r.URL.Path), r.URL.EscapedPath()} {
+		stringToSign := signAlgorithm + "\n" + s.AmzDate + "\n" + s.Scope() + "\n" + hashHex([]byte(s.canonicalRequest(r, path)))
+		if hmac.Equal([]byte(hex.EncodeToString(hmacSHA256(key, stringToSign))), []byte(s.Signature)) {
+			s.signingKey = key
+			return nil
+		}
+	}
+	return ErrSignatureDoesNotMatch
+}
+
+// canonicalRequest 构造规范请求
+func (s *Signature) canonicalRequest(r *http.Request, path string) string {
+	var b strings.Builder
+	b.WriteString(r.Method + "\n")
+	b.WriteString(path + "\n")
+	b.WriteString(s.canonicalQuery(r.URL.Query()) + "\n")
+	for _, name := range s.SignedHeaders {
+		b.WriteString(name + ":" + canonicalHeaderValue(r, name) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(s.SignedHeaders, ";") + "\n")
+	b.WriteString(s.PayloadHash)
+	return b.String()
+}
+
+// canonicalQuery 按参数名和值排序并编码查询参数，预签名请求排除签名本身
+func (s *Signature) canonicalQuery(query url.Values) string {
+	var pairs []string
+	for key, values := range query {
+		if s.Presigned && key == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			pairs = append(pairs, encode(key, true)+"="+encode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaderValue 取签名头的值，多个值以逗号连接并压缩空白
+func canonicalHeaderValue(r *http.Request, name string) string {
+	if name == "host" {
+		return r.Host
+	}
+	values := r.Header.Values(name)
+	if name == "content-length" && len(values) == 0 && r.ContentLength >= 0 {
+		values = []string{strconv.FormatInt(r.ContentLength, 10)}
+	}
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return strings.Join(trimmed, ",")
+}
+
+// encodePath 按 SigV4 规则编码路径，保留斜杠
+func encodePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return encode(path, false)
+}
+
+// encode 按 SigV4 规则进行 URI 编码：仅保留 A-Z a-z 0-9 - _ . ~
+func encode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[ch>>4])
+		b.WriteByte(hexDigits[ch&0x0f])
+	}
+	return b.String()
+}
+
+// signingKey 派生签名密钥
+func signingKey(secretKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
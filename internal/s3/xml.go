@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// xmlns S3 响应的命名空间
+const xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// Owner 对象或存储桶的所有者
+type Owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+// BucketInfo 存储桶信息
+type BucketInfo struct {
+	Name         string    `xml:"Name"`
+	CreationDate time.Time `xml:"CreationDate"`
+}
+
+// ListAllMyBucketsResult ListBuckets 响应
+type ListAllMyBucketsResult struct {
+	XMLName xml.Name     `xml:"ListAllMyBucketsResult"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Owner   Owner        `xml:"Owner"`
+	Buckets []BucketInfo `xml:"Buckets>Bucket"`
+}
+
+// NewListAllMyBucketsResult 创建 ListBuckets 响应
+func NewListAllMyBucketsResult(owner Owner, buckets []BucketInfo) ListAllMyBucketsResult {
+	return ListAllMyBucketsResult{Xmlns: xmlns, Owner: owner, Buckets: buckets}
+}
+
+// Object 列表中的对象
+type Object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int64     `xml:"Size"`
+	StorageClass string    `xml:"StorageClass"`
+	Owner        *Owner    `xml:"Owner,omitempty"`
+}
+
+// CommonPrefix 按分隔符聚合的公共前缀
+type CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// ListBucketResult ListObjects（V1 和 V2）响应，未使用的字段为空时省略
+type ListBucketResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	Marker                *string        `xml:"Marker,omitempty"`
+	NextMarker            string         `xml:"NextMarker,omitempty"`
+	KeyCount              *int           `xml:"KeyCount,omitempty"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	Contents              []Object       `xml:"Contents"`
+	CommonPrefixes        []CommonPrefix `xml:"CommonPrefixes"`
+}
+
+// NewListBucketResult 创建 ListObjects 响应
+func NewListBucketResult(bucket string) ListBucketResult {
+	return ListBucketResult{Xmlns: xmlns, Name: bucket}
+}
+
+// LocationConstraint GetBucketLocation 响应
+type LocationConstraint struct {
+	XMLName  xml.Name `xml:"LocationConstraint"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:",chardata"`
+}
+
+// NewLocationConstraint 创建 GetBucketLocation 响应
+func NewLocationConstraint(location string) LocationConstraint {
+	return LocationConstraint{Xmlns: xmlns, Location: location}
+}
+
+// InitiateMultipartUploadResult CreateMultipartUpload 响应
+type InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+// NewInitiateMultipartUploadResult 创建 CreateMultipartUpload 响应
+func NewInitiateMultipartUploadResult(bucket, key, uploadID string) InitiateMultipartUploadResult {
+	return InitiateMultipartUploadResult{Xmlns: xmlns, Bucket: bucket, Key: key, UploadID: uploadID}
+}
+
+// CompletePart CompleteMultipartUpload 请求中的分片
+type CompletePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// CompleteMultipartUpload CompleteMultipartUpload 请求体
+type CompleteMultipartUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []CompletePart `xml:"Part"`
+}
+
+// CompleteMultipartUploadResult CompleteMultipartUpload 响应
+type CompleteMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// NewCompleteMultipartUploadResult 创建 CompleteMultipartUpload 响应
+func NewCompleteMultipartUploadResult(location, bucket, key, etag string) CompleteMultipartUploadResult {
+	return CompleteMultipartUploadResult{Xmlns: xmlns, Location: location, Bucket: bucket, Key: key, ETag: etag}
+}
+
+// PartInfo ListParts 响应中的分片
+type PartInfo struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+	Size       int64  `xml:"Size"`
+}
+
+// ListPartsResult ListParts 响应
+type ListPartsResult struct {
+	XMLName     xml.Name   `xml:"ListPartsResult"`
+	Xmlns       string     `xml:"xmlns,attr"`
+	Bucket      string     `xml:"Bucket"`
+	Key         string     `xml:"Key"`
+	UploadID    string     `xml:"UploadId"`
+	IsTruncated bool       `xml:"IsTruncated"`
+	Parts       []PartInfo `xml:"Part"`
+}
+
+// NewListPartsResult 创建 ListParts 响应
+func NewListPartsResult(bucket, key, uploadID string, parts []PartInfo) ListPartsResult {
+	return ListPartsResult{Xmlns: xmlns, Bucket: bucket, Key: key, UploadID: uploadID, Parts: parts}
+}
+
+// DeleteObjectsRequest DeleteObjects 请求体
+type DeleteObjectsRequest struct {
+	XMLName xml.Name `xml:"Delete"`
+	Quiet   bool     `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+// DeletedObject 删除成功的对象
+type DeletedObject struct {
+	Key string `xml:"Key"`
+}
+
+// DeleteError 删除失败的对象
+type DeleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// DeleteResult DeleteObjects 响应
+type DeleteResult struct {
+	XMLName xml.Name        `xml:"DeleteResult"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	Deleted []DeletedObject `xml:"Deleted"`
+	Errors  []DeleteError   `xml:"Error"`
+}
+
+// NewDeleteResult 创建 DeleteObjects 响应
+func NewDeleteResult() DeleteResult {
+	return DeleteResult{Xmlns: xmlns}
+}
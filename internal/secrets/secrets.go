@@ -0,0 +1,200 @@
+// Package secrets 使用 AES-256-GCM 加密数据库中保存的存储凭据和访问密钥
+//
+// 密文格式为 enc:v1:<密钥ID>:<base64(随机数 + 密文)>，密钥ID 用于轮换主密钥：
+// 新数据始终使用主密钥加密，旧密钥只用于解密，执行 ossadmin secrets reencrypt 后即可移除。
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+)
+
+// EnvKeys 设置后覆盖配置文件中的密钥，格式为 "<密钥ID>:<base64密钥>,..."，第一个为主密钥
+const EnvKeys = "OSSMANAGER_SECRET_KEYS"
+
+// prefix 密文前缀，包含格式版本
+const prefix = "enc:v1:"
+
+// keyIDPattern 密钥ID 只能包含小写字母、数字、下划线和连字符
+var keyIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+var (
+	// ErrNoKey 未配置加密密钥
+	ErrNoKey = errors.New("未配置凭据加密密钥")
+	// ErrUnknownKey 密文使用的密钥不在当前配置中
+	ErrUnknownKey = errors.New("凭据使用的加密密钥不存在")
+	// ErrInvalid 密文格式错误或校验失败
+	ErrInvalid = errors.New("凭据密文无效")
+)
+
+// Keyring 加密密钥集合
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring 根据配置创建密钥集合，只配置了一个密钥时可以不指定主密钥
+func NewKeyring(cfg config.SecretsConfig) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, ErrNoKey
+	}
+
+	k := &Keyring{primary: cfg.PrimaryKeyID, keys: make(map[string]cipher.AEAD, len(cfg.Keys))}
+	for id, encoded := range cfg.Keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("无效的密钥ID: %s", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("密钥 %s 必须是 base64 编码的 32 字节密钥", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("初始化密钥 %s 失败: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("初始化密钥 %s 失败: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+
+	if k.primary == "" {
+		if len(k.keys) > 1 {
+			return nil, errors.New("配置了多个密钥时必须指定主密钥 primary_key_id")
+		}
+		for id := range k.keys {
+			k.primary = id
+		}
+	}
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf("主密钥 %s 不存在", k.primary)
+	}
+	return k, nil
+}
+
+// PrimaryKeyID 返回加密新数据使用的密钥ID
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// KeyIDs 返回所有密钥ID
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt 使用主密钥加密
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	// 密钥ID 作为附加数据，防止密文被改写为使用其他密钥
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.primary))
+	return prefix + k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密，value 不是密文时原样返回，以兼容加密之前保存的数据
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrInvalid
+	}
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalid
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", ErrInvalid
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencrypt 判断保存的值是否需要重新加密：尚未加密，或者不是使用主密钥加密的
+func (k *Keyring) NeedsReencrypt(value string) bool {
+	if value == "" {
+		return false
+	}
+	return KeyID(value) != k.primary
+}
+
+// IsEncrypted 判断保存的值是否为密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID 返回密文使用的密钥ID，不是密文时返回空字符串
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
+
+var defaultKeyring atomic.Pointer[Keyring]
+
+// Init 根据配置和环境变量初始化全局密钥集合，数据库模型读写加密字段时使用
+func Init(cfg config.SecretsConfig) error {
+	if env := os.Getenv(EnvKeys); env != "" {
+		keys, primary, err := parseEnvKeys(env)
+		if err != nil {
+			return err
+		}
+		cfg = config.SecretsConfig{PrimaryKeyID: primary, Keys: keys}
+	}
+	k, err := NewKeyring(cfg)
+	if err != nil {
+		return err
+	}
+	defaultKeyring.Store(k)
+	return nil
+}
+
+// Default 返回全局密钥集合，未初始化时返回 nil
+func Default() *Keyring {
+	return defaultKeyring.Load()
+}
+
+// parseEnvKeys 解析环境变量中的密钥列表，第一个密钥为主密钥
+func parseEnvKeys(env string) (map[string]string, string, error) {
+	keys := make(map[string]string)
+	var primary string
+	for _, pair := range strings.Split(env, ",") {
+		id, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || key == "" {
+			return nil, "", fmt.Errorf("环境变量 %s 格式错误，应为 <密钥ID>:<base64密钥>,...", EnvKeys)
+		}
+		if primary == "" {
+			primary = id
+		}
+		keys[id] = key
+	}
+	return keys, primary, nil
+}
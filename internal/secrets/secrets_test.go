@@ -0,0 +1,109 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testKey2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestKeyringRotation(t *testing.T) {
+	old, err := NewKeyring(config.SecretsConfig{Keys: map[string]string{"k1": testKey1}})
+	require.NoError(t, err)
+	encrypted, err := old.Encrypt("LTAI-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v1:k1:"))
+	assert.NotContains(t, encrypted, "LTAI-secret")
+
+	// 轮换后旧密文仍可解密，但需要重新加密
+	rotated, err := NewKeyring(config.SecretsConfig{PrimaryKeyID: "k2", Keys: map[string]string{"k1": testKey1, "k2": testKey2}})
+	require.NoError(t, err)
+	plaintext, err := rotated.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "LTAI-secret", plaintext)
+	assert.True(t, rotated.NeedsReencrypt(encrypted))
+	assert.True(t, rotated.NeedsReencrypt("plaintext"))
+	assert.False(t, rotated.NeedsReencrypt(""))
+
+	reencrypted, err := rotated.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", KeyID(reencrypted))
+	assert.False(t, rotated.NeedsReencrypt(reencrypted))
+
+	// 移除旧密钥后无法解密，篡改密钥ID 或密文会校验失败
+	current, err := NewKeyring(config.SecretsConfig{Keys: map[string]string{"k2": testKey2}})
+	require.NoError(t, err)
+	_, err = current.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = rotated.Decrypt(strings.Replace(reencrypted, ":k2:", ":k1:", 1))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// 加密前保存的数据原样返回
+	plaintext, err = rotated.Decrypt("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", plaintext)
+}
+
+func TestNewKeyringInvalid(t *testing.T) {
+	_, err := NewKeyring(config.SecretsConfig{})
+	assert.ErrorIs(t, err, ErrNoKey)
+	_, err = NewKeyring(config.SecretsConfig{Keys: map[string]string{"k1": "c2hvcnQ="}})
+	assert.Error(t, err)
+	_, err = NewKeyring(config.SecretsConfig{Keys: map[string]string{"k1": testKey1, "k2": testKey2}})
+	assert.Error(t, err)
+	_, err = NewKeyring(config.SecretsConfig{PrimaryKeyID: "k3", Keys: map[string]string{"k1": testKey1}})
+	assert.Error(t, err)
+	_, err = NewKeyring(config.SecretsConfig{Keys: map[string]string{"K:1": testKey1}})
+	assert.Error(t, err)
+}
+
+func TestInitFromEnv(t *testing.T) {
+	t.Setenv(EnvKeys, "k2:"+testKey2+", k1:"+testKey1)
+	require.NoError(t, Init(config.SecretsConfig{}))
+	assert.Equal(t, "k2", Default().PrimaryKeyID())
+	assert.Equal(t, []string{"k1", "k2"}, Default().KeyIDs())
+
+	t.Setenv(EnvKeys, "k1")
+	assert.Error(t, Init(config.SecretsConfig{}))
+}
+
+func TestStringValueScan(t *testing.T) {
+	require.NoError(t, Init(config.SecretsConfig{Keys: map[string]string{"k1": testKey1}}))
+
+	value, err := String("secret").Value()
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(value.(string)))
+
+	var s String
+	require.NoError(t, s.Scan([]byte(value.(string))))
+	assert.Equal(t, "secret", string(s))
+	require.NoError(t, s.Scan("legacy"))
+	assert.Equal(t, "legacy", string(s))
+
+	empty, err := String("").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+}
+
+func TestStringMasked(t *testing.T) {
+	s := String("secret")
+	for _, out := range []string{
+		fmt.Sprint(s),
+		fmt.Sprintf("%v %s %q %#v", s, s, s, s),
+		fmt.Sprintf("%+v", struct{ Key String }{s}),
+	} {
+		assert.NotContains(t, out, "secret")
+	}
+	data, err := json.Marshal(map[string]String{"key": s})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key":"******"}`, string(data))
+}
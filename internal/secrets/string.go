@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// mask 输出到日志或接口时代替凭据原文
+const mask = "******"
+
+// String 加密保存到数据库的字符串，写入时使用主密钥加密，读取时自动解密
+// 内存中保存的是原文，需要原文时显式转换为 string；格式化输出和 JSON 序列化只会得到掩码
+type String string
+
+// Value 实现 driver.Valuer，空字符串不加密
+func (s String) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	k := Default()
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	return k.Encrypt(string(s))
+}
+
+// Scan 实现 sql.Scanner，尚未加密的旧数据原样读取
+func (s *String) Scan(src interface{}) error {
+	var value string
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("无法读取加密字段: %T", src)
+	}
+
+	if !IsEncrypted(value) {
+		*s = String(value)
+		return nil
+	}
+	k := Default()
+	if k == nil {
+		return ErrNoKey
+	}
+	plaintext, err := k.Decrypt(value)
+	if err != nil {
+		return err
+	}
+	*s = String(plaintext)
+	return nil
+}
+
+// String 实现 fmt.Stringer，避免凭据被打印到日志
+func (s String) String() string {
+	if s == "" {
+		return ""
+	}
+	return mask
+}
+
+// GoString 实现 fmt.GoStringer，%#v 同样只输出掩码
+func (s String) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+// MarshalJSON 序列化时只输出掩码
+func (s String) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", s.String())), nil
+}
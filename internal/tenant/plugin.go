@@ -0,0 +1,125 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// orgField 模型中表示所属组织的字段
+const orgField = "OrgID"
+
+// Plugin gorm 插件，上下文中有组织范围时：
+// 查询、更新和删除带 OrgID 字段的模型自动加上 org_id 条件，创建时自动填充 OrgID
+// 原生 SQL 和不带模型的 Table 查询不受影响，需要自行加上组织条件
+type Plugin struct{}
+
+// Name 插件名称
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize 注册回调
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:create", setOrgID); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", addOrgCondition); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:row", addOrgCondition); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", addWriteOrgCondition); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("tenant:delete", addWriteOrgCondition)
+}
+
+// scopedField 返回当前语句的组织范围和模型的 OrgID 字段，不需要限制组织时返回 nil
+func scopedField(db *gorm.DB) (*Scope, *schema.Field) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, nil
+	}
+	scope := FromContext(db.Statement.Context)
+	if scope == nil {
+		return nil, nil
+	}
+	field := db.Statement.Schema.LookUpField(orgField)
+	if field == nil || field.DBName == "" {
+		return nil, nil
+	}
+	return scope, field
+}
+
+// addOrgCondition 查询时只返回当前组织的记录
+func addOrgCondition(db *gorm.DB) {
+	if scope, field := scopedField(db); scope != nil {
+		whereOrg(db, scope, field)
+	}
+}
+
+// addWriteOrgCondition 更新和删除时只影响当前组织的记录
+// 既没有条件也没有主键的语句不加组织条件，保留 gorm 对全表更新和删除的检查
+func addWriteOrgCondition(db *gorm.DB) {
+	scope, field := scopedField(db)
+	if scope == nil || (!db.AllowGlobalUpdate && !hasConditions(db.Statement)) {
+		return
+	}
+	whereOrg(db, scope, field)
+}
+
+// whereOrg 加上 org_id 条件
+func whereOrg(db *gorm.DB, scope *Scope, field *schema.Field) {
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: scope.OrgID},
+	}})
+}
+
+// hasConditions 语句是否已有查询条件，或者 gorm 会按模型的主键加上条件
+func hasConditions(stmt *gorm.Statement) bool {
+	if _, ok := stmt.Clauses["WHERE"]; ok {
+		return true
+	}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return false
+	}
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Struct:
+		_, isZero := pk.ValueOf(stmt.Context, rv)
+		return !isZero
+	case reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return false
+}
+
+// setOrgID 创建时填充当前组织，已指定其他组织时拒绝写入
+func setOrgID(db *gorm.DB) {
+	scope, field := scopedField(db)
+	if scope == nil {
+		return
+	}
+	set := func(rv reflect.Value) {
+		value, isZero := field.ValueOf(db.Statement.Context, rv)
+		if isZero {
+			if err := field.Set(db.Statement.Context, rv, scope.OrgID); err != nil {
+				db.AddError(err)
+			}
+		} else if orgID, ok := value.(uint); !ok || orgID != scope.OrgID {
+			db.AddError(ErrCrossOrg)
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Struct:
+		set(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	}
+}
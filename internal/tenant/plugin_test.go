@@ -0,0 +1,75 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type document struct {
+	ID    uint
+	OrgID uint
+	Name  string
+}
+
+type setting struct {
+	ID   uint
+	Name string
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.Use(tenant.Plugin{}))
+	require.NoError(t, conn.AutoMigrate(&document{}, &setting{}))
+	return conn
+}
+
+func TestPlugin(t *testing.T) {
+	conn := openDB(t)
+	org1 := tenant.WithScope(context.Background(), &tenant.Scope{OrgID: 1, UserOrgID: 1})
+	org2 := tenant.WithScope(context.Background(), &tenant.Scope{OrgID: 2, UserOrgID: 2})
+
+	// 创建时填充当前组织，不允许写入其他组织
+	a := &document{Name: "a"}
+	require.NoError(t, conn.WithContext(org1).Create(a).Error)
+	assert.EqualValues(t, 1, a.OrgID)
+	require.NoError(t, conn.WithContext(org2).Create(&[]document{{Name: "b"}, {Name: "c", OrgID: 2}}).Error)
+	assert.ErrorIs(t, conn.WithContext(org1).Create(&document{Name: "d", OrgID: 2}).Error, tenant.ErrCrossOrg)
+
+	// 查询只返回当前组织的记录，没有组织范围时不过滤
+	var docs []document
+	require.NoError(t, conn.WithContext(org2).Where("name <> ?", "").Find(&docs).Error)
+	assert.Len(t, docs, 2)
+	var count int64
+	require.NoError(t, conn.Model(&document{}).Count(&count).Error)
+	assert.EqualValues(t, 3, count)
+	assert.ErrorIs(t, conn.WithContext(org2).First(&document{}, a.ID).Error, gorm.ErrRecordNotFound)
+	require.NoError(t, conn.WithContext(tenant.WithoutScope(org2)).Model(&document{}).Count(&count).Error)
+	assert.EqualValues(t, 3, count)
+
+	// 更新和删除不影响其他组织的记录
+	result := conn.WithContext(org2).Model(&document{ID: a.ID}).Update("name", "x")
+	require.NoError(t, result.Error)
+	assert.EqualValues(t, 0, result.RowsAffected)
+	result = conn.WithContext(org2).Delete(&document{}, a.ID)
+	require.NoError(t, result.Error)
+	assert.EqualValues(t, 0, result.RowsAffected)
+	require.NoError(t, conn.WithContext(org1).Where("name = ?", "a").Delete(&document{}).Error)
+	require.NoError(t, conn.Model(&document{}).Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+
+	// 没有条件的全表删除仍然被 gorm 拒绝
+	assert.ErrorIs(t, conn.WithContext(org2).Delete(&document{}).Error, gorm.ErrMissingWhereClause)
+
+	// 没有 OrgID 字段的模型和不带模型的语句不受影响
+	require.NoError(t, conn.WithContext(org1).Create(&setting{Name: "s"}).Error)
+	require.NoError(t, conn.WithContext(org2).Model(&setting{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+	require.NoError(t, conn.WithContext(org1).Table("documents").Where("org_id = ?", 2).Delete(nil).Error)
+}
@@ -0,0 +1,50 @@
+// Package tenant 实现组织（租户）隔离：请求的组织范围保存在上下文中，
+// 数据库插件按上下文中的组织自动过滤和填充带 OrgID 字段的模型
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// ContextKey 组织范围在上下文中的键
+// 使用字符串类型，gin.Context 通过 c.Set 保存的值可以直接作为 context.Context 传给 gorm
+const ContextKey = "tenantScope"
+
+// ErrCrossOrg 写入的记录不属于当前组织
+var ErrCrossOrg = errors.New("不能操作其他组织的数据")
+
+// Scope 当前请求的组织范围
+type Scope struct {
+	OrgID       uint `json:"org_id"`       // 当前操作的组织，全局管理员可以切换到其他组织
+	UserOrgID   uint `json:"user_org_id"`  // 用户所属的组织
+	OrgAdmin    bool `json:"org_admin"`    // 是否为当前组织的管理员，全局管理员同时也是所有组织的管理员
+	GlobalAdmin bool `json:"global_admin"` // 是否为全局管理员
+}
+
+// WithScope 返回带组织范围的上下文，后台任务等没有请求上下文的场景使用
+func WithScope(ctx context.Context, scope *Scope) context.Context {
+	return context.WithValue(ctx, ContextKey, scope)
+}
+
+// WithoutScope 返回去掉组织范围的上下文，需要跨组织检查的查询使用，仍然跟随原上下文取消
+func WithoutScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ContextKey, (*Scope)(nil))
+}
+
+// FromContext 取上下文中的组织范围，没有时返回 nil，表示系统操作，不限制组织
+func FromContext(ctx context.Context) *Scope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(ContextKey).(*Scope)
+	return scope
+}
+
+// OrgID 取上下文中的当前组织ID，没有组织范围时返回 0
+func OrgID(ctx context.Context) uint {
+	if scope := FromContext(ctx); scope != nil {
+		return scope.OrgID
+	}
+	return 0
+}
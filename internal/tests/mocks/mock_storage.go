@@ -48,6 +48,11 @@ func (m *MockStorageService) DeleteObjectFromBucket(objectKey string, regionCode
 	return nil
 }
 
+// DeleteObjectsFromBucket 批量删除指定存储桶中的文件
+func (m *MockStorageService) DeleteObjectsFromBucket(objectKeys []string, regionCode string, bucketName string) ([]string, error) {
+	return objectKeys, nil
+}
+
 // GetObjectInfo 获取对象信息
 func (m *MockStorageService) GetObjectInfo(objectKey string) (int64, error) {
 	args := m.Called(objectKey)
@@ -60,6 +65,12 @@ func (m *MockStorageService) GenerateDownloadURL(objectKey string, expiration ti
 	return args.String(0), args.Get(1).(time.Time), args.Error(2)
 }
 
+// GenerateDownloadURLFromBucket 生成指定存储桶中对象的下载URL
+func (m *MockStorageService) GenerateDownloadURLFromBucket(objectKey string, regionCode string, bucketName string, expiration time.Duration) (string, time.Time, error) {
+	args := m.Called(objectKey, regionCode, bucketName, expiration)
+	return args.String(0), args.Get(1).(time.Time), args.Error(2)
+}
+
 // InitMultipartUpload 初始化分片上传
 func (m *MockStorageService) InitMultipartUpload(objectKey string) (string, map[int]string, error) {
 	args := m.Called(objectKey)
@@ -93,6 +104,18 @@ func (m *MockStorageService) GeneratePartUploadURL(objectKey string, uploadID st
 	return args.String(0), args.Error(1)
 }
 
+// CopyObjectToBucket 服务端复制对象
+func (m *MockStorageService) CopyObjectToBucket(srcBucketName string, srcObjectKey string, objectKey string, regionCode string, bucketName string) (string, error) {
+	args := m.Called(srcBucketName, srcObjectKey, objectKey, regionCode, bucketName)
+	return args.String(0), args.Error(1)
+}
+
+// PutObjectTaggingToBucket 设置对象标签
+func (m *MockStorageService) PutObjectTaggingToBucket(objectKey string, regionCode string, bucketName string, tags map[string]string) error {
+	args := m.Called(objectKey, regionCode, bucketName, tags)
+	return args.Error(0)
+}
+
 // MockStorageFactory 模拟存储工厂
 type MockStorageFactory struct {
 	mock.Mock
@@ -0,0 +1,285 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// StatusTrashed 回收站中文件记录的状态
+const StatusTrashed = "TRASHED"
+
+// KeyPrefix 回收站对象在存储桶中的隐藏前缀
+const KeyPrefix = ".trash/"
+
+var (
+	// ErrNotTrashed 文件不在回收站中
+	ErrNotTrashed = errors.New("文件不在回收站中")
+	// ErrPathOccupied 原路径已存在文件，无法恢复
+	ErrPathOccupied = errors.New("原路径已存在同名文件")
+)
+
+// Manager 回收站管理器，负责移入、恢复和过期清理
+type Manager struct {
+	db             *gorm.DB
+	storageFactory oss.StorageFactory
+	cfg            config.TrashConfig
+	wg             sync.WaitGroup
+	ctx            context.Context
+	cancel         context.CancelFunc
+}
+
+// NewManager 创建回收站管理器
+func NewManager(db *gorm.DB, storageFactory oss.StorageFactory, cfg config.TrashConfig) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		db:             db,
+		storageFactory: storageFactory,
+		cfg:            cfg,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Enabled 是否启用回收站
+func (m *Manager) Enabled() bool {
+	return m.cfg.Enabled
+}
+
+// Retention 回收站保留时长
+func (m *Manager) Retention() time.Duration {
+	return m.cfg.GetRetention()
+}
+
+// Start 启动过期清理协程
+func (m *Manager) Start() {
+	if !m.cfg.Enabled {
+		return
+	}
+	m.wg.Add(1)
+	go m.purgeLoop()
+	logger.Info("回收站清理任务已启动",
+		zap.Duration("retention", m.cfg.GetRetention()),
+		zap.Duration("interval", m.cfg.GetPurgeInterval()))
+}
+
+// Stop 停止过期清理协程
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+	logger.Info("回收站清理任务已停止")
+}
+
+// purgeLoop 定期清理超过保留期的回收站文件
+func (m *Manager) purgeLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.GetPurgeInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			cutoff := time.Now().Add(-m.cfg.GetRetention())
+			count, err := m.PurgeWhere(m.db.Where("trashed_at < ?", cutoff))
+			if err != nil {
+				logger.Error("清理过期回收站文件失败", zap.Error(err))
+				continue
+			}
+			if count > 0 {
+				logger.Info("已清理过期回收站文件", zap.Int("count", count))
+			}
+		}
+	}
+}
+
+// MoveToTrash 将文件对象移动到回收站前缀，并将记录标记为 TRASHED
+func (m *Manager) MoveToTrash(file *models.OSSFile, operatorID uint) error {
+	storage, regionCode, err := m.resolve(file)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	trashKey := fmt.Sprintf("%s%s/%s_%d", KeyPrefix, file.ObjectKey, now.Format("20060102150405"), file.ID)
+	if _, err := storage.CopyObjectToBucket(file.Bucket, file.ObjectKey, trashKey, regionCode, file.Bucket); err != nil {
+		return fmt.Errorf("移动文件到回收站失败: %w", err)
+	}
+	if err := storage.DeleteObjectFromBucket(file.ObjectKey, regionCode, file.Bucket); err != nil {
+		return fmt.Errorf("删除原文件失败: %w", err)
+	}
+
+	file.Status = StatusTrashed
+	file.TrashKey = trashKey
+	file.TrashedAt = &now
+	file.TrashedBy = operatorID
+	if err := m.db.Model(&models.OSSFile{}).Where("id = ?", file.ID).Updates(map[string]interface{}{
+		"status":     file.Status,
+		"trash_key":  file.TrashKey,
+		"trashed_at": file.TrashedAt,
+		"trashed_by": file.TrashedBy,
+	}).Error; err != nil {
+		return fmt.Errorf("更新文件记录失败: %w", err)
+	}
+
+	logger.Info("文件已移入回收站",
+		zap.Uint("file_id", file.ID),
+		zap.String("object_key", file.ObjectKey),
+		zap.String("trash_key", trashKey))
+	return nil
+}
+
+// Restore 将回收站中的文件恢复到原路径
+func (m *Manager) Restore(file *models.OSSFile) error {
+	if file.Status != StatusTrashed || file.TrashKey == "" {
+		return ErrNotTrashed
+	}
+
+	var count int64
+	if err := m.db.Model(&models.OSSFile{}).
+		Where("object_key = ? AND bucket = ? AND status = ?", file.ObjectKey, file.Bucket, "ACTIVE").
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("检查原路径失败: %w", err)
+	}
+	if count > 0 {
+		return ErrPathOccupied
+	}
+
+	storage, regionCode, err := m.resolve(file)
+	if err != nil {
+		return err
+	}
+
+	downloadURL, err := storage.CopyObjectToBucket(file.Bucket, file.TrashKey, file.ObjectKey, regionCode, file.Bucket)
+	if err != nil {
+		return fmt.Errorf("恢复文件失败: %w", err)
+	}
+	if err := storage.DeleteObjectFromBucket(file.TrashKey, regionCode, file.Bucket); err != nil {
+		// 回收站副本删除失败不影响恢复结果
+		logger.Warn("删除回收站副本失败", zap.String("trash_key", file.TrashKey), zap.Error(err))
+	}
+
+	file.Status = "ACTIVE"
+	file.TrashKey = ""
+	file.TrashedAt = nil
+	file.TrashedBy = 0
+	file.DownloadURL = downloadURL
+	if err := m.db.Model(&models.OSSFile{}).Where("id = ?", file.ID).Updates(map[string]interface{}{
+		"status":       file.Status,
+		"trash_key":    "",
+		"trashed_at":   nil,
+		"trashed_by":   0,
+		"download_url": downloadURL,
+	}).Error; err != nil {
+		return fmt.Errorf("更新文件记录失败: %w", err)
+	}
+
+	logger.Info("文件已从回收站恢复",
+		zap.Uint("file_id", file.ID),
+		zap.String("object_key", file.ObjectKey))
+	return nil
+}
+
+// Purge 彻底删除回收站中的单个文件
+func (m *Manager) Purge(file *models.OSSFile) error {
+	if file.Status != StatusTrashed || file.TrashKey == "" {
+		return ErrNotTrashed
+	}
+
+	storage, regionCode, err := m.resolve(file)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.DeleteObjectFromBucket(file.TrashKey, regionCode, file.Bucket); err != nil {
+		return fmt.Errorf("删除回收站文件失败: %w", err)
+	}
+	// 历史版本随文件一起删除，否则版本对象和记录会残留并继续占用配额
+	if err := m.purgeVersions(storage, regionCode, file); err != nil {
+		return err
+	}
+
+	if err := m.db.Delete(file).Error; err != nil {
+		return fmt.Errorf("删除文件记录失败: %w", err)
+	}
+
+	logger.Info("回收站文件已彻底删除",
+		zap.Uint("file_id", file.ID),
+		zap.String("trash_key", file.TrashKey))
+	return nil
+}
+
+// purgeVersions 删除文件被覆盖前的历史版本对象和记录
+// 文件的历史版本是同一路径上位于它和前一条非 REPLACED 记录之间的 REPLACED 记录；
+// 更早的记录属于前一个文件（例如已在回收站中的文件），不能一起删除
+func (m *Manager) purgeVersions(storage oss.StorageService, regionCode string, file *models.OSSFile) error {
+	var previous models.OSSFile
+	if err := m.db.Where("object_key = ? AND bucket = ? AND status <> ? AND id < ?",
+		file.ObjectKey, file.Bucket, "REPLACED", file.ID).
+		Order("id DESC").Limit(1).Find(&previous).Error; err != nil {
+		return fmt.Errorf("查询历史版本失败: %w", err)
+	}
+
+	var versions []models.OSSFile
+	if err := m.db.Where("object_key = ? AND bucket = ? AND status = ? AND id > ? AND id < ?",
+		file.ObjectKey, file.Bucket, "REPLACED", previous.ID, file.ID).Find(&versions).Error; err != nil {
+		return fmt.Errorf("查询历史版本失败: %w", err)
+	}
+	for i := range versions {
+		if versions[i].VersionKey != "" {
+			if err := storage.DeleteObjectFromBucket(versions[i].VersionKey, regionCode, file.Bucket); err != nil {
+				return fmt.Errorf("删除历史版本失败: %w", err)
+			}
+		}
+		if err := m.db.Delete(&versions[i]).Error; err != nil {
+			return fmt.Errorf("删除历史版本记录失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// PurgeWhere 彻底删除满足条件的回收站文件，返回成功删除的数量
+// 单个文件删除失败时记录日志并继续处理其余文件
+func (m *Manager) PurgeWhere(query *gorm.DB) (int, error) {
+	var files []models.OSSFile
+	if err := query.Model(&models.OSSFile{}).
+		Where("status = ?", StatusTrashed).
+		Find(&files).Error; err != nil {
+		return 0, fmt.Errorf("查询回收站文件失败: %w", err)
+	}
+
+	purged := 0
+	for i := range files {
+		if err := m.Purge(&files[i]); err != nil {
+			logger.Error("彻底删除回收站文件失败", zap.Uint("file_id", files[i].ID), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// resolve 获取文件对应的存储服务和所在地域
+func (m *Manager) resolve(file *models.OSSFile) (oss.StorageService, string, error) {
+	var mapping models.RegionBucketMapping
+	if err := m.db.Where("bucket_name = ?", file.Bucket).First(&mapping).Error; err != nil {
+		return nil, "", fmt.Errorf("未找到存储桶 %s 对应的区域信息: %w", file.Bucket, err)
+	}
+
+	storage, err := m.storageFactory.GetStorageService(file.StorageType)
+	if err != nil {
+		return nil, "", fmt.Errorf("获取存储服务失败: %w", err)
+	}
+	return storage, mapping.RegionCode, nil
+}
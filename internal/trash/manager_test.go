@@ -0,0 +1,69 @@
+package trash_test
+
+import (
+	"testing"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/trash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeStorage 只记录删除的对象键，其余方法不会被调用
+type fakeStorage struct {
+	oss.StorageService
+	deleted []string
+}
+
+func (s *fakeStorage) DeleteObjectFromBucket(objectKey string, regionCode string, bucketName string) error {
+	s.deleted = append(s.deleted, objectKey)
+	return nil
+}
+
+type fakeFactory struct {
+	oss.StorageFactory
+	storage *fakeStorage
+}
+
+func (f *fakeFactory) GetStorageService(storageType string) (oss.StorageService, error) {
+	return f.storage, nil
+}
+
+func TestPurgeKeepsOtherFilesVersions(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&models.OSSFile{}, &models.RegionBucketMapping{}))
+	require.NoError(t, conn.Create(&models.RegionBucketMapping{RegionCode: "cn-hangzhou", BucketName: "bucket"}).Error)
+
+	storage := &fakeStorage{}
+	m := trash.NewManager(conn, &fakeFactory{storage: storage}, config.TrashConfig{Enabled: true})
+
+	file := func(status, versionKey, trashKey string) *models.OSSFile {
+		f := &models.OSSFile{
+			Filename: "a.txt", OriginalFilename: "a.txt", StorageType: "ALIYUN_OSS",
+			Bucket: "bucket", ObjectKey: "docs/a.txt", UploaderID: 1, ConfigID: 1,
+			Status: status, VersionKey: versionKey, TrashKey: trashKey,
+		}
+		require.NoError(t, conn.Create(f).Error)
+		return f
+	}
+	// X 被 Y 覆盖，Y 移入回收站后又上传了 Z，Z 也移入回收站
+	x := file("REPLACED", ".versions/docs/a.txt/x", "")
+	y := file(trash.StatusTrashed, "", ".trash/docs/a.txt/y")
+	z := file(trash.StatusTrashed, "", ".trash/docs/a.txt/z")
+
+	// 彻底删除 Z 不影响 Y 的历史版本 X
+	require.NoError(t, m.Purge(z))
+	assert.Equal(t, []string{z.TrashKey}, storage.deleted)
+	require.NoError(t, conn.First(&models.OSSFile{}, x.ID).Error)
+
+	// 彻底删除 Y 时一起删除 X
+	storage.deleted = nil
+	require.NoError(t, m.Purge(y))
+	assert.ElementsMatch(t, []string{y.TrashKey, x.VersionKey}, storage.deleted)
+	assert.ErrorIs(t, conn.First(&models.OSSFile{}, x.ID).Error, gorm.ErrRecordNotFound)
+}
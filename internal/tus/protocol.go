@@ -0,0 +1,103 @@
+// Package tus 实现 tus 1.0 协议（https://tus.io/protocols/resumable-upload）的服务端逻辑，
+// 支持 core 以及 creation、termination、checksum 扩展，上传数据通过上传会话暂存并分片写入存储端
+package tus
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	Version                = "1.0.0"
+	Extensions             = "creation,termination,checksum"
+	ChecksumAlgorithms     = "sha1,md5,sha256"
+	ContentType            = "application/offset+octet-stream"
+	StatusChecksumMismatch = 460 // checksum 扩展定义的校验失败状态码
+)
+
+// Error tus 协议错误，按 HTTP 状态码返回给客户端
+type Error struct {
+	Status  int
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// 上传数据时的协议错误
+var (
+	ErrOffsetConflict   = &Error{http.StatusConflict, "Upload-Offset 与服务端不一致"}
+	ErrChecksumMismatch = &Error{StatusChecksumMismatch, "Checksum Mismatch"}
+	ErrLengthExceeded   = &Error{http.StatusRequestEntityTooLarge, "上传数据超出 Upload-Length"}
+	ErrIncompleteBody   = &Error{http.StatusBadRequest, "读取上传数据失败"}
+)
+
+// WriteError 返回 tus 错误
+func WriteError(c *gin.Context, err *Error) {
+	c.Header("Tus-Resumable", Version)
+	c.String(err.Status, err.Message)
+}
+
+// Require 校验客户端的协议版本，不支持时已写入响应
+func Require(c *gin.Context) bool {
+	if c.GetHeader("Tus-Resumable") != Version {
+		c.Header("Tus-Version", Version)
+		WriteError(c, &Error{http.StatusPreconditionFailed, "不支持的 tus 协议版本"})
+		return false
+	}
+	c.Header("Tus-Resumable", Version)
+	return true
+}
+
+// ParseMetadata 解析 Upload-Metadata 请求头，格式为逗号分隔的 "键 base64值"
+func ParseMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("Upload-Metadata 中 %s 的值不是合法的 base64", key)
+		}
+		meta[key] = string(value)
+	}
+	return meta, nil
+}
+
+// Checksum 请求携带的校验值
+type Checksum struct {
+	hash     hash.Hash
+	expected []byte
+}
+
+// ParseChecksum 解析 Upload-Checksum 请求头，格式为 "算法 base64摘要"
+func ParseChecksum(header string) (*Checksum, error) {
+	algorithm, encoded, _ := strings.Cut(header, " ")
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("Upload-Checksum 摘要不是合法的 base64")
+	}
+	switch algorithm {
+	case "sha1":
+		return &Checksum{sha1.New(), expected}, nil
+	case "md5":
+		return &Checksum{md5.New(), expected}, nil
+	case "sha256":
+		return &Checksum{sha256.New(), expected}, nil
+	}
+	return nil, fmt.Errorf("不支持的校验算法: %s", algorithm)
+}
@@ -0,0 +1,191 @@
+package tus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"go.uber.org/zap"
+)
+
+// PartWriter 将一个分片写入存储端
+type PartWriter func(partNumber int, data []byte) error
+
+// Offset 计算已接收的字节数：已写入存储端的分片加上暂存文件中的数据
+func Offset(sessions *upload.SessionManager, session *models.UploadSession) (int64, error) {
+	if session.Status == models.UploadSessionCompleted {
+		return session.FileSize, nil
+	}
+	info, err := os.Stat(sessions.SpoolPath(session.SessionID))
+	if os.IsNotExist(err) {
+		return session.UploadedBytes, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return session.UploadedBytes + info.Size(), nil
+}
+
+// CheckOffset 校验客户端的 Upload-Offset 与服务端一致，返回服务端的偏移量
+func CheckOffset(sessions *upload.SessionManager, session *models.UploadSession, offset int64) (int64, error) {
+	current, err := Offset(sessions, session)
+	if err != nil {
+		return 0, err
+	}
+	if offset != current {
+		return current, ErrOffsetConflict
+	}
+	return current, nil
+}
+
+// Append 将请求体从 offset 处追加到暂存文件，返回写入的字节数
+// 校验失败、超出 Upload-Length 或写入失败时回退本次数据；请求体读取中断时返回 ErrIncompleteBody，
+// 未携带校验值时已接收的部分仍保留，客户端可查询偏移量后续传
+func Append(sessions *upload.SessionManager, session *models.UploadSession, offset int64, body io.Reader, checksum *Checksum) (int64, error) {
+	spooled := offset - session.UploadedBytes
+	path := sessions.SpoolPath(session.SessionID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, fmt.Errorf("创建上传暂存目录失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("打开上传暂存文件失败: %w", err)
+	}
+	defer f.Close()
+
+	rollback := func(err error) (int64, error) {
+		if truncErr := f.Truncate(spooled); truncErr != nil {
+			logger.Error("回退上传暂存文件失败", zap.String("path", path), zap.Error(truncErr))
+		}
+		return 0, err
+	}
+
+	if _, err := f.Seek(spooled, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("定位上传暂存文件失败: %w", err)
+	}
+	reader := body
+	if checksum != nil {
+		reader = io.TeeReader(body, checksum.hash)
+	}
+	remaining := session.FileSize - session.UploadedBytes - spooled
+	written, readErr := io.Copy(f, io.LimitReader(reader, remaining))
+	if readErr != nil {
+		var pathErr *os.PathError
+		if errors.As(readErr, &pathErr) {
+			return rollback(fmt.Errorf("写入上传暂存文件失败: %w", readErr))
+		}
+	} else if n, _ := body.Read(make([]byte, 1)); n > 0 {
+		return rollback(ErrLengthExceeded)
+	}
+
+	if checksum != nil {
+		// 数据不完整时无法校验，整体丢弃由客户端重传
+		if readErr != nil {
+			return rollback(ErrIncompleteBody)
+		}
+		if !bytes.Equal(checksum.hash.Sum(nil), checksum.expected) {
+			return rollback(ErrChecksumMismatch)
+		}
+	}
+	if readErr != nil {
+		return written, ErrIncompleteBody
+	}
+	return written, nil
+}
+
+// Flush 将暂存文件中凑满的分片写入存储端，数据接收完毕时写入最后一个分片
+// 先截掉暂存文件再记录会话进度：两步之间中断只会少记进度，客户端重传的数据会以相同分片号覆盖写入
+func Flush(sessions *upload.SessionManager, session *models.UploadSession, write PartWriter) error {
+	path := sessions.SpoolPath(session.SessionID)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var sizes []int64
+	var flushed int64
+	var uploadErr error
+	for {
+		rest := info.Size() - flushed
+		partSize := session.ChunkSize
+		if rest < partSize {
+			if rest == 0 || session.UploadedBytes+flushed+rest < session.FileSize {
+				break
+			}
+			partSize = rest
+		}
+
+		data := make([]byte, partSize)
+		if _, err := f.ReadAt(data, flushed); err != nil {
+			uploadErr = fmt.Errorf("读取上传暂存文件失败: %w", err)
+			break
+		}
+		partNumber := session.PartsDone + len(sizes) + 1
+		if err := write(partNumber, data); err != nil {
+			uploadErr = err
+			break
+		}
+		upload.DefaultManager.UpdateChunk(session.SessionID, partNumber, true)
+		sizes = append(sizes, partSize)
+		flushed += partSize
+	}
+	if flushed == 0 {
+		return uploadErr
+	}
+
+	// 暂存文件只保留尚未写入存储端的数据
+	if err := rewriteSpool(f, path, flushed, info.Size()-flushed); err != nil {
+		return err
+	}
+	for _, size := range sizes {
+		sessions.PartDone(session, size)
+		session.PartsDone++
+		session.UploadedBytes += size
+	}
+	return uploadErr
+}
+
+// rewriteSpool 用暂存文件从 offset 开始的剩余数据替换原文件
+func rewriteSpool(f *os.File, path string, offset, size int64) error {
+	if size == 0 {
+		return os.Remove(path)
+	}
+	tmp, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, io.NewSectionReader(f, offset, size)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Terminate 终止上传（termination 扩展），在存储端中止分片上传，已完成的上传对应的文件不受影响
+func Terminate(sessions *upload.SessionManager, session *models.UploadSession) error {
+	if session.Status != models.UploadSessionActive {
+		return nil
+	}
+	if err := sessions.Abort(session, "客户端终止上传"); err != nil {
+		return err
+	}
+	upload.DefaultManager.Close(session.SessionID, upload.StatusCancelled, "客户端终止上传")
+	return nil
+}
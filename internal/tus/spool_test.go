@@ -0,0 +1,204 @@
+package tus_test
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/tus"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeStorage 记录被中止的分片上传，其余方法不会被调用
+type fakeStorage struct {
+	oss.StorageService
+	aborted []string
+}
+
+func (s *fakeStorage) AbortMultipartUploadToBucket(uploadID string, objectKey string, regionCode string, bucketName string) error {
+	s.aborted = append(s.aborted, uploadID)
+	return nil
+}
+
+type fakeFactory struct {
+	oss.StorageFactory
+	storage *fakeStorage
+}
+
+func (f *fakeFactory) GetStorageService(storageType string) (oss.StorageService, error) {
+	return f.storage, nil
+}
+
+// newSession 创建 10 字节、分片大小为 4 字节的上传会话
+func newSession(t *testing.T) (*upload.SessionManager, *models.UploadSession, *fakeStorage) {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&models.UploadSession{}))
+	storage := &fakeStorage{}
+	sessions := upload.NewSessionManager(conn, &fakeFactory{storage: storage}, config.UploadConfig{SpoolDir: t.TempDir()})
+	session := &models.UploadSession{
+		OwnerID:     1,
+		Mode:        models.UploadSessionModeTus,
+		StorageType: "ALIYUN_OSS",
+		RegionCode:  "cn-hangzhou",
+		BucketName:  "bucket",
+		ObjectKey:   "docs/a.txt",
+		UploadID:    "upload-1",
+		FileSize:    10,
+		ChunkSize:   4,
+		TotalParts:  3,
+	}
+	require.NoError(t, sessions.Create(session))
+	return sessions, session, storage
+}
+
+func offset(t *testing.T, sessions *upload.SessionManager, session *models.UploadSession) int64 {
+	n, err := tus.Offset(sessions, session)
+	require.NoError(t, err)
+	return n
+}
+
+func checksum(t *testing.T, data string) *tus.Checksum {
+	sum := sha1.Sum([]byte(data))
+	c, err := tus.ParseChecksum("sha1 " + base64.StdEncoding.EncodeToString(sum[:]))
+	require.NoError(t, err)
+	return c
+}
+
+func TestParseHeaders(t *testing.T) {
+	meta, err := tus.ParseMetadata("filename YS50eHQ=, bucket_name YnVja2V0,overwrite")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"filename": "a.txt", "bucket_name": "bucket", "overwrite": ""}, meta)
+	_, err = tus.ParseMetadata("filename !!!")
+	assert.Error(t, err)
+
+	_, err = tus.ParseChecksum("crc32 AAAA")
+	assert.Error(t, err)
+	_, err = tus.ParseChecksum("sha1 !!!")
+	assert.Error(t, err)
+}
+
+func TestCheckOffset(t *testing.T) {
+	sessions, session, _ := newSession(t)
+	_, err := tus.Append(sessions, session, 0, strings.NewReader("abc"), nil)
+	require.NoError(t, err)
+
+	// 偏移量与已接收的数据不一致时返回 409
+	current, err := tus.CheckOffset(sessions, session, 0)
+	require.ErrorIs(t, err, tus.ErrOffsetConflict)
+	assert.Equal(t, http.StatusConflict, tus.ErrOffsetConflict.Status)
+	assert.EqualValues(t, 3, current)
+
+	current, err = tus.CheckOffset(sessions, session, 3)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, current)
+}
+
+func TestAppendChecksum(t *testing.T) {
+	sessions, session, _ := newSession(t)
+	_, err := tus.Append(sessions, session, 0, strings.NewReader("ab"), nil)
+	require.NoError(t, err)
+
+	// 校验失败时返回 460 并回退本次数据
+	written, err := tus.Append(sessions, session, 2, strings.NewReader("cd"), checksum(t, "xx"))
+	require.ErrorIs(t, err, tus.ErrChecksumMismatch)
+	assert.Equal(t, 460, tus.ErrChecksumMismatch.Status)
+	assert.Zero(t, written)
+	assert.EqualValues(t, 2, offset(t, sessions, session))
+
+	written, err = tus.Append(sessions, session, 2, strings.NewReader("cd"), checksum(t, "cd"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, written)
+	assert.EqualValues(t, 4, offset(t, sessions, session))
+}
+
+func TestAppendRollback(t *testing.T) {
+	sessions, session, _ := newSession(t)
+
+	// 超出 Upload-Length 的请求整体回退
+	_, err := tus.Append(sessions, session, 0, strings.NewReader("0123456789x"), nil)
+	require.ErrorIs(t, err, tus.ErrLengthExceeded)
+	assert.Zero(t, offset(t, sessions, session))
+
+	// 请求体中断时，未携带校验值保留已接收部分，携带校验值则整体丢弃
+	interrupted := func() io.Reader {
+		return io.MultiReader(strings.NewReader("abc"), iotest.ErrReader(errors.New("connection reset")))
+	}
+	written, err := tus.Append(sessions, session, 0, interrupted(), checksum(t, "abc"))
+	require.ErrorIs(t, err, tus.ErrIncompleteBody)
+	assert.Zero(t, written)
+	assert.Zero(t, offset(t, sessions, session))
+
+	written, err = tus.Append(sessions, session, 0, interrupted(), nil)
+	require.ErrorIs(t, err, tus.ErrIncompleteBody)
+	assert.EqualValues(t, 3, written)
+	assert.EqualValues(t, 3, offset(t, sessions, session))
+}
+
+func TestFlush(t *testing.T) {
+	sessions, session, _ := newSession(t)
+	parts := map[int]string{}
+	write := func(partNumber int, data []byte) error {
+		parts[partNumber] = string(data)
+		return nil
+	}
+
+	// 只写入凑满的分片，剩余数据留在暂存文件中
+	_, err := tus.Append(sessions, session, 0, strings.NewReader("012345"), nil)
+	require.NoError(t, err)
+	require.NoError(t, tus.Flush(sessions, session, write))
+	assert.Equal(t, map[int]string{1: "0123"}, parts)
+	assert.Equal(t, 1, session.PartsDone)
+	assert.EqualValues(t, 4, session.UploadedBytes)
+	assert.EqualValues(t, 6, offset(t, sessions, session))
+
+	// 写入失败时不记录进度，暂存数据保留
+	failed := errors.New("网络错误")
+	_, err = tus.Append(sessions, session, 6, strings.NewReader("6789"), nil)
+	require.NoError(t, err)
+	require.ErrorIs(t, tus.Flush(sessions, session, func(int, []byte) error { return failed }), failed)
+	assert.EqualValues(t, 4, session.UploadedBytes)
+	assert.EqualValues(t, 10, offset(t, sessions, session))
+
+	// 数据接收完毕时写入最后一个不足分片大小的分片
+	require.NoError(t, tus.Flush(sessions, session, write))
+	assert.Equal(t, map[int]string{1: "0123", 2: "4567", 3: "89"}, parts)
+	assert.Equal(t, 3, session.PartsDone)
+	assert.EqualValues(t, 10, session.UploadedBytes)
+	assert.EqualValues(t, 10, offset(t, sessions, session))
+
+	saved, err := sessions.Get(session.SessionID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved.PartsDone)
+	assert.EqualValues(t, 10, saved.UploadedBytes)
+}
+
+func TestTerminate(t *testing.T) {
+	sessions, session, storage := newSession(t)
+	_, err := tus.Append(sessions, session, 0, strings.NewReader("abc"), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, tus.Terminate(sessions, session))
+	assert.Equal(t, []string{"upload-1"}, storage.aborted)
+	_, err = sessions.Get(session.SessionID, 1)
+	assert.ErrorIs(t, err, upload.ErrSessionClosed)
+	assert.EqualValues(t, 0, offset(t, sessions, session), "暂存文件应被删除")
+
+	// 已完成的上传不再中止
+	sessions, completed, storage := newSession(t)
+	completed.Status = models.UploadSessionCompleted
+	require.NoError(t, tus.Terminate(sessions, completed))
+	assert.Empty(t, storage.aborted)
+}
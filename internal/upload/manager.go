@@ -5,6 +5,16 @@ import (
 	"time"
 )
 
+// 进度状态
+const (
+	StatusPending   = "pending"   // 任务已排队，尚未开始
+	StatusRunning   = "running"   // 后台任务执行中
+	StatusUploading = "uploading" // 上传中
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
 type ChunkInfo struct {
 	ChunkNumber int   `json:"chunk_number"`
 	ChunkSize   int64 `json:"chunk_size"`
@@ -18,12 +28,16 @@ type Progress struct {
 	Speed       int64       `json:"speed"` // bytes per second
 	StartTime   time.Time   `json:"start_time"`
 	UpdateTime  time.Time   `json:"update_time"`
-	IsChunked   bool        `json:"is_chunked"`   // 是否为分片上传
-	TotalChunks int         `json:"total_chunks"` // 总分片数
-	Chunks      []ChunkInfo `json:"chunks"`       // 分片信息
-	Status      string      `json:"status"`       // uploading, completed, failed
+	IsChunked   bool        `json:"is_chunked"`        // 是否为分片上传
+	TotalChunks int         `json:"total_chunks"`      // 总分片数
+	Chunks      []ChunkInfo `json:"chunks"`            // 分片信息
+	Status      string      `json:"status"`            // pending, running, uploading, completed, failed, cancelled
+	Type        string      `json:"type,omitempty"`    // 任务类型，上传任务为空
+	Message     string      `json:"message,omitempty"` // 状态说明或错误信息
 }
 
+// Manager 进度管理器，按任务ID记录进度并推送给订阅者
+// 上传任务以字节计量进度，其他后台任务以处理项数计量（Uploaded 表示已完成数量）
 type Manager struct {
 	mu          sync.RWMutex
 	progresses  map[string]*Progress
@@ -57,7 +71,7 @@ func (m *Manager) StartWithChunks(id string, total int64, isChunked bool, totalC
 		UpdateTime:  now,
 		IsChunked:   isChunked,
 		TotalChunks: totalChunks,
-		Status:      "uploading",
+		Status:      StatusUploading,
 	}
 
 	if isChunked && totalChunks > 0 {
@@ -79,6 +93,94 @@ func (m *Manager) StartWithChunks(id string, total int64, isChunked bool, totalC
 	m.progresses[id] = progress
 }
 
+// StartTask 登记一个通用后台任务，初始状态为 pending
+func (m *Manager) StartTask(id string, taskType string, total int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.progresses[id] = &Progress{
+		Total:      total,
+		StartTime:  now,
+		UpdateTime: now,
+		Status:     StatusPending,
+		Type:       taskType,
+	}
+	m.broadcast(id)
+}
+
+// SetTotal 更新任务总量，用于开始执行后才能确定总量的任务
+func (m *Manager) SetTotal(id string, total int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.progresses[id]; ok {
+		p.Total = total
+		if total > 0 {
+			p.Percentage = float64(p.Uploaded) / float64(total) * 100
+		}
+		m.broadcast(id)
+	}
+}
+
+// SetStatus 更新任务状态和说明并通知订阅者
+func (m *Manager) SetStatus(id string, status string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.progresses[id]; ok {
+		p.Status = status
+		p.Message = message
+		p.UpdateTime = time.Now()
+		m.broadcast(id)
+	}
+}
+
+// Close 以终态结束任务，通知订阅者后延迟清理
+func (m *Manager) Close(id string, status string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.progresses[id]; ok {
+		p.Status = status
+		p.Message = message
+		p.UpdateTime = time.Now()
+		if status == StatusCompleted {
+			p.Percentage = 100
+			p.Uploaded = p.Total
+		}
+		m.broadcast(id)
+	}
+	m.scheduleRemoval(id)
+}
+
+// broadcast 将当前进度推送给订阅者，调用方需持有锁
+func (m *Manager) broadcast(id string) {
+	p, ok := m.progresses[id]
+	if !ok {
+		return
+	}
+	for ch := range m.subscribers[id] {
+		select {
+		case ch <- *p:
+		default:
+		}
+	}
+}
+
+// scheduleRemoval 延迟删除进度信息，让客户端有时间接收最终状态
+func (m *Manager) scheduleRemoval(id string) {
+	go func() {
+		time.Sleep(5 * time.Second)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.progresses, id)
+		if subs, ok := m.subscribers[id]; ok {
+			for ch := range subs {
+				close(ch)
+			}
+			delete(m.subscribers, id)
+		}
+	}()
+}
+
 func (m *Manager) Update(id string, uploaded int64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -148,39 +250,24 @@ func (m *Manager) Finish(id string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if p, ok := m.progresses[id]; ok {
-		p.Status = "completed"
+		p.Status = StatusCompleted
 		p.Percentage = 100
 		p.Uploaded = p.Total
 
 		// 最后通知一次订阅者
-		for ch := range m.subscribers[id] {
-			select {
-			case ch <- *p:
-			default:
-			}
-		}
+		m.broadcast(id)
 	}
 
 	// 延迟删除进度信息，让客户端有时间接收完成状态
-	go func() {
-		time.Sleep(5 * time.Second)
-		m.mu.Lock()
-		defer m.mu.Unlock()
-		delete(m.progresses, id)
-		if subs, ok := m.subscribers[id]; ok {
-			for ch := range subs {
-				close(ch)
-			}
-			delete(m.subscribers, id)
-		}
-	}()
+	m.scheduleRemoval(id)
 }
 
 func (m *Manager) Fail(id string, errorMsg string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if p, ok := m.progresses[id]; ok {
-		p.Status = "failed"
+		p.Status = StatusFailed
+		p.Message = errorMsg
 
 		// 通知订阅者失败状态
 		for ch := range m.subscribers[id] {
@@ -0,0 +1,246 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/logger"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	cleanupBatchSize = 100   // 每轮清理的最大会话数量
+	maxParts         = 10000 // 存储端单个分片上传的最大分片数
+)
+
+var (
+	// ErrSessionNotFound 上传会话不存在或不属于当前用户
+	ErrSessionNotFound = errors.New("上传会话不存在")
+	// ErrSessionClosed 上传会话已完成、已中止或已过期
+	ErrSessionClosed = errors.New("上传会话已结束")
+)
+
+// SessionManager 持久化分片上传会话管理器，负责会话的创建、续传查找和过期中止
+type SessionManager struct {
+	db             *gorm.DB
+	storageFactory oss.StorageFactory
+	cfg            config.UploadConfig
+	locks          sync.Map // 会话ID -> *sync.Mutex，串行化同一会话的写入
+	wg             sync.WaitGroup
+	ctx            context.Context
+	cancel         context.CancelFunc
+}
+
+// NewSessionManager 创建上传会话管理器
+func NewSessionManager(db *gorm.DB, storageFactory oss.StorageFactory, cfg config.UploadConfig) *SessionManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SessionManager{
+		db:             db,
+		storageFactory: storageFactory,
+		cfg:            cfg,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Start 启动过期会话清理协程
+func (m *SessionManager) Start() {
+	m.wg.Add(1)
+	go m.cleanupLoop()
+	logger.Info("上传会话清理任务已启动",
+		zap.Duration("ttl", m.cfg.GetSessionTTL()),
+		zap.Duration("interval", m.cfg.GetCleanupInterval()))
+}
+
+// Stop 停止过期会话清理协程
+func (m *SessionManager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+	logger.Info("上传会话清理任务已停止")
+}
+
+// Lock 锁定会话，返回解锁函数
+func (m *SessionManager) Lock(sessionID string) func() {
+	v, _ := m.locks.LoadOrStore(sessionID, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// PartSize 按文件大小计算分片大小，保证分片数不超过存储端上限
+func (m *SessionManager) PartSize(fileSize int64) int64 {
+	size := m.cfg.GetChunkSize()
+	if min := (fileSize + maxParts - 1) / maxParts; min > size {
+		size = min
+	}
+	return size
+}
+
+// SpoolPath 会话暂存文件路径，用于保存尚不足一个分片的已接收数据
+func (m *SessionManager) SpoolPath(sessionID string) string {
+	return filepath.Join(m.cfg.GetSpoolDir(), sessionID+".part")
+}
+
+// removeSpool 删除会话暂存文件
+func (m *SessionManager) removeSpool(session *models.UploadSession) {
+	if err := os.Remove(m.SpoolPath(session.SessionID)); err != nil && !os.IsNotExist(err) {
+		logger.Warn("删除上传暂存文件失败", zap.String("session_id", session.SessionID), zap.Error(err))
+	}
+	m.locks.Delete(session.SessionID)
+}
+
+// Create 登记新的分片上传会话
+func (m *SessionManager) Create(session *models.UploadSession) error {
+	session.SessionID = uuid.NewString()
+	session.Status = models.UploadSessionActive
+	session.ExpiresAt = time.Now().Add(m.cfg.GetSessionTTL())
+	return m.db.Create(session).Error
+}
+
+// Get 获取用户的上传会话，会话已结束或过期时返回 ErrSessionClosed
+func (m *SessionManager) Get(sessionID string, ownerID uint) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := m.db.Where("session_id = ? AND owner_id = ?", sessionID, ownerID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadSessionActive || time.Now().After(session.ExpiresAt) {
+		return &session, ErrSessionClosed
+	}
+	return &session, nil
+}
+
+// PartDone 记录一个分片上传完成，并顺延会话过期时间
+func (m *SessionManager) PartDone(session *models.UploadSession, size int64) {
+	if err := m.db.Model(&models.UploadSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"parts_done":     gorm.Expr("parts_done + 1"),
+		"uploaded_bytes": gorm.Expr("uploaded_bytes + ?", size),
+		"expires_at":     time.Now().Add(m.cfg.GetSessionTTL()),
+	}).Error; err != nil {
+		logger.Warn("更新上传会话进度失败", zap.String("session_id", session.SessionID), zap.Error(err))
+	}
+}
+
+// SyncParts 按存储端已上传的分片刷新会话进度
+func (m *SessionManager) SyncParts(session *models.UploadSession, parts []oss.Part) {
+	uploaded := int64(0)
+	if session.ChunkSize > 0 {
+		for _, p := range parts {
+			size := session.ChunkSize
+			if session.TotalParts > 0 && p.PartNumber == session.TotalParts {
+				size = session.FileSize - int64(session.TotalParts-1)*session.ChunkSize
+			}
+			uploaded += size
+		}
+	}
+	session.PartsDone = len(parts)
+	session.UploadedBytes = uploaded
+	session.ExpiresAt = time.Now().Add(m.cfg.GetSessionTTL())
+	if err := m.db.Model(session).Updates(map[string]interface{}{
+		"parts_done":     session.PartsDone,
+		"uploaded_bytes": session.UploadedBytes,
+		"expires_at":     session.ExpiresAt,
+	}).Error; err != nil {
+		logger.Warn("更新上传会话进度失败", zap.String("session_id", session.SessionID), zap.Error(err))
+	}
+}
+
+// Fail 记录会话最近一次失败原因，会话保持可续传
+func (m *SessionManager) Fail(session *models.UploadSession, reason string) {
+	m.db.Model(session).Update("last_error", reason)
+}
+
+// Complete 将会话标记为已完成
+func (m *SessionManager) Complete(session *models.UploadSession) {
+	m.removeSpool(session)
+	now := time.Now()
+	if err := m.db.Model(session).Updates(map[string]interface{}{
+		"status":       models.UploadSessionCompleted,
+		"completed_at": now,
+	}).Error; err != nil {
+		logger.Warn("更新上传会话状态失败", zap.String("session_id", session.SessionID), zap.Error(err))
+	}
+}
+
+// Abort 在存储端中止分片上传并将会话标记为已中止
+func (m *SessionManager) Abort(session *models.UploadSession, reason string) error {
+	m.removeSpool(session)
+	storage, err := m.storageFactory.GetStorageService(session.StorageType)
+	if err != nil {
+		return fmt.Errorf("获取存储服务失败: %w", err)
+	}
+	if err := storage.AbortMultipartUploadToBucket(session.UploadID, session.ObjectKey, session.RegionCode, session.BucketName); err != nil {
+		return fmt.Errorf("中止分片上传失败: %w", err)
+	}
+
+	now := time.Now()
+	return m.db.Model(session).Updates(map[string]interface{}{
+		"status":       models.UploadSessionAborted,
+		"last_error":   reason,
+		"completed_at": now,
+	}).Error
+}
+
+// cleanupLoop 定期中止过期的上传会话
+func (m *SessionManager) cleanupLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.GetCleanupInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := m.CleanupExpired()
+			if err != nil {
+				logger.Error("清理过期上传会话失败", zap.Error(err))
+				continue
+			}
+			if count > 0 {
+				logger.Info("已中止过期上传会话", zap.Int("count", count))
+			}
+		}
+	}
+}
+
+// CleanupExpired 中止所有已过期的上传会话，返回成功中止的数量
+func (m *SessionManager) CleanupExpired() (int, error) {
+	var sessions []models.UploadSession
+	if err := m.db.Where("status = ? AND expires_at < ?", models.UploadSessionActive, time.Now()).
+		Order("expires_at").Limit(cleanupBatchSize).Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range sessions {
+		if err := m.Abort(&sessions[i], "会话超时未完成"); err != nil {
+			// 存储端的分片上传可能已不存在，仍将会话标记为已中止，避免反复重试
+			logger.Warn("中止过期上传会话失败",
+				zap.String("session_id", sessions[i].SessionID),
+				zap.String("object_key", sessions[i].ObjectKey),
+				zap.Error(err))
+			m.db.Model(&sessions[i]).Updates(map[string]interface{}{
+				"status":     models.UploadSessionAborted,
+				"last_error": "会话超时未完成: " + err.Error(),
+			})
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
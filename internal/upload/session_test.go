@@ -0,0 +1,159 @@
+package upload_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/myysophia/ossmanager-backend/internal/config"
+	"github.com/myysophia/ossmanager-backend/internal/db/models"
+	"github.com/myysophia/ossmanager-backend/internal/oss"
+	"github.com/myysophia/ossmanager-backend/internal/upload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeStorage 记录被中止的分片上传，其余方法不会被调用
+type fakeStorage struct {
+	oss.StorageService
+	aborted []string
+	failFor string
+}
+
+func (s *fakeStorage) AbortMultipartUploadToBucket(uploadID string, objectKey string, regionCode string, bucketName string) error {
+	if uploadID == s.failFor {
+		return errors.New("NoSuchUpload")
+	}
+	s.aborted = append(s.aborted, uploadID)
+	return nil
+}
+
+type fakeFactory struct {
+	oss.StorageFactory
+	storage *fakeStorage
+}
+
+func (f *fakeFactory) GetStorageService(storageType string) (oss.StorageService, error) {
+	return f.storage, nil
+}
+
+func newSessionManager(t *testing.T) (*upload.SessionManager, *gorm.DB, *fakeStorage) {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, conn.AutoMigrate(&models.UploadSession{}))
+	storage := &fakeStorage{}
+	m := upload.NewSessionManager(conn, &fakeFactory{storage: storage}, config.UploadConfig{
+		SessionTTL: 3600,
+		ChunkSize:  100,
+		SpoolDir:   t.TempDir(),
+	})
+	return m, conn, storage
+}
+
+func createSession(t *testing.T, m *upload.SessionManager, ownerID uint, uploadID string) *models.UploadSession {
+	session := &models.UploadSession{
+		OwnerID:     ownerID,
+		Mode:        models.UploadSessionModeTus,
+		StorageType: "ALIYUN_OSS",
+		RegionCode:  "cn-hangzhou",
+		BucketName:  "bucket",
+		ObjectKey:   "docs/a.txt",
+		UploadID:    uploadID,
+		FileSize:    250,
+		ChunkSize:   100,
+		TotalParts:  3,
+	}
+	require.NoError(t, m.Create(session))
+	return session
+}
+
+func TestSessionLookup(t *testing.T) {
+	m, conn, _ := newSessionManager(t)
+	session := createSession(t, m, 1, "u1")
+	assert.NotEmpty(t, session.SessionID)
+	assert.Equal(t, models.UploadSessionActive, session.Status)
+
+	found, err := m.Get(session.SessionID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, found.ID)
+
+	// 其他用户的会话视为不存在
+	_, err = m.Get(session.SessionID, 2)
+	assert.ErrorIs(t, err, upload.ErrSessionNotFound)
+	_, err = m.Get("missing", 1)
+	assert.ErrorIs(t, err, upload.ErrSessionNotFound)
+
+	// 已过期或已完成的会话返回 ErrSessionClosed
+	require.NoError(t, conn.Model(session).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	_, err = m.Get(session.SessionID, 1)
+	assert.ErrorIs(t, err, upload.ErrSessionClosed)
+
+	other := createSession(t, m, 1, "u2")
+	m.Complete(other)
+	found, err = m.Get(other.SessionID, 1)
+	assert.ErrorIs(t, err, upload.ErrSessionClosed)
+	assert.Equal(t, models.UploadSessionCompleted, found.Status)
+	assert.NotNil(t, found.CompletedAt)
+}
+
+func TestSessionOffset(t *testing.T) {
+	m, conn, _ := newSessionManager(t)
+	session := createSession(t, m, 1, "u1")
+	require.NoError(t, conn.Model(session).Update("expires_at", time.Now().Add(time.Minute)).Error)
+
+	// 每完成一个分片累加进度并顺延过期时间
+	m.PartDone(session, 100)
+	m.PartDone(session, 100)
+	found, err := m.Get(session.SessionID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found.PartsDone)
+	assert.EqualValues(t, 200, found.UploadedBytes)
+	assert.True(t, found.ExpiresAt.After(time.Now().Add(30*time.Minute)))
+
+	// 以存储端已上传的分片为准，最后一个分片按剩余大小计算
+	m.SyncParts(found, []oss.Part{{PartNumber: 1}, {PartNumber: 3}})
+	found, err = m.Get(session.SessionID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found.PartsDone)
+	assert.EqualValues(t, 150, found.UploadedBytes)
+
+	m.Fail(found, "网络中断")
+	found, err = m.Get(session.SessionID, 1)
+	require.NoError(t, err, "失败后会话仍可续传")
+	assert.Equal(t, "网络中断", found.LastError)
+}
+
+func TestCleanupExpired(t *testing.T) {
+	m, conn, storage := newSessionManager(t)
+	expired := createSession(t, m, 1, "u1")
+	broken := createSession(t, m, 1, "u2")
+	active := createSession(t, m, 1, "u3")
+	storage.failFor = "u2"
+	require.NoError(t, conn.Model(&models.UploadSession{}).Where("id IN ?", []uint{expired.ID, broken.ID}).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	require.NoError(t, os.WriteFile(m.SpoolPath(expired.SessionID), []byte("data"), 0o600))
+
+	// 存储端中止失败的会话也标记为已中止，但不计入成功数量
+	count, err := m.CleanupExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"u1"}, storage.aborted)
+	_, err = os.Stat(m.SpoolPath(expired.SessionID))
+	assert.True(t, os.IsNotExist(err), "暂存文件应被删除")
+
+	for _, s := range []*models.UploadSession{expired, broken} {
+		var saved models.UploadSession
+		require.NoError(t, conn.First(&saved, s.ID).Error)
+		assert.Equal(t, models.UploadSessionAborted, saved.Status)
+		assert.Contains(t, saved.LastError, "会话超时未完成")
+	}
+	_, err = m.Get(active.SessionID, 1)
+	assert.NoError(t, err)
+
+	count, err = m.CleanupExpired()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
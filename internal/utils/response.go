@@ -30,6 +30,16 @@ const (
 	CodeFileNotFound   = 40405 // 文件不存在
 	CodeConfigInUse    = 40001 // 配置正在使用中
 	CodeFileExists     = 40009 // 文件已存在
+	CodeQuotaExceeded  = 40013 // 存储配额不足
+	CodePolicyDenied   = 40015 // 不符合存储桶上传策略
+
+	// 分享链接相关状态码
+	CodeShareLinkInvalid  = 40410 // 分享链接不存在或已失效
+	CodeSharePasswordFail = 40310 // 分享密码错误
+	CodeShareLocked       = 42910 // 分享密码错误次数过多
+
+	// 上传请求链接相关状态码
+	CodeUploadRequestInvalid = 40411 // 上传链接不存在或已失效
 )
 
 // 对应的消息
@@ -47,6 +57,16 @@ var codeMsgMap = map[int]string{
 	CodeFileNotFound:   "文件不存在",
 	CodeConfigInUse:    "配置正在使用中",
 	CodeFileExists:     "文件已存在",
+	CodeQuotaExceeded:  "存储配额不足",
+	CodePolicyDenied:   "不符合存储桶上传策略",
+
+	// 分享链接相关状态码消息
+	CodeShareLinkInvalid:  "分享链接不存在或已失效",
+	CodeSharePasswordFail: "分享密码错误",
+	CodeShareLocked:       "分享密码错误次数过多",
+
+	// 上传请求链接相关状态码消息
+	CodeUploadRequestInvalid: "上传链接不存在或已失效",
 }
 
 // ResponseWithJSON 返回JSON响应
@@ -143,7 +163,7 @@ func ResponseInternalError(c *gin.Context, err error) {
 // GetUserID 从上下文中获取用户ID
 func GetUserID(c *gin.Context) uint {
 	// 从上下文中获取用户ID
-	userID, exists := c.Get("user_id")
+	userID, exists := c.Get("userID")
 	if !exists {
 		return 1 // 默认返回ID为1的管理员用户，避免外键约束错误
 	}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserID(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	// 未登录时回退为默认管理员
+	assert.EqualValues(t, 1, GetUserID(c))
+
+	// 与 AuthMiddleware 使用相同的键
+	c.Set("userID", uint(42))
+	assert.EqualValues(t, 42, GetUserID(c))
+}